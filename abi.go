@@ -0,0 +1,26 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+)
+
+// ABI of the Influence contracts, embedded so the events and fixtures commands work outside of the
+// repository. The file passed with --abi overrides it.
+//
+//go:embed abis/starknet_combined.json
+var embeddedABI []byte
+
+// ReadABI reads the ABI file passed with --abi, or returns the embedded ABI if none was passed. A
+// file which was passed but cannot be read is an error, it is never replaced by the embedded ABI.
+func ReadABI(abiFile string) ([]byte, error) {
+	if abiFile == "" {
+		return embeddedABI, nil
+	}
+	data, readErr := os.ReadFile(abiFile)
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read ABI file %s, err: %v", abiFile, readErr)
+	}
+	return data, nil
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/leaderboards"
+)
+
+var ARCHIVE_MANIFEST_FILE = "manifest.json"
+
+// ArchiveStorage copies files to and from an archive destination.
+type ArchiveStorage interface {
+	Put(localPath, key string) error
+	Get(key, localPath string) error
+}
+
+// LocalArchiveStorage keeps archives in a directory, e.g. a mounted bucket.
+type LocalArchiveStorage struct {
+	Dir string
+}
+
+func (s LocalArchiveStorage) Put(localPath, key string) error {
+	return copyFile(localPath, filepath.Join(s.Dir, filepath.FromSlash(key)))
+}
+
+func (s LocalArchiveStorage) Get(key, localPath string) error {
+	return copyFile(filepath.Join(s.Dir, filepath.FromSlash(key)), localPath)
+}
+
+// CLIArchiveStorage copies files to S3 or GCS with the aws or gsutil command line tools, so their
+// usual credentials configuration applies.
+type CLIArchiveStorage struct {
+	// Command and arguments before the source and destination, e.g. ["aws", "s3", "cp"]
+	Copy []string
+	URL  string
+}
+
+func (s CLIArchiveStorage) run(source, destination string) error {
+	args := append(append([]string{}, s.Copy[1:]...), source, destination)
+	command := exec.Command(s.Copy[0], args...)
+	command.Stderr = os.Stderr
+	if runErr := command.Run(); runErr != nil {
+		return fmt.Errorf("%s failed to copy %s to %s, err: %v", s.Copy[0], source, destination, runErr)
+	}
+	return nil
+}
+
+func (s CLIArchiveStorage) Put(localPath, key string) error {
+	return s.run(localPath, strings.TrimRight(s.URL, "/")+"/"+key)
+}
+
+func (s CLIArchiveStorage) Get(key, localPath string) error {
+	return s.run(strings.TrimRight(s.URL, "/")+"/"+key, localPath)
+}
+
+// NewArchiveStorage returns storage for s3:// and gs:// URLs, or a local directory otherwise.
+func NewArchiveStorage(destination string) (ArchiveStorage, error) {
+	switch {
+	case strings.HasPrefix(destination, "s3://"):
+		return CLIArchiveStorage{Copy: []string{"aws", "s3", "cp", "--only-show-errors"}, URL: destination}, nil
+	case strings.HasPrefix(destination, "gs://"):
+		return CLIArchiveStorage{Copy: []string{"gsutil", "-q", "cp"}, URL: destination}, nil
+	case strings.Contains(destination, "://"):
+		return nil, fmt.Errorf("unsupported archive destination %s, supported destinations: s3://, gs:// or a local directory", destination)
+	}
+	return LocalArchiveStorage{Dir: destination}, nil
+}
+
+// ArchivedFile is a dump in an archive.
+type ArchivedFile struct {
+	Name             string `json:"name"`
+	Size             int64  `json:"size"`
+	SHA256           string `json:"sha256"`
+	FromBlock        uint64 `json:"from_block"`
+	ToBlock          uint64 `json:"to_block"`
+	CompressedName   string `json:"compressed_name"`
+	CompressedSize   int64  `json:"compressed_size"`
+	CompressedSHA256 string `json:"compressed_sha256"`
+}
+
+// ArchiveManifest lists the dumps archived for a month.
+type ArchiveManifest struct {
+	Month   string         `json:"month"`
+	Created string         `json:"created"`
+	Version string         `json:"version"`
+	Files   []ArchivedFile `json:"files"`
+}
+
+// PreviousMonth returns the month before now in YYYY-MM format, the month archived by default.
+func PreviousMonth(now time.Time) string {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format("2006-01")
+}
+
+func copyFile(source, destination string) error {
+	inputFile, openErr := os.Open(source)
+	if openErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", source, openErr)
+	}
+	defer inputFile.Close()
+
+	if mkdirErr := os.MkdirAll(filepath.Dir(destination), 0755); mkdirErr != nil {
+		return mkdirErr
+	}
+	outputFile, createErr := os.Create(destination)
+	if createErr != nil {
+		return createErr
+	}
+	if _, copyErr := io.Copy(outputFile, inputFile); copyErr != nil {
+		outputFile.Close()
+		return copyErr
+	}
+	return outputFile.Close()
+}
+
+func fileSHA256(filePath string) (string, int64, error) {
+	inputFile, openErr := os.Open(filePath)
+	if openErr != nil {
+		return "", 0, fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+	}
+	defer inputFile.Close()
+
+	hasher := sha256.New()
+	size, copyErr := io.Copy(hasher, inputFile)
+	if copyErr != nil {
+		return "", 0, copyErr
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+func gzipFile(source, destination string) error {
+	inputFile, openErr := os.Open(source)
+	if openErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", source, openErr)
+	}
+	defer inputFile.Close()
+
+	outputFile, createErr := os.Create(destination)
+	if createErr != nil {
+		return createErr
+	}
+	defer outputFile.Close()
+
+	writer := gzip.NewWriter(outputFile)
+	writer.Name = filepath.Base(source)
+	if _, copyErr := io.Copy(writer, inputFile); copyErr != nil {
+		return copyErr
+	}
+	return writer.Close()
+}
+
+func gunzipFile(source, destination string) error {
+	inputFile, openErr := os.Open(source)
+	if openErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", source, openErr)
+	}
+	defer inputFile.Close()
+
+	reader, readerErr := gzip.NewReader(inputFile)
+	if readerErr != nil {
+		return fmt.Errorf("Unable to decompress %s, err: %v", source, readerErr)
+	}
+	defer reader.Close()
+
+	outputFile, createErr := os.Create(destination)
+	if createErr != nil {
+		return createErr
+	}
+	defer outputFile.Close()
+
+	_, copyErr := io.Copy(outputFile, reader)
+	return copyErr
+}
+
+// CreateArchive compresses and checksums the dumps, then uploads them together with their
+// manifest under the month's prefix of the storage.
+func CreateArchive(storage ArchiveStorage, month string, filePaths []string) (ArchiveManifest, error) {
+	manifest := ArchiveManifest{Month: month, Created: time.Now().UTC().Format(time.RFC3339), Version: Version}
+
+	tempDir, tempErr := os.MkdirTemp("", "influence-eth-archive-")
+	if tempErr != nil {
+		return manifest, tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	names := make(map[string]bool)
+	for _, filePath := range filePaths {
+		name := filepath.Base(filePath)
+		if names[name] {
+			return manifest, fmt.Errorf("two dumps are named %s, archived files must have distinct names", name)
+		}
+		names[name] = true
+
+		hash, size, hashErr := fileSHA256(filePath)
+		if hashErr != nil {
+			return manifest, hashErr
+		}
+		// Raw and parsed dumps both record block numbers, other files have no block range
+		_, fromBlock, toBlock, rangeErr := leaderboards.HashEventsInput(filePath)
+		if rangeErr != nil {
+			return manifest, rangeErr
+		}
+
+		compressedPath := filepath.Join(tempDir, name+".gz")
+		if gzipErr := gzipFile(filePath, compressedPath); gzipErr != nil {
+			return manifest, gzipErr
+		}
+		compressedHash, compressedSize, hashErr := fileSHA256(compressedPath)
+		if hashErr != nil {
+			return manifest, hashErr
+		}
+
+		if putErr := storage.Put(compressedPath, path.Join(month, name+".gz")); putErr != nil {
+			return manifest, putErr
+		}
+
+		manifest.Files = append(manifest.Files, ArchivedFile{
+			Name:             name,
+			Size:             size,
+			SHA256:           hash,
+			FromBlock:        fromBlock,
+			ToBlock:          toBlock,
+			CompressedName:   name + ".gz",
+			CompressedSize:   compressedSize,
+			CompressedSHA256: compressedHash,
+		})
+	}
+
+	// The manifest is uploaded last, so an archive with a manifest is complete
+	manifestPath := filepath.Join(tempDir, ARCHIVE_MANIFEST_FILE)
+	manifestData, marshErr := json.MarshalIndent(manifest, "", "  ")
+	if marshErr != nil {
+		return manifest, marshErr
+	}
+	if writeErr := os.WriteFile(manifestPath, manifestData, 0644); writeErr != nil {
+		return manifest, writeErr
+	}
+	return manifest, storage.Put(manifestPath, path.Join(month, ARCHIVE_MANIFEST_FILE))
+}
+
+// RestoreArchive downloads the month's archive and checks its checksums. If outDir is not empty,
+// the dumps are decompressed into it and their checksums are checked as well.
+func RestoreArchive(storage ArchiveStorage, month, outDir string) (ArchiveManifest, error) {
+	var manifest ArchiveManifest
+
+	tempDir, tempErr := os.MkdirTemp("", "influence-eth-archive-")
+	if tempErr != nil {
+		return manifest, tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	manifestPath := filepath.Join(tempDir, ARCHIVE_MANIFEST_FILE)
+	if getErr := storage.Get(path.Join(month, ARCHIVE_MANIFEST_FILE), manifestPath); getErr != nil {
+		return manifest, getErr
+	}
+	manifestData, readErr := os.ReadFile(manifestPath)
+	if readErr != nil {
+		return manifest, readErr
+	}
+	if unmErr := json.Unmarshal(manifestData, &manifest); unmErr != nil {
+		return manifest, fmt.Errorf("Unable to parse archive manifest, err: %v", unmErr)
+	}
+
+	if outDir != "" {
+		if mkdirErr := os.MkdirAll(outDir, 0755); mkdirErr != nil {
+			return manifest, mkdirErr
+		}
+	}
+
+	for _, file := range manifest.Files {
+		compressedPath := filepath.Join(tempDir, file.CompressedName)
+		if getErr := storage.Get(path.Join(month, file.CompressedName), compressedPath); getErr != nil {
+			return manifest, getErr
+		}
+		compressedHash, _, hashErr := fileSHA256(compressedPath)
+		if hashErr != nil {
+			return manifest, hashErr
+		}
+		if compressedHash != file.CompressedSHA256 {
+			return manifest, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", file.CompressedName, file.CompressedSHA256, compressedHash)
+		}
+
+		restoredPath := filepath.Join(tempDir, file.Name)
+		if outDir != "" {
+			restoredPath = filepath.Join(outDir, file.Name)
+		}
+		if gunzipErr := gunzipFile(compressedPath, restoredPath); gunzipErr != nil {
+			return manifest, gunzipErr
+		}
+		hash, _, hashErr := fileSHA256(restoredPath)
+		if hashErr != nil {
+			return manifest, hashErr
+		}
+		if hash != file.SHA256 {
+			return manifest, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", file.Name, file.SHA256, hash)
+		}
+		if outDir == "" {
+			os.Remove(restoredPath)
+		}
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/moonstream-to/influence-eth/pkg/crawler"
+	"github.com/moonstream-to/influence-eth/pkg/leaderboards"
+)
+
+// NewBlockResolver creates the resolver of block aliases passed to commands, with the Starknet RPC
+// provider at providerURL (or STARKNET_RPC_URL) if set, and the start and end blocks of the rounds
+// of the registry file if set.
+func NewBlockResolver(providerURL, registryPath string) (*crawler.BlockResolver, error) {
+	if providerURL == "" {
+		providerURL = os.Getenv("STARKNET_RPC_URL")
+	}
+
+	resolver := crawler.NewBlockResolver(nil, nil)
+	if providerURL != "" {
+		client, clientErr := rpc.NewClient(providerURL)
+		if clientErr != nil {
+			return nil, clientErr
+		}
+		resolver.Client = client
+	}
+	if registryPath != "" {
+		registry, registryErr := leaderboards.ReadRoundsRegistry(registryPath)
+		if registryErr != nil {
+			return nil, registryErr
+		}
+		resolver.Named = registry.BlockAliases()
+	}
+	return resolver, nil
+}
@@ -2,25 +2,38 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"math/big"
+	"net/http"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 	"github.com/spf13/cobra"
+
+	"github.com/moonstream-to/influence-eth/pkg/chaos"
+	"github.com/moonstream-to/influence-eth/pkg/crawler"
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+	"github.com/moonstream-to/influence-eth/pkg/leaderboards"
 )
 
 func CreateRootCommand() *cobra.Command {
+	leaderboards.VERSION = Version
+
 	// rootCmd represents the base command when called without any subcommands
 	rootCmd := &cobra.Command{
 		Use:   "influence-eth",
@@ -30,6 +43,40 @@ func CreateRootCommand() *cobra.Command {
 		},
 	}
 
+	var profile, credentialsFile, configDir string
+	var chaosRPCFailureRate, chaosMalformedLineRate, chaosAPIErrorRate float64
+	var chaosSeed int64
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Profile of the credentials file to take the Moonstream.to API URL and access token from (defaults to \"default\")")
+	rootCmd.PersistentFlags().StringVar(&credentialsFile, "credentials-file", "", "Credentials file with profiles (defaults to ~/.influence-eth/credentials)")
+	rootCmd.PersistentFlags().StringVar(&configDir, "config-dir", "", "Directory with missions.json, constants.json or product-catalog.json to override the embedded defaults with")
+
+	// Failure injection for testing retries, dead letters and failure reports, not meant for
+	// production runs
+	rootCmd.PersistentFlags().Float64Var(&chaosRPCFailureRate, "chaos-rpc-failure-rate", 0, "Share of RPC requests of event crawls to fail")
+	rootCmd.PersistentFlags().Float64Var(&chaosMalformedLineRate, "chaos-malformed-line-rate", 0, "Share of event lines to cut short before they are decoded")
+	rootCmd.PersistentFlags().Float64Var(&chaosAPIErrorRate, "chaos-api-error-rate", 0, "Share of Moonstream.to API requests to answer with a 500 instead of sending them")
+	rootCmd.PersistentFlags().Int64Var(&chaosSeed, "chaos-seed", 0, "Seed of the failure injection, runs with the same seed fail the same requests and lines (defaults to a random seed)")
+	for _, flag := range []string{"chaos-rpc-failure-rate", "chaos-malformed-line-rate", "chaos-api-error-rate", "chaos-seed"} {
+		rootCmd.PersistentFlags().MarkHidden(flag)
+	}
+
+	// Commands define their own persistent hooks, so the profile is applied on initialization,
+	// once flags are parsed
+	cobra.OnInitialize(func() {
+		if profileErr := leaderboards.ApplyProfile(credentialsFile, profile); profileErr != nil {
+			fmt.Fprintln(os.Stderr, profileErr.Error())
+			os.Exit(1)
+		}
+		if configErr := leaderboards.SetConfigDir(configDir); configErr != nil {
+			fmt.Fprintln(os.Stderr, configErr.Error())
+			os.Exit(1)
+		}
+		if chaosErr := chaos.Configure(chaosRPCFailureRate, chaosMalformedLineRate, chaosAPIErrorRate, chaosSeed); chaosErr != nil {
+			fmt.Fprintln(os.Stderr, chaosErr.Error())
+			os.Exit(1)
+		}
+	})
+
 	completionCmd := CreateCompletionCommand(rootCmd)
 	versionCmd := CreateVersionCommand()
 	blockNumberCmd := CreateBlockNumberCommand()
@@ -39,7 +86,18 @@ func CreateRootCommand() *cobra.Command {
 	parseCmd := CreateParseCommand()
 	leaderboardCmd := CreateLeaderboardCommand()
 	leaderboardsCmd := CreateLeaderboardsCommand()
-	rootCmd.AddCommand(completionCmd, versionCmd, doEverythingCmd, blockNumberCmd, eventsCmd, findDeploymentBlockCmd, parseCmd, leaderboardCmd, leaderboardsCmd)
+	replayCmd := CreateReplayCommand()
+	roundsCmd := CreateRoundsCommand()
+	rpcProxyCmd := CreateRPCProxyCommand()
+	exportCmd := CreateExportCommand()
+	analyticsCmd := CreateAnalyticsCommand()
+	archiveCmd := CreateArchiveCommand()
+	eligibilityCmd := CreateEligibilityCommand()
+	stateCmd := CreateStateCommand()
+	fixturesCmd := CreateFixturesCommand()
+	workerCmd := CreateWorkerCommand()
+	configCmd := CreateConfigCommand()
+	rootCmd.AddCommand(completionCmd, versionCmd, configCmd, doEverythingCmd, blockNumberCmd, eventsCmd, findDeploymentBlockCmd, parseCmd, leaderboardCmd, leaderboardsCmd, replayCmd, roundsCmd, rpcProxyCmd, exportCmd, analyticsCmd, archiveCmd, eligibilityCmd, stateCmd, fixturesCmd, workerCmd)
 
 	// By default, cobra Command objects write to stderr. We have to forcibly set them to output to
 	// stdout.
@@ -114,6 +172,45 @@ func CreateVersionCommand() *cobra.Command {
 	return versionCmd
 }
 
+func CreateConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Embedded defaults of missions, constants and products",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	var outdir string
+	var overwrite bool
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the embedded defaults to a directory",
+		Long:  "Write the embedded missions.json, constants.json and product-catalog.json to a directory. Edit them and pass the directory with --config-dir to override the defaults, files and fields left out keep the embedded values.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if outdir == "" {
+				return errors.New("please specify directory to write defaults to with --outdir")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			written, exportErr := leaderboards.ExportDefaults(outdir, overwrite)
+			for _, path := range written {
+				cmd.Println(path)
+			}
+			return exportErr
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&outdir, "outdir", "o", "", "Directory to write the defaults to")
+	exportCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace files which already exist in the directory")
+
+	configCmd.AddCommand(exportCmd)
+
+	return configCmd
+}
+
 func CreateBlockNumberCommand() *cobra.Command {
 	var providerURL string
 	var timeout uint64
@@ -141,7 +238,9 @@ func CreateBlockNumberCommand() *cobra.Command {
 
 			ctx := context.Background()
 			if timeout > 0 {
-				ctx, _ = context.WithDeadline(ctx, time.Now().Add(time.Duration(timeout)*time.Second))
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, time.Now().Add(time.Duration(timeout)*time.Second))
+				defer cancel()
 			}
 
 			blockNumber, err := provider.BlockNumber(ctx)
@@ -161,9 +260,12 @@ func CreateBlockNumberCommand() *cobra.Command {
 }
 
 func CreateEventsCommand() *cobra.Command {
-	var providerURL, contractAddress string
-	var timeout, fromBlock, toBlock uint64
-	var batchSize, coldInterval, hotInterval, hotThreshold, confirmations int
+	var providerURL, network, contractName, outfile, rotateSize, lagWebhook, fromAlias, toAlias, roundsRegistry, auditLog, leaderboardsMapFilePath, checkpointFile, abiFile string
+	var timeout, fromBlock, toBlock, maxLag, crew uint64
+	var batchSize, coldInterval, hotInterval, hotThreshold, confirmations, blockBatchSize, lagCheckInterval, dedupeWindow, flushEvery int
+	var rotateDaily, blockTimestamps, transactionFees, exitOnLag, sinceLastPush bool
+	var filterExpressions, contractAddresses []string
+	var flushInterval, checkpointInterval time.Duration
 
 	eventsCmd := &cobra.Command{
 		Use:   "events",
@@ -179,59 +281,584 @@ func CreateEventsCommand() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			crawler.DEDUPE_WINDOW_SIZE = dedupeWindow
+			if flushEvery < 0 || flushInterval < 0 {
+				return errors.New("--flush-every and --flush-interval must not be negative")
+			}
+
+			feltFilters, filtersErr := influence.ParseFeltFilters(filterExpressions)
+			if filtersErr != nil {
+				return filtersErr
+			}
+
 			client, clientErr := rpc.NewClient(providerURL)
 			if clientErr != nil {
 				return clientErr
 			}
 
 			provider := rpc.NewProvider(client)
-			ctx := context.Background()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 
-			eventsChan := make(chan RawEvent)
+			resolver, resolverErr := NewBlockResolver(providerURL, roundsRegistry)
+			if resolverErr != nil {
+				return resolverErr
+			}
+			var resolveErr error
+			if fromBlock, resolveErr = resolver.Resolve(ctx, fromAlias); resolveErr != nil {
+				return resolveErr
+			}
+			if toBlock, resolveErr = resolver.Resolve(ctx, toAlias); resolveErr != nil {
+				return resolveErr
+			}
 
-			// If "fromBlock" is not specified, find the block at which the contract was deployed and
-			// use that instead.
-			if fromBlock == 0 {
-				addressFelt, parseAddressErr := FeltFromHexString(contractAddress)
-				if parseAddressErr != nil {
-					return parseAddressErr
+			// Delta crawls pick up after the events of the last pushes, so the events file only
+			// grows by the blocks the leaderboards have not seen yet
+			if sinceLastPush {
+				if cmd.Flags().Changed("from") {
+					return errors.New("use either --from or --since-last-push, not both")
+				}
+				var leaderboardIds []string
+				if leaderboardsMapFilePath != "" {
+					leaderboardsMap, mapErr := leaderboards.ReadLeaderboardsMap(leaderboardsMapFilePath)
+					if mapErr != nil {
+						return mapErr
+					}
+					for _, targets := range leaderboardsMap {
+						for _, target := range targets {
+							leaderboardIds = append(leaderboardIds, target.LeaderboardId)
+						}
+					}
 				}
-				deploymentBlock, fromBlockErr := DeploymentBlock(ctx, provider, addressFelt)
-				if fromBlockErr != nil {
-					return fromBlockErr
+				lastBlock, pushed, lastErr := leaderboards.LastPushedBlock(auditLog, leaderboardIds)
+				if lastErr != nil {
+					return lastErr
+				}
+				if pushed {
+					fromBlock = lastBlock + 1
+					log.Printf("Crawling from block %d, after the events of the last pushes in %s", fromBlock, auditLog)
+				} else {
+					log.Printf("Warning: no successful push with a known block range in %s, crawling from the deployment block", auditLog)
 				}
-				fromBlock = deploymentBlock
 			}
 
-			go ContractEvents(ctx, provider, contractAddress, eventsChan, hotThreshold, time.Duration(hotInterval)*time.Millisecond, time.Duration(coldInterval)*time.Millisecond, fromBlock, toBlock, confirmations, batchSize)
+			eventsChan := make(chan influence.RawEvent)
 
-			for event := range eventsChan {
-				unparsedEvent := ParsedEvent{Name: EVENT_UNKNOWN, Event: event}
-				serializedEvent, marshalErr := json.Marshal(unparsedEvent)
-				if marshalErr != nil {
-					cmd.ErrOrStderr().Write([]byte(marshalErr.Error()))
+			addresses := contractAddresses
+			if contractName != "" {
+				if len(contractAddresses) > 0 {
+					return errors.New("use either -c/--contract or --contract-name, not both")
+				}
+				var addressesErr error
+				addresses, addressesErr = crawler.ContractAddresses(network, contractName)
+				if addressesErr != nil {
+					return addressesErr
 				}
-				cmd.Println(string(serializedEvent))
 			}
 
-			return nil
+			// Every contract is crawled with its own cursor, as a Starknet event filter takes a
+			// single address, and its events are tagged with the name of the contract if it is known
+			var contractLabels map[felt.Felt]string
+			if len(addresses) > 0 {
+				var labelsErr error
+				addresses, contractLabels, labelsErr = crawler.ContractLabels(network, addresses)
+				if labelsErr != nil {
+					return labelsErr
+				}
+			} else {
+				addresses = []string{""}
+			}
+
+			var checkpoint *crawler.CheckpointFile
+			if checkpointFile != "" {
+				var checkpointErr error
+				checkpoint, checkpointErr = crawler.LoadCheckpointFile(checkpointFile, batchSize)
+				if checkpointErr != nil {
+					return checkpointErr
+				}
+			}
+
+			// If "fromBlock" is not specified, find the block at which each version of the contract
+			// was deployed and use that instead. Crawls with a checkpoint resume from their cursor.
+			fromBlocks := make([]uint64, len(addresses))
+			cursors := make([]crawler.CrawlCursor, len(addresses))
+			for i, address := range addresses {
+				fromBlocks[i] = fromBlock
+				cursors[i] = crawler.CrawlCursor{FromBlock: fromBlock, ToBlock: toBlock}
+				if checkpoint != nil {
+					if cursor, ok := checkpoint.Cursor(address); ok {
+						if cursor.ContinuationToken == "" {
+							cursor.ToBlock = toBlock
+						}
+						cursors[i] = cursor
+						fromBlocks[i] = cursor.FromBlock
+						log.Printf("Resuming crawl of %s from block %d with checkpoint %s", address, cursor.FromBlock, checkpointFile)
+						continue
+					}
+				}
+				if fromBlock == 0 {
+					addressFelt, parseAddressErr := influence.FeltFromHexString(address)
+					if parseAddressErr != nil {
+						return parseAddressErr
+					}
+					deploymentBlock, fromBlockErr := crawler.DeploymentBlock(ctx, provider, addressFelt)
+					if fromBlockErr != nil {
+						return fromBlockErr
+					}
+					fromBlocks[i] = deploymentBlock
+					cursors[i].FromBlock = deploymentBlock
+				}
+			}
+
+			var lagGuard *crawler.LagGuard
+			lagErrChan := make(chan error, 1)
+			if maxLag > 0 {
+				startBlock := fromBlocks[0]
+				for _, block := range fromBlocks {
+					if block < startBlock {
+						startBlock = block
+					}
+				}
+				contract := contractName
+				if contract == "" {
+					contract = strings.Join(contractAddresses, ",")
+				}
+				lagGuard = crawler.NewLagGuard(contract, maxLag, uint64(confirmations), startBlock)
+				lagGuard.Interval = time.Duration(lagCheckInterval) * time.Second
+				lagGuard.WebhookURL = lagWebhook
+				lagGuard.Exit = exitOnLag
+				go func() {
+					lagErrChan <- lagGuard.Run(ctx, provider)
+				}()
+			}
+
+			// Events of a crew are filtered by key where the Dispatcher indexes the caller crew, other
+			// events are crawled in full and filtered after parsing
+			var crewFilter *influence.CrewEventFilter
+			if crew > 0 {
+				abi, abiErr := ReadABI(abiFile)
+				if abiErr != nil {
+					return abiErr
+				}
+				keyed, keyedErr := influence.CrewKeyedEvents(abi)
+				if keyedErr != nil {
+					return fmt.Errorf("Unable to read the events indexing their caller crew, err: %v", keyedErr)
+				}
+				influence.CREW_KEYED_EVENTS = keyed
+				keys, keysErr := influence.CrewKeysFilter(crew)
+				if keysErr == nil {
+					crawler.EVENT_KEYS_FILTER = keys
+				} else if errors.Is(keysErr, influence.ErrNoCrewKeyedEvents) {
+					log.Printf("Warning: %v, crawling all events and keeping those of crew %d", keysErr, crew)
+					var filterErr error
+					crewFilter, filterErr = influence.NewCrewEventFilter(crew)
+					if filterErr != nil {
+						return filterErr
+					}
+				} else {
+					return keysErr
+				}
+			}
+
+			// The crawl closes eventsChan when it ends, its error is read once the events before it
+			// are written
+			crawlErrChan := make(chan error, 1)
+			go func() {
+				var onPage func(string, crawler.CrawlCursor)
+				if checkpoint != nil {
+					onPage = checkpoint.Update
+				}
+				crawlProvider := crawler.WithTimeout(crawler.WithChaos(provider), time.Duration(timeout)*time.Second)
+				crawlErrChan <- crawler.ContractVersionsEvents(ctx, crawlProvider, addresses, cursors, eventsChan, hotThreshold, time.Duration(hotInterval)*time.Millisecond, time.Duration(coldInterval)*time.Millisecond, toBlock, confirmations, batchSize, onPage)
+			}()
+
+			var rotatingWriter *influence.RotatingWriter
+			var ofp io.Writer = cmd.OutOrStdout()
+			if outfile != "" {
+				if rotateSize != "" || rotateDaily {
+					var maxSize int64
+					if rotateSize != "" {
+						var sizeErr error
+						maxSize, sizeErr = influence.ParseSize(rotateSize)
+						if sizeErr != nil {
+							return sizeErr
+						}
+					}
+					var writerErr error
+					rotatingWriter, writerErr = influence.NewRotatingWriter(outfile, maxSize, rotateDaily)
+					if writerErr != nil {
+						return writerErr
+					}
+					defer rotatingWriter.Close()
+				} else {
+					outputFile, openErr := os.OpenFile(outfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+					if openErr != nil {
+						return openErr
+					}
+					defer outputFile.Close()
+					ofp = outputFile
+				}
+			}
+
+			// Event lines are buffered, and flushed following the flush policy so consumers following
+			// the output see events soon after they are crawled
+			bufferedOutput := bufio.NewWriter(ofp)
+			defer bufferedOutput.Flush()
+			flushPolicy := &influence.FlushPolicy{Every: flushEvery, Interval: flushInterval}
+			flushOutput := func() error {
+				if !flushPolicy.Pending() {
+					return nil
+				}
+				flushPolicy.Flushed()
+				if rotatingWriter != nil {
+					return rotatingWriter.Flush()
+				}
+				return bufferedOutput.Flush()
+			}
+			var outputFlushTicker <-chan time.Time
+			if flushInterval > 0 {
+				ticker := time.NewTicker(flushInterval)
+				defer ticker.Stop()
+				outputFlushTicker = ticker.C
+			}
+
+			var fetcher *crawler.BlockMetadataFetcher
+			var receiptFetcher *crawler.ReceiptFetcher
+			var flushTicker <-chan time.Time
+			if blockTimestamps {
+				fetcher = crawler.NewBlockMetadataFetcher(client, blockBatchSize)
+			}
+			if transactionFees {
+				receiptFetcher = crawler.NewReceiptFetcher(client, blockBatchSize)
+			}
+			buffered := fetcher != nil || receiptFetcher != nil
+			flushSize := crawler.BLOCK_METADATA_BATCH_SIZE
+			if blockBatchSize > 0 {
+				flushSize = blockBatchSize
+			}
+			if buffered {
+				ticker := time.NewTicker(crawler.BLOCK_METADATA_FLUSH_INTERVAL)
+				defer ticker.Stop()
+				flushTicker = ticker.C
+			}
+
+			// Events are buffered while block timestamps or transaction fees are enabled, so the
+			// metadata of many blocks and transactions is fetched in a single batch request
+			var pending []influence.RawEvent
+			pendingBlocks := make(map[uint64]bool)
+			flush := func() error {
+				var blocks map[uint64]crawler.BlockMetadata
+				if fetcher != nil && len(pending) > 0 {
+					blockNumbers := make([]uint64, 0, len(pendingBlocks))
+					for blockNumber := range pendingBlocks {
+						blockNumbers = append(blockNumbers, blockNumber)
+					}
+					var fetchErr error
+					blocks, fetchErr = fetcher.Fetch(ctx, blockNumbers)
+					if fetchErr != nil {
+						return fetchErr
+					}
+				}
+
+				var receipts map[string]crawler.TransactionReceipt
+				if receiptFetcher != nil && len(pending) > 0 {
+					transactionHashes := make([]string, 0, len(pending))
+					for _, event := range pending {
+						transactionHashes = append(transactionHashes, event.TransactionHash.String())
+					}
+					var fetchErr error
+					receipts, fetchErr = receiptFetcher.Fetch(ctx, transactionHashes)
+					if fetchErr != nil {
+						return fetchErr
+					}
+				}
+
+				for _, event := range pending {
+					unparsedEvent := leaderboards.EventLine{Name: influence.EVENT_UNKNOWN, Event: event, Confirmations: confirmations}
+					if event.FromAddress != nil {
+						unparsedEvent.Contract = contractLabels[*event.FromAddress]
+					}
+					if fetcher != nil {
+						unparsedEvent.Timestamp = blocks[event.BlockNumber].Timestamp
+					}
+					if receiptFetcher != nil {
+						if receipt, ok := receipts[event.TransactionHash.String()]; ok && receipt.ActualFee.Amount != nil {
+							unparsedEvent.TransactionFee = &leaderboards.TransactionFee{Amount: receipt.ActualFee.Amount.String(), Unit: receipt.ActualFee.Unit}
+						}
+					}
+					serializedEvent, marshalErr := json.Marshal(unparsedEvent)
+					if marshalErr != nil {
+						cmd.ErrOrStderr().Write([]byte(marshalErr.Error()))
+					}
+					if rotatingWriter != nil {
+						if writeErr := rotatingWriter.Write(event.BlockNumber, serializedEvent); writeErr != nil {
+							return writeErr
+						}
+					} else {
+						fmt.Fprintln(bufferedOutput, string(serializedEvent))
+					}
+					if flushPolicy.Wrote() {
+						if flushErr := flushOutput(); flushErr != nil {
+							return flushErr
+						}
+					}
+				}
+
+				pending = pending[:0]
+				pendingBlocks = make(map[uint64]bool)
+				return nil
+			}
+
+			// Cursors are saved once the events before them are in the output, so a crawl killed in
+			// between resumes at the page it was crawling
+			var checkpointTicker <-chan time.Time
+			saveCheckpoint := func() error {
+				if checkpoint == nil {
+					return nil
+				}
+				if flushErr := flush(); flushErr != nil {
+					return flushErr
+				}
+				if flushErr := flushOutput(); flushErr != nil {
+					return flushErr
+				}
+				return checkpoint.Save()
+			}
+			if checkpoint != nil && checkpointInterval > 0 {
+				ticker := time.NewTicker(checkpointInterval)
+				defer ticker.Stop()
+				checkpointTicker = ticker.C
+			}
+
+			for {
+				select {
+				case event, ok := <-eventsChan:
+					if !ok {
+						if flushErr := flush(); flushErr != nil {
+							return flushErr
+						}
+						if flushErr := flushOutput(); flushErr != nil {
+							return flushErr
+						}
+						if saveErr := saveCheckpoint(); saveErr != nil {
+							return saveErr
+						}
+						if crawlErr := <-crawlErrChan; crawlErr != nil {
+							return fmt.Errorf("Crawl failed, err: %v", crawlErr)
+						}
+						return nil
+					}
+					if lagGuard != nil {
+						lagGuard.Record(event.BlockNumber)
+					}
+					if crewFilter != nil && !crewFilter.Matches(event) {
+						continue
+					}
+					if !influence.MatchesFeltFilters(feltFilters, event) {
+						continue
+					}
+					pending = append(pending, event)
+					pendingBlocks[event.BlockNumber] = true
+					if !buffered || len(pendingBlocks) >= flushSize || (receiptFetcher != nil && len(pending) >= flushSize) {
+						if flushErr := flush(); flushErr != nil {
+							return flushErr
+						}
+					}
+				case <-flushTicker:
+					if flushErr := flush(); flushErr != nil {
+						return flushErr
+					}
+				case <-outputFlushTicker:
+					if flushErr := flushOutput(); flushErr != nil {
+						return flushErr
+					}
+				case <-checkpointTicker:
+					if saveErr := saveCheckpoint(); saveErr != nil {
+						return saveErr
+					}
+				case lagErr := <-lagErrChan:
+					if lagErr != nil {
+						// Events crawled so far are written before exiting
+						if flushErr := flush(); flushErr != nil {
+							return flushErr
+						}
+						if saveErr := saveCheckpoint(); saveErr != nil {
+							return saveErr
+						}
+						return lagErr
+					}
+				}
+			}
 		},
 	}
 
 	eventsCmd.PersistentFlags().StringVarP(&providerURL, "provider", "p", "", "The URL of your Starknet RPC provider (defaults to value of STARKNET_RPC_URL environment variable)")
-	eventsCmd.PersistentFlags().Uint64VarP(&timeout, "timeout", "t", 0, "The timeout for requests to your Starknet RPC provider")
-	eventsCmd.Flags().StringVarP(&contractAddress, "contract", "c", "", "The address of the contract from which to crawl events (if not provided, no contract constraint will be specified)")
+	eventsCmd.Flags().Uint64VarP(&timeout, "timeout", "t", 0, "Seconds after which a request of the crawl to your Starknet RPC provider fails (0 disables the timeout)")
+	eventsCmd.Flags().StringSliceVarP(&contractAddresses, "contract", "c", nil, "The address of a contract from which to crawl events, can be repeated to crawl several contracts in one run (if not provided, no contract constraint will be specified)")
+	eventsCmd.Flags().StringVar(&network, "network", "mainnet", "Network of the contract named with --contract-name (mainnet, sepolia or goerli)")
+	eventsCmd.Flags().StringVar(&contractName, "contract-name", "", "Name of the Influence contract from which to crawl events (e.g. dispatcher), resolved to the addresses of all its versions on --network")
 	eventsCmd.Flags().IntVarP(&batchSize, "batch-size", "N", 100, "The number of events to fetch per batch (defaults to 100)")
 	eventsCmd.Flags().IntVar(&hotThreshold, "hot-threshold", 2, "Number of successive iterations which must return events before we consider the crawler hot")
 	eventsCmd.Flags().IntVar(&hotInterval, "hot-interval", 100, "Milliseconds at which to poll the provider for updates on the contract while the crawl is hot")
 	eventsCmd.Flags().IntVar(&coldInterval, "cold-interval", 10000, "Milliseconds at which to poll the provider for updates on the contract while the crawl is cold")
 	eventsCmd.Flags().IntVar(&confirmations, "confirmations", 5, "Number of confirmations to wait for before considering a block canonical")
-	eventsCmd.Flags().Uint64Var(&fromBlock, "from", 0, "The block number from which to start crawling")
-	eventsCmd.Flags().Uint64Var(&toBlock, "to", 0, "The block number to which to crawl (set to 0 for continuous crawl)")
+	eventsCmd.Flags().StringVar(&fromAlias, "from", "0", "The block from which to start crawling, a block number or alias such as latest-7d or round2-start")
+	eventsCmd.Flags().StringVar(&toAlias, "to", "0", "The block to which to crawl, a block number or alias such as latest or round2-end (set to 0 for continuous crawl)")
+	eventsCmd.Flags().BoolVar(&sinceLastPush, "since-last-push", false, "Crawl from the block after the events of the last successful pushes recorded in --audit-log, to append only the blocks pushed leaderboards have not seen")
+	eventsCmd.Flags().StringVar(&auditLog, "audit-log", leaderboards.AUDIT_LOG, "Audit log of the leaderboard pushes read with --since-last-push")
+	eventsCmd.Flags().StringVarP(&leaderboardsMapFilePath, "leaderboards-map", "m", "", "With --since-last-push, crawl from the oldest last push of the leaderboards of this map instead of the latest push to any leaderboard")
+	eventsCmd.PersistentFlags().StringVar(&roundsRegistry, "rounds-registry", "", "Rounds registry JSON file to resolve block aliases such as round2-start and current-end with")
+	eventsCmd.Flags().StringVar(&checkpointFile, "checkpoint-file", "", "File to save the cursor of the crawl of each contract to, and to resume from when restarted, taking precedence over --from and --since-last-push (disabled by default)")
+	eventsCmd.Flags().DurationVar(&checkpointInterval, "checkpoint-interval", 10*time.Second, "Interval at which to flush the output and save cursors to --checkpoint-file")
+	eventsCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to append events to (defaults to stdout), with a %d placeholder for the segment number if rotated, e.g. events-%d.jsonl")
+	eventsCmd.Flags().StringVar(&rotateSize, "rotate-size", "", "Start a new output file once the current one reaches this size, e.g. 1GB")
+	eventsCmd.Flags().BoolVar(&rotateDaily, "rotate-daily", false, "Start a new output file every day (UTC)")
+	eventsCmd.Flags().BoolVar(&blockTimestamps, "block-timestamps", false, "Add the timestamp of their block to events, fetched with JSON-RPC batch requests (or one by one if the provider does not support batches)")
+	eventsCmd.Flags().Uint64Var(&maxLag, "max-lag", 0, "Alert if the crawl falls more than this many blocks behind the chain head (disabled by default)")
+	eventsCmd.Flags().IntVar(&lagCheckInterval, "lag-check-interval", int(crawler.LAG_CHECK_INTERVAL/time.Second), "Seconds between checks of the chain head with --max-lag")
+	eventsCmd.Flags().StringVar(&lagWebhook, "lag-webhook", "", "URL to POST a JSON alert to when the crawl falls behind by more than --max-lag blocks, and once it caught up")
+	eventsCmd.Flags().IntVar(&dedupeWindow, "dedupe-window", crawler.DEDUPE_WINDOW_SIZE, "Number of recent events remembered to drop duplicates delivered again by overlapping crawl iterations (0 to disable)")
+	eventsCmd.Flags().BoolVar(&exitOnLag, "exit-on-lag", false, fmt.Sprintf("Exit with code %d when the crawl falls behind by more than --max-lag blocks, so orchestration can restart it", LAG_EXIT_CODE))
+	eventsCmd.Flags().BoolVar(&transactionFees, "transaction-fees", false, "Add the fee paid by their transaction to events, fetched from transaction receipts with JSON-RPC batch requests")
+	eventsCmd.Flags().IntVar(&flushEvery, "flush-every", influence.FLUSH_EVERY, "Flush the output after every N events (0 to only flush on --flush-interval and when the buffer is full)")
+	eventsCmd.Flags().DurationVar(&flushInterval, "flush-interval", influence.FLUSH_INTERVAL, "Flush buffered events to the output at this interval, so consumers following the output see them promptly (0 to disable)")
+	eventsCmd.Flags().StringArrayVar(&filterExpressions, "filter", nil, "Only write events matching this expression on their felts, e.g. 'keys[1]==0x3 && parameters[0]>=100' (can be repeated, events must match all filters)")
+	eventsCmd.Flags().Uint64Var(&crew, "crew", 0, "Only crawl events emitted by this crew, filtered by key where the Dispatcher indexes the caller crew")
+	eventsCmd.Flags().StringVar(&abiFile, "abi", "", "ABI file listing which events index their caller crew in their keys, for --crew (defaults to the ABI embedded in the binary)")
+	eventsCmd.Flags().IntVar(&blockBatchSize, "block-batch-size", crawler.BLOCK_METADATA_BATCH_SIZE, "Number of blocks (or transaction receipts) to request per JSON-RPC batch with --block-timestamps (or --transaction-fees)")
+
+	eventsTracesCmd := CreateEventsTracesCommand(&providerURL, &roundsRegistry)
+	eventsCmd.AddCommand(eventsTracesCmd)
 
 	return eventsCmd
 }
 
+func CreateEventsTracesCommand(providerURL, roundsRegistry *string) *cobra.Command {
+	var infile, outfile, contractAddress, network, contractName, fromAlias, toAlias string
+	var fromBlock, toBlock, minGap, maxBlocks uint64
+
+	tracesCmd := &cobra.Command{
+		Use:   "traces",
+		Short: "Re-derive events missing from a crawl from transaction traces",
+		Long:  "Some providers miss events in starknet_getEvents responses, mostly of older blocks. This command finds gaps of more than --min-gap blocks without events in a crawl, traces the transactions of the blocks in them and writes the events of the contract which the crawl does not have, as the events command would have written them. Append them to the crawl to complete it.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return errors.New("please specify the crawled events with -i/--infile")
+			}
+
+			addresses := []string{contractAddress}
+			if contractName != "" {
+				if contractAddress != "" {
+					return errors.New("use either -c/--contract or --contract-name, not both")
+				}
+				var addressesErr error
+				addresses, addressesErr = crawler.ContractAddresses(network, contractName)
+				if addressesErr != nil {
+					return addressesErr
+				}
+			} else if contractAddress == "" {
+				return errors.New("please specify the contract with -c/--contract or --contract-name")
+			}
+			addressFelts := make([]*felt.Felt, len(addresses))
+			for i, address := range addresses {
+				addressFelt, parseAddressErr := influence.FeltFromHexString(address)
+				if parseAddressErr != nil {
+					return parseAddressErr
+				}
+				addressFelts[i] = addressFelt
+			}
+
+			resolver, resolverErr := NewBlockResolver(*providerURL, *roundsRegistry)
+			if resolverErr != nil {
+				return resolverErr
+			}
+			var resolveErr error
+			if fromBlock, resolveErr = resolver.Resolve(context.Background(), fromAlias); resolveErr != nil {
+				return resolveErr
+			}
+			if toBlock, resolveErr = resolver.Resolve(context.Background(), toAlias); resolveErr != nil {
+				return resolveErr
+			}
+
+			crawled, readErr := crawler.ReadCrawledEvents(infile)
+			if readErr != nil {
+				return readErr
+			}
+			blockNumbers := crawled.SuspiciousBlocks(fromBlock, toBlock, minGap)
+			if uint64(len(blockNumbers)) > maxBlocks {
+				return fmt.Errorf("%d blocks are in gaps of more than %d blocks, more than --max-blocks %d, narrow the range with --from and --to or raise --max-blocks", len(blockNumbers), minGap, maxBlocks)
+			}
+			log.Printf("Tracing %d blocks in gaps of more than %d blocks", len(blockNumbers), minGap)
+
+			client, clientErr := rpc.NewClient(*providerURL)
+			if clientErr != nil {
+				return clientErr
+			}
+			ctx := context.Background()
+
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, openErr := os.OpenFile(outfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+				if openErr != nil {
+					return openErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+
+			fetcher := crawler.NewBlockMetadataFetcher(client, crawler.BLOCK_METADATA_BATCH_SIZE)
+			recovered := 0
+			for start := 0; start < len(blockNumbers); start += fetcher.BatchSize {
+				end := start + fetcher.BatchSize
+				if end > len(blockNumbers) {
+					end = len(blockNumbers)
+				}
+				blocks, fetchErr := fetcher.Fetch(ctx, blockNumbers[start:end])
+				if fetchErr != nil {
+					return fetchErr
+				}
+
+				for _, blockNumber := range blockNumbers[start:end] {
+					if blocks[blockNumber].BlockHash == "" {
+						return fmt.Errorf("provider returned no hash of block %d", blockNumber)
+					}
+					blockHash, hashErr := influence.FeltFromHexString(blocks[blockNumber].BlockHash)
+					if hashErr != nil {
+						return fmt.Errorf("Unable to parse hash of block %d, err: %v", blockNumber, hashErr)
+					}
+					traced, traceErr := crawler.TraceBlockEvents(ctx, client, blockNumber, blockHash, addressFelts)
+					if traceErr != nil {
+						return traceErr
+					}
+					for _, event := range crawled.MissingEvents(traced) {
+						serializedEvent, marshalErr := json.Marshal(leaderboards.EventLine{Name: influence.EVENT_UNKNOWN, Event: event})
+						if marshalErr != nil {
+							return marshalErr
+						}
+						fmt.Fprintln(ofp, string(serializedEvent))
+						recovered++
+					}
+				}
+			}
+
+			log.Printf("Recovered %d events missing from %s", recovered, infile)
+			return nil
+		},
+	}
+
+	tracesCmd.Flags().StringVarP(&infile, "infile", "i", "", "File (or directory of partitioned files) containing crawled events, as produced by the \"influence-eth events\" or \"influence-eth parse\" commands")
+	tracesCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to append recovered events to (defaults to stdout)")
+	tracesCmd.Flags().StringVarP(&contractAddress, "contract", "c", "", "The address of the contract whose events to recover")
+	tracesCmd.Flags().StringVar(&network, "network", "mainnet", "Network of the contract named with --contract-name (mainnet, sepolia or goerli)")
+	tracesCmd.Flags().StringVar(&contractName, "contract-name", "", "Name of the Influence contract whose events to recover (e.g. dispatcher), resolved to the addresses of all its versions on --network")
+	tracesCmd.Flags().StringVar(&fromAlias, "from", "0", "The block from which to look for gaps, a block number or alias such as latest-7d or round2-start")
+	tracesCmd.Flags().StringVar(&toAlias, "to", "0", "The block up to which to look for gaps, a block number or alias such as round2-end (defaults to the last block of the crawl)")
+	tracesCmd.Flags().Uint64Var(&minGap, "min-gap", crawler.TRACE_MIN_GAP, "Minimum number of successive blocks without crawled events for them to be traced")
+	tracesCmd.Flags().Uint64Var(&maxBlocks, "max-blocks", crawler.TRACE_MAX_BLOCKS, "Maximum number of blocks to trace, the command fails before tracing if the gaps hold more")
+
+	return tracesCmd
+}
+
 func CreateFindDeploymentCmd() *cobra.Command {
 	var providerURL, contractAddress string
 
@@ -271,7 +898,7 @@ func CreateFindDeploymentCmd() *cobra.Command {
 			address := felt.NewFelt(&fieldAdditiveIdentity)
 			address.SetBytes(decodedAddress)
 
-			deploymentBlock, err := DeploymentBlock(ctx, provider, address)
+			deploymentBlock, err := crawler.DeploymentBlock(ctx, provider, address)
 			if err != nil {
 				return err
 			}
@@ -287,12 +914,42 @@ func CreateFindDeploymentCmd() *cobra.Command {
 	return findDeploymentCmd
 }
 
+// Size of the output buffer of the parse command, events are written in blocks of this size
+// rather than line by line.
+var PARSE_OUTPUT_BUFFER_SIZE = 1 << 20
+
 func CreateParseCommand() *cobra.Command {
-	var infile, outfile string
+	var infile, outfile, partitionBy, deadLetterFile string
+	var onlyEvents, excludeEvents, fields, decodeFields, tokenContracts []string
+	var annotateMissions, relevantOnly, eventTimes bool
+	var eventMissions map[string][]string
 
 	parseCmd := &cobra.Command{
 		Use:   "parse",
 		Short: "Parse a file (as produced by the \"stark events\" command) to process previously unknown events",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if partitionBy != "" {
+				if partitionBy != influence.PARTITION_BY_EVENT {
+					return fmt.Errorf("unsupported --partition-by value %s, supported values: %s", partitionBy, influence.PARTITION_BY_EVENT)
+				}
+				if outfile == "" {
+					return errors.New("flag -o/--outfile should be set to a directory when using --partition-by")
+				}
+			}
+			for _, spec := range decodeFields {
+				if decodingErr := influence.SetFieldDecoding(spec); decodingErr != nil {
+					return decodingErr
+				}
+			}
+			if annotateMissions || relevantOnly {
+				var missionsErr error
+				eventMissions, missionsErr = leaderboards.EventMissions()
+				if missionsErr != nil {
+					return missionsErr
+				}
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ifp := os.Stdin
 			var infileErr error
@@ -304,105 +961,546 @@ func CreateParseCommand() *cobra.Command {
 				defer ifp.Close()
 			}
 
+			var partitionedWriter *influence.PartitionedWriter
 			ofp := os.Stdout
 			var outfileErr error
-			if outfile != "" {
+			if partitionBy == influence.PARTITION_BY_EVENT {
+				partitionedWriter, outfileErr = influence.NewPartitionedWriter(outfile, false)
+				if outfileErr != nil {
+					return outfileErr
+				}
+				defer partitionedWriter.Close()
+			} else if outfile != "" {
 				ofp, outfileErr = os.Create(outfile)
 				if outfileErr != nil {
 					return outfileErr
 				}
 				defer ofp.Close()
 			}
+			output := bufio.NewWriterSize(ofp, PARSE_OUTPUT_BUFFER_SIZE)
+			defer output.Flush()
 
-			parser, newParserErr := NewEventParser()
+			parser, newParserErr := influence.NewEventParser()
 			if newParserErr != nil {
 				return newParserErr
 			}
 
+			// Transfer events of all token contracts share a selector, they are named by the
+			// address of the contract which emitted them
+			transfers, transfersErr := influence.NewTransferResolver(crawler.TokenContracts())
+			if transfersErr != nil {
+				return transfersErr
+			}
+			for _, spec := range tokenContracts {
+				name, address, ok := strings.Cut(spec, "=")
+				if !ok {
+					return fmt.Errorf("invalid --token-contract %s, expected <contract>=<address>", spec)
+				}
+				if setErr := transfers.SetContract(strings.TrimSpace(name), strings.TrimSpace(address)); setErr != nil {
+					return setErr
+				}
+			}
+
+			deadLetters, deadLetterErr := NewDeadLetterWriter(deadLetterFile)
+			if deadLetterErr != nil {
+				return deadLetterErr
+			}
+			defer deadLetters.Close()
+
 			newline := []byte("\n")
+			filter := NewEventFilter(onlyEvents, excludeEvents, fields)
+
+			writeEvent := func(eventName string, eventBytes []byte) error {
+				if !filter.Allows(eventName) {
+					return nil
+				}
+				if relevantOnly && len(eventMissions[eventName]) == 0 {
+					return nil
+				}
+
+				eventBytes, projectErr := filter.Project(eventBytes)
+				if projectErr != nil {
+					return projectErr
+				}
+
+				if partitionedWriter != nil {
+					return partitionedWriter.Write(eventName, eventBytes)
+				}
+
+				_, writeErr := output.Write(eventBytes)
+				if writeErr != nil {
+					return writeErr
+				}
+				_, writeErr = output.Write(newline)
+				return writeErr
+			}
+
+			// Lines are encoded into one scratch buffer, which is reused once they are written
+			var lineBuffer bytes.Buffer
+			lineEncoder := json.NewEncoder(&lineBuffer)
+			encodeLine := func(v any) ([]byte, error) {
+				lineBuffer.Reset()
+				if encodeErr := lineEncoder.Encode(v); encodeErr != nil {
+					return nil, encodeErr
+				}
+				return bytes.TrimSuffix(lineBuffer.Bytes(), newline), nil
+			}
 
+			lineNumber := 0
 			scanner := bufio.NewScanner(ifp)
 			for scanner.Scan() {
-				var partialEvent PartialEvent
-				line := scanner.Text()
-				json.Unmarshal([]byte(line), &partialEvent)
+				lineNumber++
+				var partialEvent leaderboards.PartialEventLine
+				json.Unmarshal(chaos.MalformLine(scanner.Bytes()), &partialEvent)
 
 				passThrough := true
 
-				if partialEvent.Name == EVENT_UNKNOWN {
-					var event RawEvent
+				if partialEvent.Name == influence.EVENT_UNKNOWN {
+					var event influence.RawEvent
 					json.Unmarshal(partialEvent.Event, &event)
-					parsedEvent, parseErr := parser.Parse(event)
+					parsedEvent, parseErr := parser.Decode(event)
+					if parseErr == nil {
+						parsedEvent, parseErr = transfers.Resolve(event, parsedEvent)
+					}
 					if parseErr == nil {
 						passThrough = false
 
-						parsedEventBytes, marshalErr := json.Marshal(parsedEvent)
+						eventLine := leaderboards.NewEventLine(parsedEvent, event.TransactionHash)
+						eventLine.Confirmations = partialEvent.Confirmations
+						eventLine.Timestamp = partialEvent.Timestamp
+						eventLine.TransactionFee = partialEvent.TransactionFee
+						eventLine.Contract = partialEvent.Contract
+						if annotateMissions {
+							eventLine.Missions = eventMissions[parsedEvent.Name]
+						}
+						if eventTimes {
+							eventLine.Times = influence.EventTimes(parsedEvent.Event)
+						}
+						parsedEventBytes, marshalErr := encodeLine(eventLine)
 						if marshalErr != nil {
 							return marshalErr
 						}
 
-						_, writeErr := ofp.Write(parsedEventBytes)
-						if writeErr != nil {
-							return writeErr
-						}
-						_, writeErr = ofp.Write(newline)
-						if writeErr != nil {
+						if writeErr := writeEvent(parsedEvent.Name, parsedEventBytes); writeErr != nil {
 							return writeErr
 						}
+					} else if writeErr := deadLetters.Write(lineNumber, partialEvent.Event, parseErr); writeErr != nil {
+						return writeErr
 					}
 				}
 
 				if passThrough {
-					partialEventBytes, marshalErr := json.Marshal(partialEvent)
+					if annotateMissions {
+						partialEvent.Missions = eventMissions[partialEvent.Name]
+					}
+					partialEventBytes, marshalErr := encodeLine(partialEvent)
 					if marshalErr != nil {
 						return marshalErr
 					}
 
-					_, writeErr := ofp.Write(partialEventBytes)
-					if writeErr != nil {
-						return writeErr
-					}
-					_, writeErr = ofp.Write(newline)
-					if writeErr != nil {
+					if writeErr := writeEvent(partialEvent.Name, partialEventBytes); writeErr != nil {
 						return writeErr
 					}
 				}
 			}
 
-			return nil
+			if flushErr := output.Flush(); flushErr != nil {
+				return flushErr
+			}
+			deadLetters.Report()
+			return deadLetters.Close()
 		},
 	}
 
 	parseCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing crawled events from which to build the leaderboard (as produced by the \"influence-eth stark events\" command, defaults to stdin)")
 	parseCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write reparsed events to (defaults to stdout)")
+	parseCmd.Flags().StringSliceVar(&onlyEvents, "only-events", nil, "Comma-separated names of events to keep, other events are dropped")
+	parseCmd.Flags().StringSliceVar(&excludeEvents, "exclude-events", nil, "Comma-separated names of events to drop")
+	parseCmd.Flags().StringSliceVar(&fields, "fields", nil, "Comma-separated event fields to keep, nested fields are separated with dots (e.g. CallerCrew.Id). BlockNumber is always kept")
+	parseCmd.Flags().StringSliceVar(&decodeFields, "decode-field", nil, "Decoding of a felt field as <event>.<field>=<mode>, with mode hex, address or short-string (e.g. NameChanged.Name.Value=hex), overriding the built-in decodings of names and addresses. Can be repeated")
+	parseCmd.Flags().StringVar(&partitionBy, "partition-by", "", "Set to \"event\" to write events into one file per event type in the -o/--outfile directory")
+	parseCmd.Flags().BoolVar(&annotateMissions, "annotate-missions", false, "Tag every event with the names of the registered missions which read it, as Missions")
+	parseCmd.Flags().BoolVar(&relevantOnly, "relevant-only", false, "Drop events which no registered mission reads")
+	parseCmd.Flags().BoolVar(&eventTimes, "event-times", false, "Add the time fields of events (e.g. FinishTime) as Times, with their UTC time and in-game time in Adalian days")
+	parseCmd.Flags().StringSliceVar(&tokenContracts, "token-contract", nil, "Token contract whose Transfer events to name after it, as <contract>=<address> with contract asteroid, crew, crewmate, ship or sway, in addition to the known addresses of each network. Can be repeated")
+	parseCmd.Flags().StringVar(&deadLetterFile, "dead-letter", "", "File to write events of known types which fail to decode to, together with the decode error (they are still passed through as UNKNOWN)")
 
 	return parseCmd
 }
 
-func CreateDoEverythingCommand() *cobra.Command {
-	var providerURL, contractAddress, outfile, fromBlockFilePath string
-	var batchSize, coldInterval, hotInterval, hotThreshold, confirmations int
+func CreateFixturesCommand() *cobra.Command {
+	var abiFile, outfile, eventNames string
+	var count int
+	var seed int64
+	var fromBlock, startTime, blockTime uint64
 
-	doEverythingCmd := &cobra.Command{
-		Use:   "do-everything",
-		Short: "Just do everything with events",
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if providerURL == "" {
-				providerURLFromEnv := os.Getenv("STARKNET_RPC_URL")
-				if providerURLFromEnv == "" {
-					return errors.New("you must provide a provider URL using -p/--provider or set the STARKNET_RPC_URL environment variable")
-				}
-				providerURL = providerURLFromEnv
+	fixturesCmd := &cobra.Command{
+		Use:   "fixtures",
+		Short: "Generate random events for testing",
+		Long:  "Generate random events of registered types from their ABI definitions, in the format written by \"influence-eth events\" for events which are not parsed yet. Every generated event is checked with the event decoders, so the file can seed tests of \"influence-eth parse\" and leaderboards without chain data. Runs with the same --seed generate the same events.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if count < 1 {
+				return fmt.Errorf("--count should be at least 1")
 			}
 
-			if fromBlockFilePath == "" {
-				return errors.New("flag --from-block-file should be set")
+			names, namesErr := ParseEventNames(eventNames)
+			if namesErr != nil {
+				return namesErr
+			}
+			infos, infosErr := influence.FixtureEvents(names)
+			if infosErr != nil {
+				return infosErr
+			}
+
+			abi, abiErr := ReadABI(abiFile)
+			if abiErr != nil {
+				return abiErr
+			}
+			generator, generatorErr := influence.NewFixtureGenerator(abi, seed)
+			if generatorErr != nil {
+				return generatorErr
+			}
+			parser, newParserErr := influence.NewEventParser()
+			if newParserErr != nil {
+				return newParserErr
+			}
+
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, createErr := os.Create(outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+			encoder := json.NewEncoder(ofp)
+
+			blockNumber := fromBlock
+			for i := 0; i < count; i++ {
+				for _, info := range infos {
+					event, eventErr := generator.Event(info, blockNumber)
+					if eventErr != nil {
+						return fmt.Errorf("Unable to generate %s, err: %v", info.Name, eventErr)
+					}
+					if checkErr := influence.CheckFixture(parser, info, event); checkErr != nil {
+						return fmt.Errorf("Generated %s does not decode, err: %v", info.Name, checkErr)
+					}
+
+					eventLine := leaderboards.EventLine{Name: influence.EVENT_UNKNOWN, Event: event}
+					if startTime > 0 {
+						eventLine.Timestamp = startTime + (blockNumber-fromBlock)*blockTime
+					}
+					if encodeErr := encoder.Encode(eventLine); encodeErr != nil {
+						return encodeErr
+					}
+					blockNumber++
+				}
+			}
+
+			return nil
+		},
+	}
+
+	fixturesCmd.Flags().StringVar(&abiFile, "abi", "", "ABI file with the definitions of the events (defaults to the ABI embedded in the binary)")
+	fixturesCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write generated events to (defaults to stdout)")
+	fixturesCmd.Flags().StringVar(&eventNames, "events", "", "Comma-separated names of events to generate, e.g. TransitFinished,ConstructionFinished (defaults to all events)")
+	fixturesCmd.Flags().IntVar(&count, "count", 10, "Number of events to generate of each event")
+	fixturesCmd.Flags().Int64Var(&seed, "seed", 1, "Seed of the random generator")
+	fixturesCmd.Flags().Uint64Var(&fromBlock, "from-block", 1, "Block number of the first event, each event is in the next block")
+	fixturesCmd.Flags().Uint64Var(&startTime, "start-time", 0, "Unix timestamp of the first event, events have no timestamps if it is not set")
+	fixturesCmd.Flags().Uint64Var(&blockTime, "block-time", 60, "Seconds between the timestamps of consecutive events")
+
+	return fixturesCmd
+}
+
+func CreateReplayCommand() *cobra.Command {
+	var infile, rate, sinkSpec string
+
+	replayCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay a recorded events dump into a sink at a configurable pace",
+		Long: `Replay a recorded events dump into a sink at a configurable pace, for load testing downstream services.
+
+Supported sinks:
+  stdout                                  - write events to stdout (default)
+  http(s)://<url>                         - POST each event to a webhook
+  kafka://<broker>[,<broker>...]/<topic>  - produce each event to a Kafka topic`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			interval, rateErr := ParseReplayRate(rate)
+			if rateErr != nil {
+				return rateErr
+			}
+
+			ifp := os.Stdin
+			var infileErr error
+			if infile != "" && infile != "-" {
+				ifp, infileErr = os.Open(infile)
+				if infileErr != nil {
+					return infileErr
+				}
+				defer ifp.Close()
+			}
+
+			sink, sinkErr := NewReplaySink(sinkSpec, cmd.OutOrStdout())
+			if sinkErr != nil {
+				return sinkErr
+			}
+			defer sink.Close()
+
+			ctx := context.Background()
+
+			var ticker *time.Ticker
+			if interval > 0 {
+				ticker = time.NewTicker(interval)
+				defer ticker.Stop()
+			}
+
+			startedAt := time.Now()
+			replayed := 0
+
+			scanner := bufio.NewScanner(ifp)
+			for scanner.Scan() {
+				line := scanner.Bytes()
+				if len(line) == 0 {
+					continue
+				}
+				if ticker != nil {
+					<-ticker.C
+				}
+				if sendErr := sink.Send(ctx, line); sendErr != nil {
+					return fmt.Errorf("failed to replay event %d: %v", replayed+1, sendErr)
+				}
+				replayed++
+			}
+			if scanErr := scanner.Err(); scanErr != nil {
+				return scanErr
+			}
+
+			elapsed := time.Since(startedAt)
+			log.Printf("Replayed %d events in %s (%.1f events/s)", replayed, elapsed.Round(time.Millisecond), float64(replayed)/elapsed.Seconds())
+
+			return nil
+		},
+	}
+
+	replayCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing recorded events to replay (as produced by the \"influence-eth events\" or \"influence-eth parse\" commands, defaults to stdin)")
+	replayCmd.Flags().StringVarP(&rate, "rate", "r", "0", "Pace at which to replay events, e.g. 100/s, 600/m or 5000/h (0 replays as fast as possible)")
+	replayCmd.Flags().StringVarP(&sinkSpec, "sink", "s", "stdout", "Sink to replay events into: stdout, http(s)://<webhook_url> or kafka://<brokers>/<topic>")
+
+	return replayCmd
+}
+
+func CreateWorkerCommand() *cobra.Command {
+	var queueSpec, resultsSpec, name string
+	var visibilityTimeout time.Duration
+
+	workerCmd := &cobra.Command{
+		Use:   "worker",
+		Short: "Run crawl and generate jobs from a Redis or SQS queue",
+		Long: `Run crawl and generate jobs from a Redis or SQS queue and report their results, to spread backfills across machines.
+
+Jobs are JSON objects:
+  {"id": "...", "kind": "crawl", "contract_name": "dispatcher", "from_block": 600000, "to_block": 610000, "parse": true, "output": "events.jsonl"}
+  {"id": "...", "kind": "generate", "input": "events.jsonl", "missions": ["3-market-maker-r1"], "output": "scores"}
+
+Supported queues:
+  redis://[:<password>@]<host>:<port>/<db>/<list>  - pop jobs from a Redis list
+  sqs://sqs.<region>.amazonaws.com/<account>/<name> - receive jobs from an SQS queue`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if queueSpec == "" {
+				return errors.New("please specify the queue to take jobs from with --queue")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			executable, executableErr := os.Executable()
+			if executableErr != nil {
+				return executableErr
+			}
+			if name == "" {
+				name, _ = os.Hostname()
+			}
+
+			jobs, jobsErr := NewWorkerQueue(ctx, queueSpec, visibilityTimeout)
+			if jobsErr != nil {
+				return jobsErr
+			}
+			defer jobs.Close()
+
+			worker := &Worker{Jobs: jobs, Executable: executable, Name: name}
+			if resultsSpec != "" {
+				results, resultsErr := NewWorkerQueue(ctx, resultsSpec, 0)
+				if resultsErr != nil {
+					return resultsErr
+				}
+				defer results.Close()
+				worker.Results = results
+			}
+
+			log.Printf("Worker %s waiting for jobs on %s", name, queueSpec)
+			return worker.Run(ctx)
+		},
+	}
+
+	workerCmd.Flags().StringVarP(&queueSpec, "queue", "q", "", "Queue to take jobs from: redis://<host>:<port>/<db>/<list> or sqs://<queue_url_host_and_path>")
+	workerCmd.Flags().StringVar(&resultsSpec, "results-queue", "", "Queue to send job results to, in the same format as --queue (results are logged if not set)")
+	workerCmd.Flags().StringVar(&name, "name", "", "Name of the worker in job results (defaults to the hostname)")
+	workerCmd.Flags().DurationVar(&visibilityTimeout, "visibility-timeout", 0, "How long SQS hides a received job from other workers, longer than the slowest job (defaults to the setting of the queue)")
+
+	return workerCmd
+}
+
+func CreateEligibilityCommand() *cobra.Command {
+	var infile, mission, crew, format string
+
+	eligibilityCmd := &cobra.Command{
+		Use:   "eligibility",
+		Short: "Check the progress of a crew towards completing a mission",
+		Long:  "Check the progress of a crew towards completing a mission. Runs the mission's generator on the events, without writing or pushing scores, and reports which requirements the crew has and has not met yet, e.g. \"4/5 buy orders, 0/1 sell orders\".",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if mission == "" {
+				return fmt.Errorf("Please specify mission with --mission flag")
+			}
+			if crew == "" {
+				return fmt.Errorf("Please specify crew with --crew flag")
+			}
+			if format != "text" && format != "json" {
+				return fmt.Errorf("unknown format %s, supported formats: json, text", format)
+			}
+
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
+
+			eligibility, eligibilityErr := leaderboards.CheckEligibility(cmd.Context(), leaderboards.DefaultMissionOptions(""), mission, eventsFile, crew)
+			if eligibilityErr != nil {
+				return eligibilityErr
+			}
+
+			if format == "json" {
+				return json.NewEncoder(cmd.OutOrStdout()).Encode(eligibility)
+			}
+			cmd.Println(eligibility.String())
+			return nil
+		},
+	}
+
+	eligibilityCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing parsed events (defaults to stdin, which is buffered into a temporary file)")
+	eligibilityCmd.Flags().StringVar(&mission, "mission", "", "Name of the mission, as in the \"influence-eth leaderboard\" subcommands (e.g. 3-market-maker-r1)")
+	eligibilityCmd.Flags().StringVar(&crew, "crew", "", "ID of the crew to check (or wallet address for missions ranking wallets)")
+	eligibilityCmd.Flags().StringVar(&format, "format", "text", "Output format (text or json)")
+
+	return eligibilityCmd
+}
+
+func CreateExportCommand() *cobra.Command {
+	var infile, outfile, eventNames, format string
+	var crewId uint64
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export all events of a crew",
+		Long:  "Export all events of a crew from a file of parsed events (or a directory partitioned by event type), to check which of its events are counted by leaderboards. With --format certificates, runs all missions instead and exports a certificate of completed missions and scores for each crew and wallet (or only the crew passed with --crew).",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return fmt.Errorf("Please specify file with events with --infile flag")
+			}
+			if format != "jsonl" && format != "csv" && format != "certificates" {
+				return fmt.Errorf("unknown format %s, supported formats: certificates, csv, jsonl", format)
+			}
+
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, createErr := os.Create(outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+
+			if format == "certificates" {
+				certificates, certificatesErr := leaderboards.GenerateCertificates(cmd.Context(), leaderboards.DefaultMissionOptions(""), infile)
+				if certificatesErr != nil {
+					return certificatesErr
+				}
+				if crewId != 0 {
+					crewCertificates := []leaderboards.Certificate{}
+					for _, certificate := range certificates {
+						if certificate.Address == strconv.FormatUint(crewId, 10) {
+							crewCertificates = append(crewCertificates, certificate)
+						}
+					}
+					certificates = crewCertificates
+				}
+				return json.NewEncoder(ofp).Encode(certificates)
+			}
+
+			if crewId == 0 {
+				return fmt.Errorf("Please specify crew with --crew flag")
+			}
+
+			names, namesErr := ParseEventNames(eventNames)
+			if namesErr != nil {
+				return namesErr
+			}
+
+			events, exportErr := ExportCrewEvents(infile, crewId, names)
+			if exportErr != nil {
+				return exportErr
+			}
+
+			if format == "csv" {
+				return WriteExportedEventsCSV(ofp, events)
+			}
+			return WriteExportedEventsJSONL(ofp, events)
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing parsed events (as produced by the \"influence-eth events\" or \"influence-eth parse\" commands)")
+	exportCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write exported events to (defaults to stdout)")
+	exportCmd.Flags().Uint64Var(&crewId, "crew", 0, "ID of the crew to export events of")
+	exportCmd.Flags().StringVar(&eventNames, "events", "", "Comma-separated names of events to export, e.g. TransitFinished,ConstructionFinished (defaults to all events)")
+	exportCmd.Flags().StringVar(&format, "format", "jsonl", "Output format (jsonl, csv or certificates)")
+
+	return exportCmd
+}
+
+func CreateDoEverythingCommand() *cobra.Command {
+	var providerURL, contractAddress, outfile, fromBlockFilePath, partitionBy, checkpointURL, checkpointToken string
+	var batchSize, coldInterval, hotInterval, hotThreshold, confirmations int
+	var tui bool
+
+	doEverythingCmd := &cobra.Command{
+		Use:   "do-everything",
+		Short: "Just do everything with events",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if providerURL == "" {
+				providerURLFromEnv := os.Getenv("STARKNET_RPC_URL")
+				if providerURLFromEnv == "" {
+					return errors.New("you must provide a provider URL using -p/--provider or set the STARKNET_RPC_URL environment variable")
+				}
+				providerURL = providerURLFromEnv
+			}
+
+			if fromBlockFilePath == "" {
+				return errors.New("flag --from-block-file should be set")
 			}
 
 			if outfile == "" {
 				return errors.New("flag -o/--outfile should be set")
 			}
 
+			if partitionBy != "" && partitionBy != influence.PARTITION_BY_EVENT {
+				return fmt.Errorf("unsupported --partition-by value %s, supported values: %s", partitionBy, influence.PARTITION_BY_EVENT)
+			}
+
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -412,9 +1510,19 @@ func CreateDoEverythingCommand() *cobra.Command {
 			}
 
 			provider := rpc.NewProvider(client)
-			ctx := context.Background()
-
-			eventsChan := make(chan RawEvent)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			eventsChan := make(chan influence.RawEvent)
+
+			var out io.Writer = os.Stdout
+			var monitor *Monitor
+			if tui {
+				monitor = NewMonitor("influence-eth do-everything", cancel)
+				out = monitor
+				log.SetOutput(monitor)
+				defer log.SetOutput(os.Stderr)
+			}
 
 			var fromBlock uint64
 			fromBlockFile, err := os.Open(fromBlockFilePath)
@@ -444,7 +1552,7 @@ func CreateDoEverythingCommand() *cobra.Command {
 				address := felt.NewFelt(&fieldAdditiveIdentity)
 				address.SetBytes(decodedAddress)
 
-				fromBlock, err = DeploymentBlock(ctx, provider, address)
+				fromBlock, err = crawler.DeploymentBlock(ctx, provider, address)
 				if err != nil {
 					return err
 				}
@@ -460,17 +1568,37 @@ func CreateDoEverythingCommand() *cobra.Command {
 				return nil
 			}
 
-			ofp, err := os.OpenFile(outfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				return err
+			var partitionedWriter *influence.PartitionedWriter
+			var ofp *os.File
+			if partitionBy == influence.PARTITION_BY_EVENT {
+				partitionedWriter, err = influence.NewPartitionedWriter(outfile, true)
+				if err != nil {
+					return err
+				}
+				defer partitionedWriter.Close()
+			} else {
+				ofp, err = os.OpenFile(outfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+				if err != nil {
+					return err
+				}
+				defer ofp.Close()
+			}
+
+			if monitor != nil {
+				monitor.SetChainHead(latestBlock)
+				monitor.Start()
+				defer monitor.Stop()
 			}
-			defer ofp.Close()
 
-			fmt.Printf("Starting processing events from block %d to block %d\n", fromBlock, latestBlock)
+			fmt.Fprintf(out, "Starting processing events from block %d to block %d\n", fromBlock, latestBlock)
 
-			go ContractEvents(ctx, provider, contractAddress, eventsChan, hotThreshold, time.Duration(hotInterval)*time.Millisecond, time.Duration(coldInterval)*time.Millisecond, fromBlock, latestBlock, confirmations, batchSize)
+			cursors := []crawler.CrawlCursor{{FromBlock: fromBlock, ToBlock: latestBlock}}
+			crawlErrChan := make(chan error, 1)
+			go func() {
+				crawlErrChan <- crawler.ContractVersionsEvents(ctx, crawler.WithChaos(provider), []string{contractAddress}, cursors, eventsChan, hotThreshold, time.Duration(hotInterval)*time.Millisecond, time.Duration(coldInterval)*time.Millisecond, latestBlock, confirmations, batchSize, nil)
+			}()
 
-			parser, newParserErr := NewEventParser()
+			parser, newParserErr := influence.NewEventParser()
 			if newParserErr != nil {
 				return newParserErr
 			}
@@ -481,31 +1609,43 @@ func CreateDoEverythingCommand() *cobra.Command {
 			eventsCounter := big.NewInt(0)
 			for event := range eventsChan {
 				if batchCounter >= 1000 {
-					fmt.Printf("Processed another 1000 events with total %s, working block number %d\n", eventsCounter.String(), event.BlockNumber)
+					fmt.Fprintf(out, "Processed another 1000 events with total %s, working block number %d\n", eventsCounter.String(), event.BlockNumber)
 					batchCounter = 0
 				}
 				batchCounter++
 				eventsCounter.Add(eventsCounter, big.NewInt(1))
 
-				unparsedEvent := ParsedEvent{Name: EVENT_UNKNOWN, Event: event}
+				unparsedEvent := influence.ParsedEvent{Name: influence.EVENT_UNKNOWN, Event: event}
 
 				passThrough := true
 
-				parsedEvent, parseErr := parser.Parse(event)
+				parsedEvent, parseErr := parser.Decode(event)
+				if monitor != nil {
+					monitor.RecordEvent(parsedEvent.Name, event.BlockNumber)
+				}
 				if parseErr == nil {
 					passThrough = false
 
-					parsedEventBytes, marshalErr := json.Marshal(parsedEvent)
+					eventLine := leaderboards.NewEventLine(parsedEvent, event.TransactionHash)
+					eventLine.Confirmations = confirmations
+					parsedEventBytes, marshalErr := json.Marshal(eventLine)
 					if marshalErr != nil {
 						return marshalErr
 					}
 
+					if partitionedWriter != nil {
+						if writeErr := partitionedWriter.Write(parsedEvent.Name, parsedEventBytes); writeErr != nil {
+							fmt.Fprintf(out, "Error writing to file: %v\n", writeErr)
+						}
+						continue
+					}
+
 					if _, writeErr := ofp.Write(parsedEventBytes); writeErr != nil {
-						fmt.Printf("Error writing to file: %v\n", writeErr)
+						fmt.Fprintf(out, "Error writing to file: %v\n", writeErr)
 						continue
 					}
 					if _, writeErr := ofp.Write(newline); writeErr != nil {
-						fmt.Printf("Error writing newline to file: %v\n", writeErr)
+						fmt.Fprintf(out, "Error writing newline to file: %v\n", writeErr)
 						continue
 					}
 				}
@@ -515,25 +1655,57 @@ func CreateDoEverythingCommand() *cobra.Command {
 					if marshalErr != nil {
 						return marshalErr
 					}
+					if partitionedWriter != nil {
+						if writeErr := partitionedWriter.Write(unparsedEvent.Name, serializedEvent); writeErr != nil {
+							fmt.Fprintf(out, "Error writing to file: %v\n", writeErr)
+						}
+						continue
+					}
 					if _, writeErr := ofp.Write(serializedEvent); writeErr != nil {
-						fmt.Printf("Error writing to file: %v\n", writeErr)
+						fmt.Fprintf(out, "Error writing to file: %v\n", writeErr)
 						continue
 					}
 					if _, writeErr := ofp.Write(newline); writeErr != nil {
-						fmt.Printf("Error writing newline to file: %v\n", writeErr)
+						fmt.Fprintf(out, "Error writing newline to file: %v\n", writeErr)
 						continue
 					}
 				}
 			}
 
-			fmt.Printf("Processed %s events from block %d to block %d\n", eventsCounter.String(), fromBlock, latestBlock)
+			if ctx.Err() != nil {
+				return fmt.Errorf("crawl interrupted after %s events, block number in file %s is not updated", eventsCounter.String(), fromBlockFilePath)
+			}
+			if crawlErr := <-crawlErrChan; crawlErr != nil {
+				return fmt.Errorf("crawl failed after %s events, block number in file %s is not updated, err: %v", eventsCounter.String(), fromBlockFilePath, crawlErr)
+			}
+
+			fmt.Fprintf(out, "Processed %s events from block %d to block %d\n", eventsCounter.String(), fromBlock, latestBlock)
 
 			recordedBlock := latestBlock + 1
 			writeBlockErr := os.WriteFile(fromBlockFilePath, []byte(fmt.Sprintf("%d", recordedBlock)), 0644)
 			if writeBlockErr != nil {
 				return writeBlockErr
 			}
-			fmt.Printf("Updated old block number %d to %d in file %s\n", fromBlock, recordedBlock, fromBlockFilePath)
+			fmt.Fprintf(out, "Updated old block number %d to %d in file %s\n", fromBlock, recordedBlock, fromBlockFilePath)
+
+			if checkpointURL != "" {
+				if checkpointToken == "" {
+					checkpointToken = leaderboards.MOONSTREAM_ACCESS_TOKEN
+				}
+				// The address is stripped of its 0x prefix if the deployment block was looked up
+				checkpoint := crawler.Checkpoint{
+					Contract:    "0x" + strings.TrimPrefix(contractAddress, "0x"),
+					LatestBlock: latestBlock,
+					ChainHead:   latestBlock,
+					Events:      eventsCounter.Uint64(),
+				}
+				if chainHead, headErr := provider.BlockNumber(ctx); headErr == nil {
+					checkpoint.ChainHead = chainHead
+				}
+				if publishErr := crawler.PublishCheckpoint(checkpointURL, checkpointToken, checkpoint); publishErr != nil {
+					log.Printf("Unable to publish crawl checkpoint, err: %v", publishErr)
+				}
+			}
 
 			return nil
 		},
@@ -547,763 +1719,1889 @@ func CreateDoEverythingCommand() *cobra.Command {
 	doEverythingCmd.Flags().IntVar(&confirmations, "confirmations", 5, "Number of confirmations to wait for before considering a block canonical")
 	doEverythingCmd.Flags().StringVarP(&fromBlockFilePath, "from-block-file", "f", "", "File contains the block number from which to start crawling")
 	doEverythingCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write reparsed events to")
+	doEverythingCmd.Flags().StringVar(&partitionBy, "partition-by", "", "Set to \"event\" to append events into one file per event type in the -o/--outfile directory")
+	doEverythingCmd.Flags().BoolVar(&tui, "tui", false, "Show crawl lag and event rates live in an interactive terminal UI")
+	doEverythingCmd.Flags().StringVar(&checkpointURL, "checkpoint-url", "", "Moonstream journal entries endpoint to publish the latest processed block of the crawl to after each run (disabled by default)")
+	doEverythingCmd.Flags().StringVar(&checkpointToken, "checkpoint-token", "", "Access token for --checkpoint-url (defaults to value of MOONSTREAM_ACCESS_TOKEN environment variable)")
 
 	return doEverythingCmd
 }
 
-type LeaderboardCommandCreator func(infile, outfile, accessToken, leaderboardId *string) error
+// InfileOrStdin returns the events file to read. Missions read their input in several passes, so
+// if no file was passed, events piped to stdin are buffered into a temporary file, which is removed
+// by the returned function.
+func InfileOrStdin(cmd *cobra.Command, infile string) (string, func(), error) {
+	if infile != "" {
+		return infile, func() {}, nil
+	}
+
+	stdin := cmd.InOrStdin()
+	if stdinFile, ok := stdin.(*os.File); ok {
+		if info, statErr := stdinFile.Stat(); statErr == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return "", nil, errors.New("please specify file with events with --infile flag or pipe events to stdin")
+		}
+	}
+
+	spillFile, spillErr := leaderboards.SpillInput(stdin)
+	if spillErr != nil {
+		return "", nil, spillErr
+	}
+	leaderboards.AUDIT_INPUT_FILE = spillFile
+	return spillFile, func() { os.Remove(spillFile) }, nil
+}
+
+// MissionFlags are the flags shared by the leaderboard and leaderboards commands, which set how
+// missions generate, enrich and push their scores.
+type MissionFlags struct {
+	PointsDataSchema     string
+	Explorer             string
+	NamesCache           string
+	AggregationsOutfile  string
+	AuditLog             string
+	AuditUploadURL       string
+	ScoreScale           string
+	ProductCatalog       string
+	ProductFilters       string
+	ScoringPolicies      string
+	GroupFile            string
+	DuplicateAddresses   string
+	ManagedAsteroids     string
+	AddressFormat        string
+	UploadMode           string
+	Asteroids            string
+	UploadStateDir       string
+	OutputFormat         string
+	NameSources          []string
+	MarketplaceAddresses []string
+	MinScore             uint64
+	MinEvents            uint64
+	MinConfirmations     uint64
+	ChainHead            uint64
+	MaxFailureRatio      float64
+	EnrichmentRate       float64
+	OutputVersion        int
+	MaxDataItems         int
+	ChunkSize            int
+	EnrichmentWorkers    int
+	Jobs                 int
+	Stream               bool
+	CommunityEntry       bool
+	VerifyPush           bool
+	AllowEmpty           bool
+	PreviousFromPortal   bool
+	EntityRefs           bool
+}
+
+// AddMissionFlags adds the flags of MissionFlags to the persistent flags of the command.
+func AddMissionFlags(cmd *cobra.Command, f *MissionFlags) {
+	cmd.PersistentFlags().StringVar(&f.PointsDataSchema, "points-data-schema", "v1", "Schema of points_data keys expected by the Moonstream.to portal (v1 or v2)")
+	cmd.PersistentFlags().StringSliceVar(&f.NameSources, "resolve-names", nil, "Comma-separated sources to resolve wallet addresses into names shown as address_name (starknet-id, ens), in order of priority")
+	cmd.PersistentFlags().StringVar(&f.NamesCache, "names-cache", "", "JSON file to cache resolved names in")
+	cmd.PersistentFlags().StringVar(&f.AggregationsOutfile, "aggregations-outfile", "", "JSON lines file to write the intermediate aggregations of missions to, e.g. per-crew counters and sets of visited asteroids, for custom dashboard views (disabled by default)")
+	cmd.PersistentFlags().IntVar(&f.Jobs, "jobs", leaderboards.MATCH_JOBS, "Number of goroutines missions which match pairs of events (e.g. started and finished actions) shard crews across")
+	cmd.PersistentFlags().IntVar(&f.EnrichmentWorkers, "enrichment-workers", leaderboards.ENRICHMENT_WORKERS, "Number of addresses to resolve names of concurrently with --resolve-names")
+	cmd.PersistentFlags().Float64Var(&f.EnrichmentRate, "enrichment-rate", leaderboards.ENRICHMENT_RATE_PER_HOST, "Maximum requests per second to each names API, shared by all workers. Rate limited and failed requests are retried with exponential backoff")
+	cmd.PersistentFlags().BoolVar(&f.Stream, "stream", false, "Read events from file on each pass instead of loading them into memory, for machines with limited RAM")
+	cmd.PersistentFlags().StringVar(&f.Explorer, "explorer", "", "Block explorer to link the latest transaction of each score with in points_data (voyager or starkscan, disabled by default)")
+	cmd.PersistentFlags().IntVar(&f.OutputVersion, "output-version", leaderboards.LATEST_SCORES_FILE_VERSION, "Version of score files (1 for a plain list of scores)")
+	cmd.PersistentFlags().StringVar(&f.OutputFormat, "format", leaderboards.SCORES_FILE_FORMAT_JSON, "Format of score files (json, or moonstream-csv for CSV bulk uploads to the Moonstream.to portal)")
+	cmd.PersistentFlags().Uint64Var(&f.MinScore, "min-score", 0, "Drop scores below this value (overrides the mission default)")
+	cmd.PersistentFlags().Uint64Var(&f.MinEvents, "min-events", 0, "Drop scores of addresses which participated in fewer events (overrides the mission default)")
+	cmd.PersistentFlags().IntVar(&f.MaxDataItems, "max-data-items", 0, "Truncate list valued points_data data to this many items and report the full length as total_count (disabled by default)")
+	cmd.PersistentFlags().StringSliceVar(&f.MarketplaceAddresses, "marketplace-addresses", nil, "Comma-separated addresses of marketplace contracts to leave out of the marketplace-activity leaderboard")
+	cmd.PersistentFlags().BoolVar(&f.EntityRefs, "entity-refs", false, "Output entity references in points_data as {id, type_label, name} objects, named with the latest NameChanged event of each entity, instead of numeric labels and IDs")
+	cmd.PersistentFlags().BoolVar(&f.CommunityEntry, "community-entry", false, "Add a \"__community__\" entry with the total, goal and percent complete to scores of community missions")
+	cmd.PersistentFlags().StringVar(&f.ScoreScale, "score-scale", "1", "Scale applied to large scores (e.g. SWAY volumes) before they are rounded down to integers, as a decimal or a fraction (e.g. 1e-6 or 1/1000000)")
+	cmd.PersistentFlags().StringVar(&f.ProductCatalog, "product-catalog", "", "JSON file with product categories to replace the embedded catalog with")
+	cmd.PersistentFlags().StringVar(&f.ProductFilters, "product-filters", "", "JSON file mapping missions to the product categories they include or exclude, replacing the mission defaults")
+	cmd.PersistentFlags().StringVar(&f.AddressFormat, "address-format", "", "Format of the addresses of scores: decimal, hex or padded-hex (0x and 64 digits), defaults to decimal crew and asteroid IDs and hex wallets as generated")
+	cmd.PersistentFlags().StringVar(&f.DuplicateAddresses, "duplicate-addresses", leaderboards.ADDRESS_MERGE_SUM, "How scores of the same address (compared without case and leading zeros) are merged before output: sum, max, or error to fail the mission")
+	cmd.PersistentFlags().StringVar(&f.GroupFile, "group-file", "", "JSON file mapping crew IDs to team labels, crews of a team compete as a single entry with their combined score")
+	cmd.PersistentFlags().StringVar(&f.ScoringPolicies, "scoring-policies", "", "JSON file mapping missions to scoring policies which convert their raw scores into points, e.g. 1 point per 1000 kg capped at 50 with a completion bonus")
+	cmd.PersistentFlags().StringVar(&f.Asteroids, "asteroids", "", "Asteroids to count events on in construction, extraction and transit missions, as comma-separated asteroid IDs (e.g. 1,104,250), ap or belt (defaults to the asteroids of each mission)")
+	cmd.PersistentFlags().StringVar(&f.ManagedAsteroids, "managed-asteroids", "", "Restrict construction and extraction missions to asteroids managed by the acting crew (own) or to other asteroids (others), by the AsteroidManaged events up to the activity")
+	cmd.PersistentFlags().Uint64Var(&f.MinConfirmations, "min-confirmations", 0, "Ignore events with fewer blocks on top of them, so events near the chain head which may be reorged are not counted (disabled by default)")
+	cmd.PersistentFlags().Uint64Var(&f.ChainHead, "chain-head", 0, "Chain head to count confirmations against (defaults to the latest block of the events file plus the confirmations the crawler waited for)")
+	cmd.PersistentFlags().Float64Var(&f.MaxFailureRatio, "max-failure-ratio", leaderboards.MAX_PARSE_FAILURE_RATIO, "Exit with an error if a larger share of event lines is invalid JSON or fails to decode")
+	cmd.PersistentFlags().StringVar(&f.AuditLog, "audit-log", leaderboards.AUDIT_LOG, "File to append a record of every leaderboard push to (set to an empty string to disable)")
+	cmd.PersistentFlags().StringVar(&f.AuditUploadURL, "audit-upload-url", "", "URL to POST audit records of leaderboard pushes to")
+	cmd.PersistentFlags().BoolVar(&f.PreviousFromPortal, "previous-from-portal", false, "Pull the current scores of the leaderboard before pushing and add previous_score, delta and rank_change of each address to points_data")
+	cmd.PersistentFlags().BoolVar(&f.AllowEmpty, "allow-empty", false, "Push missions which generated no scores, which overwrites their leaderboards with zero entries (skipped with a warning by default)")
+	cmd.PersistentFlags().BoolVar(&f.VerifyPush, "verify-push", leaderboards.DefaultMissionOptions("").Push.Verify, "After pushing scores, check that the entry count and last update of the leaderboard at the portal match the push, and fail the mission otherwise")
+	cmd.PersistentFlags().StringVar(&f.UploadMode, "mode", leaderboards.UPLOAD_MODE_OVERWRITE, "How scores are pushed: overwrite replaces all scores of the leaderboard, append only uploads scores which changed since the last push")
+	cmd.PersistentFlags().IntVar(&f.ChunkSize, "chunk-size", 0, "Push scores in chunks of this many scores with idempotency keys, resuming unfinished uploads after their last accepted chunk (disabled by default)")
+	cmd.PersistentFlags().StringVar(&f.UploadStateDir, "upload-state-dir", leaderboards.DEFAULT_UPLOAD_STATE_DIR, "Directory to keep the state of unfinished chunked uploads in")
+}
 
-type LeaderboardCommandFunc struct {
-	Name        string
-	Description string
-	Func        LeaderboardCommandCreator
+// Apply returns the mission options set by the flags, and sets the process wide settings of the
+// leaderboards package: enrichment, parse statistics, aggregations and the audit log. The input
+// file is recorded in the audit log of pushes.
+func (f *MissionFlags) Apply(infile string) (leaderboards.MissionOptions, error) {
+	opts := leaderboards.DefaultMissionOptions("")
+
+	if versionErr := leaderboards.CheckScoresFileVersion(f.OutputVersion); versionErr != nil {
+		return opts, versionErr
+	}
+	opts.Output.FileVersion = f.OutputVersion
+	if formatErr := leaderboards.CheckScoresFileFormat(f.OutputFormat); formatErr != nil {
+		return opts, formatErr
+	}
+	opts.Output.FileFormat = f.OutputFormat
+	if explorerErr := leaderboards.CheckExplorer(f.Explorer); explorerErr != nil {
+		return opts, explorerErr
+	}
+	opts.Output.Explorer = f.Explorer
+	if f.EnrichmentWorkers < 1 || f.EnrichmentRate <= 0 {
+		return opts, errors.New("--enrichment-workers and --enrichment-rate must be positive")
+	}
+	leaderboards.ENRICHMENT_WORKERS = f.EnrichmentWorkers
+	leaderboards.ENRICHMENT_RATE_PER_HOST = f.EnrichmentRate
+	if f.Jobs < 1 {
+		return opts, errors.New("--jobs must be positive")
+	}
+	leaderboards.MATCH_JOBS = f.Jobs
+	if len(f.NameSources) > 0 {
+		resolver, resolverErr := leaderboards.NewNameResolver(f.NameSources, f.NamesCache)
+		if resolverErr != nil {
+			return opts, resolverErr
+		}
+		opts.Output.NameResolver = resolver
+	}
+	if aggregationsErr := leaderboards.SetAggregationsOutfile(f.AggregationsOutfile); aggregationsErr != nil {
+		return opts, aggregationsErr
+	}
+	opts.Events = leaderboards.EventOptions{MinConfirmations: f.MinConfirmations, ChainHead: f.ChainHead, Stream: f.Stream}
+	opts.Output.MaxDataItems = f.MaxDataItems
+	leaderboards.PARSE_STATS = leaderboards.ParseStats{}
+	leaderboards.MAX_PARSE_FAILURE_RATIO = f.MaxFailureRatio
+	scale, scaleErr := leaderboards.ParseScoreScale(f.ScoreScale)
+	if scaleErr != nil {
+		return opts, scaleErr
+	}
+	opts.ScoreScale = scale
+	if filtersErr := leaderboards.SetProductFilters(f.ProductCatalog, f.ProductFilters); filtersErr != nil {
+		return opts, filtersErr
+	}
+	if policiesErr := leaderboards.SetScoringPolicies(f.ScoringPolicies); policiesErr != nil {
+		return opts, policiesErr
+	}
+	if f.GroupFile != "" {
+		groups, groupsErr := leaderboards.ReadCrewGroups(f.GroupFile)
+		if groupsErr != nil {
+			return opts, groupsErr
+		}
+		opts.Output.CrewGroups = groups
+	}
+	if mergeErr := leaderboards.CheckAddressMergePolicy(f.DuplicateAddresses); mergeErr != nil {
+		return opts, mergeErr
+	}
+	opts.Output.AddressMerge = f.DuplicateAddresses
+	if formatErr := leaderboards.CheckAddressFormat(f.AddressFormat); formatErr != nil {
+		return opts, formatErr
+	}
+	opts.AddressFormat = f.AddressFormat
+	if f.Asteroids != "" {
+		asteroids, asteroidsErr := leaderboards.ParseAsteroidSelector(f.Asteroids)
+		if asteroidsErr != nil {
+			return opts, asteroidsErr
+		}
+		opts.Asteroids = &asteroids
+	}
+	if managedErr := leaderboards.CheckManagedAsteroidsMode(f.ManagedAsteroids); managedErr != nil {
+		return opts, managedErr
+	}
+	opts.ManagedAsteroids = f.ManagedAsteroids
+	opts.Output.CommunityEntry = f.CommunityEntry
+	if f.EntityRefs {
+		opts.Output.EntityRefs = leaderboards.NewEntityRefs()
+	}
+	opts.Output.PreviousFromPortal = f.PreviousFromPortal
+	if modeErr := leaderboards.CheckUploadMode(f.UploadMode); modeErr != nil {
+		return opts, modeErr
+	}
+	if f.ChunkSize < 0 {
+		return opts, fmt.Errorf("invalid --chunk-size %d, it must not be negative", f.ChunkSize)
+	}
+	opts.Push = leaderboards.PushOptions{Mode: f.UploadMode, AllowEmpty: f.AllowEmpty, Verify: f.VerifyPush, ChunkSize: f.ChunkSize, StateDir: f.UploadStateDir}
+	opts.Marketplaces = f.MarketplaceAddresses
+	leaderboards.AUDIT_LOG = f.AuditLog
+	leaderboards.AUDIT_UPLOAD_URL = f.AuditUploadURL
+	leaderboards.AUDIT_INPUT_FILE = infile
+	if schemaErr := leaderboards.CheckPointsDataSchema(f.PointsDataSchema); schemaErr != nil {
+		return opts, schemaErr
+	}
+	opts.Output.PointsDataSchema = f.PointsDataSchema
+	return opts, nil
 }
 
-var LEADERBOARD_MISSIONS = []LeaderboardCommandFunc{
-	{
-		Name:        "c-1-base-camp",
-		Description: "Prepare community leaderboard",
-		Func:        CL1BaseCamp,
-	},
-	{
-		Name:        "c-2-romulus-remus-and-the-rest",
-		Description: "Prepare community leaderboard",
-		Func:        CL2RomulusRemusAndTheRest,
-	},
-	{
-		Name:        "c-3-learn-by-doing",
-		Description: "Prepare community leaderboard",
-		Func:        CL3LearnByDoing,
-	},
-	{
-		Name:        "c-4-four-pillars",
-		Description: "Prepare community leaderboard",
-		Func:        CL4FourPillars,
-	},
-	{
-		Name:        "c-5-together-we-can-rise",
-		Description: "Prepare community leaderboard",
-		Func:        CL5TogetherWeCanRise,
-	},
-	{
-		Name:        "c-6-the-fleet",
-		Description: "Prepare community leaderboard",
-		Func:        CL6TheFleet,
-	},
-	{
-		Name:        "c-7-rock-breaker",
-		Description: "Prepare community leaderboard",
-		Func:        CL7RockBreaker,
-	},
-	{
-		Name:        "c-8-good-news-everyone",
-		Description: "Prepare community leaderboard",
-		Func:        CL8GoodNewsEveryone,
-	},
-	{
-		Name:        "c-9-prospecting-pays-off",
-		Description: "Prepare community leaderboard",
-		Func:        CL9ProspectingPaysOff,
-	},
-	{
-		Name:        "c-10-potluck",
-		Description: "Prepare community leaderboard",
-		Func:        CL10Potluck,
-	},
-	{
-		Name:        "1-new-recruits-r1",
-		Description: "Prepare leaderboard",
-		Func:        L1NewRecruitsR1,
-	},
-	{
-		Name:        "1-new-recruits-r2",
-		Description: "Prepare leaderboard",
-		Func:        L1NewRecruitsR2,
-	},
-	{
-		Name:        "2-buried-treasure-r1",
-		Description: "Prepare leaderboard",
-		Func:        L2BuriedTreasureR1,
-	},
-	{
-		Name:        "2-buried-treasure-r2",
-		Description: "Prepare leaderboard",
-		Func:        L2BuriedTreasureR2,
-	},
-	{
-		Name:        "3-market-maker-r1",
-		Description: "Prepare leaderboard",
-		Func:        L3MarketMakerR1,
-	},
-	{
-		Name:        "3-market-maker-r2",
-		Description: "Prepare leaderboard",
-		Func:        L3MarketMakerR2,
-	},
-	{
-		Name:        "4-breaking-ground-r1",
-		Description: "Prepare leaderboard",
-		Func:        L4BreakingGroundR1,
-	},
-	{
-		Name:        "4-breaking-ground-r2",
-		Description: "Prepare leaderboard",
-		Func:        L4BreakingGroundR2,
-	},
-	{
-		Name:        "5-city-builder",
-		Description: "Prepare leaderboard",
-		Func:        L5CityBuilder,
-	},
-	{
-		Name:        "6-explore-the-stars-r1",
-		Description: "Prepare leaderboard",
-		Func:        L6ExploreTheStarsR1,
-	},
-	{
-		Name:        "6-explore-the-stars-r2",
-		Description: "Prepare leaderboard",
-		Func:        L6ExploreTheStarsR2,
-	},
-	{
-		Name:        "7-expand-the-colony",
-		Description: "Prepare leaderboard",
-		Func:        L7ExpandTheColony,
-	},
-	{
-		Name:        "8-special-delivery",
-		Description: "Prepare leaderboard",
-		Func:        L8SpecialDelivery,
-	},
-	{
-		Name:        "9-dinner-is-served",
-		Description: "Prepare leaderboard",
-		Func:        L9DinnerIsServed,
-	},
+// NamedMissionOptions returns the options of a leaderboard which is not prepared by a mission of
+// the registry, named after the command which prepares it.
+func NamedMissionOptions(opts leaderboards.MissionOptions, mission string) leaderboards.MissionOptions {
+	opts.Mission = mission
+	return opts
 }
 
-type LeaderboardsMap struct {
-	Name          string `json:"name"`
-	LeaderboardId string `json:"leaderboard_id"`
+// MissionThresholds returns the mission thresholds, overridden by --min-score and --min-events
+// flags if they were passed to the command.
+func MissionThresholds(lm leaderboards.LeaderboardCommandFunc, cmd *cobra.Command, minScore, minEvents uint64) leaderboards.ScoreThresholds {
+	thresholds := leaderboards.ScoreThresholds{MinScore: lm.MinScore, MinEvents: lm.MinEvents}
+	if cmd.Flags().Changed("min-score") {
+		thresholds.MinScore = minScore
+	}
+	if cmd.Flags().Changed("min-events") {
+		thresholds.MinEvents = minEvents
+	}
+	return thresholds
 }
 
 func CreateLeaderboardsCommand() *cobra.Command {
-	var infile, accessToken, leaderboardsMapFilePath string
+	var infile, accessToken, leaderboardsMapFilePath, cacheDir, transitMissions, changedSince string
+	var sinkSpecs []string
+	var tui bool
+	var missionTimeout time.Duration
+	var missionFlags MissionFlags
+	var base leaderboards.MissionOptions
 
 	leaderboardsCmd := &cobra.Command{
 		Use:   "leaderboards",
 		Short: "Prepare all Moonstream.to leaderboards",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if transitMissions != "" {
+				if registerErr := leaderboards.RegisterTransitMissions(transitMissions); registerErr != nil {
+					return registerErr
+				}
+			}
+			var flagsErr error
+			base, flagsErr = missionFlags.Apply(infile)
+			if flagsErr != nil {
+				return flagsErr
+			}
+			var sinksErr error
+			base.Sinks, sinksErr = leaderboards.NewScoreSinks(sinkSpecs)
+			if sinksErr != nil {
+				return sinksErr
+			}
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if leaderboards.AGGREGATIONS_WRITER != nil {
+				if closeErr := leaderboards.AGGREGATIONS_WRITER.Close(); closeErr != nil {
+					return closeErr
+				}
+			}
+			return leaderboards.PARSE_STATS.Report()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var inputFile *os.File
-			var readErr error
-			if leaderboardsMapFilePath != "" {
-				inputFile, readErr = os.Open(leaderboardsMapFilePath)
-				if readErr != nil {
-					log.Fatalf("Unable to read file %s, err: %v", leaderboardsMapFilePath, readErr)
+			if leaderboardsMapFilePath == "" {
+				log.Fatalf("Please specify leaderboards map file with --leaderboards-map flag")
+			}
+
+			leaderboardsMap, err := leaderboards.ReadLeaderboardsMap(leaderboardsMapFilePath)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			var changedMissions map[string]bool
+			if changedSince != "" {
+				changedMissions, err = leaderboards.ChangedMissions(changedSince)
+				if err != nil {
+					return err
 				}
-			} else {
-				log.Fatalf("Please specify file with events with --input flag")
+				log.Printf("%d of %d missions changed since %s", len(changedMissions), len(leaderboards.LEADERBOARD_MISSIONS), changedSince)
+			}
+
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
+			infile = eventsFile
+			if namesErr := base.Output.EntityRefs.LoadNames(infile); namesErr != nil {
+				return namesErr
 			}
 
-			defer inputFile.Close()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
 
-			byteValue, err := ioutil.ReadAll(inputFile)
-			if err != nil {
-				log.Fatalf("Error reading file, err: %v", err)
+			var monitor *Monitor
+			if tui {
+				monitor = NewMonitor("influence-eth leaderboards", cancel)
+				for _, lm := range leaderboards.LEADERBOARD_MISSIONS {
+					for _, target := range leaderboardsMap[lm.Name] {
+						monitor.SetPushStatus(leaderboards.LeaderboardTargetLabel(lm.Name, target, leaderboardsMap[lm.Name]), "pending")
+					}
+				}
+				log.SetOutput(monitor)
+				defer log.SetOutput(os.Stderr)
+				monitor.Start()
+				defer monitor.Stop()
 			}
 
-			leaderboardsMap := make(map[string]string)
-			err = json.Unmarshal(byteValue, &leaderboardsMap)
-			if err != nil {
-				log.Fatalf("Error unmarshalling JSON, err: %v", err)
+			var cache *leaderboards.LeaderboardsCache
+			if cacheDir != "" {
+				cache, err = leaderboards.NewLeaderboardsCache(cacheDir, infile)
+				if err != nil {
+					return err
+				}
 			}
 
-			for _, lm := range LEADERBOARD_MISSIONS {
-				lId, ok := leaderboardsMap[lm.Name]
+			for _, lm := range leaderboards.LEADERBOARD_MISSIONS {
+				targets, ok := leaderboardsMap[lm.Name]
 				if !ok {
 					log.Printf("Passed %s leaderboard, not ID passed in config file", lm.Name)
 					continue
 				}
-				emptyOutput := ""
-				err := lm.Func(&infile, &emptyOutput, &accessToken, &lId)
-				if err != nil {
-					log.Printf("Failed %s leaderboard", lm.Name)
+				if changedMissions != nil && !changedMissions[lm.Name] {
+					log.Printf("Passed %s leaderboard, its scoring did not change since %s", lm.Name, changedSince)
+					if monitor != nil {
+						for _, target := range targets {
+							monitor.SetPushStatus(leaderboards.LeaderboardTargetLabel(lm.Name, target, targets), "unchanged")
+						}
+					}
 					continue
 				}
 
-				log.Printf("Updated %s leaderboard known as %s", lId, lm.Name)
-				time.Sleep(500 * time.Millisecond)
-			}
-
-			return nil
-		},
-	}
+				for _, target := range targets {
+					if ctx.Err() != nil {
+						break
+					}
 
-	leaderboardsCmd.PersistentFlags().StringVarP(&infile, "infile", "i", "", "File containing crawled events from which to build the leaderboard (as produced by the \"influence-eth stark events\" command, defaults to stdin)")
-	leaderboardsCmd.PersistentFlags().StringVarP(&accessToken, "token", "t", "", "Moonstream user access token (could be set with MOONSTREAM_ACCESS_TOKEN environment variable)")
-	leaderboardsCmd.PersistentFlags().StringVarP(&leaderboardsMapFilePath, "leaderboards-map", "m", "", "Pass to leaderboards map JSON file")
+					label := leaderboards.LeaderboardTargetLabel(lm.Name, target, targets)
+					lId := target.LeaderboardId
 
-	return leaderboardsCmd
-}
+					lAccessToken := accessToken
+					if target.AccessToken != "" {
+						lAccessToken = target.AccessToken
+					}
+					opts := base.ForMission(lm, MissionThresholds(lm, cmd, missionFlags.MinScore, missionFlags.MinEvents))
+					opts, err = opts.ForTarget(target)
+					if err != nil {
+						return fmt.Errorf("invalid sinks of %s leaderboard: %v", label, err)
+					}
 
-func CreateLeaderboardCommand() *cobra.Command {
-	var infile, outfile, accessToken, leaderboardId string
+					output := ""
+					if cache != nil {
+						// Scores of missions whose scoring changed are recomputed from the same inputs
+						if cache.Exists(opts, lId) && !changedMissions[lm.Name] {
+							log.Printf("Passed %s leaderboard, scores for blocks %d-%d are up to date in cache", label, cache.FromBlock, cache.ToBlock)
+							if monitor != nil {
+								monitor.SetPushStatus(label, "cached")
+							}
+							continue
+						}
+						output, err = cache.Prepare(opts, lId)
+						if err != nil {
+							log.Printf("Unable to prepare cache for %s leaderboard, err: %v", label, err)
+							output = ""
+						}
+					}
+					if monitor != nil {
+						monitor.SetPushStatus(label, "running")
+					}
+					err := leaderboards.RunMission(ctx, lm, opts, missionTimeout, &infile, &output, &lAccessToken, &lId)
+					if err != nil {
+						log.Printf("Failed %s leaderboard, err: %v", label, err)
+						if monitor != nil {
+							monitor.SetPushStatus(label, fmt.Sprintf("failed: %v", err))
+						}
+						if output != "" {
+							os.Remove(output)
+						}
+						continue
+					}
+					if monitor != nil {
+						monitor.SetPushStatus(label, "updated")
+					}
+					if output != "" {
+						if pruneErr := cache.Prune(opts, lId); pruneErr != nil {
+							log.Printf("Unable to prune cache for %s leaderboard, err: %v", label, pruneErr)
+						}
+					}
+
+					log.Printf("Updated %s leaderboard known as %s", lId, lm.Name)
+					time.Sleep(500 * time.Millisecond)
+				}
+
+				if ctx.Err() != nil {
+					log.Printf("Interrupted, remaining leaderboards are not updated")
+					break
+				}
+			}
+
+			return nil
+		},
+	}
+
+	leaderboardsCmd.PersistentFlags().StringVarP(&infile, "infile", "i", "", "File containing crawled events from which to build the leaderboard (as produced by the \"influence-eth stark events\" command, defaults to stdin, which is buffered into a temporary file)")
+	leaderboardsCmd.PersistentFlags().StringVarP(&accessToken, "token", "t", "", "Moonstream user access token (could be set with MOONSTREAM_ACCESS_TOKEN environment variable)")
+	leaderboardsCmd.PersistentFlags().StringVarP(&leaderboardsMapFilePath, "leaderboards-map", "m", "", "Pass to leaderboards map JSON file")
+	AddMissionFlags(leaderboardsCmd, &missionFlags)
+	leaderboardsCmd.PersistentFlags().StringSliceVar(&sinkSpecs, "sink", nil, "Sinks to write the scores of missions to instead of pushing them to Moonstream.to (moonstream, stdout, file://<path>, http(s)://<url>, s3://<bucket>/<prefix>, postgres://<dsn>?table=<table>), overridden by the sinks of leaderboards map targets")
+	leaderboardsCmd.PersistentFlags().DurationVar(&missionTimeout, "mission-timeout", leaderboards.MISSION_TIMEOUT, "Fail missions which run for longer than this, e.g. 30m, and continue with the rest (disabled by default)")
+	leaderboardsCmd.PersistentFlags().BoolVar(&tui, "tui", false, "Show push status of each leaderboard live in an interactive terminal UI")
+	leaderboardsCmd.PersistentFlags().StringVar(&transitMissions, "transit-missions", "", "JSON file with missions ranking crews by transits between origin and destination asteroids, to list in the leaderboards map like built-in missions")
+	leaderboardsCmd.PersistentFlags().StringVar(&changedSince, "changed-since", "", "Only update leaderboards of missions whose scoring changed since this date (YYYY-MM-DD) or git revision, according to the version of each mission in the missions registry")
+	leaderboardsCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Directory to cache generated scores in, leaderboards with unchanged inputs are skipped (disabled by default)")
+
+	exportCmd := CreateLeaderboardsExportCommand(&infile, &leaderboardsMapFilePath, &base, &missionFlags.MinScore, &missionFlags.MinEvents)
+	leaderboardsCmd.AddCommand(exportCmd)
+
+	return leaderboardsCmd
+}
+
+func CreateLeaderboardsExportCommand(infile, leaderboardsMapFilePath *string, base *leaderboards.MissionOptions, minScore, minEvents *uint64) *cobra.Command {
+	var dir string
+	var tarball bool
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the scores of all missions into a timestamped directory instead of pushing them",
+		Long:  "Run every mission (or the missions of --leaderboards-map) and write their score files, a manifest with the scored events and the program version, and a report of each mission into a timestamped directory under --dir, or a .tar.gz archive of it with --tarball, as a complete artifact of a scoring run which can be published or pushed later.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				return errors.New("please specify the directory to export to with --dir")
+			}
+
+			missions := leaderboards.LEADERBOARD_MISSIONS
+			if *leaderboardsMapFilePath != "" {
+				leaderboardsMap, mapErr := leaderboards.ReadLeaderboardsMap(*leaderboardsMapFilePath)
+				if mapErr != nil {
+					return mapErr
+				}
+				missions = nil
+				for _, lm := range leaderboards.LEADERBOARD_MISSIONS {
+					if _, ok := leaderboardsMap[lm.Name]; ok {
+						missions = append(missions, lm)
+					}
+				}
+			}
+
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
+			if namesErr := base.Output.EntityRefs.LoadNames(eventsFile); namesErr != nil {
+				return namesErr
+			}
+
+			startedAt := time.Now()
+			runDir := ExportRunDir(dir, startedAt)
+			if mkdirErr := os.MkdirAll(runDir, 0755); mkdirErr != nil {
+				return mkdirErr
+			}
+
+			manifest := RunExportManifest{
+				Created:          startedAt.UTC().Format(time.RFC3339),
+				Version:          Version,
+				PointsDataSchema: base.Output.Schema().Name,
+			}
+			leaderboards.AUDIT_INPUT_FILE = eventsFile
+			if input, inputErr := leaderboards.NewProvenance(""); inputErr == nil {
+				manifest.Input = &input
+			} else {
+				log.Printf("Unable to describe the input of the export, err: %v", inputErr)
+			}
+
+			for _, lm := range missions {
+				opts := base.ForMission(lm, MissionThresholds(lm, cmd, *minScore, *minEvents))
+				// Scores only go to the score files of the export
+				opts.Sinks = []leaderboards.ScoreSink{}
+
+				exported := ExportMission(cmd.Context(), lm, opts, eventsFile, runDir)
+				if exported.Status == EXPORT_STATUS_FAILED {
+					manifest.Failed++
+					log.Printf("Failed %s leaderboard, err: %s", lm.Name, exported.Error)
+				} else {
+					log.Printf("Exported %s leaderboard with %d entries", lm.Name, exported.Entries)
+				}
+				manifest.Missions = append(manifest.Missions, exported)
+			}
+
+			if writeErr := WriteRunExportManifest(runDir, manifest); writeErr != nil {
+				return writeErr
+			}
+			exportPath := runDir
+			if tarball {
+				archivePath, tarErr := TarRunExport(runDir)
+				if tarErr != nil {
+					return tarErr
+				}
+				exportPath = archivePath
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Exported %d missions (%d failed) to %s\n", len(manifest.Missions), manifest.Failed, exportPath)
+			if manifest.Failed > 0 {
+				return fmt.Errorf("%d missions failed, see %s in the export", manifest.Failed, RUN_EXPORT_MANIFEST_FILE)
+			}
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVar(&dir, "dir", "", "Directory to create the timestamped export directory in")
+	exportCmd.Flags().BoolVar(&tarball, "tarball", false, "Bundle the export into a .tar.gz archive instead of leaving a directory")
+
+	return exportCmd
+}
+
+func CreateLeaderboardCommand() *cobra.Command {
+	var infile, outfile, accessToken, leaderboardId, fullDataOutfile, previousScores, roundsRegistry string
+	var sinkSpecs []string
+	var missionFlags MissionFlags
+	// Options of the leaderboard, the thresholds of the flags apply to leaderboards which are not
+	// prepared by a mission of the registry
+	var base leaderboards.MissionOptions
 
 	leaderboardCmd := &cobra.Command{
 		Use:   "leaderboard",
 		Short: "Prepare Moonstream.to leaderboard",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if previousScores != "" && missionFlags.PreviousFromPortal {
+				return errors.New("use either --previous-scores or --previous-from-portal, not both")
+			}
+			var flagsErr error
+			base, flagsErr = missionFlags.Apply(infile)
+			if flagsErr != nil {
+				return flagsErr
+			}
+			base.Thresholds = leaderboards.ScoreThresholds{MinScore: missionFlags.MinScore, MinEvents: missionFlags.MinEvents}
+			base.Output.PreviousScoresFile = previousScores
+			base.Output.FullDataFile = fullDataOutfile
+			var sinksErr error
+			base.Sinks, sinksErr = leaderboards.NewScoreSinks(sinkSpecs)
+			if sinksErr != nil {
+				return sinksErr
+			}
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if leaderboards.AGGREGATIONS_WRITER != nil {
+				if closeErr := leaderboards.AGGREGATIONS_WRITER.Close(); closeErr != nil {
+					return closeErr
+				}
+			}
+			return leaderboards.PARSE_STATS.Report()
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 		},
 	}
 
-	leaderboardCmd.PersistentFlags().StringVarP(&infile, "infile", "i", "", "File containing crawled events from which to build the leaderboard (as produced by the \"influence-eth stark events\" command, defaults to stdin)")
+	leaderboardCmd.PersistentFlags().StringVarP(&infile, "infile", "i", "", "File containing crawled events from which to build the leaderboard (as produced by the \"influence-eth stark events\" command, defaults to stdin, which is buffered into a temporary file)")
 	leaderboardCmd.PersistentFlags().StringVarP(&outfile, "outfile", "o", "", "File to write reparsed events to (defaults to stdout)")
 	leaderboardCmd.PersistentFlags().StringVarP(&accessToken, "token", "t", "", "Moonstream user access token (could be set with MOONSTREAM_ACCESS_TOKEN environment variable)")
 	leaderboardCmd.PersistentFlags().StringVarP(&leaderboardId, "leaderboard-id", "l", "", "Leaderboard ID to update data for at Moonstream.to portal")
+	leaderboardCmd.PersistentFlags().StringVar(&roundsRegistry, "rounds-registry", "", "Rounds registry JSON file to resolve block aliases such as round2-end of --from-block, --to-block and --as-of-block with")
+	AddMissionFlags(leaderboardCmd, &missionFlags)
+	leaderboardCmd.PersistentFlags().StringSliceVar(&sinkSpecs, "sink", nil, "Sinks to write the scores to instead of pushing them to Moonstream.to (moonstream, stdout, file://<path>, http(s)://<url>, s3://<bucket>/<prefix>, postgres://<dsn>?table=<table>)")
+	leaderboardCmd.PersistentFlags().StringVar(&previousScores, "previous-scores", "", "Score file of a previous run to add previous_score, delta and rank_change of each address to points_data")
+	leaderboardCmd.PersistentFlags().StringVar(&fullDataOutfile, "full-data-outfile", "", "File to write the complete points_data data of every address to, before truncation with --max-data-items")
 
-	for _, lm := range LEADERBOARD_MISSIONS {
+	for _, lm := range leaderboards.LEADERBOARD_MISSIONS {
 		lm := lm // Create a local copy of lm for closure to capture
 		newCmd := &cobra.Command{
 			Use:   lm.Name,
 			Short: lm.Description,
 			RunE: func(cmd *cobra.Command, args []string) error {
-				err := lm.Func(&infile, &outfile, &accessToken, &leaderboardId)
+				missionInfile, cleanup, inputErr := InfileOrStdin(cmd, infile)
+				if inputErr != nil {
+					return inputErr
+				}
+				defer cleanup()
+				if namesErr := base.Output.EntityRefs.LoadNames(missionInfile); namesErr != nil {
+					return namesErr
+				}
+
+				if scopeErr := leaderboards.CheckAsteroidScope(lm, base.Asteroids); scopeErr != nil {
+					return scopeErr
+				}
+				if managedErr := leaderboards.CheckManagedAsteroids(lm, base.ManagedAsteroids); managedErr != nil {
+					return managedErr
+				}
+				opts := base.ForMission(lm, MissionThresholds(lm, cmd, missionFlags.MinScore, missionFlags.MinEvents))
+				err := lm.Func(cmd.Context(), opts, &missionInfile, &outfile, &accessToken, &leaderboardId)
 				return err
 			},
 		}
 		leaderboardCmd.AddCommand(newCmd)
 	}
 
-	lCrewOwnersCmd := CreateLCrewOwnersCommand(&infile, &outfile, &accessToken, &leaderboardId)
-	lCrewsCmd := CreateLCrewsCommand(&infile, &outfile, &accessToken, &leaderboardId)
-
-	leaderboardCmd.AddCommand(lCrewOwnersCmd, lCrewsCmd)
+	lCrewOwnersCmd := CreateLCrewOwnersCommand(&base, &infile, &outfile, &accessToken, &leaderboardId)
+	lCrewsCmd := CreateLCrewsCommand(&base, &infile, &outfile, &accessToken, &leaderboardId)
+	lConvertCmd := CreateLConvertCommand(&base, &infile, &outfile, &accessToken, &leaderboardId)
+	lResetCmd := CreateLResetCommand(&accessToken, &leaderboardId)
+	lDeleteScoresCmd := CreateLDeleteScoresCommand(&accessToken, &leaderboardId)
+	lScriptCmd := CreateLScriptCommand(&base, &infile, &outfile, &accessToken, &leaderboardId)
+	lDuplicatesCmd := CreateLDuplicatesCommand(&base, &infile)
+	lRostersCmd := CreateLRostersCommand(&base, &infile, &outfile)
+	lTransitRouteCmd := CreateLTransitRouteCommand(&base, &infile, &outfile, &accessToken, &leaderboardId)
+	lTransactionFeesCmd := CreateLTransactionFeesCommand(&base, &infile, &outfile, &accessToken, &leaderboardId, &roundsRegistry)
+	lActiveDaysCmd := CreateLActiveDaysCommand(&base, &infile, &outfile, &accessToken, &leaderboardId, &roundsRegistry)
+	lFreezeCmd := CreateLFreezeCommand(&base, &infile, &outfile, &roundsRegistry)
+	lVerifyFreezeCmd := CreateLVerifyFreezeCommand(&base, &infile)
+	lAuthCmd := CreateLAuthCommand(&accessToken, &leaderboardId)
+	lExplainCmd := CreateLExplainCommand(&base, &infile, &outfile)
+
+	leaderboardCmd.AddCommand(lCrewOwnersCmd, lCrewsCmd, lConvertCmd, lResetCmd, lDeleteScoresCmd, lScriptCmd, lDuplicatesCmd, lRostersCmd, lTransitRouteCmd, lTransactionFeesCmd, lActiveDaysCmd, lFreezeCmd, lVerifyFreezeCmd, lAuthCmd, lExplainCmd)
 
 	return leaderboardCmd
 }
 
-func CL1BaseCamp(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[TransitFinished](*infile, "TransitFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLCrewOwnersCommand(base *leaderboards.MissionOptions, infile, outfile, accessToken, leaderboardId *string) *cobra.Command {
+	leaderboardCrewOwnersCmd := &cobra.Command{
+		Use:   "crew-owners",
+		Short: "Prepare leaderboard with crews",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-	scores := GenerateC1BaseCampToScores(events)
+			events, parseEventsErr := leaderboards.LoadEvents[influence.Influence_Contracts_Crew_Crew_Transfer](cmd.Context(), base.Events, eventsFile)
+			if parseEventsErr != nil {
+				return parseEventsErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
-	}
+			scores := leaderboards.GenerateCrewOwnersToScores(events)
+			if streamErr := leaderboards.EventSourcesErr(events); streamErr != nil {
+				return streamErr
+			}
 
-	return nil
-}
+			outErr := leaderboards.PrepareLeaderboardOutput(cmd.Context(), NamedMissionOptions(*base, "crew-owners"), scores, *outfile, *accessToken, *leaderboardId)
+			if outErr != nil {
+				return outErr
+			}
 
-func CL2RomulusRemusAndTheRest(infile, outfile, accessToken, leaderboardId *string) error {
-	conPlanEvents, parseEventsErr := ParseEventFromFile[ConstructionPlanned](*infile, "ConstructionPlanned")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	conFinEvents, parseEventsErr := ParseEventFromFile[ConstructionFinished](*infile, "ConstructionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
+			return nil
+		},
 	}
 
-	asteroids := map[uint64]bool{
-		1: true, // AP
-	}
-	scores := GenerateCommunityConstructionsToScores(conPlanEvents, conFinEvents, nil, asteroids, 5000, 15000)
+	return leaderboardCrewOwnersCmd
+}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
-	}
+func CreateLCrewsCommand(base *leaderboards.MissionOptions, infile, outfile, accessToken, leaderboardId *string) *cobra.Command {
+	leaderboardCrewsCmd := &cobra.Command{
+		Use:   "crews",
+		Short: "Prepare leaderboard with crews",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-	return nil
-}
+			events, parseEventsErr := leaderboards.LoadEvents[influence.Influence_Contracts_Crew_Crew_Transfer](cmd.Context(), base.Events, eventsFile)
+			if parseEventsErr != nil {
+				return parseEventsErr
+			}
 
-func CL3LearnByDoing(infile, outfile, accessToken, leaderboardId *string) error {
-	conPlanEvents, parseEventsErr := ParseEventFromFile[ConstructionPlanned](*infile, "ConstructionPlanned")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	conFinEvents, parseEventsErr := ParseEventFromFile[ConstructionFinished](*infile, "ConstructionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+			scores := leaderboards.GenerateOwnerCrewsToScores(events)
+			if streamErr := leaderboards.EventSourcesErr(events); streamErr != nil {
+				return streamErr
+			}
 
-	buildingTypes := map[uint64]bool{
-		1: true, // Warehouse
-		2: true, // Extractor
-	}
-	scores := GenerateCommunityConstructionsToScores(conPlanEvents, conFinEvents, buildingTypes, nil, 4000, 10000)
+			outErr := leaderboards.PrepareLeaderboardOutput(cmd.Context(), NamedMissionOptions(*base, "crews"), scores, *outfile, *accessToken, *leaderboardId)
+			if outErr != nil {
+				return outErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			return nil
+		},
 	}
 
-	return nil
+	return leaderboardCrewsCmd
 }
 
-func CL4FourPillars(infile, outfile, accessToken, leaderboardId *string) error {
-	conPlanEvents, parseEventsErr := ParseEventFromFile[ConstructionPlanned](*infile, "ConstructionPlanned")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	conFinEvents, parseEventsErr := ParseEventFromFile[ConstructionFinished](*infile, "ConstructionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLScriptCommand(base *leaderboards.MissionOptions, infile, outfile, accessToken, leaderboardId *string) *cobra.Command {
+	var scriptFile string
 
-	buildingTypes := map[uint64]bool{
-		3: true, // Refinery
-		4: true, // Bioreactor
-		5: true, // Factory
-		6: true, // Shipyard
-	}
-	scores := GenerateCommunityConstructionsToScores(conPlanEvents, conFinEvents, buildingTypes, nil, 2000, 5000)
+	leaderboardScriptCmd := &cobra.Command{
+		Use:   "script",
+		Short: "Prepare leaderboard with scores computed by a Starlark script",
+		Long:  "Prepare leaderboard with scores computed by a Starlark script. The script defines a scores(events) function, which iterates over the events (or events(\"EventName\", ...) for events with the given names) and returns a list of score dicts or a dict of scores by address.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if scriptFile == "" {
+				return errors.New("please specify script with --file flag")
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
+
+			return leaderboards.LScript(scriptFile)(cmd.Context(), NamedMissionOptions(*base, "script"), &eventsFile, outfile, accessToken, leaderboardId)
+		},
 	}
 
-	return nil
+	leaderboardScriptCmd.Flags().StringVarP(&scriptFile, "file", "f", "", "Starlark script with the scores(events) function")
+
+	return leaderboardScriptCmd
 }
 
-func CL5TogetherWeCanRise(infile, outfile, accessToken, leaderboardId *string) error {
-	conPlanEvents, parseEventsErr := ParseEventFromFile[ConstructionPlanned](*infile, "ConstructionPlanned")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	conFinEvents, parseEventsErr := ParseEventFromFile[ConstructionFinished](*infile, "ConstructionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLTransitRouteCommand(base *leaderboards.MissionOptions, infile, outfile, accessToken, leaderboardId *string) *cobra.Command {
+	var origin, destination string
+	var mustReach uint64
 
-	buildingTypes := map[uint64]bool{
-		7: true, // Spaceport
-		8: true, // Marketplace
-		9: true, // Habitat
-	}
-	scores := GenerateCommunityConstructionsToScores(conPlanEvents, conFinEvents, buildingTypes, nil, 300, 1000)
+	leaderboardTransitRouteCmd := &cobra.Command{
+		Use:   "transit-route",
+		Short: "Prepare leaderboard with crews ranked by transits between origin and destination asteroids",
+		Long:  "Prepare leaderboard with crews ranked by finished transits whose origin and destination match --origin and --destination, each of which is any, ap (Adalia Prime), belt (any asteroid but Adalia Prime) or comma-separated asteroid IDs.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			originSelector, originErr := leaderboards.ParseAsteroidSelector(origin)
+			if originErr != nil {
+				return originErr
+			}
+			destinationSelector, destinationErr := leaderboards.ParseAsteroidSelector(destination)
+			if destinationErr != nil {
+				return destinationErr
+			}
+
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			route := leaderboards.TransitRoute{Origin: originSelector, Destination: destinationSelector, MustReach: mustReach}
+			return leaderboards.LTransitRoute(route)(cmd.Context(), NamedMissionOptions(*base, "transit-route"), &eventsFile, outfile, accessToken, leaderboardId)
+		},
 	}
 
-	return nil
+	leaderboardTransitRouteCmd.Flags().StringVar(&origin, "origin", "any", "Asteroids transits depart from: any, ap, belt or comma-separated asteroid IDs")
+	leaderboardTransitRouteCmd.Flags().StringVar(&destination, "destination", "any", "Asteroids transits arrive at: any, ap, belt or comma-separated asteroid IDs")
+	leaderboardTransitRouteCmd.Flags().Uint64Var(&mustReach, "must-reach", 1, "Number of transits along the route a crew needs to complete the mission")
+
+	return leaderboardTransitRouteCmd
 }
 
-func CL6TheFleet(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[ShipAssemblyFinished](*infile, "ShipAssemblyFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLTransactionFeesCommand(base *leaderboards.MissionOptions, infile, outfile, accessToken, leaderboardId, roundsRegistry *string) *cobra.Command {
+	var fromAlias, toAlias string
+	var unit string
+
+	leaderboardTransactionFeesCmd := &cobra.Command{
+		Use:   "transaction-fees",
+		Short: "Prepare leaderboard with wallets ranked by transaction fees spent on Influence",
+		Long:  "Prepare leaderboard with wallets ranked by the fees of transactions which emitted Influence events with a caller, between --from-block and --to-block. Fees are only known for events crawled with \"influence-eth events --transaction-fees\". Each transaction is counted once, for the caller of its first event, and only if its fee was paid in --unit.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resolver, resolverErr := NewBlockResolver("", *roundsRegistry)
+			if resolverErr != nil {
+				return resolverErr
+			}
+			fromBlock, resolveErr := resolver.Resolve(context.Background(), fromAlias)
+			if resolveErr != nil {
+				return resolveErr
+			}
+			toBlock, resolveErr := resolver.Resolve(context.Background(), toAlias)
+			if resolveErr != nil {
+				return resolveErr
+			}
+			if toBlock > 0 && toBlock < fromBlock {
+				return fmt.Errorf("--to-block %d is before --from-block %d", toBlock, fromBlock)
+			}
 
-	scores := GenerateC6TheFleet(events)
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			window := leaderboards.BlockWindow{From: fromBlock, To: toBlock}
+			return leaderboards.LTransactionFees(window, unit)(cmd.Context(), NamedMissionOptions(*base, "transaction-fees"), &eventsFile, outfile, accessToken, leaderboardId)
+		},
 	}
 
-	return nil
+	leaderboardTransactionFeesCmd.Flags().StringVar(&fromAlias, "from-block", "0", "First block of the window fees are counted in, a block number or alias such as latest-7d or round2-start")
+	leaderboardTransactionFeesCmd.Flags().StringVar(&toAlias, "to-block", "0", "Last block of the window fees are counted in, a block number or alias such as round2-end (0 for no limit)")
+	leaderboardTransactionFeesCmd.Flags().StringVar(&unit, "unit", "WEI", "Unit of the fees to count, WEI (ETH) or FRI (STRK)")
+
+	return leaderboardTransactionFeesCmd
 }
 
-func CL7RockBreaker(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[ResourceExtractionFinished](*infile, "ResourceExtractionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLActiveDaysCommand(base *leaderboards.MissionOptions, infile, outfile, accessToken, leaderboardId, roundsRegistry *string) *cobra.Command {
+	var fromAlias, toAlias string
+
+	leaderboardActiveDaysCmd := &cobra.Command{
+		Use:   "active-days",
+		Short: "Prepare streak leaderboard with crews ranked by the number of days they were active on",
+		Long:  "Prepare leaderboard with crews ranked by the number of distinct UTC days on which they emitted any Influence event, between --from-block and --to-block. Days are taken from block timestamps, so events have to be crawled with \"influence-eth events --block-timestamps\"; events without a timestamp are skipped with a warning. The longest streak of consecutive active days is reported in the points data of each crew.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resolver, resolverErr := NewBlockResolver("", *roundsRegistry)
+			if resolverErr != nil {
+				return resolverErr
+			}
+			fromBlock, resolveErr := resolver.Resolve(context.Background(), fromAlias)
+			if resolveErr != nil {
+				return resolveErr
+			}
+			toBlock, resolveErr := resolver.Resolve(context.Background(), toAlias)
+			if resolveErr != nil {
+				return resolveErr
+			}
+			if toBlock > 0 && toBlock < fromBlock {
+				return fmt.Errorf("--to-block %d is before --from-block %d", toBlock, fromBlock)
+			}
 
-	scores := GenerateC7RockBreaker(events)
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			window := leaderboards.BlockWindow{From: fromBlock, To: toBlock}
+			return leaderboards.LActiveDays(window)(cmd.Context(), NamedMissionOptions(*base, "active-days"), &eventsFile, outfile, accessToken, leaderboardId)
+		},
 	}
 
-	return nil
+	leaderboardActiveDaysCmd.Flags().StringVar(&fromAlias, "from-block", "0", "First block of the window active days are counted in, a block number or alias such as latest-7d or round2-start")
+	leaderboardActiveDaysCmd.Flags().StringVar(&toAlias, "to-block", "0", "Last block of the window active days are counted in, a block number or alias such as round2-end (0 for no limit)")
+
+	return leaderboardActiveDaysCmd
 }
 
-func CL8GoodNewsEveryone(infile, outfile, accessToken, leaderboardId *string) error {
-	unknownEvents, parseEventsErr := ParseEventFromFile[RawEvent](*infile, "UNKNOWN")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	trFinEvents, parseEventsErr := ParseEventFromFile[TransitFinished](*infile, "TransitFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLDuplicatesCommand(base *leaderboards.MissionOptions, infile *string) *cobra.Command {
+	var threshold float64
+	var minScores int
+	var strict bool
+
+	leaderboardDuplicatesCmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Warn about missions which produce the same leaderboard",
+		Long:  "Run every mission without writing or pushing scores and warn about missions registered with the same function or producing identical or suspiciously overlapping scores, e.g. after a registry entry was copied without changing its generator.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-	scores := GenerateC8GoodNewsEveryoneToScores(trFinEvents, unknownEvents)
+			leaderboards.DUPLICATE_MIN_SCORES = minScores
+			overlaps, checkErr := leaderboards.CheckDuplicateMissions(cmd.Context(), *base, eventsFile, threshold)
+			if checkErr != nil {
+				return checkErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			for _, overlap := range overlaps {
+				log.Printf("WARNING: %s", overlap)
+			}
+			if len(overlaps) == 0 {
+				log.Printf("No duplicate missions found")
+			} else if strict {
+				return fmt.Errorf("found %d pair(s) of duplicate missions", len(overlaps))
+			}
+			return nil
+		},
 	}
 
-	return nil
+	leaderboardDuplicatesCmd.Flags().Float64Var(&threshold, "threshold", leaderboards.DUPLICATE_OVERLAP_THRESHOLD, "Share of (address, score) pairs two missions may have in common before they are reported")
+	leaderboardDuplicatesCmd.Flags().IntVar(&minScores, "min-scores", leaderboards.DUPLICATE_MIN_SCORES, "Only compare missions with at least this many scores, small leaderboards overlap by chance")
+	leaderboardDuplicatesCmd.Flags().BoolVar(&strict, "strict", false, "Exit with an error if duplicate missions are found, e.g. in CI")
+
+	return leaderboardDuplicatesCmd
 }
 
-func CL9ProspectingPaysOff(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[SamplingDepositFinished](*infile, "SamplingDepositFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLRostersCommand(base *leaderboards.MissionOptions, infile, outfile *string) *cobra.Command {
+	var strict bool
 
-	scores := GenerateC9ProspectingPaysOff(events)
+	leaderboardRostersCmd := &cobra.Command{
+		Use:   "rosters",
+		Short: "Cross-check crew rosters against Crew and Crewmate ownership",
+		Long:  "Compare the latest roster of every crew, from recruitments, arrangements and exchanges of crewmates, with the owners of the crew and its crewmates from Transfer events of the Crew and Crewmate contracts. Crewmates owned by another wallet than their crew, crews or crewmates without any Transfer and crewmates in several crews point to gaps in the crawls, check them before publishing leaderboards.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			changes, changesErr := leaderboards.LoadRosterChanges(cmd.Context(), base.Events, eventsFile)
+			if changesErr != nil {
+				return changesErr
+			}
+			crewOwnerships, crewmateOwnerships, ownershipsErr := leaderboards.LoadTokenOwnerships(cmd.Context(), base.Events, eventsFile)
+			if ownershipsErr != nil {
+				return ownershipsErr
+			}
+			if len(crewmateOwnerships) == 0 {
+				log.Printf("Warning: no Transfer events of the Crewmate contract, parse events with its address with --token-contract crewmate=<address>")
+			}
+
+			report := leaderboards.ReconcileRosters(changes, crewOwnerships, crewmateOwnerships)
+
+			ofp := cmd.OutOrStdout()
+			if *outfile != "" {
+				outputFile, createErr := os.Create(*outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			if encodeErr := encoder.Encode(report); encodeErr != nil {
+				return encodeErr
+			}
+
+			for kind, count := range report.IssueCounts {
+				log.Printf("WARNING: %d roster issue(s) of kind %s", count, kind)
+			}
+			if len(report.Issues) == 0 {
+				log.Printf("Rosters of %d crew(s) with %d crewmate(s) match Crew and Crewmate ownership", report.Crews, report.Crewmates)
+			} else if strict {
+				return fmt.Errorf("found %d roster issue(s)", len(report.Issues))
+			}
+			return nil
+		},
 	}
 
-	return nil
+	leaderboardRostersCmd.Flags().BoolVar(&strict, "strict", false, "Exit with an error if any roster issue is found, e.g. before publishing leaderboards")
+
+	return leaderboardRostersCmd
 }
 
-func CL10Potluck(infile, outfile, accessToken, leaderboardId *string) error {
-	stEventsV1, parseEventsErr := ParseEventFromFile[MaterialProcessingStartedV1](*infile, "MaterialProcessingStartedV1")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	finEvents, parseEventsErr := ParseEventFromFile[MaterialProcessingFinished](*infile, "MaterialProcessingFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLExplainCommand(base *leaderboards.MissionOptions, infile, outfile *string) *cobra.Command {
+	var mission, address string
 
-	scores := GenerateC10Potluck(stEventsV1, finEvents)
+	leaderboardExplainCmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Show the events behind the score of an address in a mission",
+		Long:  "Runs the mission without writing or pushing scores, tracking the transactions which contributed to each score, and prints the score of the address with the events of those transactions the mission reads: their names, blocks, transaction hashes and amounts.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if mission == "" {
+				return errors.New("please specify the mission with --mission")
+			}
+			if address == "" {
+				return errors.New("please specify the address with --address")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
+
+			explanation, explainErr := leaderboards.ExplainScore(cmd.Context(), *base, mission, eventsFile, address)
+			if explainErr != nil {
+				return explainErr
+			}
+
+			ofp := cmd.OutOrStdout()
+			if *outfile != "" {
+				outputFile, createErr := os.Create(*outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			if encodeErr := encoder.Encode(explanation); encodeErr != nil {
+				return encodeErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			log.Printf("Score %d of %s in %s comes from %d event(s) in %d transaction(s)", explanation.Score, explanation.Address, explanation.Mission, len(explanation.Events), len(explanation.Transactions))
+			return nil
+		},
 	}
 
-	return nil
+	leaderboardExplainCmd.Flags().StringVar(&mission, "mission", "", "Name of the mission, as in the \"influence-eth leaderboard\" subcommands (e.g. 4-breaking-ground-r1)")
+	leaderboardExplainCmd.Flags().StringVar(&address, "address", "", "Address of the score, a crew ID or wallet as in the leaderboard")
+
+	return leaderboardExplainCmd
 }
 
-func CreateLCrewOwnersCommand(infile, outfile, accessToken, leaderboardId *string) *cobra.Command {
-	leaderboardCrewOwnersCmd := &cobra.Command{
-		Use:   "crew-owners",
-		Short: "Prepare leaderboard with crews",
+func CreateLFreezeCommand(base *leaderboards.MissionOptions, infile, outfile, roundsRegistry *string) *cobra.Command {
+	var mission, asOfAlias string
+
+	leaderboardFreezeCmd := &cobra.Command{
+		Use:   "freeze",
+		Short: "Snapshot the final standings of a mission at the end of a round",
+		Long:  "Runs the mission on the events of blocks up to --as-of-block and writes its standings, ordered by score, with a hash of the mission, block and standings. Publish the file with the final results, anyone can check it against raw events with \"leaderboard verify-freeze\".",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if mission == "" {
+				return errors.New("please specify the mission with --mission")
+			}
+			if !cmd.Flags().Changed("as-of-block") {
+				return errors.New("please specify the last block of the round with --as-of-block")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			events, parseEventsErr := ParseEventFromFile[Influence_Contracts_Crew_Crew_Transfer](*infile, "influence::contracts::crew::Crew::Transfer")
-			if parseEventsErr != nil {
-				return parseEventsErr
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
 			}
+			defer cleanup()
 
-			scores := GenerateCrewOwnersToScores(events)
+			resolver, resolverErr := NewBlockResolver("", *roundsRegistry)
+			if resolverErr != nil {
+				return resolverErr
+			}
+			asOfBlock, resolveErr := resolver.Resolve(context.Background(), asOfAlias)
+			if resolveErr != nil {
+				return resolveErr
+			}
 
-			outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-			if outErr != nil {
-				return outErr
+			freeze, freezeErr := leaderboards.FreezeMission(cmd.Context(), *base, mission, eventsFile, asOfBlock)
+			if freezeErr != nil {
+				return freezeErr
+			}
+
+			ofp := cmd.OutOrStdout()
+			if *outfile != "" {
+				outputFile, createErr := os.Create(*outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			if encodeErr := encoder.Encode(freeze); encodeErr != nil {
+				return encodeErr
 			}
 
+			log.Printf("Froze %d standings of %s as of block %d with hash %s", len(freeze.Standings), freeze.Mission, freeze.AsOfBlock, freeze.Hash)
 			return nil
 		},
 	}
 
-	return leaderboardCrewOwnersCmd
+	leaderboardFreezeCmd.Flags().StringVar(&mission, "mission", "", "Name of the mission, as in the \"influence-eth leaderboard\" subcommands (e.g. 3-market-maker-r1)")
+	leaderboardFreezeCmd.Flags().StringVar(&asOfAlias, "as-of-block", "", "Last block of the round, a block number or alias such as round2-end or current-end; events of later blocks are not counted")
+
+	return leaderboardFreezeCmd
 }
 
-func CreateLCrewsCommand(infile, outfile, accessToken, leaderboardId *string) *cobra.Command {
-	leaderboardCrewsCmd := &cobra.Command{
-		Use:   "crews",
-		Short: "Prepare leaderboard with crews",
+func CreateLVerifyFreezeCommand(base *leaderboards.MissionOptions, infile *string) *cobra.Command {
+	var freezeFile string
+
+	leaderboardVerifyFreezeCmd := &cobra.Command{
+		Use:   "verify-freeze",
+		Short: "Recompute the standings of a freeze file from raw events and confirm its hash",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if freezeFile == "" {
+				return errors.New("please specify the freeze file with --freeze")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			events, parseEventsErr := ParseEventFromFile[Influence_Contracts_Crew_Crew_Transfer](*infile, "influence::contracts::crew::Crew::Transfer")
-			if parseEventsErr != nil {
-				return parseEventsErr
+			stored, readErr := leaderboards.ReadFreeze(freezeFile)
+			if readErr != nil {
+				return readErr
 			}
 
-			scores := GenerateOwnerCrewsToScores(events)
+			eventsFile, cleanup, inputErr := InfileOrStdin(cmd, *infile)
+			if inputErr != nil {
+				return inputErr
+			}
+			defer cleanup()
 
-			outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-			if outErr != nil {
-				return outErr
+			recomputed, verifyErr := leaderboards.VerifyFreeze(cmd.Context(), *base, stored, eventsFile)
+			if verifyErr != nil {
+				return verifyErr
+			}
+			if recomputed.EventsHash != stored.EventsHash {
+				log.Printf("Events differ from those the freeze was computed from (hash %s instead of %s), but produce the same standings", recomputed.EventsHash, stored.EventsHash)
 			}
 
+			log.Printf("Verified %d standings of %s as of block %d, hash %s", len(recomputed.Standings), recomputed.Mission, recomputed.AsOfBlock, recomputed.Hash)
 			return nil
 		},
 	}
 
-	return leaderboardCrewsCmd
+	leaderboardVerifyFreezeCmd.Flags().StringVar(&freezeFile, "freeze", "", "Freeze file written by \"influence-eth leaderboard freeze\"")
+
+	return leaderboardVerifyFreezeCmd
 }
 
-func L1NewRecruitsR1(infile, outfile, accessToken, leaderboardId *string) error {
-	recEvents, parseEventsErr := ParseEventFromFile[CrewmateRecruited](*infile, "CrewmateRecruited")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	recV1Events, parseEventsErr := ParseEventFromFile[CrewmateRecruitedV1](*infile, "CrewmateRecruitedV1")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLConvertCommand(base *leaderboards.MissionOptions, infile, outfile, accessToken, leaderboardId *string) *cobra.Command {
+	leaderboardConvertCmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert a score file to another output version or points_data schema",
+		Long:  "Reads scores generated by any leaderboard command (with --infile pointing to the score file) and writes them with the selected --output-version and --points-data-schema. If --leaderboard-id is set, scores are pushed to the leaderboard as well.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *infile == "" {
+				return fmt.Errorf("Please specify score file with --infile flag")
+			}
 
-	scores := Generate1NewRecruitsR1(recEvents, recV1Events)
+			opts := *base
+			scoresFile, readErr := leaderboards.ReadScoresFile(*infile, opts.Output.Schema())
+			if readErr != nil {
+				return readErr
+			}
+
+			// Event counts are not stored in score files
+			opts.Thresholds.MinEvents = 0
+			opts.Output.Provenance = scoresFile.Provenance
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			return leaderboards.PrepareLeaderboardOutput(cmd.Context(), opts, scoresFile.Scores, *outfile, *accessToken, *leaderboardId)
+		},
 	}
 
-	return nil
+	return leaderboardConvertCmd
 }
 
-func L1NewRecruitsR2(infile, outfile, accessToken, leaderboardId *string) error {
-	recEvents, parseEventsErr := ParseEventFromFile[CrewmateRecruited](*infile, "CrewmateRecruited")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	recV1Events, parseEventsErr := ParseEventFromFile[CrewmateRecruitedV1](*infile, "CrewmateRecruitedV1")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLResetCommand(accessToken, leaderboardId *string) *cobra.Command {
+	leaderboardResetCmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Remove all scores of a leaderboard at the Moonstream.to portal",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *leaderboardId == "" {
+				return fmt.Errorf("Please specify leaderboard with --leaderboard-id flag")
+			}
+			if *accessToken == "" {
+				*accessToken = leaderboards.MOONSTREAM_ACCESS_TOKEN
+			}
 
-	scores := Generate1NewRecruitsR2(recEvents, recV1Events)
+			if resetErr := leaderboards.ResetLeaderboardScores("", *accessToken, *leaderboardId); resetErr != nil {
+				return resetErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			log.Printf("Removed all scores of leaderboard %s", *leaderboardId)
+			return nil
+		},
 	}
 
-	return nil
+	return leaderboardResetCmd
 }
 
-func L2BuriedTreasureR1(infile, outfile, accessToken, leaderboardId *string) error {
-	stEventsV1, parseEventsErr := ParseEventFromFile[MaterialProcessingStartedV1](*infile, "MaterialProcessingStartedV1")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	finEvents, parseEventsErr := ParseEventFromFile[MaterialProcessingFinished](*infile, "MaterialProcessingFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	sofEvents, parseEventsErr := ParseEventFromFile[SellOrderFilled](*infile, "SellOrderFilled")
-	if parseEventsErr != nil {
-		return parseEventsErr
+func CreateLAuthCommand(accessToken, leaderboardId *string) *cobra.Command {
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage access to the Moonstream.to portal",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
 	}
 
-	scores := Generate2BuriedTreasureR1(stEventsV1, finEvents, sofEvents)
+	var leaderboardsMapPath string
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check that the access token is valid and can push scores to the leaderboards",
+		Long:  "Check that the access token is valid and can push scores to the leaderboard passed with --leaderboard-id, or to all leaderboards of a leaderboards map, so misconfigured tokens are found before generating leaderboards rather than at upload time.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *accessToken == "" {
+				*accessToken = leaderboards.MOONSTREAM_ACCESS_TOKEN
+			}
+
+			// Leaderboard IDs to check by API URL and token, targets of a leaderboards map may
+			// override both
+			type authTarget struct {
+				APIURL      string
+				AccessToken string
+			}
+			targets := map[authTarget][]string{}
+			order := []authTarget{}
+			addTarget := func(target authTarget, leaderboardId string) {
+				if _, ok := targets[target]; !ok {
+					order = append(order, target)
+				}
+				if leaderboardId != "" {
+					targets[target] = append(targets[target], leaderboardId)
+				} else if targets[target] == nil {
+					targets[target] = []string{}
+				}
+			}
+
+			defaultTarget := authTarget{APIURL: leaderboards.MOONSTREAM_API_URL, AccessToken: *accessToken}
+			addTarget(defaultTarget, *leaderboardId)
+			if leaderboardsMapPath != "" {
+				leaderboardsMap, readErr := leaderboards.ReadLeaderboardsMap(leaderboardsMapPath)
+				if readErr != nil {
+					return readErr
+				}
+				missions := make([]string, 0, len(leaderboardsMap))
+				for mission := range leaderboardsMap {
+					missions = append(missions, mission)
+				}
+				sort.Strings(missions)
+				for _, mission := range missions {
+					for _, mapTarget := range leaderboardsMap[mission] {
+						target := defaultTarget
+						if mapTarget.AccessToken != "" {
+							target.AccessToken = mapTarget.AccessToken
+						}
+						if mapTarget.APIURL != "" {
+							target.APIURL = mapTarget.APIURL
+						}
+						addTarget(target, mapTarget.LeaderboardId)
+					}
+				}
+			}
+
+			failed := 0
+			for _, target := range order {
+				user, whoamiErr := leaderboards.WhoAmI(target.AccessToken)
+				if whoamiErr != nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "Token %s: %v\n", leaderboards.MaskToken(target.AccessToken), whoamiErr)
+					failed += len(targets[target]) + 1
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Token %s belongs to %s (%s)\n", leaderboards.MaskToken(target.AccessToken), user.Username, user.Id)
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+				if len(targets[target]) == 0 {
+					continue
+				}
+				access, accessErr := leaderboards.CheckLeaderboardAccess(target.APIURL, target.AccessToken, targets[target])
+				if accessErr != nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "  unable to list leaderboards at %s: %v\n", leaderboards.PortalAPIURL(target.APIURL), accessErr)
+					failed += len(targets[target])
+					continue
+				}
+				for _, leaderboard := range access {
+					if leaderboard.Owned {
+						fmt.Fprintf(cmd.OutOrStdout(), "  %s %s: ok\n", leaderboard.LeaderboardId, leaderboard.Title)
+					} else {
+						fmt.Fprintf(cmd.OutOrStdout(), "  %s: not accessible with this token\n", leaderboard.LeaderboardId)
+						failed++
+					}
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d auth checks failed", failed)
+			}
+			return nil
+		},
 	}
 
-	return nil
+	checkCmd.Flags().StringVarP(&leaderboardsMapPath, "leaderboards-map", "m", "", "Leaderboards map JSON file to check the leaderboards and tokens of all targets of")
+
+	authCmd.AddCommand(checkCmd)
+
+	return authCmd
 }
 
-func L2BuriedTreasureR2(infile, outfile, accessToken, leaderboardId *string) error {
-	sdsEvents, parseEventsErr := ParseEventFromFile[SamplingDepositStarted](*infile, "SamplingDepositStarted")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	sdsEventsV1, parseEventsErr := ParseEventFromFile[SamplingDepositStartedV1](*infile, "SamplingDepositStartedV1")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	sdfEvents, parseEventsErr := ParseEventFromFile[SamplingDepositFinished](*infile, "SamplingDepositFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateLDeleteScoresCommand(accessToken, leaderboardId *string) *cobra.Command {
+	var addressesFile string
 
-	scores := Generate2BuriedTreasureR2(sdsEvents, sdsEventsV1, sdfEvents)
+	leaderboardDeleteScoresCmd := &cobra.Command{
+		Use:   "delete-scores",
+		Short: "Remove scores of some addresses from a leaderboard at the Moonstream.to portal",
+		Long:  "Reads addresses from the --addresses file (one per line) and removes their scores from the leaderboard. Scores of other addresses are fetched from the portal and pushed back unchanged.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *leaderboardId == "" {
+				return fmt.Errorf("Please specify leaderboard with --leaderboard-id flag")
+			}
+			if addressesFile == "" {
+				return fmt.Errorf("Please specify file with addresses with --addresses flag")
+			}
+			if *accessToken == "" {
+				*accessToken = leaderboards.MOONSTREAM_ACCESS_TOKEN
+			}
+
+			addressesData, readErr := os.ReadFile(addressesFile)
+			if readErr != nil {
+				return fmt.Errorf("Unable to read file %s, err: %v", addressesFile, readErr)
+			}
+			var addresses []string
+			for _, line := range strings.Split(string(addressesData), "\n") {
+				if address := strings.TrimSpace(line); address != "" {
+					addresses = append(addresses, address)
+				}
+			}
+			if len(addresses) == 0 {
+				return fmt.Errorf("No addresses found in file %s", addressesFile)
+			}
+
+			deleted, deleteErr := leaderboards.DeleteLeaderboardScores("", *accessToken, *leaderboardId, addresses)
+			if deleteErr != nil {
+				return deleteErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			log.Printf("Removed %d scores from leaderboard %s", deleted, *leaderboardId)
+			return nil
+		},
 	}
 
-	return nil
+	leaderboardDeleteScoresCmd.Flags().StringVar(&addressesFile, "addresses", "", "File with addresses to remove scores of, one per line")
+
+	return leaderboardDeleteScoresCmd
 }
 
-func L3MarketMakerR1(infile, outfile, accessToken, leaderboardId *string) error {
-	buyEvents, parseEventsErr := ParseEventFromFile[BuyOrderFilled](*infile, "BuyOrderFilled")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	sellEvents, parseEventsErr := ParseEventFromFile[SellOrderFilled](*infile, "SellOrderFilled")
-	if parseEventsErr != nil {
-		return parseEventsErr
+func CreateRoundsCommand() *cobra.Command {
+	roundsCmd := &cobra.Command{
+		Use:   "rounds",
+		Short: "Manage leaderboard rounds",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
 	}
 
-	scores := Generate3MarketMakerR1(buyEvents, sellEvents)
+	roundsRolloverCmd := CreateRoundsRolloverCommand()
+	roundsCmd.AddCommand(roundsRolloverCmd)
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+	return roundsCmd
+}
+
+func CreateRoundsRolloverCommand() *cobra.Command {
+	var infile, accessToken, leaderboardsMapFilePath, registryFilePath, archiveDir, pointsDataSchema string
+
+	rolloverCmd := &cobra.Command{
+		Use:   "rollover",
+		Short: "Freeze leaderboards of the current round and create leaderboards of the next one",
+		Long:  "Pushes final scores of the current round from the rounds registry and archives them with the leaderboards map, then creates leaderboards of the next round at the Moonstream.to portal, writes their IDs to the leaderboards map and marks the next round as current.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if registryFilePath == "" {
+				return errors.New("please specify rounds registry file with --registry flag")
+			}
+			if leaderboardsMapFilePath == "" {
+				return errors.New("please specify leaderboards map file with --leaderboards-map flag")
+			}
+			leaderboards.AUDIT_INPUT_FILE = infile
+			return leaderboards.CheckPointsDataSchema(pointsDataSchema)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := leaderboards.DefaultMissionOptions("")
+			opts.Output.PointsDataSchema = pointsDataSchema
+			return leaderboards.RoundsRollover(cmd.Context(), opts, registryFilePath, leaderboardsMapFilePath, infile, accessToken, archiveDir)
+		},
 	}
 
-	return nil
+	rolloverCmd.Flags().StringVarP(&registryFilePath, "registry", "r", "", "Rounds registry JSON file")
+	rolloverCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing crawled events of the ending round (as produced by the \"influence-eth parse\" command)")
+	rolloverCmd.Flags().StringVarP(&accessToken, "token", "t", "", "Moonstream user access token (could be set with MOONSTREAM_ACCESS_TOKEN environment variable)")
+	rolloverCmd.Flags().StringVarP(&leaderboardsMapFilePath, "leaderboards-map", "m", "", "Leaderboards map JSON file, rewritten with leaderboards of the next round")
+	rolloverCmd.Flags().StringVar(&archiveDir, "archive-dir", "rounds-archive", "Directory to archive final scores and leaderboards map of the ending round in")
+	rolloverCmd.Flags().StringVar(&pointsDataSchema, "points-data-schema", "v1", "Schema of points_data keys expected by the Moonstream.to portal (v1 or v2)")
+
+	return rolloverCmd
 }
 
-func L3MarketMakerR2(infile, outfile, accessToken, leaderboardId *string) error {
-	buyEvents, parseEventsErr := ParseEventFromFile[BuyOrderCreated](*infile, "BuyOrderCreated")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	sellEvents, parseEventsErr := ParseEventFromFile[SellOrderCreated](*infile, "SellOrderCreated")
-	if parseEventsErr != nil {
-		return parseEventsErr
+func CreateAnalyticsCommand() *cobra.Command {
+	analyticsCmd := &cobra.Command{
+		Use:   "analytics",
+		Short: "Analyze crawled events",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
 	}
 
-	scores := Generate3MarketMakerR2(buyEvents, sellEvents)
+	analyticsFlowsCmd := CreateAnalyticsFlowsCommand()
+	analyticsRetentionCmd := CreateAnalyticsRetentionCommand()
+	analyticsCompletionCmd := CreateAnalyticsCompletionCommand()
+	analyticsExtractionMapCmd := CreateAnalyticsExtractionMapCommand()
+	analyticsOrderBookCmd := CreateAnalyticsOrderBookCommand()
+	analyticsCmd.AddCommand(analyticsFlowsCmd, analyticsRetentionCmd, analyticsCompletionCmd, analyticsExtractionMapCmd, analyticsOrderBookCmd)
+
+	return analyticsCmd
+}
+
+func CreateAnalyticsFlowsCommand() *cobra.Command {
+	var infile, outfile, categoriesFilePath string
+
+	flowsCmd := &cobra.Command{
+		Use:   "flows",
+		Short: "Product flows between asteroids",
+		Long:  "Correlates sent and received deliveries and locates their origins and destinations on asteroids (buildings by the lots they were planned on, ships by their latest transit) to produce an origin to destination matrix of delivered products per product category, together with the amounts each crew exported from one asteroid to another.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return errors.New("please specify events file with --infile flag")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			categories := leaderboards.ProductCategories{}
+			if categoriesFilePath != "" {
+				var readErr error
+				categories, readErr = leaderboards.ReadProductCategories(categoriesFilePath)
+				if readErr != nil {
+					return readErr
+				}
+			}
+
+			plannedEvents, loadErr := leaderboards.LoadEvents[influence.ConstructionPlanned](cmd.Context(), leaderboards.EventOptions{}, infile)
+			if loadErr != nil {
+				return loadErr
+			}
+			transitEvents, loadErr := leaderboards.LoadEvents[influence.TransitFinished](cmd.Context(), leaderboards.EventOptions{}, infile)
+			if loadErr != nil {
+				return loadErr
+			}
+			sentEvents, loadErr := leaderboards.LoadEvents[influence.DeliverySent](cmd.Context(), leaderboards.EventOptions{}, infile)
+			if loadErr != nil {
+				return loadErr
+			}
+			receivedEvents, loadErr := leaderboards.LoadEvents[influence.DeliveryReceived](cmd.Context(), leaderboards.EventOptions{}, infile)
+			if loadErr != nil {
+				return loadErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			locator := leaderboards.NewEntityLocator(plannedEvents, transitEvents)
+			flows := leaderboards.ComputeProductFlows(sentEvents, receivedEvents, locator, categories)
+			if streamErr := leaderboards.EventSourcesErr(plannedEvents, transitEvents, sentEvents, receivedEvents); streamErr != nil {
+				return streamErr
+			}
+
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, createErr := os.Create(outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(flows)
+		},
 	}
 
-	return nil
+	flowsCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing crawled events (as produced by the \"influence-eth parse\" command)")
+	flowsCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write product flows to (default: stdout)")
+	flowsCmd.Flags().StringVar(&categoriesFilePath, "categories", "", "JSON file mapping product IDs to categories (by default each product is its own category)")
+
+	return flowsCmd
 }
 
-func L4BreakingGroundR1(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[ResourceExtractionFinished](*infile, "ResourceExtractionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateAnalyticsExtractionMapCommand() *cobra.Command {
+	var infile, outfile string
 
-	scores := Generate4BreakingGroundR1(events)
+	extractionMapCmd := &cobra.Command{
+		Use:   "extraction-map",
+		Short: "Heatmap of extracted resources by asteroid and lot",
+		Long:  "Sums the yield of finished resource extractions by the lot their extractor was planned on and by resource, and writes it as a JSON heatmap of asteroids, their lots and the total yield of each resource extracted there, for community mapping tools. Extractions by extractors planned before the first crawled event can not be placed on a lot and are only counted.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return errors.New("please specify events file with --infile flag")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plannedEvents, loadErr := leaderboards.LoadEvents[influence.ConstructionPlanned](cmd.Context(), leaderboards.EventOptions{}, infile)
+			if loadErr != nil {
+				return loadErr
+			}
+			extractionEvents, loadErr := leaderboards.LoadEvents[influence.ResourceExtractionFinished](cmd.Context(), leaderboards.EventOptions{}, infile)
+			if loadErr != nil {
+				return loadErr
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			extractionMap := leaderboards.ComputeExtractionMap(plannedEvents, extractionEvents)
+			if streamErr := leaderboards.EventSourcesErr(plannedEvents, extractionEvents); streamErr != nil {
+				return streamErr
+			}
+			if extractionMap.Unlocated > 0 {
+				log.Printf("%d extractions could not be placed on a lot, their extractors were not planned in the events file", extractionMap.Unlocated)
+			}
+
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, createErr := os.Create(outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
+
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(extractionMap)
+		},
 	}
 
-	return nil
+	extractionMapCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing crawled events (as produced by the \"influence-eth parse\" command)")
+	extractionMapCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write the extraction heatmap to (default: stdout)")
+
+	return extractionMapCmd
 }
 
-func L4BreakingGroundR2(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[ResourceExtractionFinished](*infile, "ResourceExtractionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateAnalyticsOrderBookCommand() *cobra.Command {
+	var infile, outfile string
 
-	scores := Generate4BreakingGroundR2(events)
+	orderBookCmd := &cobra.Command{
+		Use:   "order-book",
+		Short: "Check the order book events of a crawl for gaps",
+		Long:  "Matches BuyOrderFilled/Cancelled and SellOrderFilled/Cancelled events with the BuyOrderCreated and SellOrderCreated events of their orders, and lists orders which were filled or cancelled without being created before in the events file. Such orders indicate a gap in the crawl, which skews the Market Maker leaderboards; those missions log a warning when they find any.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return errors.New("please specify events file with --infile flag")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, checkErr := leaderboards.CheckOrderBook(cmd.Context(), leaderboards.EventOptions{}, infile)
+			if checkErr != nil {
+				return checkErr
+			}
+			log.Printf("%d orders were created, %d orders were filled or cancelled without being created", report.CreatedOrders, len(report.Orphaned))
+
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, createErr := os.Create(outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		},
 	}
 
-	return nil
+	orderBookCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing crawled events (as produced by the \"influence-eth parse\" command)")
+	orderBookCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write the order book report to (default: stdout)")
+
+	return orderBookCmd
 }
 
-func L5CityBuilder(infile, outfile, accessToken, leaderboardId *string) error {
-	conFinEvents, parseEventsErr := ParseEventFromFile[ConstructionFinished](*infile, "ConstructionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateAnalyticsRetentionCommand() *cobra.Command {
+	var infile, outfile, format string
 
-	conPlanEvents, parseEventsErr := ParseEventFromFile[ConstructionPlanned](*infile, "ConstructionPlanned")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+	retentionCmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Weekly crew activity, retention and churn",
+		Long:  "Places events in weeks (starting Monday 00:00 UTC) by their block timestamp and counts, for each week, the crews which emitted events, how many of them were new or returning, and how many crews of the previous week were retained or churned. Events are only placed in weeks if they were crawled with --block-timestamps.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return errors.New("please specify events file with --infile flag")
+			}
+			if format != "json" && format != "csv" {
+				return fmt.Errorf("unknown format %s, supported formats: csv, json", format)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, retentionErr := ComputeCrewRetention(infile)
+			if retentionErr != nil {
+				return retentionErr
+			}
+			if report.UntimedEvents > 0 {
+				log.Printf("%d of %d crew events have no block timestamp and were skipped, crawl with --block-timestamps to include them", report.UntimedEvents, report.Events)
+			}
 
-	scores := Generate5CityBuilder(conFinEvents, conPlanEvents)
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, createErr := os.Create(outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			if format == "csv" {
+				return WriteRetentionCSV(ofp, report)
+			}
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		},
 	}
 
-	return nil
+	retentionCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing parsed events (as produced by the \"influence-eth events\" or \"influence-eth parse\" commands)")
+	retentionCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write weekly retention to (default: stdout)")
+	retentionCmd.Flags().StringVar(&format, "format", "json", "Output format (json or csv)")
+
+	return retentionCmd
 }
 
-func L6ExploreTheStarsR1(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[ShipAssemblyFinished](*infile, "ShipAssemblyFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateAnalyticsCompletionCommand() *cobra.Command {
+	var infile, outfile, format string
+	var minCompleted int
 
-	scores := Generate6ExploreTheStarsR1(events)
+	completionCmd := &cobra.Command{
+		Use:   "completion",
+		Short: "Matrix of missions completed by each crew or wallet",
+		Long:  "Runs all missions on the events file, without pushing scores, and writes for every crew or wallet with a score whether it completed each mission and its score there. Use --min-completed to only list addresses which completed at least that many missions.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return errors.New("please specify events file with --infile flag")
+			}
+			if format != "json" && format != "csv" {
+				return fmt.Errorf("unknown format %s, supported formats: csv, json", format)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			certificates, certificatesErr := leaderboards.GenerateCertificates(cmd.Context(), leaderboards.DefaultMissionOptions(""), infile)
+			if certificatesErr != nil {
+				return certificatesErr
+			}
+			matrix := leaderboards.NewCompletionMatrix(certificates, minCompleted)
+
+			ofp := cmd.OutOrStdout()
+			if outfile != "" {
+				outputFile, createErr := os.Create(outfile)
+				if createErr != nil {
+					return createErr
+				}
+				defer outputFile.Close()
+				ofp = outputFile
+			}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+			if format == "csv" {
+				return leaderboards.WriteCompletionMatrixCSV(ofp, matrix)
+			}
+			encoder := json.NewEncoder(ofp)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(matrix)
+		},
 	}
 
-	return nil
+	completionCmd.Flags().StringVarP(&infile, "infile", "i", "", "File containing parsed events (as produced by the \"influence-eth events\" or \"influence-eth parse\" commands)")
+	completionCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write the completion matrix to (default: stdout)")
+	completionCmd.Flags().StringVar(&format, "format", "json", "Output format (json or csv)")
+	completionCmd.Flags().IntVar(&minCompleted, "min-completed", 0, "Only list crews and wallets which completed at least this many missions")
+
+	return completionCmd
 }
 
-func L6ExploreTheStarsR2(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[TransitFinished](*infile, "TransitFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
+func CreateArchiveCommand() *cobra.Command {
+	archiveCmd := &cobra.Command{
+		Use:   "archive",
+		Short: "Archive monthly event dumps to S3, GCS or a directory",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
 	}
 
-	scores := Generate6ExploreTheStarsR2(events)
+	archiveCreateCmd := CreateArchiveCreateCommand()
+	archiveVerifyCmd := CreateArchiveRestoreCommand("verify", "Download an archive and check its checksums")
+	archiveRestoreCmd := CreateArchiveRestoreCommand("restore", "Download an archive, check its checksums and decompress its dumps")
+	archiveCmd.AddCommand(archiveCreateCmd, archiveVerifyCmd, archiveRestoreCmd)
+
+	return archiveCmd
+}
+
+func CreateArchiveCreateCommand() *cobra.Command {
+	var destination, month string
+	var files []string
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Compress, checksum and upload the month's raw and parsed dumps with a manifest",
+		Long:  "Compresses the dumps with gzip, records their SHA256 checksums and block ranges in a manifest, and uploads them under <destination>/<month>/. Destinations starting with s3:// or gs:// are uploaded to with the aws or gsutil command line tools, other destinations are local directories.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if destination == "" {
+				return errors.New("please specify archive destination with --dest flag")
+			}
+			if len(files) == 0 {
+				return errors.New("please specify dumps to archive with --files flag")
+			}
+			if _, parseErr := time.Parse("2006-01", month); parseErr != nil {
+				return fmt.Errorf("invalid month %s, expected YYYY-MM", month)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storage, storageErr := NewArchiveStorage(destination)
+			if storageErr != nil {
+				return storageErr
+			}
+			manifest, archiveErr := CreateArchive(storage, month, files)
+			if archiveErr != nil {
+				return archiveErr
+			}
+			for _, file := range manifest.Files {
+				cmd.Printf("Archived %s (blocks %d-%d, %d bytes compressed to %d)\n", file.Name, file.FromBlock, file.ToBlock, file.Size, file.CompressedSize)
+			}
+			return nil
+		},
 	}
 
-	return nil
+	createCmd.Flags().StringVar(&destination, "dest", "", "Archive destination: s3://bucket/prefix, gs://bucket/prefix or a local directory")
+	createCmd.Flags().StringVar(&month, "month", PreviousMonth(time.Now()), "Month of the archive in YYYY-MM format, the previous month by default")
+	createCmd.Flags().StringSliceVar(&files, "files", nil, "Comma-separated raw and parsed dumps to archive")
+
+	return createCmd
 }
 
-func L7ExpandTheColony(infile, outfile, accessToken, leaderboardId *string) error {
-	conFinEvents, parseEventsErr := ParseEventFromFile[ConstructionFinished](*infile, "ConstructionFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
+// CreateArchiveRestoreCommand creates the verify and restore commands, which only differ in
+// whether dumps are kept after their checksums are checked.
+func CreateArchiveRestoreCommand(use, short string) *cobra.Command {
+	var destination, month, outDir string
+
+	restoreCmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if destination == "" {
+				return errors.New("please specify archive destination with --dest flag")
+			}
+			if month == "" {
+				return errors.New("please specify month of the archive with --month flag")
+			}
+			if use == "restore" && outDir == "" {
+				return errors.New("please specify directory to restore dumps into with --outdir flag")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			storage, storageErr := NewArchiveStorage(destination)
+			if storageErr != nil {
+				return storageErr
+			}
+			manifest, restoreErr := RestoreArchive(storage, month, outDir)
+			if restoreErr != nil {
+				return restoreErr
+			}
+			for _, file := range manifest.Files {
+				cmd.Printf("Verified %s (blocks %d-%d)\n", file.Name, file.FromBlock, file.ToBlock)
+			}
+			return nil
+		},
 	}
 
-	conPlanEvents, parseEventsErr := ParseEventFromFile[ConstructionPlanned](*infile, "ConstructionPlanned")
-	if parseEventsErr != nil {
-		return parseEventsErr
+	restoreCmd.Flags().StringVar(&destination, "dest", "", "Archive destination: s3://bucket/prefix, gs://bucket/prefix or a local directory")
+	restoreCmd.Flags().StringVar(&month, "month", "", "Month of the archive in YYYY-MM format")
+	if use == "restore" {
+		restoreCmd.Flags().StringVarP(&outDir, "outdir", "o", "", "Directory to restore dumps into")
 	}
 
-	scores := Generate7ExpandTheColony(conFinEvents, conPlanEvents)
+	return restoreCmd
+}
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+func CreateStateCommand() *cobra.Command {
+	stateCmd := &cobra.Command{
+		Use:   "state",
+		Short: "Export and import crawl state, so a new machine can take over crawling",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
 	}
 
-	return nil
+	stateExportCmd := CreateStateExportCommand()
+	stateImportCmd := CreateStateImportCommand()
+	stateCmd.AddCommand(stateExportCmd, stateImportCmd)
+
+	return stateCmd
 }
 
-func L8SpecialDelivery(infile, outfile, accessToken, leaderboardId *string) error {
-	unknownEvents, parseEventsErr := ParseEventFromFile[RawEvent](*infile, "UNKNOWN")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
-	trEvents, parseEventsErr := ParseEventFromFile[TransitFinished](*infile, "TransitFinished")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+// Names of crawl state components in state archives.
+const (
+	STATE_FROM_BLOCK_FILE = "from-block-file"
+	STATE_EVENTS_INDEX    = "events-index"
+	STATE_RPC_CACHE       = "rpc-cache"
+)
+
+func addStateFlags(cmd *cobra.Command, fromBlockFilePath, eventsIndexPath, rpcCacheDir *string, verb string) {
+	cmd.Flags().StringVarP(fromBlockFilePath, "from-block-file", "f", "", fmt.Sprintf("File with the block number from which \"influence-eth do-everything\" continues crawling, to %s", verb))
+	cmd.Flags().StringVar(eventsIndexPath, "events-index", "", fmt.Sprintf("Index of rotated \"influence-eth events\" output files (e.g. events-index.json), to %s", verb))
+	cmd.Flags().StringVar(rpcCacheDir, "rpc-cache", "", fmt.Sprintf("Directory of getEvents responses cached by \"influence-eth rpc-proxy\", to %s", verb))
+}
 
-	scores := Generate8SpecialDelivery(trEvents, unknownEvents)
+func CreateStateExportCommand() *cobra.Command {
+	var outfile, fromBlockFilePath, eventsIndexPath, rpcCacheDir string
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Bundle the crawl cursor, rotated output index and RPC cache into a single archive",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if outfile == "" {
+				return errors.New("please specify file to write the state archive to with -o/--outfile flag")
+			}
+			if fromBlockFilePath == "" && eventsIndexPath == "" && rpcCacheDir == "" {
+				return errors.New("please specify state to export with --from-block-file, --events-index or --rpc-cache flags")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifest, exportErr := ExportState(outfile, []StateComponent{
+				{Name: STATE_FROM_BLOCK_FILE, Path: fromBlockFilePath},
+				{Name: STATE_EVENTS_INDEX, Path: eventsIndexPath},
+				{Name: STATE_RPC_CACHE, Path: rpcCacheDir},
+			})
+			if exportErr != nil {
+				return exportErr
+			}
+			for _, component := range manifest.Components {
+				cmd.Printf("Exported %s from %s (%d files, %d bytes)\n", component.Name, component.Path, component.Files, component.Size)
+			}
+			return nil
+		},
 	}
 
-	return nil
+	exportCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "File to write the state archive to (.tar.gz)")
+	addStateFlags(exportCmd, &fromBlockFilePath, &eventsIndexPath, &rpcCacheDir, "export")
+
+	return exportCmd
 }
 
-func L9DinnerIsServed(infile, outfile, accessToken, leaderboardId *string) error {
-	events, parseEventsErr := ParseEventFromFile[FoodSupplied](*infile, "FoodSupplied")
-	if parseEventsErr != nil {
-		return parseEventsErr
-	}
+func CreateStateImportCommand() *cobra.Command {
+	var infile, fromBlockFilePath, eventsIndexPath, rpcCacheDir string
+	var force bool
 
-	eventsV1, parseEventsErr := ParseEventFromFile[FoodSuppliedV1](*infile, "FoodSuppliedV1")
-	if parseEventsErr != nil {
-		return parseEventsErr
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Restore crawl state from an archive written by \"state export\"",
+		Long:  "Restores the components of a state archive to the paths they were exported from, or to the paths passed with --from-block-file, --events-index and --rpc-cache.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if infile == "" {
+				return errors.New("please specify state archive with -i/--infile flag")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			destinations := map[string]string{
+				STATE_FROM_BLOCK_FILE: fromBlockFilePath,
+				STATE_EVENTS_INDEX:    eventsIndexPath,
+				STATE_RPC_CACHE:       rpcCacheDir,
+			}
+			manifest, importErr := ImportState(infile, destinations, force)
+			if importErr != nil {
+				return importErr
+			}
+			for _, component := range manifest.Components {
+				cmd.Printf("Imported %s to %s (%d files, %d bytes)\n", component.Name, component.Path, component.Files, component.Size)
+			}
+			return nil
+		},
 	}
 
-	scores := Generate9DinnerIsServed(events, eventsV1)
+	importCmd.Flags().StringVarP(&infile, "infile", "i", "", "State archive written by \"influence-eth state export\"")
+	importCmd.Flags().BoolVar(&force, "force", false, "Replace existing files with the state of the archive")
+	addStateFlags(importCmd, &fromBlockFilePath, &eventsIndexPath, &rpcCacheDir, "restore instead of the exported path")
+
+	return importCmd
+}
+
+func CreateRPCProxyCommand() *cobra.Command {
+	var providerURL, listenAddress, cacheDir string
+	var confirmations uint64
 
-	outErr := PrepareLeaderboardOutput(scores, *outfile, *accessToken, *leaderboardId)
-	if outErr != nil {
-		return outErr
+	rpcProxyCmd := &cobra.Command{
+		Use:   "rpc-proxy",
+		Short: "Run a Starknet RPC proxy which caches getEvents responses",
+		Long:  "Run a Starknet RPC proxy which caches starknet_getEvents responses for historical block ranges on disk. Point crawls at the proxy with -p/--provider, so that crawling the same range again is served from the cache instead of the provider.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if providerURL == "" {
+				providerURLFromEnv := os.Getenv("STARKNET_RPC_URL")
+				if providerURLFromEnv == "" {
+					return errors.New("you must provide a provider URL using -p/--provider or set the STARKNET_RPC_URL environment variable")
+				}
+				providerURL = providerURLFromEnv
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			proxy, proxyErr := NewRPCCachingProxy(providerURL, cacheDir, confirmations)
+			if proxyErr != nil {
+				return proxyErr
+			}
+
+			log.Printf("Serving Starknet RPC proxy on %s, caching getEvents responses in %s", listenAddress, cacheDir)
+			return http.ListenAndServe(listenAddress, proxy)
+		},
 	}
 
-	return nil
+	rpcProxyCmd.Flags().StringVarP(&providerURL, "provider", "p", "", "The URL of your Starknet RPC provider (defaults to value of STARKNET_RPC_URL environment variable)")
+	rpcProxyCmd.Flags().StringVarP(&listenAddress, "listen", "l", "127.0.0.1:8545", "Address to serve the proxy on")
+	rpcProxyCmd.Flags().StringVar(&cacheDir, "cache-dir", "rpc-cache", "Directory to cache getEvents responses in")
+	rpcProxyCmd.Flags().Uint64Var(&confirmations, "confirmations", 10, "Only cache responses for block ranges with at least this many blocks on top of them")
+
+	return rpcProxyCmd
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// DeadLetter is an event whose selector matches a known event but whose parameters could not be
+// decoded, written by parse to the dead-letter file together with the decode error.
+type DeadLetter struct {
+	LineNumber      int             `json:"line_number"`
+	EventName       string          `json:"event_name,omitempty"`
+	BlockNumber     uint64          `json:"block_number"`
+	TransactionHash string          `json:"transaction_hash,omitempty"`
+	PrimaryKey      string          `json:"primary_key,omitempty"`
+	Error           string          `json:"error"`
+	Event           json.RawMessage `json:"event"`
+}
+
+// DeadLetterWriter counts undecodable events by event name and, if it has a file, writes them to
+// it as JSON lines.
+type DeadLetterWriter struct {
+	Counts map[string]int
+	Total  int
+
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewDeadLetterWriter creates the dead-letter file at filePath, or only counts undecodable events
+// if filePath is empty.
+func NewDeadLetterWriter(filePath string) (*DeadLetterWriter, error) {
+	writer := &DeadLetterWriter{Counts: make(map[string]int)}
+	if filePath != "" {
+		file, createErr := os.Create(filePath)
+		if createErr != nil {
+			return nil, createErr
+		}
+		writer.file = file
+		writer.encoder = json.NewEncoder(file)
+	}
+	return writer, nil
+}
+
+func (w *DeadLetterWriter) Write(lineNumber int, event json.RawMessage, decodeErr error) error {
+	letter := DeadLetter{LineNumber: lineNumber, Error: decodeErr.Error(), Event: event}
+	var eventDecodeErr *influence.DecodeError
+	if errors.As(decodeErr, &eventDecodeErr) {
+		letter.EventName = influence.EventNameOfKey(eventDecodeErr.PrimaryKey)
+		letter.BlockNumber = eventDecodeErr.BlockNumber
+		letter.TransactionHash = eventDecodeErr.TransactionHash
+		letter.PrimaryKey = eventDecodeErr.PrimaryKey
+		letter.Error = eventDecodeErr.Err.Error()
+	}
+
+	name := letter.EventName
+	if name == "" {
+		name = letter.PrimaryKey
+	}
+	w.Counts[name]++
+	w.Total++
+
+	if w.encoder == nil {
+		return nil
+	}
+	return w.encoder.Encode(letter)
+}
+
+// Report logs the number of undecodable events of each event, which usually means that the ABI of
+// the event changed and the bindings need to be regenerated.
+func (w *DeadLetterWriter) Report() {
+	if w.Total == 0 {
+		return
+	}
+	names := make([]string, 0, len(w.Counts))
+	for name := range w.Counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	destination := "passed through as " + influence.EVENT_UNKNOWN
+	if w.file != nil {
+		destination += ", written to " + w.file.Name()
+	}
+	log.Printf("%d events of known types failed to decode (%s)", w.Total, destination)
+	for _, name := range names {
+		log.Printf("  %s: %d", name, w.Counts[name])
+	}
+}
+
+func (w *DeadLetterWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	closeErr := w.file.Close()
+	w.file, w.encoder = nil, nil
+	return closeErr
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+	"github.com/moonstream-to/influence-eth/pkg/leaderboards"
+)
+
+// Label of crew entities in Influence_Common_Types_Entity_Entity.
+var CREW_LABEL = uint64(1)
+
+// ExportedEvent is an event of an events dump together with the line it was read from.
+type ExportedEvent struct {
+	LineNumber      int             `json:"line_number"`
+	Name            string          `json:"name"`
+	TransactionHash string          `json:"transaction_hash,omitempty"`
+	Event           json.RawMessage `json:"event"`
+}
+
+// EventInvolvesCrew checks if any top level field of the event (e.g. CallerCrew) is the crew
+// entity with the given ID.
+func EventInvolvesCrew(event json.RawMessage, crewId uint64) bool {
+	var fields map[string]json.RawMessage
+	if unmErr := json.Unmarshal(event, &fields); unmErr != nil {
+		return false
+	}
+	for _, value := range fields {
+		var entity influence.Influence_Common_Types_Entity_Entity
+		if unmErr := json.Unmarshal(value, &entity); unmErr != nil {
+			continue
+		}
+		if entity.Label == CREW_LABEL && entity.Id == crewId {
+			return true
+		}
+	}
+	return false
+}
+
+// EventFilePaths returns the files holding events with the given names (or all events if names is
+// empty) of an events dump, or of a directory partitioned by event type.
+func EventFilePaths(filePath string, names []string) ([]string, error) {
+	filePaths := []string{filePath}
+	if info, statErr := os.Stat(filePath); statErr == nil && info.IsDir() {
+		filePaths = nil
+		if len(names) == 0 {
+			matches, globErr := filepath.Glob(filepath.Join(filePath, "*.jsonl"))
+			if globErr != nil {
+				return nil, globErr
+			}
+			filePaths = matches
+		}
+		for _, name := range names {
+			partitionPath := filepath.Join(filePath, influence.PartitionFileName(name))
+			if _, statErr := os.Stat(partitionPath); statErr == nil {
+				filePaths = append(filePaths, partitionPath)
+			}
+		}
+	}
+	return filePaths, nil
+}
+
+// ExportCrewEvents reads events of the crew from an events dump, or from a directory partitioned
+// by event type. Only events with the given names are exported, or all events if names is empty.
+func ExportCrewEvents(filePath string, crewId uint64, names []string) ([]ExportedEvent, error) {
+	filePaths, pathsErr := EventFilePaths(filePath, names)
+	if pathsErr != nil {
+		return nil, pathsErr
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	events := []ExportedEvent{}
+	for _, path := range filePaths {
+		inputFile, openErr := os.Open(path)
+		if openErr != nil {
+			return nil, fmt.Errorf("Unable to read file %s, err: %v", path, openErr)
+		}
+
+		lineNumber := 0
+		scanner := bufio.NewScanner(inputFile)
+		for scanner.Scan() {
+			lineNumber++
+
+			var line leaderboards.PartialEventLine
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+			if len(wanted) > 0 && !wanted[line.Name] {
+				continue
+			}
+			if !EventInvolvesCrew(line.Event, crewId) {
+				continue
+			}
+
+			events = append(events, ExportedEvent{
+				LineNumber:      lineNumber,
+				Name:            line.Name,
+				TransactionHash: line.TransactionHash,
+				Event:           line.Event,
+			})
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return nil, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	return events, nil
+}
+
+func WriteExportedEventsJSONL(w io.Writer, events []ExportedEvent) error {
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if encodeErr := encoder.Encode(event); encodeErr != nil {
+			return encodeErr
+		}
+	}
+	return nil
+}
+
+func WriteExportedEventsCSV(w io.Writer, events []ExportedEvent) error {
+	writer := csv.NewWriter(w)
+	if writeErr := writer.Write([]string{"line_number", "name", "transaction_hash", "event"}); writeErr != nil {
+		return writeErr
+	}
+	for _, event := range events {
+		record := []string{strconv.Itoa(event.LineNumber), event.Name, event.TransactionHash, string(event.Event)}
+		if writeErr := writer.Write(record); writeErr != nil {
+			return writeErr
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ParseEventNames splits a comma-separated list of event names and checks that they are known.
+func ParseEventNames(value string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !influence.REGISTERED_EVENT_NAMES[name] {
+			return nil, fmt.Errorf("unknown event %s", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
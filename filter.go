@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/moonstream-to/influence-eth/pkg/leaderboards"
+)
+
+// EventFilter selects events by name and projects them to a subset of their fields, to produce
+// slimmed-down dumps for the parse command.
+type EventFilter struct {
+	Only    map[string]bool
+	Exclude map[string]bool
+	// Field paths to keep, nested fields are separated with dots (e.g. CallerCrew.Id)
+	Fields [][]string
+}
+
+func NewEventFilter(onlyEvents, excludeEvents, fields []string) *EventFilter {
+	filter := &EventFilter{}
+	if len(onlyEvents) > 0 {
+		filter.Only = make(map[string]bool, len(onlyEvents))
+		for _, name := range onlyEvents {
+			filter.Only[name] = true
+		}
+	}
+	if len(excludeEvents) > 0 {
+		filter.Exclude = make(map[string]bool, len(excludeEvents))
+		for _, name := range excludeEvents {
+			filter.Exclude[name] = true
+		}
+	}
+	if len(fields) > 0 {
+		// Block numbers are used to order events and to cache leaderboards, so they are always kept
+		filter.Fields = append(filter.Fields, []string{"BlockNumber"})
+		for _, field := range fields {
+			filter.Fields = append(filter.Fields, strings.Split(field, "."))
+		}
+	}
+	return filter
+}
+
+func (f *EventFilter) Allows(eventName string) bool {
+	if f.Only != nil && !f.Only[eventName] {
+		return false
+	}
+	return !f.Exclude[eventName]
+}
+
+// Project keeps only the selected fields of the event line. Lines are returned unchanged if no
+// fields were selected.
+func (f *EventFilter) Project(line []byte) ([]byte, error) {
+	if len(f.Fields) == 0 {
+		return line, nil
+	}
+
+	var partialEvent leaderboards.PartialEventLine
+	if unmErr := json.Unmarshal(line, &partialEvent); unmErr != nil {
+		return nil, unmErr
+	}
+
+	var event map[string]json.RawMessage
+	if unmErr := json.Unmarshal(partialEvent.Event, &event); unmErr != nil {
+		// Event is not an object, there is nothing to project
+		return line, nil
+	}
+
+	projected := make(map[string]json.RawMessage)
+	for _, path := range f.Fields {
+		projectField(event, projected, path)
+	}
+
+	projectedBytes, marshalErr := json.Marshal(projected)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+	partialEvent.Event = projectedBytes
+
+	return json.Marshal(partialEvent)
+}
+
+func projectField(source, target map[string]json.RawMessage, path []string) {
+	value, ok := source[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		target[path[0]] = value
+		return
+	}
+
+	var nestedSource map[string]json.RawMessage
+	if unmErr := json.Unmarshal(value, &nestedSource); unmErr != nil {
+		return
+	}
+
+	nestedTarget := make(map[string]json.RawMessage)
+	if existing, ok := target[path[0]]; ok {
+		if unmErr := json.Unmarshal(existing, &nestedTarget); unmErr != nil {
+			return
+		}
+	}
+	projectField(nestedSource, nestedTarget, path[1:])
+
+	nestedBytes, marshalErr := json.Marshal(nestedTarget)
+	if marshalErr != nil {
+		return
+	}
+	target[path[0]] = nestedBytes
+}
@@ -1,15 +1,25 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+
+	"github.com/moonstream-to/influence-eth/pkg/crawler"
 )
 
+// Exit code of crawls which fell behind the chain head by more than --max-lag blocks, distinct from
+// other failures so orchestration can react to provider slowness.
+const LAG_EXIT_CODE = 3
+
 func main() {
 	command := CreateRootCommand()
 	err := command.Execute()
 	if err != nil {
 		fmt.Println(err.Error())
+		if errors.Is(err, crawler.ErrMaxLagExceeded) {
+			os.Exit(LAG_EXIT_CODE)
+		}
 		os.Exit(1)
 	}
 }
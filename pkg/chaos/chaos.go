@@ -0,0 +1,108 @@
+// Package chaos injects failures into the pipeline at configurable rates, so that retries, dead
+// letters and the reporting of partial failures can be seen to work before they are relied on in
+// production. Rates are set with hidden flags of the root command and are 0 in normal runs.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shares of RPC requests which fail, of event lines which are cut short before they are decoded and
+// of Moonstream.to API requests which are answered with a 500 instead of being sent.
+var (
+	RPC_FAILURE_RATE    float64
+	MALFORMED_LINE_RATE float64
+	API_ERROR_RATE      float64
+)
+
+// ErrInjected is returned by RPC requests which were failed on purpose.
+var ErrInjected = errors.New("failure injected with --chaos flags")
+
+var (
+	randomMutex sync.Mutex
+	random      = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Configure sets the failure rates, each between 0 and 1. Runs with the same non-zero seed inject
+// failures into the same requests and lines.
+func Configure(rpcFailureRate, malformedLineRate, apiErrorRate float64, seed int64) error {
+	for name, rate := range map[string]float64{"--chaos-rpc-failure-rate": rpcFailureRate, "--chaos-malformed-line-rate": malformedLineRate, "--chaos-api-error-rate": apiErrorRate} {
+		if !(rate >= 0 && rate <= 1) {
+			return fmt.Errorf("%s should be between 0 and 1, got %v", name, rate)
+		}
+	}
+	RPC_FAILURE_RATE = rpcFailureRate
+	MALFORMED_LINE_RATE = malformedLineRate
+	API_ERROR_RATE = apiErrorRate
+
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+	if seed != 0 {
+		random = rand.New(rand.NewSource(seed))
+	}
+	return nil
+}
+
+// Inject reports whether a failure should be injected at the given rate.
+func Inject(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+	return random.Float64() < rate
+}
+
+// MalformLine returns the line cut in half at MALFORMED_LINE_RATE, and the line itself otherwise.
+func MalformLine(line []byte) []byte {
+	if !Inject(MALFORMED_LINE_RATE) {
+		return line
+	}
+	return line[:len(line)/2]
+}
+
+// Transport answers requests with a 500 at API_ERROR_RATE and sends the others with Base, or
+// http.DefaultTransport if it is nil.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if Inject(API_ERROR_RATE) {
+		if request.Body != nil {
+			request.Body.Close()
+		}
+		return &http.Response{
+			Status:     "500 Internal Server Error",
+			StatusCode: http.StatusInternalServerError,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"detail":"failure injected with --chaos flags"}`)),
+			Request:    request,
+		}, nil
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(request)
+}
+
+// APITransport returns the transport of Moonstream.to API clients: nil, for the default transport,
+// unless API errors are injected.
+func APITransport() http.RoundTripper {
+	if API_ERROR_RATE <= 0 {
+		return nil
+	}
+	return &Transport{}
+}
@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrNoProvider is returned when resolving block aliases which need the chain head or block
+// timestamps without a Starknet RPC provider.
+var ErrNoProvider = errors.New("resolving this block alias needs a Starknet RPC provider (-p/--provider or STARKNET_RPC_URL)")
+
+var latestAliasPattern = regexp.MustCompile(`^latest(?:-(\d+)(w|d|h|m|s)?)?$`)
+var blockNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Units of the durations of latest-<duration> aliases.
+var ALIAS_DURATION_UNITS = map[string]time.Duration{
+	"w": 7 * 24 * time.Hour,
+	"d": 24 * time.Hour,
+	"h": time.Hour,
+	"m": time.Minute,
+	"s": time.Second,
+}
+
+// BlockResolver resolves block numbers given as aliases on the command line:
+//   - a plain block number
+//   - "latest", the chain head, or "latest-<N>" N blocks before it
+//   - "latest-<N><unit>" the first block at most N weeks (w), days (d), hours (h), minutes (m) or
+//     seconds (s) old, e.g. latest-7d
+//   - a date (YYYY-MM-DD, UTC) or RFC3339 time, the first block at or after it
+//   - a name of Named, e.g. round2-start from the rounds registry
+type BlockResolver struct {
+	// Client of the Starknet RPC provider, needed for aliases relative to the chain head or by time
+	Client *ethrpc.Client
+	// Block numbers of named points, e.g. the start and end of rounds
+	Named map[string]uint64
+	Now   func() time.Time
+
+	fetcher *BlockMetadataFetcher
+}
+
+func NewBlockResolver(client *ethrpc.Client, named map[string]uint64) *BlockResolver {
+	return &BlockResolver{Client: client, Named: named, Now: time.Now}
+}
+
+// ValidateBlockAlias checks the syntax of an alias without resolving it. Names are only known
+// once resolved.
+func ValidateBlockAlias(alias string) error {
+	if alias == "" || latestAliasPattern.MatchString(alias) || blockNamePattern.MatchString(alias) {
+		return nil
+	}
+	if _, timeErr := parseAliasTime(alias); timeErr == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid block %s, expected a block number, latest, latest-<N>, latest-<N>d, a date or a round alias such as round2-start", alias)
+}
+
+func parseAliasTime(alias string) (time.Time, error) {
+	if t, parseErr := time.Parse("2006-01-02", alias); parseErr == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, alias)
+}
+
+// Resolve returns the block number of an alias. The empty alias resolves to 0.
+func (r *BlockResolver) Resolve(ctx context.Context, alias string) (uint64, error) {
+	if alias == "" {
+		return 0, nil
+	}
+	if blockNumber, parseErr := strconv.ParseUint(alias, 10, 64); parseErr == nil {
+		return blockNumber, nil
+	}
+	if blockNumber, ok := r.Named[alias]; ok {
+		return blockNumber, nil
+	}
+
+	if match := latestAliasPattern.FindStringSubmatch(alias); match != nil {
+		head, headErr := r.head(ctx)
+		if headErr != nil {
+			return 0, headErr
+		}
+		if match[1] == "" {
+			return head, nil
+		}
+		count, countErr := strconv.ParseUint(match[1], 10, 64)
+		if countErr != nil {
+			return 0, countErr
+		}
+		if match[2] == "" {
+			if count > head {
+				return 0, nil
+			}
+			return head - count, nil
+		}
+		return r.BlockAtTime(ctx, r.Now().Add(-time.Duration(count)*ALIAS_DURATION_UNITS[match[2]]), head)
+	}
+
+	if t, timeErr := parseAliasTime(alias); timeErr == nil {
+		head, headErr := r.head(ctx)
+		if headErr != nil {
+			return 0, headErr
+		}
+		return r.BlockAtTime(ctx, t, head)
+	}
+
+	return 0, fmt.Errorf("unknown block alias %s, expected a block number, latest, latest-<N>, latest-<N>d, a date or a round alias such as round2-start", alias)
+}
+
+func (r *BlockResolver) head(ctx context.Context) (uint64, error) {
+	if r.Client == nil {
+		return 0, ErrNoProvider
+	}
+	return rpc.NewProvider(r.Client).BlockNumber(ctx)
+}
+
+// BlockAtTime returns the first block up to head whose timestamp is at or after t, found by a
+// binary search over block timestamps, or head if all blocks are older.
+func (r *BlockResolver) BlockAtTime(ctx context.Context, t time.Time, head uint64) (uint64, error) {
+	if r.Client == nil {
+		return 0, ErrNoProvider
+	}
+	if r.fetcher == nil {
+		r.fetcher = NewBlockMetadataFetcher(r.Client, 1)
+	}
+	target := uint64(t.Unix())
+
+	low, high := uint64(0), head
+	for low < high {
+		middle := low + (high-low)/2
+		block, fetchErr := r.fetcher.fetchOne(ctx, middle)
+		if fetchErr != nil {
+			return 0, fetchErr
+		}
+		if block.Timestamp < target {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+	return low, nil
+}
@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockMetadata is the part of a block header events are enriched with.
+type BlockMetadata struct {
+	BlockNumber uint64 `json:"block_number"`
+	BlockHash   string `json:"block_hash"`
+	Timestamp   uint64 `json:"timestamp"`
+}
+
+// Default number of blocks requested in a single JSON-RPC batch.
+var BLOCK_METADATA_BATCH_SIZE = 100
+
+// How long crawled events wait for events of further blocks before the metadata of their blocks is
+// fetched, so that quiet crawls are not held back until a batch fills up.
+var BLOCK_METADATA_FLUSH_INTERVAL = time.Second
+
+// BlockMetadataFetcher fetches block headers with JSON-RPC batch requests, many blocks per HTTP
+// round-trip. Providers which reject batches are detected on the first batch, after which blocks
+// are requested one by one. Fetched blocks are cached until a later fetch starts past them, since
+// crawls move forward.
+type BlockMetadataFetcher struct {
+	BatchSize int
+	// Set once the provider rejected a batch request
+	Sequential bool
+
+	client *ethrpc.Client
+	cache  map[uint64]BlockMetadata
+}
+
+func NewBlockMetadataFetcher(client *ethrpc.Client, batchSize int) *BlockMetadataFetcher {
+	if batchSize <= 0 {
+		batchSize = BLOCK_METADATA_BATCH_SIZE
+	}
+	return &BlockMetadataFetcher{BatchSize: batchSize, client: client, cache: make(map[uint64]BlockMetadata)}
+}
+
+func (f *BlockMetadataFetcher) fetchOne(ctx context.Context, blockNumber uint64) (BlockMetadata, error) {
+	var block BlockMetadata
+	if callErr := f.client.CallContext(ctx, &block, "starknet_getBlockWithTxHashes", rpc.WithBlockNumber(blockNumber)); callErr != nil {
+		return block, fmt.Errorf("Unable to fetch block %d, err: %v", blockNumber, callErr)
+	}
+	return block, nil
+}
+
+func (f *BlockMetadataFetcher) fetchBatch(ctx context.Context, blockNumbers []uint64) error {
+	results := make([]BlockMetadata, len(blockNumbers))
+	elements := make([]ethrpc.BatchElem, len(blockNumbers))
+	for i, blockNumber := range blockNumbers {
+		elements[i] = ethrpc.BatchElem{
+			Method: "starknet_getBlockWithTxHashes",
+			Args:   []interface{}{rpc.WithBlockNumber(blockNumber)},
+			Result: &results[i],
+		}
+	}
+
+	if batchErr := f.client.BatchCallContext(ctx, elements); batchErr != nil {
+		if ctx.Err() != nil {
+			return batchErr
+		}
+		log.Printf("Provider rejected batch request, falling back to sequential block requests, err: %v", batchErr)
+		f.Sequential = true
+		return f.fetchSequential(ctx, blockNumbers)
+	}
+
+	for i, element := range elements {
+		if element.Error != nil {
+			// Some providers answer oversized batches with per request errors, those blocks are
+			// retried on their own
+			block, fetchErr := f.fetchOne(ctx, blockNumbers[i])
+			if fetchErr != nil {
+				return fetchErr
+			}
+			results[i] = block
+		}
+		f.cache[blockNumbers[i]] = results[i]
+	}
+	return nil
+}
+
+func (f *BlockMetadataFetcher) fetchSequential(ctx context.Context, blockNumbers []uint64) error {
+	for _, blockNumber := range blockNumbers {
+		block, fetchErr := f.fetchOne(ctx, blockNumber)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		f.cache[blockNumber] = block
+	}
+	return nil
+}
+
+// Fetch returns the metadata of the blocks, requesting blocks which are not cached yet in batches
+// of BatchSize.
+func (f *BlockMetadataFetcher) Fetch(ctx context.Context, blockNumbers []uint64) (map[uint64]BlockMetadata, error) {
+	if len(blockNumbers) == 0 {
+		return map[uint64]BlockMetadata{}, nil
+	}
+
+	lowestBlock := blockNumbers[0]
+	for _, blockNumber := range blockNumbers {
+		if blockNumber < lowestBlock {
+			lowestBlock = blockNumber
+		}
+	}
+	for blockNumber := range f.cache {
+		if blockNumber < lowestBlock {
+			delete(f.cache, blockNumber)
+		}
+	}
+
+	missing := []uint64{}
+	requested := make(map[uint64]bool)
+	for _, blockNumber := range blockNumbers {
+		if _, ok := f.cache[blockNumber]; ok || requested[blockNumber] {
+			continue
+		}
+		requested[blockNumber] = true
+		missing = append(missing, blockNumber)
+	}
+
+	for start := 0; start < len(missing); start += f.BatchSize {
+		end := start + f.BatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		var fetchErr error
+		if f.Sequential || end-start == 1 {
+			fetchErr = f.fetchSequential(ctx, missing[start:end])
+		} else {
+			fetchErr = f.fetchBatch(ctx, missing[start:end])
+		}
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+	}
+
+	result := make(map[uint64]BlockMetadata, len(blockNumbers))
+	for _, blockNumber := range blockNumbers {
+		result[blockNumber] = f.cache[blockNumber]
+	}
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package crawler
+
+import (
+	"context"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/moonstream-to/influence-eth/pkg/chaos"
+)
+
+// ChaosProvider fails requests of the wrapped provider at chaos.RPC_FAILURE_RATE with
+// chaos.ErrInjected, without sending them.
+type ChaosProvider struct {
+	Provider EventsProvider
+}
+
+func (p ChaosProvider) BlockNumber(ctx context.Context) (uint64, error) {
+	if chaos.Inject(chaos.RPC_FAILURE_RATE) {
+		return 0, chaos.ErrInjected
+	}
+	return p.Provider.BlockNumber(ctx)
+}
+
+func (p ChaosProvider) Events(ctx context.Context, input rpc.EventsInput) (*rpc.EventChunk, error) {
+	if chaos.Inject(chaos.RPC_FAILURE_RATE) {
+		return nil, chaos.ErrInjected
+	}
+	return p.Provider.Events(ctx, input)
+}
+
+// WithChaos wraps the provider into a ChaosProvider if RPC failures are injected.
+func WithChaos(provider EventsProvider) EventsProvider {
+	if chaos.RPC_FAILURE_RATE <= 0 {
+		return provider
+	}
+	return ChaosProvider{Provider: provider}
+}
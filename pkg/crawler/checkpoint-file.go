@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CheckpointFile keeps the cursors of the crawls of the events command, by contract address, so a
+// crawl which was killed resumes where its output ends. Cursors are updated as pages are crawled
+// and written to disk by Save, after the events before them were written to the output.
+type CheckpointFile struct {
+	Path string `json:"-"`
+
+	// Batch size of the crawl, continuation tokens are only resumed with the same page size
+	BatchSize int                    `json:"batch_size"`
+	Cursors   map[string]CrawlCursor `json:"cursors"`
+	UpdatedAt string                 `json:"updated_at,omitempty"`
+
+	mutex sync.Mutex
+	dirty bool
+}
+
+// LoadCheckpointFile reads the checkpoint file at the path, or returns an empty one if it does not
+// exist yet.
+func LoadCheckpointFile(path string, batchSize int) (*CheckpointFile, error) {
+	checkpoint := &CheckpointFile{Path: path, BatchSize: batchSize, Cursors: make(map[string]CrawlCursor)}
+	contents, readErr := os.ReadFile(path)
+	if errors.Is(readErr, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", path, readErr)
+	}
+
+	var saved CheckpointFile
+	if unmErr := json.Unmarshal(contents, &saved); unmErr != nil {
+		return nil, fmt.Errorf("Unable to parse checkpoint file %s, err: %v", path, unmErr)
+	}
+	for address, cursor := range saved.Cursors {
+		// Tokens are offsets into pages of the saved size, with another size the range is crawled
+		// again from its first block
+		if saved.BatchSize != batchSize {
+			cursor.ContinuationToken = ""
+		}
+		checkpoint.Cursors[address] = cursor
+	}
+	return checkpoint, nil
+}
+
+// Cursor returns the saved cursor of the crawl of the contract.
+func (c *CheckpointFile) Cursor(address string) (CrawlCursor, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cursor, ok := c.Cursors[address]
+	return cursor, ok
+}
+
+// Update records the cursor of the crawl of the contract, to be written by the next Save.
+func (c *CheckpointFile) Update(address string, cursor CrawlCursor) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.Cursors[address] = cursor
+	c.dirty = true
+}
+
+// Save writes the cursors to disk if they changed since the last save. The file is replaced
+// atomically, so a crawl killed while saving keeps the previous checkpoint.
+func (c *CheckpointFile) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	c.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	contents, marshalErr := json.MarshalIndent(c, "", "  ")
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	temporaryFile, createErr := os.CreateTemp(filepath.Dir(c.Path), filepath.Base(c.Path)+".*.tmp")
+	if createErr != nil {
+		return fmt.Errorf("Unable to write checkpoint file %s, err: %v", c.Path, createErr)
+	}
+	defer os.Remove(temporaryFile.Name())
+	if _, writeErr := temporaryFile.Write(contents); writeErr != nil {
+		temporaryFile.Close()
+		return fmt.Errorf("Unable to write checkpoint file %s, err: %v", c.Path, writeErr)
+	}
+	if syncErr := temporaryFile.Sync(); syncErr != nil {
+		temporaryFile.Close()
+		return fmt.Errorf("Unable to write checkpoint file %s, err: %v", c.Path, syncErr)
+	}
+	if closeErr := temporaryFile.Close(); closeErr != nil {
+		return fmt.Errorf("Unable to write checkpoint file %s, err: %v", c.Path, closeErr)
+	}
+	if renameErr := os.Rename(temporaryFile.Name(), c.Path); renameErr != nil {
+		return fmt.Errorf("Unable to write checkpoint file %s, err: %v", c.Path, renameErr)
+	}
+
+	c.dirty = false
+	return nil
+}
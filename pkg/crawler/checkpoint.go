@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Checkpoint is the progress of a crawl of a contract, published after each crawl iteration so
+// operators can check crawler freshness.
+type Checkpoint struct {
+	Contract    string `json:"contract"`
+	LatestBlock uint64 `json:"latest_block"`
+	ChainHead   uint64 `json:"chain_head"`
+	Events      uint64 `json:"events"`
+	Time        string `json:"time"`
+}
+
+// CheckpointEntry is a checkpoint in the format of Moonstream journal entries.
+type CheckpointEntry struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+func NewCheckpointEntry(checkpoint Checkpoint) (CheckpointEntry, error) {
+	content, marshErr := json.Marshal(checkpoint)
+	if marshErr != nil {
+		return CheckpointEntry{}, marshErr
+	}
+	return CheckpointEntry{
+		Title:   fmt.Sprintf("influence-eth crawl checkpoint: %s", checkpoint.Contract),
+		Content: string(content),
+		Tags: []string{
+			"type:crawl-checkpoint",
+			fmt.Sprintf("contract:%s", checkpoint.Contract),
+			fmt.Sprintf("latest_block:%d", checkpoint.LatestBlock),
+		},
+	}, nil
+}
+
+// PublishCheckpoint POSTs the checkpoint as a journal entry to the endpoint.
+func PublishCheckpoint(endpoint, accessToken string, checkpoint Checkpoint) error {
+	if checkpoint.Time == "" {
+		checkpoint.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+	entry, entryErr := NewCheckpointEntry(checkpoint)
+	if entryErr != nil {
+		return entryErr
+	}
+	body, marshErr := json.Marshal(entry)
+	if marshErr != nil {
+		return marshErr
+	}
+
+	request, requestErr := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if requestErr != nil {
+		return fmt.Errorf("error making requests: %v", requestErr)
+	}
+	if accessToken != "" {
+		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	}
+	request.Header.Add("Content-Type", "application/json")
+
+	httpClient := http.Client{Timeout: 10 * time.Second}
+	response, responseErr := httpClient.Do(request)
+	if responseErr != nil {
+		return fmt.Errorf("error parsing response: %v", responseErr)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unable to publish checkpoint, status code: %d", response.StatusCode)
+	}
+	return nil
+}
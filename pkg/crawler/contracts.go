@@ -0,0 +1,154 @@
+// Package crawler crawls events of Influence.eth contracts from a Starknet RPC provider.
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Addresses of Influence contracts by network and contract name. Contracts which were redeployed
+// at a new address list all of their addresses, oldest first, so that crawls cover every version.
+// Contracts upgraded in place keep a single address.
+var INFLUENCE_CONTRACTS = map[string]map[string][]string{
+	"mainnet": {
+		"dispatcher": {"0x0422d33a3638dcc4c62e72e1d6942cd31eb643ef596ccac2351e0e21f6cd4bf4"},
+	},
+	"sepolia": {
+		"dispatcher": {"0x0517567ac7026ce129c950e6e113e437aa3c83716cd61481c6bb8c5057e6923e"},
+		"asteroid":   {"0x0680710b95255a852ed9ead04d4c1ffcf4f0695e29fb5c327abe2b8cb305ba25"},
+		"crew":       {"0x0560387d35b9b8df47a1973b7208e52b2df4f6dda579c7902678f9c1f2625215"},
+		"crewmate":   {"0x026b26dc1cd021d7a1e78615cdf9f8f7d19ddbec73a4187e37af1d57f9bcfdc6"},
+		"ship":       {"0x061645ea472d543200c28291c92d54066b1088de67069c1ff0ad2c4c05ef2ed8"},
+		"sway":       {"0x0030058f19ed447208015f6430f0102e8ab82d6c291566d7e73fe8e613c3d2ed"},
+	},
+	"goerli": {
+		"dispatcher": {"0x020cd0c1f8cc0ca293d17b8184a6d51605ef4175827432ed24818ce24891bcdf"},
+		"asteroid":   {"0x056df02ae800a0a6b6e4ad65fa6c0b3d55c97b80f63c451a47844a6ca87015b7"},
+		"crew":       {"0x67f42045568d7a0e7cf15d32b6fde313f6908c830a3a55bd5bb26965e1caa4"},
+		"crewmate":   {"0x0314553b9c33ac655538d7d207543eb2e3bebde2e7e6724cb8b1ad485f3fa622"},
+		"ship":       {"0x04dc116bd1b8c9bc3e25d2f03e03dfd60dd42e6de2c8483bf100f259dc80e282"},
+		"sway":       {"0x04dc116bd1b8c9bc3e25d2f03e03dfd60dd42e6de2c8483bf100f259dc80e282"},
+	},
+}
+
+// ContractAddresses returns all known addresses of the named contract on the network.
+func ContractAddresses(network, contractName string) ([]string, error) {
+	contracts, ok := INFLUENCE_CONTRACTS[network]
+	if !ok {
+		var networks []string
+		for n := range INFLUENCE_CONTRACTS {
+			networks = append(networks, n)
+		}
+		sort.Strings(networks)
+		return nil, fmt.Errorf("unknown network %s, supported networks: %s", network, strings.Join(networks, ", "))
+	}
+
+	addresses, ok := contracts[contractName]
+	if !ok {
+		var names []string
+		for n := range contracts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown contract %s on %s, known contracts: %s", contractName, network, strings.Join(names, ", "))
+	}
+	return addresses, nil
+}
+
+// ContractLabels returns the label of each address events of the contract are tagged with: the
+// name of the Influence contract at the address on the network, or the address itself. Addresses
+// are returned without duplicates, in the order they were given.
+func ContractLabels(network string, addresses []string) ([]string, map[felt.Felt]string, error) {
+	names := make(map[felt.Felt]string)
+	for name, contractAddresses := range INFLUENCE_CONTRACTS[network] {
+		for _, address := range contractAddresses {
+			addressFelt, parseErr := influence.FeltFromHexString(address)
+			if parseErr != nil {
+				return nil, nil, parseErr
+			}
+			names[*addressFelt] = name
+		}
+	}
+
+	unique := []string{}
+	labels := make(map[felt.Felt]string, len(addresses))
+	for _, address := range addresses {
+		addressFelt, parseErr := influence.FeltFromHexString(address)
+		if parseErr != nil {
+			return nil, nil, fmt.Errorf("invalid contract address %s: %v", address, parseErr)
+		}
+		if _, ok := labels[*addressFelt]; ok {
+			continue
+		}
+		unique = append(unique, address)
+		labels[*addressFelt] = address
+		if name, ok := names[*addressFelt]; ok {
+			labels[*addressFelt] = name
+		}
+	}
+	return unique, labels, nil
+}
+
+// TokenContracts returns the names of the token contracts (see influence.TRANSFER_EVENTS) of all
+// networks by address, to name their Transfer events with influence.NewTransferResolver. Addresses
+// shared by several token contracts are left out, their Transfer events can not be told apart.
+func TokenContracts() map[string]string {
+	tokenContracts := make(map[string]string)
+	shared := make(map[string]bool)
+	for _, contracts := range INFLUENCE_CONTRACTS {
+		for name, addresses := range contracts {
+			if _, ok := influence.TRANSFER_EVENTS[name]; !ok {
+				continue
+			}
+			for _, address := range addresses {
+				address = strings.ToLower(address)
+				if other, ok := tokenContracts[address]; ok && other != name {
+					shared[address] = true
+				}
+				tokenContracts[address] = name
+			}
+		}
+	}
+	for address := range shared {
+		delete(tokenContracts, address)
+	}
+	return tokenContracts
+}
+
+// ContractVersionsEvents crawls events of all versions of a contract into outChan, starting each
+// version at its own cursor from cursors. Events of different versions are interleaved. onPage, if
+// set, is called with the address and cursor of a version once the events of a page were received.
+// It closes outChan once all crawls are finished and returns the first crawl error.
+func ContractVersionsEvents(ctx context.Context, provider EventsProvider, addresses []string, cursors []CrawlCursor, outChan chan<- influence.RawEvent, hotThreshold int, hotInterval, coldInterval time.Duration, toBlock uint64, confirmations, batchSize int, onPage func(address string, cursor CrawlCursor)) error {
+	defer func() { close(outChan) }()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(addresses))
+	for i, address := range addresses {
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			var versionOnPage func(CrawlCursor)
+			if onPage != nil {
+				versionOnPage = func(cursor CrawlCursor) { onPage(address, cursor) }
+			}
+			errs[i] = CursorEvents(ctx, provider, address, outChan, hotThreshold, hotInterval, coldInterval, cursors[i], toBlock, confirmations, batchSize, versionOnPage)
+		}(i, address)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Key filter of the starknet_getEvents requests of CursorEvents, by position in the event keys.
+// Keys at positions which are empty or missing match any value. All events are requested if it is
+// nil.
+var EVENT_KEYS_FILTER [][]*felt.Felt
+
+// CrawlCursor is the position of a crawl of a contract: the block range of the current request
+// and the continuation token of its next page. Interval and Heat are the polling state, they are
+// not checkpointed.
+type CrawlCursor struct {
+	FromBlock         uint64        `json:"from_block"`
+	ToBlock           uint64        `json:"to_block"`
+	ContinuationToken string        `json:"continuation_token,omitempty"`
+	Interval          time.Duration `json:"-"`
+	Heat              int           `json:"-"`
+}
+
+// CursorEvents crawls events of a contract into outChan from the cursor, like
+// influence.ContractEvents, and calls onPage with the cursor of the next request once all events
+// of a page were received from outChan. It does not close outChan, so crawls of several contracts
+// can share it and a consumer which checkpoints the latest cursors has read every event before
+// them.
+func CursorEvents(ctx context.Context, provider EventsProvider, contractAddress string, outChan chan<- influence.RawEvent, hotThreshold int, hotInterval, coldInterval time.Duration, cursor CrawlCursor, toBlock uint64, confirmations, batchSize int, onPage func(CrawlCursor)) error {
+	cursor.Interval = hotInterval
+	cursor.Heat = 0
+
+	// Events are keyed by their position in their transaction, counted over the pages of the
+	// current range, so events delivered again by an overlapping range get the same keys
+	dedupe := NewDedupeWindow(DEDUPE_WINDOW_SIZE)
+	transactionEvents := make(map[felt.Felt]int)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cursor.Interval):
+			if cursor.ToBlock == 0 {
+				currentBlock, blockErr := provider.BlockNumber(ctx)
+				if blockErr != nil {
+					return blockErr
+				}
+				if currentBlock > uint64(confirmations) {
+					cursor.ToBlock = currentBlock - uint64(confirmations)
+				}
+			}
+
+			if cursor.ToBlock <= cursor.FromBlock {
+				cursor.Interval = coldInterval
+				if toBlock != 0 {
+					return nil
+				}
+				// Waits for the chain to move on, the head is read again on the next iteration
+				cursor.ToBlock = 0
+				break
+			}
+
+			filter, filterErr := influence.AllEventsFilter(cursor.FromBlock, cursor.ToBlock, contractAddress)
+			if filterErr != nil {
+				return filterErr
+			}
+			if EVENT_KEYS_FILTER != nil {
+				filter.Keys = EVENT_KEYS_FILTER
+			}
+
+			eventsInput := rpc.EventsInput{
+				EventFilter:       *filter,
+				ResultPageRequest: rpc.ResultPageRequest{ChunkSize: batchSize, ContinuationToken: cursor.ContinuationToken},
+			}
+
+			eventsChunk, getEventsErr := provider.Events(ctx, eventsInput)
+			if getEventsErr != nil {
+				return getEventsErr
+			}
+
+			for _, event := range eventsChunk.Events {
+				key := EventKey{TransactionHash: *event.TransactionHash, Index: transactionEvents[*event.TransactionHash]}
+				transactionEvents[*event.TransactionHash]++
+				if dedupe.Seen(key) {
+					continue
+				}
+
+				crawledEvent := influence.RawEvent{
+					BlockNumber:     event.BlockNumber,
+					BlockHash:       event.BlockHash,
+					TransactionHash: event.TransactionHash,
+					FromAddress:     event.FromAddress,
+					PrimaryKey:      event.Keys[0],
+					Keys:            event.Keys,
+					Parameters:      event.Data,
+				}
+				select {
+				case outChan <- crawledEvent:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+
+			if eventsChunk.ContinuationToken != "" {
+				cursor.ContinuationToken = eventsChunk.ContinuationToken
+				cursor.Interval = hotInterval
+			} else {
+				cursor.FromBlock = cursor.ToBlock + 1
+				cursor.ToBlock = toBlock
+				cursor.ContinuationToken = ""
+				transactionEvents = make(map[felt.Felt]int)
+				if len(eventsChunk.Events) > 0 {
+					cursor.Heat++
+					if cursor.Heat >= hotThreshold {
+						cursor.Interval = hotInterval
+					}
+				} else {
+					cursor.Heat = 0
+					cursor.Interval = coldInterval
+				}
+			}
+
+			if onPage != nil {
+				onPage(cursor)
+			}
+		}
+	}
+}
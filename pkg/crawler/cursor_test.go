@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+const (
+	testHotInterval  = time.Millisecond
+	testColdInterval = 2 * time.Millisecond
+)
+
+// mockEvent is an event of its own transaction in the block.
+func mockEvent(blockNumber, transaction uint64) rpc.EmittedEvent {
+	return rpc.EmittedEvent{
+		Event:           rpc.Event{Keys: []*felt.Felt{new(felt.Felt).SetUint64(1)}},
+		BlockNumber:     blockNumber,
+		TransactionHash: new(felt.Felt).SetUint64(transaction),
+	}
+}
+
+// crawl runs CursorEvents, cancelling it after stopAfter pages if it is positive, and returns the
+// blocks of the events it sent and the cursors passed to onPage.
+func crawl(t *testing.T, provider *MockProvider, cursor CrawlCursor, toBlock uint64, hotThreshold, confirmations, batchSize, stopAfter int) ([]uint64, []CrawlCursor) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	outChan := make(chan influence.RawEvent, 100)
+	var pages []CrawlCursor
+	onPage := func(c CrawlCursor) {
+		pages = append(pages, c)
+		if stopAfter > 0 && len(pages) >= stopAfter {
+			cancel()
+		}
+	}
+	if crawlErr := CursorEvents(ctx, provider, "", outChan, hotThreshold, testHotInterval, testColdInterval, cursor, toBlock, confirmations, batchSize, onPage); crawlErr != nil {
+		t.Fatalf("crawl failed: %v", crawlErr)
+	}
+	close(outChan)
+
+	var blocks []uint64
+	for event := range outChan {
+		blocks = append(blocks, event.BlockNumber)
+	}
+	return blocks, pages
+}
+
+func TestCursorEventsAdvancesThroughPages(t *testing.T) {
+	provider := &MockProvider{ChainEvents: []rpc.EmittedEvent{
+		mockEvent(10, 1), mockEvent(11, 2), mockEvent(12, 3), mockEvent(13, 4), mockEvent(14, 5),
+	}}
+
+	blocks, pages := crawl(t, provider, CrawlCursor{FromBlock: 10, ToBlock: 14}, 14, 1, 0, 2, 0)
+
+	if len(blocks) != 5 {
+		t.Fatalf("expected 5 events, got %v", blocks)
+	}
+	for i, block := range blocks {
+		if block != uint64(10+i) {
+			t.Fatalf("expected events in block order, got %v", blocks)
+		}
+	}
+
+	expected := []CrawlCursor{
+		{FromBlock: 10, ToBlock: 14, ContinuationToken: "2"},
+		{FromBlock: 10, ToBlock: 14, ContinuationToken: "4"},
+		{FromBlock: 15, ToBlock: 14},
+	}
+	if len(pages) != len(expected) {
+		t.Fatalf("expected %d pages, got %d: %+v", len(expected), len(pages), pages)
+	}
+	for i, page := range pages {
+		if page.FromBlock != expected[i].FromBlock || page.ToBlock != expected[i].ToBlock || page.ContinuationToken != expected[i].ContinuationToken {
+			t.Errorf("page %d: expected cursor %+v, got %+v", i, expected[i], page)
+		}
+	}
+	if len(provider.EventsRequests) != 3 {
+		t.Errorf("expected 3 events requests, got %d", len(provider.EventsRequests))
+	}
+}
+
+func TestCursorEventsWaitsForConfirmations(t *testing.T) {
+	provider := &MockProvider{Heads: []uint64{20}, ChainEvents: []rpc.EmittedEvent{mockEvent(12, 1), mockEvent(18, 2)}}
+
+	blocks, pages := crawl(t, provider, CrawlCursor{FromBlock: 10}, 0, 1, 5, 100, 1)
+
+	if len(provider.EventsRequests) == 0 {
+		t.Fatal("expected an events request")
+	}
+	if requested := *provider.EventsRequests[0].ToBlock.Number; requested != 15 {
+		t.Errorf("expected the crawl to stop 5 blocks below the head at block 15, requested up to %d", requested)
+	}
+	if len(blocks) != 1 || blocks[0] != 12 {
+		t.Errorf("expected only the confirmed event of block 12, got %v", blocks)
+	}
+	if len(pages) != 1 || pages[0].FromBlock != 16 {
+		t.Errorf("expected the cursor to move past the confirmed blocks, got %+v", pages)
+	}
+}
+
+func TestCursorEventsHeadBelowConfirmations(t *testing.T) {
+	provider := &MockProvider{Heads: []uint64{3}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	outChan := make(chan influence.RawEvent, 1)
+	if crawlErr := CursorEvents(ctx, provider, "", outChan, 1, testHotInterval, testColdInterval, CrawlCursor{}, 0, 5, 100, nil); crawlErr != nil {
+		t.Fatalf("crawl failed: %v", crawlErr)
+	}
+	if provider.BlockNumberCalls == 0 {
+		t.Error("expected the chain head to be read")
+	}
+	if len(provider.EventsRequests) != 0 {
+		t.Errorf("expected no events requests while the chain has fewer blocks than confirmations, got %d", len(provider.EventsRequests))
+	}
+}
+
+func TestCursorEventsHotColdSwitching(t *testing.T) {
+	provider := &MockProvider{
+		Heads:       []uint64{14, 14, 20, 30},
+		ChainEvents: []rpc.EmittedEvent{mockEvent(12, 1), mockEvent(25, 2)},
+	}
+
+	_, pages := crawl(t, provider, CrawlCursor{FromBlock: 10}, 0, 1, 0, 100, 3)
+
+	expected := []struct {
+		fromBlock uint64
+		interval  time.Duration
+		heat      int
+	}{
+		// Events in the range heat the crawl up
+		{15, testHotInterval, 1},
+		// A range without events cools it down
+		{21, testColdInterval, 0},
+		{31, testHotInterval, 1},
+	}
+	if len(pages) != len(expected) {
+		t.Fatalf("expected %d pages, got %d: %+v", len(expected), len(pages), pages)
+	}
+	for i, page := range pages {
+		if page.FromBlock != expected[i].fromBlock || page.Interval != expected[i].interval || page.Heat != expected[i].heat {
+			t.Errorf("page %d: expected from block %d, interval %s and heat %d, got %+v", i, expected[i].fromBlock, expected[i].interval, expected[i].heat, page)
+		}
+	}
+}
+
+func TestCursorEventsDropsRepeatedEvents(t *testing.T) {
+	first := mockEvent(10, 1)
+	provider := &MockProvider{
+		Heads: []uint64{12, 14},
+		Pages: map[MockPageKey]*rpc.EventChunk{
+			{FromBlock: 10, ToBlock: 12}: {Events: []rpc.EmittedEvent{first}},
+			// The provider delivers the event again with the next range
+			{FromBlock: 13, ToBlock: 14}: {Events: []rpc.EmittedEvent{first, mockEvent(14, 2)}},
+		},
+	}
+
+	blocks, _ := crawl(t, provider, CrawlCursor{FromBlock: 10}, 0, 1, 0, 100, 2)
+
+	if len(blocks) != 2 || blocks[0] != 10 || blocks[1] != 14 {
+		t.Errorf("expected the repeated event to be dropped, got events of blocks %v", blocks)
+	}
+}
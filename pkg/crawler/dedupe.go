@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"container/list"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Number of recent events CursorEvents remembers to drop duplicates delivered again by
+// overlapping crawl iterations. Deduplication is disabled if it is 0.
+var DEDUPE_WINDOW_SIZE = 10000
+
+// EventKey identifies an event by its transaction and its position among the events of the
+// transaction.
+type EventKey struct {
+	TransactionHash felt.Felt
+	Index           int
+}
+
+// DedupeWindow is an LRU set of the most recent event keys.
+type DedupeWindow struct {
+	Size       int
+	Duplicates int
+
+	order *list.List
+	keys  map[EventKey]*list.Element
+}
+
+func NewDedupeWindow(size int) *DedupeWindow {
+	return &DedupeWindow{Size: size, order: list.New(), keys: make(map[EventKey]*list.Element)}
+}
+
+// Seen reports whether the key is in the window, and records it as the most recent key. The least
+// recent key is evicted once the window holds Size keys.
+func (w *DedupeWindow) Seen(key EventKey) bool {
+	if w.Size <= 0 {
+		return false
+	}
+	if element, ok := w.keys[key]; ok {
+		w.order.MoveToFront(element)
+		w.Duplicates++
+		return true
+	}
+	w.keys[key] = w.order.PushFront(key)
+	if w.order.Len() > w.Size {
+		oldest := w.order.Back()
+		w.order.Remove(oldest)
+		delete(w.keys, oldest.Value.(EventKey))
+	}
+	return false
+}
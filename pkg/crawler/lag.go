@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMaxLagExceeded is returned by LagGuard.Run if the crawl fell further behind the chain head
+// than allowed and the guard is set to stop the crawl.
+var ErrMaxLagExceeded error = errors.New("crawl fell behind the chain head by more than the maximum lag")
+
+// How often LagGuard checks the chain head by default.
+var LAG_CHECK_INTERVAL = time.Minute
+
+// LagAlert is logged and POSTed to the webhook of a LagGuard when the crawl falls behind the chain
+// head, and again once it caught up.
+type LagAlert struct {
+	Contract    string `json:"contract"`
+	LatestBlock uint64 `json:"latest_block"`
+	ChainHead   uint64 `json:"chain_head"`
+	Lag         uint64 `json:"lag"`
+	MaxLag      uint64 `json:"max_lag"`
+	Recovered   bool   `json:"recovered"`
+	Time        string `json:"time"`
+}
+
+// LagGuard watches a continuous crawl and alerts if it falls more than MaxLag blocks behind the
+// chain head, so orchestration can react to slow providers. The lag is counted from the block of
+// the latest crawled event, recorded with Record, to the chain head minus the confirmations the
+// crawler waits for.
+type LagGuard struct {
+	Contract      string
+	MaxLag        uint64
+	Confirmations uint64
+	Interval      time.Duration
+	// URL to POST LagAlert JSON bodies to, alerts are only logged if empty
+	WebhookURL string
+	// Stop the crawl with ErrMaxLagExceeded instead of only alerting
+	Exit bool
+
+	latestBlock atomic.Uint64
+	lagging     bool
+}
+
+func NewLagGuard(contract string, maxLag, confirmations uint64, startBlock uint64) *LagGuard {
+	guard := &LagGuard{Contract: contract, MaxLag: maxLag, Confirmations: confirmations, Interval: LAG_CHECK_INTERVAL}
+	guard.latestBlock.Store(startBlock)
+	return guard
+}
+
+// Record marks the block of a crawled event, events of earlier blocks are ignored.
+func (g *LagGuard) Record(blockNumber uint64) {
+	for {
+		latest := g.latestBlock.Load()
+		if blockNumber <= latest || g.latestBlock.CompareAndSwap(latest, blockNumber) {
+			return
+		}
+	}
+}
+
+func (g *LagGuard) alert(alert LagAlert) {
+	if alert.Recovered {
+		log.Printf("Crawl of %s caught up, %d blocks behind chain head %d", alert.Contract, alert.Lag, alert.ChainHead)
+	} else {
+		log.Printf("ALERT: crawl of %s is %d blocks behind chain head %d (max lag %d), latest crawled block %d", alert.Contract, alert.Lag, alert.ChainHead, alert.MaxLag, alert.LatestBlock)
+	}
+	if g.WebhookURL == "" {
+		return
+	}
+
+	body, marshErr := json.Marshal(alert)
+	if marshErr != nil {
+		log.Printf("Unable to send lag alert, err: %v", marshErr)
+		return
+	}
+	httpClient := http.Client{Timeout: 10 * time.Second}
+	response, responseErr := httpClient.Post(g.WebhookURL, "application/json", bytes.NewReader(body))
+	if responseErr != nil {
+		log.Printf("Unable to send lag alert, err: %v", responseErr)
+		return
+	}
+	response.Body.Close()
+	if response.StatusCode >= 300 {
+		log.Printf("Unable to send lag alert, status code: %d", response.StatusCode)
+	}
+}
+
+// Check compares the latest crawled block against the chain head, alerts when the crawl starts or
+// stops lagging and returns ErrMaxLagExceeded if it lags and Exit is set.
+func (g *LagGuard) Check(ctx context.Context, provider EventsProvider) error {
+	head, headErr := provider.BlockNumber(ctx)
+	if headErr != nil {
+		// Unreachable providers are noticed by the crawl itself
+		log.Printf("Unable to get chain head for lag check, err: %v", headErr)
+		return nil
+	}
+
+	var lag uint64
+	latestBlock := g.latestBlock.Load()
+	if head > g.Confirmations && head-g.Confirmations > latestBlock {
+		lag = head - g.Confirmations - latestBlock
+	}
+
+	alert := LagAlert{
+		Contract:    g.Contract,
+		LatestBlock: latestBlock,
+		ChainHead:   head,
+		Lag:         lag,
+		MaxLag:      g.MaxLag,
+		Time:        time.Now().UTC().Format(time.RFC3339),
+	}
+	if lag > g.MaxLag {
+		if !g.lagging {
+			g.lagging = true
+			g.alert(alert)
+		}
+		if g.Exit {
+			return fmt.Errorf("%w: %d blocks behind chain head %d", ErrMaxLagExceeded, lag, head)
+		}
+	} else if g.lagging {
+		g.lagging = false
+		alert.Recovered = true
+		g.alert(alert)
+	}
+	return nil
+}
+
+// Run checks the lag every Interval until the context is cancelled or the lag is exceeded with
+// Exit set.
+func (g *LagGuard) Run(ctx context.Context, provider EventsProvider) error {
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if checkErr := g.Check(ctx, provider); checkErr != nil {
+				return checkErr
+			}
+		}
+	}
+}
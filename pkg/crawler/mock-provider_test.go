@@ -0,0 +1,148 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// MockPageKey identifies a page of starknet_getEvents by its block range and continuation token.
+type MockPageKey struct {
+	FromBlock         uint64
+	ToBlock           uint64
+	ContinuationToken string
+}
+
+// MockProvider is an EventsProvider serving canned chain heads, events and errors, so
+// the cursor, hot/cold and confirmation logic of CursorEvents can be exercised without a Starknet
+// RPC provider. Requests are recorded for inspection once the crawl returned.
+type MockProvider struct {
+	// Chain heads returned by successive BlockNumber calls, the last one is repeated
+	Heads []uint64
+	// Events of the chain in block order. Events requests are served pages of the events in their
+	// block range which match their address and key filters, with the offset of the next page as
+	// continuation token.
+	ChainEvents []rpc.EmittedEvent
+	// Pages served as they are instead of pages of ChainEvents, e.g. to repeat events or return
+	// tokens a real provider would return
+	Pages map[MockPageKey]*rpc.EventChunk
+	// Errors returned by the call of BlockNumber or Events with the same index, starting at 0
+	BlockNumberErrors map[int]error
+	EventsErrors      map[int]error
+
+	BlockNumberCalls int
+	EventsRequests   []rpc.EventsInput
+
+	mutex sync.Mutex
+}
+
+// NewMockProvider creates a MockProvider serving raw events, e.g. read from a crawled events file
+// or generated with a FixtureGenerator.
+func NewMockProvider(events []influence.RawEvent, heads ...uint64) *MockProvider {
+	chainEvents := make([]rpc.EmittedEvent, len(events))
+	for i, event := range events {
+		chainEvents[i] = rpc.EmittedEvent{
+			Event:           rpc.Event{FromAddress: event.FromAddress, Keys: event.Keys, Data: event.Parameters},
+			BlockHash:       event.BlockHash,
+			BlockNumber:     event.BlockNumber,
+			TransactionHash: event.TransactionHash,
+		}
+	}
+	return &MockProvider{Heads: heads, ChainEvents: chainEvents}
+}
+
+func (m *MockProvider) BlockNumber(ctx context.Context) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	call := m.BlockNumberCalls
+	m.BlockNumberCalls++
+	if err, ok := m.BlockNumberErrors[call]; ok {
+		return 0, err
+	}
+	if len(m.Heads) == 0 {
+		return 0, errors.New("mock provider has no chain head")
+	}
+	if call >= len(m.Heads) {
+		call = len(m.Heads) - 1
+	}
+	return m.Heads[call], nil
+}
+
+func (m *MockProvider) Events(ctx context.Context, input rpc.EventsInput) (*rpc.EventChunk, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	call := len(m.EventsRequests)
+	m.EventsRequests = append(m.EventsRequests, input)
+	if err, ok := m.EventsErrors[call]; ok {
+		return nil, err
+	}
+	if input.FromBlock.Number == nil || input.ToBlock.Number == nil {
+		return nil, errors.New("mock provider only serves events of block number ranges")
+	}
+
+	key := MockPageKey{FromBlock: *input.FromBlock.Number, ToBlock: *input.ToBlock.Number, ContinuationToken: input.ContinuationToken}
+	if page, ok := m.Pages[key]; ok {
+		return page, nil
+	}
+
+	offset := 0
+	if input.ContinuationToken != "" {
+		var tokenErr error
+		offset, tokenErr = strconv.Atoi(input.ContinuationToken)
+		if tokenErr != nil {
+			return nil, fmt.Errorf("invalid continuation token %s", input.ContinuationToken)
+		}
+	}
+
+	matching := []rpc.EmittedEvent{}
+	for _, event := range m.ChainEvents {
+		if event.BlockNumber >= key.FromBlock && event.BlockNumber <= key.ToBlock && mockEventMatches(input.EventFilter, event) {
+			matching = append(matching, event)
+		}
+	}
+	if offset > len(matching) {
+		return nil, fmt.Errorf("invalid continuation token %s", input.ContinuationToken)
+	}
+
+	chunk := &rpc.EventChunk{Events: matching[offset:]}
+	if input.ChunkSize > 0 && len(chunk.Events) > input.ChunkSize {
+		chunk.Events = chunk.Events[:input.ChunkSize]
+		chunk.ContinuationToken = strconv.Itoa(offset + input.ChunkSize)
+	}
+	return chunk, nil
+}
+
+// mockEventMatches applies the address and positional key filters of starknet_getEvents, where
+// empty positions match any key.
+func mockEventMatches(filter rpc.EventFilter, event rpc.EmittedEvent) bool {
+	if filter.Address != nil && (event.FromAddress == nil || filter.Address.Cmp(event.FromAddress) != 0) {
+		return false
+	}
+	for i, keys := range filter.Keys {
+		if len(keys) == 0 {
+			continue
+		}
+		if i >= len(event.Keys) {
+			return false
+		}
+		matches := false
+		for _, key := range keys {
+			if key.Cmp(event.Keys[i]) == 0 {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
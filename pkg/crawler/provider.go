@@ -0,0 +1,41 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"github.com/NethermindEth/starknet.go/rpc"
+)
+
+// EventsProvider is the part of a Starknet RPC provider which CursorEvents crawls with. It is
+// implemented by *rpc.Provider, and by MockProvider for deterministic crawls in tests.
+type EventsProvider interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	Events(ctx context.Context, input rpc.EventsInput) (*rpc.EventChunk, error)
+}
+
+// TimeoutProvider cuts off each request of the wrapped provider after Timeout.
+type TimeoutProvider struct {
+	Provider EventsProvider
+	Timeout  time.Duration
+}
+
+func (p TimeoutProvider) BlockNumber(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	return p.Provider.BlockNumber(ctx)
+}
+
+func (p TimeoutProvider) Events(ctx context.Context, input rpc.EventsInput) (*rpc.EventChunk, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	return p.Provider.Events(ctx, input)
+}
+
+// WithTimeout wraps the provider into a TimeoutProvider if the timeout is positive.
+func WithTimeout(provider EventsProvider, timeout time.Duration) EventsProvider {
+	if timeout <= 0 {
+		return provider
+	}
+	return TimeoutProvider{Provider: provider, Timeout: timeout}
+}
@@ -0,0 +1,149 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// ActualFee is the fee a transaction paid. Providers on JSON-RPC v0.4 return the amount as a bare
+// felt, which was always paid in WEI; later versions return an object with the amount and unit.
+type ActualFee struct {
+	Amount *big.Int
+	Unit   string
+}
+
+func (f *ActualFee) UnmarshalJSON(data []byte) error {
+	var fee struct {
+		Amount string `json:"amount"`
+		Unit   string `json:"unit"`
+	}
+	if unmErr := json.Unmarshal(data, &fee); unmErr != nil {
+		if unmErr := json.Unmarshal(data, &fee.Amount); unmErr != nil {
+			return fmt.Errorf("Unable to parse actual fee %s, err: %v", string(data), unmErr)
+		}
+		fee.Unit = "WEI"
+	}
+
+	amount, ok := new(big.Int).SetString(strings.TrimPrefix(fee.Amount, "0x"), 16)
+	if !ok {
+		return fmt.Errorf("Unable to parse actual fee amount %s", fee.Amount)
+	}
+	f.Amount = amount
+	f.Unit = fee.Unit
+	return nil
+}
+
+// TransactionReceipt is the part of a transaction receipt events are enriched with.
+type TransactionReceipt struct {
+	TransactionHash string    `json:"transaction_hash"`
+	ActualFee       ActualFee `json:"actual_fee"`
+}
+
+// ReceiptFetcher fetches transaction receipts with JSON-RPC batch requests, in the same way as
+// BlockMetadataFetcher fetches blocks.
+type ReceiptFetcher struct {
+	BatchSize int
+	// Set once the provider rejected a batch request
+	Sequential bool
+
+	client *ethrpc.Client
+}
+
+func NewReceiptFetcher(client *ethrpc.Client, batchSize int) *ReceiptFetcher {
+	if batchSize <= 0 {
+		batchSize = BLOCK_METADATA_BATCH_SIZE
+	}
+	return &ReceiptFetcher{BatchSize: batchSize, client: client}
+}
+
+func (f *ReceiptFetcher) fetchOne(ctx context.Context, transactionHash string) (TransactionReceipt, error) {
+	var receipt TransactionReceipt
+	if callErr := f.client.CallContext(ctx, &receipt, "starknet_getTransactionReceipt", transactionHash); callErr != nil {
+		return receipt, fmt.Errorf("Unable to fetch receipt of transaction %s, err: %v", transactionHash, callErr)
+	}
+	return receipt, nil
+}
+
+func (f *ReceiptFetcher) fetchBatch(ctx context.Context, transactionHashes []string, receipts map[string]TransactionReceipt) error {
+	results := make([]TransactionReceipt, len(transactionHashes))
+	elements := make([]ethrpc.BatchElem, len(transactionHashes))
+	for i, transactionHash := range transactionHashes {
+		elements[i] = ethrpc.BatchElem{
+			Method: "starknet_getTransactionReceipt",
+			Args:   []interface{}{transactionHash},
+			Result: &results[i],
+		}
+	}
+
+	if batchErr := f.client.BatchCallContext(ctx, elements); batchErr != nil {
+		if ctx.Err() != nil {
+			return batchErr
+		}
+		log.Printf("Provider rejected batch request, falling back to sequential receipt requests, err: %v", batchErr)
+		f.Sequential = true
+		return f.fetchSequential(ctx, transactionHashes, receipts)
+	}
+
+	for i, element := range elements {
+		if element.Error != nil {
+			receipt, fetchErr := f.fetchOne(ctx, transactionHashes[i])
+			if fetchErr != nil {
+				return fetchErr
+			}
+			results[i] = receipt
+		}
+		receipts[transactionHashes[i]] = results[i]
+	}
+	return nil
+}
+
+func (f *ReceiptFetcher) fetchSequential(ctx context.Context, transactionHashes []string, receipts map[string]TransactionReceipt) error {
+	for _, transactionHash := range transactionHashes {
+		receipt, fetchErr := f.fetchOne(ctx, transactionHash)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		receipts[transactionHash] = receipt
+	}
+	return nil
+}
+
+// Fetch returns the receipts of the transactions, keyed by the hashes as they were passed, in
+// batches of BatchSize.
+func (f *ReceiptFetcher) Fetch(ctx context.Context, transactionHashes []string) (map[string]TransactionReceipt, error) {
+	missing := []string{}
+	receipts := make(map[string]TransactionReceipt, len(transactionHashes))
+	requested := make(map[string]bool)
+	for _, transactionHash := range transactionHashes {
+		if requested[transactionHash] {
+			continue
+		}
+		requested[transactionHash] = true
+		missing = append(missing, transactionHash)
+	}
+
+	for start := 0; start < len(missing); start += f.BatchSize {
+		end := start + f.BatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		var fetchErr error
+		if f.Sequential || end-start == 1 {
+			fetchErr = f.fetchSequential(ctx, missing[start:end], receipts)
+		} else {
+			fetchErr = f.fetchBatch(ctx, missing[start:end], receipts)
+		}
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,227 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Default minimum number of blocks between two crawled events for the blocks between them to be
+// traced, set with the --min-gap flag.
+var TRACE_MIN_GAP uint64 = 1000
+
+// Default maximum number of blocks traced in a single run, set with the --max-blocks flag. Tracing
+// is expensive for providers, so runs over more blocks have to raise it explicitly.
+var TRACE_MAX_BLOCKS uint64 = 1000
+
+type traceEvent struct {
+	Order int          `json:"order"`
+	Keys  []*felt.Felt `json:"keys"`
+	Data  []*felt.Felt `json:"data"`
+}
+
+// functionInvocation is a call in a transaction trace. Reverted executions only carry a revert
+// reason, so they unmarshal to an invocation without events or calls.
+type functionInvocation struct {
+	ContractAddress *felt.Felt           `json:"contract_address"`
+	Events          []traceEvent         `json:"events"`
+	Calls           []functionInvocation `json:"calls"`
+}
+
+type transactionTrace struct {
+	ExecuteInvocation     *functionInvocation `json:"execute_invocation"`
+	ConstructorInvocation *functionInvocation `json:"constructor_invocation"`
+	FunctionInvocation    *functionInvocation `json:"function_invocation"`
+}
+
+type blockTransactionTrace struct {
+	TransactionHash *felt.Felt       `json:"transaction_hash"`
+	TraceRoot       transactionTrace `json:"trace_root"`
+}
+
+type orderedEvent struct {
+	traceEvent
+	fromAddress *felt.Felt
+}
+
+func collectEvents(invocation *functionInvocation, events []orderedEvent) []orderedEvent {
+	if invocation == nil {
+		return events
+	}
+	for _, event := range invocation.Events {
+		events = append(events, orderedEvent{traceEvent: event, fromAddress: invocation.ContractAddress})
+	}
+	for i := range invocation.Calls {
+		events = collectEvents(&invocation.Calls[i], events)
+	}
+	return events
+}
+
+// TraceBlockEvents re-derives the events emitted by the given contracts in a block from the traces
+// of its transactions, for blocks whose events providers do not return from starknet_getEvents.
+// Events of a transaction are returned in the order they were emitted in.
+func TraceBlockEvents(ctx context.Context, client *ethrpc.Client, blockNumber uint64, blockHash *felt.Felt, addresses []*felt.Felt) ([]influence.RawEvent, error) {
+	var traces []blockTransactionTrace
+	if callErr := client.CallContext(ctx, &traces, "starknet_traceBlockTransactions", rpc.WithBlockNumber(blockNumber)); callErr != nil {
+		return nil, fmt.Errorf("Unable to trace transactions of block %d, err: %v", blockNumber, callErr)
+	}
+
+	var events []influence.RawEvent
+	for _, trace := range traces {
+		var transactionEvents []orderedEvent
+		transactionEvents = collectEvents(trace.TraceRoot.ExecuteInvocation, transactionEvents)
+		transactionEvents = collectEvents(trace.TraceRoot.ConstructorInvocation, transactionEvents)
+		transactionEvents = collectEvents(trace.TraceRoot.FunctionInvocation, transactionEvents)
+		sort.SliceStable(transactionEvents, func(i, j int) bool {
+			return transactionEvents[i].Order < transactionEvents[j].Order
+		})
+
+		for _, event := range transactionEvents {
+			if event.fromAddress == nil || len(event.Keys) == 0 || !containsAddress(addresses, event.fromAddress) {
+				continue
+			}
+			events = append(events, influence.RawEvent{
+				BlockNumber:     blockNumber,
+				BlockHash:       blockHash,
+				TransactionHash: trace.TransactionHash,
+				FromAddress:     event.fromAddress,
+				PrimaryKey:      event.Keys[0],
+				Keys:            event.Keys,
+				Parameters:      event.Data,
+			})
+		}
+	}
+	return events, nil
+}
+
+func containsAddress(addresses []*felt.Felt, address *felt.Felt) bool {
+	for _, a := range addresses {
+		if a.Equal(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// CrawledEvents summarizes a dump of crawled events: the blocks it has events in and the number of
+// events of each transaction.
+type CrawledEvents struct {
+	Blocks            []uint64
+	TransactionEvents map[string]int
+}
+
+// ReadCrawledEvents reads the blocks and transactions of the events in files written by the events
+// or parse commands. Transaction hashes are normalized with felt, so hashes written with and
+// without leading zeros match.
+func ReadCrawledEvents(filePath string) (CrawledEvents, error) {
+	crawled := CrawledEvents{TransactionEvents: make(map[string]int)}
+	files, filesErr := influence.EventFilePaths(filePath)
+	if filesErr != nil {
+		return crawled, fmt.Errorf("Unable to read file %s, err: %v", filePath, filesErr)
+	}
+
+	blocks := make(map[uint64]bool)
+	for _, file := range files {
+		inputFile, openErr := os.Open(file)
+		if openErr != nil {
+			return crawled, fmt.Errorf("Unable to read file %s, err: %v", file, openErr)
+		}
+
+		scanner := bufio.NewScanner(inputFile)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var line struct {
+				TransactionHash string
+				Event           struct {
+					BlockNumber     uint64
+					TransactionHash string
+				}
+			}
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+			transactionHash := line.TransactionHash
+			if transactionHash == "" {
+				transactionHash = line.Event.TransactionHash
+			}
+			if transactionHash == "" {
+				continue
+			}
+			hashFelt, hashErr := influence.FeltFromHexString(transactionHash)
+			if hashErr != nil {
+				continue
+			}
+			blocks[line.Event.BlockNumber] = true
+			crawled.TransactionEvents[hashFelt.String()]++
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return crawled, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	for blockNumber := range blocks {
+		crawled.Blocks = append(crawled.Blocks, blockNumber)
+	}
+	sort.Slice(crawled.Blocks, func(i, j int) bool { return crawled.Blocks[i] < crawled.Blocks[j] })
+	return crawled, nil
+}
+
+// SuspiciousBlocks returns the blocks from fromBlock to toBlock (inclusive, 0 for the last crawled
+// block) in gaps of more than minGap blocks without crawled events. An active contract like the
+// dispatcher emits events in most blocks, so long gaps are where providers most likely dropped
+// events.
+func (c CrawledEvents) SuspiciousBlocks(fromBlock, toBlock, minGap uint64) []uint64 {
+	if toBlock == 0 {
+		if len(c.Blocks) == 0 {
+			return nil
+		}
+		toBlock = c.Blocks[len(c.Blocks)-1]
+	}
+
+	var suspicious []uint64
+	// Adds the blocks from start up to, but not including, end if there are more than minGap
+	addGap := func(start, end uint64) {
+		if end > start && end-start > minGap {
+			for blockNumber := start; blockNumber < end; blockNumber++ {
+				suspicious = append(suspicious, blockNumber)
+			}
+		}
+	}
+	next := fromBlock
+	for _, blockNumber := range c.Blocks {
+		if blockNumber < fromBlock || blockNumber > toBlock {
+			continue
+		}
+		addGap(next, blockNumber)
+		next = blockNumber + 1
+	}
+	addGap(next, toBlock+1)
+	return suspicious
+}
+
+// MissingEvents returns the traced events which are not in the dump. Events are matched by their
+// position in their transaction, so a transaction with n crawled events only contributes its
+// events after the n-th.
+func (c CrawledEvents) MissingEvents(traced []influence.RawEvent) []influence.RawEvent {
+	var missing []influence.RawEvent
+	seen := make(map[string]int)
+	for _, event := range traced {
+		transactionHash := event.TransactionHash.String()
+		seen[transactionHash]++
+		if seen[transactionHash] > c.TransactionEvents[transactionHash] {
+			missing = append(missing, event)
+		}
+	}
+	return missing
+}
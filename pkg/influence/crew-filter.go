@@ -0,0 +1,170 @@
+package influence
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Label of crew entities.
+const CREW_ENTITY_LABEL = uint64(1)
+
+// CREW_KEYED_EVENTS maps events which index their caller crew in their keys to the position of the
+// label of the crew entity in the keys, followed by its ID. It is read from the ABI with
+// CrewKeyedEvents. The Dispatcher ABI does not index the caller crew of any event yet, it is part
+// of the data of events, so events of a crew can only be found by crawling all events.
+var CREW_KEYED_EVENTS = map[string]int{}
+
+// Name of the event member holding the crew which emitted the event.
+const CALLER_CREW_MEMBER = "caller_crew"
+
+// Number of keys taken by key members of events by type. Members of other types end the keys
+// CrewKeyedEvents can locate.
+var abiKeySizes = map[string]int{
+	"core::integer::u8":   1,
+	"core::integer::u16":  1,
+	"core::integer::u32":  1,
+	"core::integer::u64":  1,
+	"core::integer::u128": 1,
+	"core::felt252":       1,
+	"core::starknet::contract_address::ContractAddress": 1,
+	"core::starknet::class_hash::ClassHash":             1,
+	"core::starknet::eth_address::EthAddress":           1,
+	"influence::common::types::entity::Entity":          2,
+}
+
+// CrewKeyedEvents reads the events which index their caller crew in their keys from an ABI,
+// with the position of the label of the crew in their keys (position 0 is the selector).
+func CrewKeyedEvents(abi []byte) (map[string]int, error) {
+	definitions, parseErr := parseABIDefinitions(abi)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	keyed := make(map[string]int)
+	for name, definition := range definitions {
+		if definition.Type != "event" {
+			continue
+		}
+		position := 1
+		for _, member := range definition.Members {
+			if member.Kind != "key" {
+				continue
+			}
+			if member.Name == CALLER_CREW_MEMBER && member.Type == "influence::common::types::entity::Entity" {
+				keyed[name] = position
+				break
+			}
+			size, ok := abiKeySizes[member.Type]
+			if !ok {
+				break
+			}
+			position += size
+		}
+	}
+	return keyed, nil
+}
+
+// ErrNoCrewKeyedEvents is returned by CrewKeysFilter if no event indexes its caller crew.
+var ErrNoCrewKeyedEvents = errors.New("no event indexes its caller crew in its keys")
+
+// CrewKeysFilter returns the key filter of starknet_getEvents requests which only returns the events
+// of CREW_KEYED_EVENTS emitted by the crew.
+func CrewKeysFilter(crewId uint64) ([][]*felt.Felt, error) {
+	if len(CREW_KEYED_EVENTS) == 0 {
+		return nil, ErrNoCrewKeyedEvents
+	}
+
+	hashes := make(map[string]string)
+	for _, info := range EVENT_REGISTRY {
+		hashes[info.Name] = info.Hash
+	}
+
+	eventNames := make([]string, 0, len(CREW_KEYED_EVENTS))
+	for eventName := range CREW_KEYED_EVENTS {
+		eventNames = append(eventNames, eventName)
+	}
+	sort.Strings(eventNames)
+
+	// Key filters are positional, so a single request can only match events which index the crew
+	// at the same position
+	position := CREW_KEYED_EVENTS[eventNames[0]]
+	selectors := []*felt.Felt{}
+	for _, eventName := range eventNames {
+		if CREW_KEYED_EVENTS[eventName] != position {
+			return nil, fmt.Errorf("events %s and %s index their caller crew at different key positions", eventNames[0], eventName)
+		}
+		if hashes[eventName] == "" {
+			return nil, fmt.Errorf("unknown selector of event %s", eventName)
+		}
+		selector, selectorErr := FeltFromHexString(hashes[eventName])
+		if selectorErr != nil {
+			return nil, selectorErr
+		}
+		selectors = append(selectors, selector)
+	}
+	if position < 1 {
+		return nil, fmt.Errorf("invalid key position %d of the caller crew, position 0 is the selector", position)
+	}
+
+	keys := make([][]*felt.Felt, position+2)
+	for i := range keys {
+		keys[i] = []*felt.Felt{}
+	}
+	keys[0] = selectors
+	keys[position] = []*felt.Felt{new(felt.Felt).SetUint64(CREW_ENTITY_LABEL)}
+	keys[position+1] = []*felt.Felt{new(felt.Felt).SetUint64(crewId)}
+	return keys, nil
+}
+
+// CrewEventFilter matches crawled events by the crew in their CallerCrew field, for crawls of a crew
+// whose events can not be filtered by key.
+type CrewEventFilter struct {
+	CrewId uint64
+	parser *EventParser
+}
+
+func NewCrewEventFilter(crewId uint64) (*CrewEventFilter, error) {
+	parser, parserErr := NewEventParser()
+	if parserErr != nil {
+		return nil, parserErr
+	}
+	return &CrewEventFilter{CrewId: crewId, parser: parser}, nil
+}
+
+// Matches reports whether the event was emitted by the crew. Events which do not parse or have no
+// caller crew never match.
+func (f *CrewEventFilter) Matches(event RawEvent) bool {
+	parsed, parseErr := f.parser.Decode(event)
+	if parseErr != nil || parsed.Name == EVENT_UNKNOWN {
+		return false
+	}
+	return f.MatchesParsed(parsed)
+}
+
+// MatchesParsed reports whether the decoded event was emitted by the crew, from the CallerCrew
+// field of its struct.
+func (f *CrewEventFilter) MatchesParsed(parsed ParsedEvent) bool {
+	value := reflect.ValueOf(parsed.Event)
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return false
+	}
+	field := value.FieldByName("CallerCrew")
+	if !field.IsValid() {
+		return false
+	}
+	callerCrew, ok := field.Interface().(Influence_Common_Types_Entity_Entity)
+	if !ok {
+		return false
+	}
+	return callerCrew.Label == CREW_ENTITY_LABEL && callerCrew.Id == f.CrewId
+}
@@ -0,0 +1,40 @@
+package influence
+
+import (
+	"testing"
+)
+
+func TestCrewKeyedEvents(t *testing.T) {
+	abi := `[
+		{"type": "event", "name": "Indexed", "kind": "struct", "members": [
+			{"name": "caller", "type": "core::starknet::contract_address::ContractAddress", "kind": "key"},
+			{"name": "caller_crew", "type": "influence::common::types::entity::Entity", "kind": "key"}
+		]},
+		{"type": "event", "name": "NotIndexed", "kind": "struct", "members": [
+			{"name": "caller_crew", "type": "influence::common::types::entity::Entity", "kind": "data"}
+		]}
+	]`
+	keyed, keyedErr := CrewKeyedEvents([]byte(abi))
+	if keyedErr != nil {
+		t.Fatal(keyedErr)
+	}
+	if len(keyed) != 1 || keyed["Indexed"] != 2 {
+		t.Fatalf("expected Indexed at key position 2 only, got %v", keyed)
+	}
+}
+
+func TestCrewEventFilterMatchesParsed(t *testing.T) {
+	filter := &CrewEventFilter{CrewId: 7}
+	crew := Influence_Common_Types_Entity_Entity{Label: CREW_ENTITY_LABEL, Id: 7}
+
+	if !filter.MatchesParsed(ParsedEvent{Name: Event_ConstructionStarted, Event: ConstructionStarted{CallerCrew: crew}}) {
+		t.Error("expected an event of the crew to match")
+	}
+	other := Influence_Common_Types_Entity_Entity{Label: CREW_ENTITY_LABEL, Id: 8}
+	if filter.MatchesParsed(ParsedEvent{Name: Event_ConstructionStarted, Event: ConstructionStarted{CallerCrew: other}}) {
+		t.Error("expected an event of another crew not to match")
+	}
+	if filter.MatchesParsed(ParsedEvent{Name: EVENT_UNKNOWN, Event: RawEvent{}}) {
+		t.Error("expected an event without caller crew not to match")
+	}
+}
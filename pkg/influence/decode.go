@@ -0,0 +1,155 @@
+package influence
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// DecodeError is returned by EventParser.Decode for events whose parameters could not be decoded,
+// including those which made a decoder panic, so a malformed event does not crash the pipeline.
+type DecodeError struct {
+	BlockNumber     uint64
+	TransactionHash string
+	PrimaryKey      string
+	Err             error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unable to decode event with key %s in block %d (transaction %s): %v", e.PrimaryKey, e.BlockNumber, e.TransactionHash, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func NewDecodeError(event RawEvent, err error) *DecodeError {
+	decodeErr := &DecodeError{BlockNumber: event.BlockNumber, Err: err}
+	if event.TransactionHash != nil {
+		decodeErr.TransactionHash = event.TransactionHash.String()
+	}
+	if event.PrimaryKey != nil {
+		decodeErr.PrimaryKey = event.PrimaryKey.String()
+	}
+	return decodeErr
+}
+
+// Decode parses the event like EventParser.Parse, which is generated by seer and can panic on
+// malformed parameters, e.g. nil felts or array lengths which overflow an int. The parameters of
+// registered events are first checked against the layout of their Go struct with CheckParameters,
+// so the generated decoders only see parameters they can index. recover() stays as a backstop for
+// events which are not in EVENT_REGISTRY. Errors of the checks and decoders, and panics, are
+// returned as a DecodeError, and the event is returned unparsed.
+func (p *EventParser) Decode(event RawEvent) (result ParsedEvent, err error) {
+	defer recoverDecodeError(event, &result, &err)
+	if event.PrimaryKey == nil {
+		return ParsedEvent{Name: EVENT_UNKNOWN, Event: event}, nil
+	}
+	if eventType, ok := p.registeredEventTypes()[event.PrimaryKey.String()]; ok {
+		if checkErr := CheckParameters(eventType, event.Parameters); checkErr != nil {
+			return ParsedEvent{Name: EVENT_UNKNOWN, Event: event}, checkErr
+		}
+	}
+	return p.Parse(event)
+}
+
+var eventTypesOnce sync.Once
+var eventTypes map[string]reflect.Type
+
+// registeredEventTypes maps selectors of the events in EVENT_REGISTRY, as felt strings, to the Go
+// structs the parser decodes them into. Events of several contracts share selectors (e.g.
+// Transfer), so the struct is the one Parse returns for parameters of zeros, which every decoder
+// accepts.
+func (p *EventParser) registeredEventTypes() map[string]reflect.Type {
+	eventTypesOnce.Do(func() {
+		zeros := make([]*felt.Felt, 256)
+		for i := range zeros {
+			zeros[i] = new(felt.Felt)
+		}
+
+		eventTypes = make(map[string]reflect.Type)
+		for _, info := range EVENT_REGISTRY {
+			if info.Hash == "" {
+				continue
+			}
+			key, keyErr := FeltFromHexString(info.Hash)
+			if keyErr != nil {
+				continue
+			}
+			parsed, parseErr := p.Parse(RawEvent{PrimaryKey: key, Parameters: zeros})
+			if parseErr != nil || parsed.Name == EVENT_UNKNOWN {
+				continue
+			}
+			eventTypes[key.String()] = reflect.TypeOf(parsed.Event)
+		}
+	})
+	return eventTypes
+}
+
+// CheckParameters checks that parameters hold an event with the Go struct eventType, as the
+// generated decoders read it: every field in order takes a felt, nested structs take the felts of
+// their fields, and arrays a length felt followed by their items. The BlockNumber of the event is
+// not one of its parameters.
+func CheckParameters(eventType reflect.Type, parameters []*felt.Felt) error {
+	_, checkErr := checkFelts(eventType, parameters, true)
+	return checkErr
+}
+
+// checkFelts returns the number of felts a value of type t takes at the start of parameters.
+func checkFelts(t reflect.Type, parameters []*felt.Felt, event bool) (int, error) {
+	switch t.Kind() {
+	case reflect.Struct:
+		consumed := 0
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if event && field.Name == "BlockNumber" {
+				continue
+			}
+			fieldConsumed, fieldErr := checkFelts(field.Type, parameters[consumed:], false)
+			if fieldErr != nil {
+				return 0, fieldErr
+			}
+			consumed += fieldConsumed
+		}
+		return consumed, nil
+	case reflect.Slice:
+		if len(parameters) < 1 || parameters[0] == nil {
+			return 0, ErrIncorrectParameters
+		}
+		length := parameters[0].Uint64()
+		if length > uint64(len(parameters)-1) {
+			return 0, fmt.Errorf("array length %s exceeds the %d remaining parameters", parameters[0].String(), len(parameters)-1)
+		}
+		consumed := 1
+		for i := uint64(0); i < length; i++ {
+			itemConsumed, itemErr := checkFelts(t.Elem(), parameters[consumed:], false)
+			if itemErr != nil {
+				return 0, itemErr
+			}
+			consumed += itemConsumed
+		}
+		return consumed, nil
+	default:
+		// Integers, enums, addresses and big integers are a felt each
+		if len(parameters) < 1 || parameters[0] == nil {
+			return 0, ErrIncorrectParameters
+		}
+		return 1, nil
+	}
+}
+
+// recoverDecodeError is deferred by EventParser.Decode. It turns panics of decoders and their errors
+// into a DecodeError, and returns the event unparsed.
+func recoverDecodeError(event RawEvent, result *ParsedEvent, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("decoder panicked: %v", r)
+	}
+	if *err != nil {
+		if _, ok := (*err).(*DecodeError); !ok {
+			*err = NewDecodeError(event, *err)
+		}
+		*result = ParsedEvent{Name: EVENT_UNKNOWN, Event: event}
+	}
+}
@@ -0,0 +1,126 @@
+package influence
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// registeredKeys returns the selectors of all events of the registry, in a stable order.
+func registeredKeys(t testing.TB) []*felt.Felt {
+	var hashes []string
+	for _, info := range EVENT_REGISTRY {
+		if info.Hash != "" {
+			hashes = append(hashes, info.Hash)
+		}
+	}
+	sort.Strings(hashes)
+
+	keys := make([]*felt.Felt, 0, len(hashes))
+	for _, hash := range hashes {
+		key, keyErr := FeltFromHexString(hash)
+		if keyErr != nil {
+			t.Fatalf("invalid hash %s in the registry: %v", hash, keyErr)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// FuzzDecode checks that EventParser.Decode returns an error instead of panicking on any input. The
+// first byte of the input picks a registered event, the rest is split into 8 byte parameters,
+// which keeps array lengths small enough for the decoders to get past them.
+func FuzzDecode(f *testing.F) {
+	parser, parserErr := NewEventParser()
+	if parserErr != nil {
+		f.Fatal(parserErr)
+	}
+	keys := registeredKeys(f)
+
+	f.Add([]byte{0})
+	f.Add([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1})
+	f.Add([]byte{2, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) < 1 {
+			return
+		}
+
+		event := RawEvent{PrimaryKey: keys[int(data[0])%len(keys)]}
+		for rest := data[1:]; len(rest) > 0; {
+			chunk := make([]byte, 8)
+			n := copy(chunk, rest)
+			rest = rest[n:]
+			event.Parameters = append(event.Parameters, new(felt.Felt).SetUint64(binary.BigEndian.Uint64(chunk)))
+		}
+
+		parsed, parseErr := parser.Decode(event)
+		if parseErr == nil {
+			return
+		}
+		var decodeErr *DecodeError
+		if !errors.As(parseErr, &decodeErr) {
+			t.Fatalf("expected a DecodeError, got %T: %v", parseErr, parseErr)
+		}
+		if parsed.Name != EVENT_UNKNOWN {
+			t.Fatalf("expected the event to be left unparsed, got %s", parsed.Name)
+		}
+	})
+}
+
+func TestDecodeMissingParameters(t *testing.T) {
+	parser, parserErr := NewEventParser()
+	if parserErr != nil {
+		t.Fatal(parserErr)
+	}
+
+	for _, key := range registeredKeys(t) {
+		event := RawEvent{PrimaryKey: key, Parameters: []*felt.Felt{nil}}
+		parsed, parseErr := parser.Decode(event)
+		if parseErr == nil {
+			continue
+		}
+		if parsed.Name != EVENT_UNKNOWN {
+			t.Errorf("event with key %s: expected it to be left unparsed, got %s", key.String(), parsed.Name)
+		}
+	}
+}
+
+// TestCheckParametersMatchesDecoders checks that CheckParameters reads the layout of every
+// registered event like its generated decoder: the fewest parameters it accepts decode, one less
+// is rejected.
+func TestCheckParametersMatchesDecoders(t *testing.T) {
+	parser, parserErr := NewEventParser()
+	if parserErr != nil {
+		t.Fatal(parserErr)
+	}
+
+	zeros := make([]*felt.Felt, 256)
+	for i := range zeros {
+		zeros[i] = new(felt.Felt)
+	}
+	eventTypes := parser.registeredEventTypes()
+	for _, key := range registeredKeys(t) {
+		eventType, ok := eventTypes[key.String()]
+		if !ok {
+			t.Errorf("event with key %s: expected the type it decodes into", key.String())
+			continue
+		}
+
+		// Arrays of zero felts are empty, so the parameters of the event take their fewest felts
+		minimum, checkErr := checkFelts(eventType, zeros, true)
+		if checkErr != nil {
+			t.Fatalf("event %s: %v", eventType.Name(), checkErr)
+		}
+		parsed, parseErr := parser.Parse(RawEvent{PrimaryKey: key, Parameters: zeros[:minimum]})
+		if parseErr != nil || reflect.TypeOf(parsed.Event) != eventType {
+			t.Errorf("event %s: expected %d parameters to decode, got %s with error %v", eventType.Name(), minimum, parsed.Name, parseErr)
+		}
+		if minimum > 0 && CheckParameters(eventType, zeros[:minimum-1]) == nil {
+			t.Errorf("event %s: expected %d parameters to be rejected", eventType.Name(), minimum-1)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Package influence holds Go bindings of Influence.eth events generated by seer, with the
+// registry of their names and selectors.
+package influence
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EventInfo holds the canonical name of an event, as written to parsed event files, and the hash
+// of its selector.
+type EventInfo struct {
+	Name string
+	Hash string
+}
+
+// EVENT_REGISTRY maps the Go structs generated in influence.go to their events. Events are looked
+// up by type with EventInfoOf, so readers of parsed event files do not repeat event names.
+var EVENT_REGISTRY = map[reflect.Type]EventInfo{
+	reflect.TypeOf(RawEvent{}):                                                     {Name: EVENT_UNKNOWN},
+	reflect.TypeOf(AddedToWhitelist{}):                                             {Name: Event_AddedToWhitelist, Hash: Hash_AddedToWhitelist},
+	reflect.TypeOf(ArrivalRewardClaimed{}):                                         {Name: Event_ArrivalRewardClaimed, Hash: Hash_ArrivalRewardClaimed},
+	reflect.TypeOf(AsteroidInitialized{}):                                          {Name: Event_AsteroidInitialized, Hash: Hash_AsteroidInitialized},
+	reflect.TypeOf(AsteroidManaged{}):                                              {Name: Event_AsteroidManaged, Hash: Hash_AsteroidManaged},
+	reflect.TypeOf(AsteroidPurchased{}):                                            {Name: Event_AsteroidPurchased, Hash: Hash_AsteroidPurchased},
+	reflect.TypeOf(BuildingRepossessed{}):                                          {Name: Event_BuildingRepossessed, Hash: Hash_BuildingRepossessed},
+	reflect.TypeOf(BuyOrderCancelled{}):                                            {Name: Event_BuyOrderCancelled, Hash: Hash_BuyOrderCancelled},
+	reflect.TypeOf(BuyOrderCreated{}):                                              {Name: Event_BuyOrderCreated, Hash: Hash_BuyOrderCreated},
+	reflect.TypeOf(BuyOrderFilled{}):                                               {Name: Event_BuyOrderFilled, Hash: Hash_BuyOrderFilled},
+	reflect.TypeOf(ConstructionAbandoned{}):                                        {Name: Event_ConstructionAbandoned, Hash: Hash_ConstructionAbandoned},
+	reflect.TypeOf(ConstructionDeconstructed{}):                                    {Name: Event_ConstructionDeconstructed, Hash: Hash_ConstructionDeconstructed},
+	reflect.TypeOf(ConstructionFinished{}):                                         {Name: Event_ConstructionFinished, Hash: Hash_ConstructionFinished},
+	reflect.TypeOf(ConstructionPlanned{}):                                          {Name: Event_ConstructionPlanned, Hash: Hash_ConstructionPlanned},
+	reflect.TypeOf(ConstructionStarted{}):                                          {Name: Event_ConstructionStarted, Hash: Hash_ConstructionStarted},
+	reflect.TypeOf(ContractAgreementAccepted{}):                                    {Name: Event_ContractAgreementAccepted, Hash: Hash_ContractAgreementAccepted},
+	reflect.TypeOf(ContractPolicyAssigned{}):                                       {Name: Event_ContractPolicyAssigned, Hash: Hash_ContractPolicyAssigned},
+	reflect.TypeOf(ContractPolicyRemoved{}):                                        {Name: Event_ContractPolicyRemoved, Hash: Hash_ContractPolicyRemoved},
+	reflect.TypeOf(CrewDelegated{}):                                                {Name: Event_CrewDelegated, Hash: Hash_CrewDelegated},
+	reflect.TypeOf(CrewEjected{}):                                                  {Name: Event_CrewEjected, Hash: Hash_CrewEjected},
+	reflect.TypeOf(CrewStationed{}):                                                {Name: Event_CrewStationed, Hash: Hash_CrewStationed},
+	reflect.TypeOf(CrewmatePurchased{}):                                            {Name: Event_CrewmatePurchased, Hash: Hash_CrewmatePurchased},
+	reflect.TypeOf(CrewmateRecruited{}):                                            {Name: Event_CrewmateRecruited, Hash: Hash_CrewmateRecruited},
+	reflect.TypeOf(CrewmateRecruitedV1{}):                                          {Name: Event_CrewmateRecruitedV1, Hash: Hash_CrewmateRecruitedV1},
+	reflect.TypeOf(CrewmatesArranged{}):                                            {Name: Event_CrewmatesArranged, Hash: Hash_CrewmatesArranged},
+	reflect.TypeOf(CrewmatesArrangedV1{}):                                          {Name: Event_CrewmatesArrangedV1, Hash: Hash_CrewmatesArrangedV1},
+	reflect.TypeOf(CrewmatesExchanged{}):                                           {Name: Event_CrewmatesExchanged, Hash: Hash_CrewmatesExchanged},
+	reflect.TypeOf(DeliveryCancelled{}):                                            {Name: Event_DeliveryCancelled, Hash: Hash_DeliveryCancelled},
+	reflect.TypeOf(DeliveryPackaged{}):                                             {Name: Event_DeliveryPackaged, Hash: Hash_DeliveryPackaged},
+	reflect.TypeOf(DeliveryPackagedV1{}):                                           {Name: Event_DeliveryPackagedV1, Hash: Hash_DeliveryPackagedV1},
+	reflect.TypeOf(DeliveryReceived{}):                                             {Name: Event_DeliveryReceived, Hash: Hash_DeliveryReceived},
+	reflect.TypeOf(DeliverySent{}):                                                 {Name: Event_DeliverySent, Hash: Hash_DeliverySent},
+	reflect.TypeOf(DepositListedForSale{}):                                         {Name: Event_DepositListedForSale, Hash: Hash_DepositListedForSale},
+	reflect.TypeOf(DepositPurchased{}):                                             {Name: Event_DepositPurchased, Hash: Hash_DepositPurchased},
+	reflect.TypeOf(DepositUnlistedForSale{}):                                       {Name: Event_DepositUnlistedForSale, Hash: Hash_DepositUnlistedForSale},
+	reflect.TypeOf(EmergencyActivated{}):                                           {Name: Event_EmergencyActivated, Hash: Hash_EmergencyActivated},
+	reflect.TypeOf(EmergencyDeactivated{}):                                         {Name: Event_EmergencyDeactivated, Hash: Hash_EmergencyDeactivated},
+	reflect.TypeOf(EmergencyPropellantCollected{}):                                 {Name: Event_EmergencyPropellantCollected, Hash: Hash_EmergencyPropellantCollected},
+	reflect.TypeOf(EventAnnotated{}):                                               {Name: Event_EventAnnotated, Hash: Hash_EventAnnotated},
+	reflect.TypeOf(ExchangeConfigured{}):                                           {Name: Event_ExchangeConfigured, Hash: Hash_ExchangeConfigured},
+	reflect.TypeOf(FoodSupplied{}):                                                 {Name: Event_FoodSupplied, Hash: Hash_FoodSupplied},
+	reflect.TypeOf(FoodSuppliedV1{}):                                               {Name: Event_FoodSuppliedV1, Hash: Hash_FoodSuppliedV1},
+	reflect.TypeOf(Influence_Contracts_Asteroid_Asteroid_Approval{}):               {Name: Event_Influence_Contracts_Asteroid_Asteroid_Approval, Hash: Hash_Influence_Contracts_Asteroid_Asteroid_Approval},
+	reflect.TypeOf(Influence_Contracts_Asteroid_Asteroid_ApprovalForAll{}):         {Name: Event_Influence_Contracts_Asteroid_Asteroid_ApprovalForAll, Hash: Hash_Influence_Contracts_Asteroid_Asteroid_ApprovalForAll},
+	reflect.TypeOf(Influence_Contracts_Asteroid_Asteroid_BridgedFromL1{}):          {Name: Event_Influence_Contracts_Asteroid_Asteroid_BridgedFromL1, Hash: Hash_Influence_Contracts_Asteroid_Asteroid_BridgedFromL1},
+	reflect.TypeOf(Influence_Contracts_Asteroid_Asteroid_BridgedToL1{}):            {Name: Event_Influence_Contracts_Asteroid_Asteroid_BridgedToL1, Hash: Hash_Influence_Contracts_Asteroid_Asteroid_BridgedToL1},
+	reflect.TypeOf(Influence_Contracts_Asteroid_Asteroid_SellOrderFilled{}):        {Name: Event_Influence_Contracts_Asteroid_Asteroid_SellOrderFilled, Hash: Hash_Influence_Contracts_Asteroid_Asteroid_SellOrderFilled},
+	reflect.TypeOf(Influence_Contracts_Asteroid_Asteroid_SellOrderSet{}):           {Name: Event_Influence_Contracts_Asteroid_Asteroid_SellOrderSet, Hash: Hash_Influence_Contracts_Asteroid_Asteroid_SellOrderSet},
+	reflect.TypeOf(Influence_Contracts_Asteroid_Asteroid_Transfer{}):               {Name: Event_Influence_Contracts_Asteroid_Asteroid_Transfer, Hash: Hash_Influence_Contracts_Asteroid_Asteroid_Transfer},
+	reflect.TypeOf(Influence_Contracts_Crew_Crew_Approval{}):                       {Name: Event_Influence_Contracts_Crew_Crew_Approval, Hash: Hash_Influence_Contracts_Crew_Crew_Approval},
+	reflect.TypeOf(Influence_Contracts_Crew_Crew_ApprovalForAll{}):                 {Name: Event_Influence_Contracts_Crew_Crew_ApprovalForAll, Hash: Hash_Influence_Contracts_Crew_Crew_ApprovalForAll},
+	reflect.TypeOf(Influence_Contracts_Crew_Crew_BridgedFromL1{}):                  {Name: Event_Influence_Contracts_Crew_Crew_BridgedFromL1, Hash: Hash_Influence_Contracts_Crew_Crew_BridgedFromL1},
+	reflect.TypeOf(Influence_Contracts_Crew_Crew_BridgedToL1{}):                    {Name: Event_Influence_Contracts_Crew_Crew_BridgedToL1, Hash: Hash_Influence_Contracts_Crew_Crew_BridgedToL1},
+	reflect.TypeOf(Influence_Contracts_Crew_Crew_SellOrderFilled{}):                {Name: Event_Influence_Contracts_Crew_Crew_SellOrderFilled, Hash: Hash_Influence_Contracts_Crew_Crew_SellOrderFilled},
+	reflect.TypeOf(Influence_Contracts_Crew_Crew_SellOrderSet{}):                   {Name: Event_Influence_Contracts_Crew_Crew_SellOrderSet, Hash: Hash_Influence_Contracts_Crew_Crew_SellOrderSet},
+	reflect.TypeOf(Influence_Contracts_Crew_Crew_Transfer{}):                       {Name: Event_Influence_Contracts_Crew_Crew_Transfer, Hash: Hash_Influence_Contracts_Crew_Crew_Transfer},
+	reflect.TypeOf(Influence_Contracts_Crewmate_Crewmate_Approval{}):               {Name: Event_Influence_Contracts_Crewmate_Crewmate_Approval, Hash: Hash_Influence_Contracts_Crewmate_Crewmate_Approval},
+	reflect.TypeOf(Influence_Contracts_Crewmate_Crewmate_ApprovalForAll{}):         {Name: Event_Influence_Contracts_Crewmate_Crewmate_ApprovalForAll, Hash: Hash_Influence_Contracts_Crewmate_Crewmate_ApprovalForAll},
+	reflect.TypeOf(Influence_Contracts_Crewmate_Crewmate_BridgedFromL1{}):          {Name: Event_Influence_Contracts_Crewmate_Crewmate_BridgedFromL1, Hash: Hash_Influence_Contracts_Crewmate_Crewmate_BridgedFromL1},
+	reflect.TypeOf(Influence_Contracts_Crewmate_Crewmate_BridgedToL1{}):            {Name: Event_Influence_Contracts_Crewmate_Crewmate_BridgedToL1, Hash: Hash_Influence_Contracts_Crewmate_Crewmate_BridgedToL1},
+	reflect.TypeOf(Influence_Contracts_Crewmate_Crewmate_SellOrderFilled{}):        {Name: Event_Influence_Contracts_Crewmate_Crewmate_SellOrderFilled, Hash: Hash_Influence_Contracts_Crewmate_Crewmate_SellOrderFilled},
+	reflect.TypeOf(Influence_Contracts_Crewmate_Crewmate_SellOrderSet{}):           {Name: Event_Influence_Contracts_Crewmate_Crewmate_SellOrderSet, Hash: Hash_Influence_Contracts_Crewmate_Crewmate_SellOrderSet},
+	reflect.TypeOf(Influence_Contracts_Crewmate_Crewmate_Transfer{}):               {Name: Event_Influence_Contracts_Crewmate_Crewmate_Transfer, Hash: Hash_Influence_Contracts_Crewmate_Crewmate_Transfer},
+	reflect.TypeOf(Influence_Contracts_Designate_Designate_Designated{}):           {Name: Event_Influence_Contracts_Designate_Designate_Designated, Hash: Hash_Influence_Contracts_Designate_Designate_Designated},
+	reflect.TypeOf(Influence_Contracts_Dispatcher_Dispatcher_ConstantRegistered{}): {Name: Event_Influence_Contracts_Dispatcher_Dispatcher_ConstantRegistered, Hash: Hash_Influence_Contracts_Dispatcher_Dispatcher_ConstantRegistered},
+	reflect.TypeOf(Influence_Contracts_Dispatcher_Dispatcher_ContractRegistered{}): {Name: Event_Influence_Contracts_Dispatcher_Dispatcher_ContractRegistered, Hash: Hash_Influence_Contracts_Dispatcher_Dispatcher_ContractRegistered},
+	reflect.TypeOf(Influence_Contracts_Dispatcher_Dispatcher_SystemRegistered{}):   {Name: Event_Influence_Contracts_Dispatcher_Dispatcher_SystemRegistered, Hash: Hash_Influence_Contracts_Dispatcher_Dispatcher_SystemRegistered},
+	reflect.TypeOf(Influence_Contracts_Escrow_Escrow_Deposited{}):                  {Name: Event_Influence_Contracts_Escrow_Escrow_Deposited, Hash: Hash_Influence_Contracts_Escrow_Escrow_Deposited},
+	reflect.TypeOf(Influence_Contracts_Escrow_Escrow_ForcedWithdrawFinished{}):     {Name: Event_Influence_Contracts_Escrow_Escrow_ForcedWithdrawFinished, Hash: Hash_Influence_Contracts_Escrow_Escrow_ForcedWithdrawFinished},
+	reflect.TypeOf(Influence_Contracts_Escrow_Escrow_ForcedWithdrawStarted{}):      {Name: Event_Influence_Contracts_Escrow_Escrow_ForcedWithdrawStarted, Hash: Hash_Influence_Contracts_Escrow_Escrow_ForcedWithdrawStarted},
+	reflect.TypeOf(Influence_Contracts_Escrow_Escrow_Withdrawn{}):                  {Name: Event_Influence_Contracts_Escrow_Escrow_Withdrawn, Hash: Hash_Influence_Contracts_Escrow_Escrow_Withdrawn},
+	reflect.TypeOf(Influence_Contracts_Ship_Ship_Approval{}):                       {Name: Event_Influence_Contracts_Ship_Ship_Approval, Hash: Hash_Influence_Contracts_Ship_Ship_Approval},
+	reflect.TypeOf(Influence_Contracts_Ship_Ship_ApprovalForAll{}):                 {Name: Event_Influence_Contracts_Ship_Ship_ApprovalForAll, Hash: Hash_Influence_Contracts_Ship_Ship_ApprovalForAll},
+	reflect.TypeOf(Influence_Contracts_Ship_Ship_BridgedFromL1{}):                  {Name: Event_Influence_Contracts_Ship_Ship_BridgedFromL1, Hash: Hash_Influence_Contracts_Ship_Ship_BridgedFromL1},
+	reflect.TypeOf(Influence_Contracts_Ship_Ship_BridgedToL1{}):                    {Name: Event_Influence_Contracts_Ship_Ship_BridgedToL1, Hash: Hash_Influence_Contracts_Ship_Ship_BridgedToL1},
+	reflect.TypeOf(Influence_Contracts_Ship_Ship_SellOrderFilled{}):                {Name: Event_Influence_Contracts_Ship_Ship_SellOrderFilled, Hash: Hash_Influence_Contracts_Ship_Ship_SellOrderFilled},
+	reflect.TypeOf(Influence_Contracts_Ship_Ship_SellOrderSet{}):                   {Name: Event_Influence_Contracts_Ship_Ship_SellOrderSet, Hash: Hash_Influence_Contracts_Ship_Ship_SellOrderSet},
+	reflect.TypeOf(Influence_Contracts_Ship_Ship_Transfer{}):                       {Name: Event_Influence_Contracts_Ship_Ship_Transfer, Hash: Hash_Influence_Contracts_Ship_Ship_Transfer},
+	reflect.TypeOf(Influence_Contracts_Sway_Sway_Approval{}):                       {Name: Event_Influence_Contracts_Sway_Sway_Approval, Hash: Hash_Influence_Contracts_Sway_Sway_Approval},
+	reflect.TypeOf(Influence_Contracts_Sway_Sway_ConfirmationCreated{}):            {Name: Event_Influence_Contracts_Sway_Sway_ConfirmationCreated, Hash: Hash_Influence_Contracts_Sway_Sway_ConfirmationCreated},
+	reflect.TypeOf(Influence_Contracts_Sway_Sway_DepositHandled{}):                 {Name: Event_Influence_Contracts_Sway_Sway_DepositHandled, Hash: Hash_Influence_Contracts_Sway_Sway_DepositHandled},
+	reflect.TypeOf(Influence_Contracts_Sway_Sway_ReceiptConfirmed{}):               {Name: Event_Influence_Contracts_Sway_Sway_ReceiptConfirmed, Hash: Hash_Influence_Contracts_Sway_Sway_ReceiptConfirmed},
+	reflect.TypeOf(Influence_Contracts_Sway_Sway_Transfer{}):                       {Name: Event_Influence_Contracts_Sway_Sway_Transfer, Hash: Hash_Influence_Contracts_Sway_Sway_Transfer},
+	reflect.TypeOf(Influence_Contracts_Sway_Sway_WithdrawInitiated{}):              {Name: Event_Influence_Contracts_Sway_Sway_WithdrawInitiated, Hash: Hash_Influence_Contracts_Sway_Sway_WithdrawInitiated},
+	reflect.TypeOf(LotReclaimed{}):                                                 {Name: Event_LotReclaimed, Hash: Hash_LotReclaimed},
+	reflect.TypeOf(MaterialProcessingFinished{}):                                   {Name: Event_MaterialProcessingFinished, Hash: Hash_MaterialProcessingFinished},
+	reflect.TypeOf(MaterialProcessingStartedV1{}):                                  {Name: Event_MaterialProcessingStartedV1, Hash: Hash_MaterialProcessingStartedV1},
+	reflect.TypeOf(NameChanged{}):                                                  {Name: Event_NameChanged, Hash: Hash_NameChanged},
+	reflect.TypeOf(PrepaidAgreementAccepted{}):                                     {Name: Event_PrepaidAgreementAccepted, Hash: Hash_PrepaidAgreementAccepted},
+	reflect.TypeOf(PrepaidAgreementCancelled{}):                                    {Name: Event_PrepaidAgreementCancelled, Hash: Hash_PrepaidAgreementCancelled},
+	reflect.TypeOf(PrepaidAgreementExtended{}):                                     {Name: Event_PrepaidAgreementExtended, Hash: Hash_PrepaidAgreementExtended},
+	reflect.TypeOf(PrepaidMerkleAgreementAccepted{}):                               {Name: Event_PrepaidMerkleAgreementAccepted, Hash: Hash_PrepaidMerkleAgreementAccepted},
+	reflect.TypeOf(PrepaidMerklePolicyAssigned{}):                                  {Name: Event_PrepaidMerklePolicyAssigned, Hash: Hash_PrepaidMerklePolicyAssigned},
+	reflect.TypeOf(PrepaidMerklePolicyRemoved{}):                                   {Name: Event_PrepaidMerklePolicyRemoved, Hash: Hash_PrepaidMerklePolicyRemoved},
+	reflect.TypeOf(PrepaidPolicyAssigned{}):                                        {Name: Event_PrepaidPolicyAssigned, Hash: Hash_PrepaidPolicyAssigned},
+	reflect.TypeOf(PrepaidPolicyRemoved{}):                                         {Name: Event_PrepaidPolicyRemoved, Hash: Hash_PrepaidPolicyRemoved},
+	reflect.TypeOf(PrepareForLaunchRewardClaimed{}):                                {Name: Event_PrepareForLaunchRewardClaimed, Hash: Hash_PrepareForLaunchRewardClaimed},
+	reflect.TypeOf(PublicPolicyAssigned{}):                                         {Name: Event_PublicPolicyAssigned, Hash: Hash_PublicPolicyAssigned},
+	reflect.TypeOf(PublicPolicyRemoved{}):                                          {Name: Event_PublicPolicyRemoved, Hash: Hash_PublicPolicyRemoved},
+	reflect.TypeOf(RandomEventResolved{}):                                          {Name: Event_RandomEventResolved, Hash: Hash_RandomEventResolved},
+	reflect.TypeOf(RemovedFromWhitelist{}):                                         {Name: Event_RemovedFromWhitelist, Hash: Hash_RemovedFromWhitelist},
+	reflect.TypeOf(ResourceExtractionFinished{}):                                   {Name: Event_ResourceExtractionFinished, Hash: Hash_ResourceExtractionFinished},
+	reflect.TypeOf(ResourceExtractionStarted{}):                                    {Name: Event_ResourceExtractionStarted, Hash: Hash_ResourceExtractionStarted},
+	reflect.TypeOf(ResourceScanFinished{}):                                         {Name: Event_ResourceScanFinished, Hash: Hash_ResourceScanFinished},
+	reflect.TypeOf(ResourceScanStarted{}):                                          {Name: Event_ResourceScanStarted, Hash: Hash_ResourceScanStarted},
+	reflect.TypeOf(SamplingDepositFinished{}):                                      {Name: Event_SamplingDepositFinished, Hash: Hash_SamplingDepositFinished},
+	reflect.TypeOf(SamplingDepositStarted{}):                                       {Name: Event_SamplingDepositStarted, Hash: Hash_SamplingDepositStarted},
+	reflect.TypeOf(SamplingDepositStartedV1{}):                                     {Name: Event_SamplingDepositStartedV1, Hash: Hash_SamplingDepositStartedV1},
+	reflect.TypeOf(SellOrderCancelled{}):                                           {Name: Event_SellOrderCancelled, Hash: Hash_SellOrderCancelled},
+	reflect.TypeOf(SellOrderCreated{}):                                             {Name: Event_SellOrderCreated, Hash: Hash_SellOrderCreated},
+	reflect.TypeOf(SellOrderFilled{}):                                              {Name: Event_SellOrderFilled, Hash: Hash_SellOrderFilled},
+	reflect.TypeOf(ShipAssemblyFinished{}):                                         {Name: Event_ShipAssemblyFinished, Hash: Hash_ShipAssemblyFinished},
+	reflect.TypeOf(ShipAssemblyStarted{}):                                          {Name: Event_ShipAssemblyStarted, Hash: Hash_ShipAssemblyStarted},
+	reflect.TypeOf(ShipAssemblyStartedV1{}):                                        {Name: Event_ShipAssemblyStartedV1, Hash: Hash_ShipAssemblyStartedV1},
+	reflect.TypeOf(ShipCommandeered{}):                                             {Name: Event_ShipCommandeered, Hash: Hash_ShipCommandeered},
+	reflect.TypeOf(ShipDocked{}):                                                   {Name: Event_ShipDocked, Hash: Hash_ShipDocked},
+	reflect.TypeOf(ShipUndocked{}):                                                 {Name: Event_ShipUndocked, Hash: Hash_ShipUndocked},
+	reflect.TypeOf(SurfaceScanFinished{}):                                          {Name: Event_SurfaceScanFinished, Hash: Hash_SurfaceScanFinished},
+	reflect.TypeOf(SurfaceScanStarted{}):                                           {Name: Event_SurfaceScanStarted, Hash: Hash_SurfaceScanStarted},
+	reflect.TypeOf(TestnetSwayClaimed{}):                                           {Name: Event_TestnetSwayClaimed, Hash: Hash_TestnetSwayClaimed},
+	reflect.TypeOf(TransitFinished{}):                                              {Name: Event_TransitFinished, Hash: Hash_TransitFinished},
+	reflect.TypeOf(TransitStarted{}):                                               {Name: Event_TransitStarted, Hash: Hash_TransitStarted},
+}
+
+// REGISTERED_EVENT_NAMES holds the names of all events in EVENT_REGISTRY.
+var REGISTERED_EVENT_NAMES = func() map[string]bool {
+	names := make(map[string]bool, len(EVENT_REGISTRY))
+	for _, info := range EVENT_REGISTRY {
+		names[info.Name] = true
+	}
+	return names
+}()
+
+// EventInfoOf returns the registered event of type T.
+func EventInfoOf[T any]() (EventInfo, error) {
+	eventType := reflect.TypeOf((*T)(nil)).Elem()
+	info, ok := EVENT_REGISTRY[eventType]
+	if !ok {
+		return EventInfo{}, fmt.Errorf("No event registered for type %s", eventType.Name())
+	}
+	return info, nil
+}
+
+func normalizeEventHash(hash string) string {
+	return strings.TrimLeft(strings.TrimPrefix(strings.ToLower(hash), "0x"), "0")
+}
+
+// EVENT_NAMES_BY_HASH maps the selector hashes of registered events (without 0x prefix and leading
+// zeros) to their names.
+var EVENT_NAMES_BY_HASH = func() map[string]string {
+	names := make(map[string]string, len(EVENT_REGISTRY))
+	for _, info := range EVENT_REGISTRY {
+		if info.Hash != "" {
+			names[normalizeEventHash(info.Hash)] = info.Name
+		}
+	}
+	return names
+}()
+
+// EventNameOfKey returns the name of the registered event with the given primary key, e.g. the
+// PrimaryKey of a DecodeError, or an empty string if the key is unknown.
+func EventNameOfKey(primaryKey string) string {
+	return EVENT_NAMES_BY_HASH[normalizeEventHash(primaryKey)]
+}
@@ -0,0 +1,54 @@
+package influence
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/NethermindEth/starknet.go/utils"
+)
+
+// TestEventRegistryCoversABI fails when an event of the ABI has no entry in EVENT_REGISTRY, e.g.
+// after the bindings are regenerated from an ABI with new events. Events are registered under
+// their short name, or their full path if the short name is ambiguous.
+func TestEventRegistryCoversABI(t *testing.T) {
+	abi, readErr := os.ReadFile("../../abis/starknet_combined.json")
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	definitions, parseErr := parseABIDefinitions(abi)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	registered := make(map[string]EventInfo, len(EVENT_REGISTRY))
+	for _, info := range EVENT_REGISTRY {
+		registered[info.Name] = info
+	}
+
+	events := 0
+	for name, definition := range definitions {
+		// Enum events only wrap the struct events of a contract
+		if definition.Type != "event" || definition.Variants != nil {
+			continue
+		}
+		events++
+
+		path := strings.Split(name, "::")
+		shortName := path[len(path)-1]
+		info, ok := registered[name]
+		if !ok {
+			info, ok = registered[shortName]
+		}
+		if !ok {
+			t.Errorf("event %s of the ABI is not in EVENT_REGISTRY", name)
+			continue
+		}
+		if selector := utils.GetSelectorFromNameFelt(shortName).String(); normalizeEventHash(info.Hash) != normalizeEventHash(selector) {
+			t.Errorf("event %s is registered with hash %s, expected the selector %s", name, info.Hash, selector)
+		}
+	}
+	if events == 0 {
+		t.Fatal("expected events in the ABI")
+	}
+}
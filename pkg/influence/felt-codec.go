@@ -0,0 +1,189 @@
+package influence
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// Felts are decoded from hex on every event of a dump. The conversions of the felt package go
+// through big.Int and intermediate strings, the helpers in this file work on fixed size buffers
+// instead and fall back to the felt package for anything unusual, so their results are always the
+// same.
+
+var errInvalidHexFelt = errors.New("invalid hex felt")
+
+// Values of hex digits, 0xff for other characters.
+var hexValues = func() (values [256]byte) {
+	for i := range values {
+		values[i] = 0xff
+	}
+	for c := '0'; c <= '9'; c++ {
+		values[c] = byte(c - '0')
+	}
+	for c := 'a'; c <= 'f'; c++ {
+		values[c] = byte(c-'a') + 10
+		values[c-'a'+'A'] = byte(c-'a') + 10
+	}
+	return values
+}()
+
+// Big endian bytes of the field modulus, felts are below it.
+var feltModulus = func() (modulus [fp.Bytes]byte) {
+	fp.Modulus().FillBytes(modulus[:])
+	return modulus
+}()
+
+// SetFeltFromHex sets f to the value of up to 64 hex digits, without 0x prefix. Values which are
+// not below the field modulus are rejected.
+func SetFeltFromHex(f *felt.Felt, digits []byte) error {
+	if len(digits) == 0 || len(digits) > 2*fp.Bytes {
+		return errInvalidHexFelt
+	}
+
+	// Digits are right aligned, so an odd number of digits leaves the high nibble of the first
+	// byte zero
+	var raw [fp.Bytes]byte
+	position := 2*fp.Bytes - len(digits)
+	for _, c := range digits {
+		nibble := hexValues[c]
+		if nibble == 0xff {
+			return errInvalidHexFelt
+		}
+		if position%2 == 0 {
+			raw[position/2] = nibble << 4
+		} else {
+			raw[position/2] |= nibble
+		}
+		position++
+	}
+
+	if bytes.Compare(raw[:], feltModulus[:]) >= 0 {
+		return errInvalidHexFelt
+	}
+	f.SetBytes(raw[:])
+	return nil
+}
+
+// jsonFelt decodes a felt of an events file in place, so the felts of an event can share one
+// allocation. Null values leave it unset.
+type jsonFelt struct {
+	value felt.Felt
+	set   bool
+}
+
+func (f *jsonFelt) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 4 && data[0] == '"' && data[1] == '0' && data[2] == 'x' && data[len(data)-1] == '"' {
+		if SetFeltFromHex(&f.value, data[3:len(data)-1]) == nil {
+			f.set = true
+			return nil
+		}
+	}
+	if unmErr := f.value.UnmarshalJSON(data); unmErr != nil {
+		return unmErr
+	}
+	f.set = true
+	return nil
+}
+
+func (f *jsonFelt) pointer() *felt.Felt {
+	if !f.set {
+		return nil
+	}
+	return &f.value
+}
+
+// jsonFelts decodes an array of felts of an events file. Arrays of quoted hex felts without
+// whitespace, as written by the crawler, are scanned directly, anything else is decoded by
+// encoding/json.
+type jsonFelts []jsonFelt
+
+func (fs *jsonFelts) UnmarshalJSON(data []byte) error {
+	if felts, ok := scanHexFelts(data); ok {
+		*fs = felts
+		return nil
+	}
+	var felts []jsonFelt
+	if unmErr := json.Unmarshal(data, &felts); unmErr != nil {
+		return unmErr
+	}
+	*fs = felts
+	return nil
+}
+
+// scanHexFelts decodes a compact JSON array of quoted hex felts, e.g. ["0x1","0x2a"]. It returns
+// false for anything else.
+func scanHexFelts(data []byte) ([]jsonFelt, bool) {
+	if len(data) < 2 || data[0] != '[' || data[len(data)-1] != ']' {
+		return nil, false
+	}
+	items := data[1 : len(data)-1]
+	if len(items) == 0 {
+		return []jsonFelt{}, true
+	}
+
+	felts := make([]jsonFelt, bytes.Count(items, []byte{','})+1)
+	for i := range felts {
+		end := bytes.IndexByte(items, ',')
+		if end < 0 {
+			end = len(items)
+		}
+		item := items[:end]
+		if len(item) < 4 || item[0] != '"' || item[1] != '0' || item[2] != 'x' || item[len(item)-1] != '"' {
+			return nil, false
+		}
+		if SetFeltFromHex(&felts[i].value, item[3:len(item)-1]) != nil {
+			return nil, false
+		}
+		felts[i].set = true
+		if end < len(items) {
+			items = items[end+1:]
+		}
+	}
+	return felts, true
+}
+
+func jsonFeltPointers(felts []jsonFelt) []*felt.Felt {
+	if felts == nil {
+		return nil
+	}
+	pointers := make([]*felt.Felt, len(felts))
+	for i := range felts {
+		pointers[i] = felts[i].pointer()
+	}
+	return pointers
+}
+
+// UnmarshalJSON decodes a raw event with the hex decoding of SetFeltFromHex, with the keys and
+// the parameters of the event in one allocation each. The event is replaced as a whole.
+func (e *RawEvent) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		BlockNumber     uint64
+		BlockHash       jsonFelt
+		TransactionHash jsonFelt
+		FromAddress     jsonFelt
+		PrimaryKey      jsonFelt
+		Keys            jsonFelts
+		Parameters      jsonFelts
+	}
+	if unmErr := json.Unmarshal(data, &decoded); unmErr != nil {
+		return unmErr
+	}
+
+	*e = RawEvent{
+		BlockNumber:     decoded.BlockNumber,
+		BlockHash:       decoded.BlockHash.pointer(),
+		TransactionHash: decoded.TransactionHash.pointer(),
+		FromAddress:     decoded.FromAddress.pointer(),
+		PrimaryKey:      decoded.PrimaryKey.pointer(),
+		Keys:            jsonFeltPointers(decoded.Keys),
+		Parameters:      jsonFeltPointers(decoded.Parameters),
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package influence
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// bigIntRawEvent has the fields of RawEvent without its UnmarshalJSON, so its felts are decoded by
+// the felt package through big.Int.
+type bigIntRawEvent struct {
+	BlockNumber     uint64
+	BlockHash       *felt.Felt
+	TransactionHash *felt.Felt
+	FromAddress     *felt.Felt
+	PrimaryKey      *felt.Felt
+	Keys            []*felt.Felt
+	Parameters      []*felt.Felt
+}
+
+// randomHexFelt returns a value below the field modulus as formatted by felt.String.
+func randomHexFelt(r *rand.Rand) string {
+	raw := make([]byte, 1+r.Intn(31))
+	r.Read(raw)
+	return new(felt.Felt).SetBytes(raw).String()
+}
+
+// eventLine is an event of an events file with the given number of parameters.
+func eventLine(r *rand.Rand, parameters int) []byte {
+	felts := make([]string, parameters)
+	for i := range felts {
+		felts[i] = fmt.Sprintf("%q", randomHexFelt(r))
+	}
+	key := fmt.Sprintf("%q", randomHexFelt(r))
+	return []byte(fmt.Sprintf(`{"BlockNumber":631234,"BlockHash":%q,"TransactionHash":%q,"FromAddress":%q,"PrimaryKey":%s,"Keys":[%s],"Parameters":[%s]}`,
+		randomHexFelt(r), randomHexFelt(r), randomHexFelt(r), key, key, strings.Join(felts, ",")))
+}
+
+func TestRawEventUnmarshalMatchesFelt(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 200; i++ {
+		line := eventLine(r, r.Intn(12))
+
+		var event RawEvent
+		if unmErr := json.Unmarshal(line, &event); unmErr != nil {
+			t.Fatalf("unable to decode %s: %v", line, unmErr)
+		}
+		var expected bigIntRawEvent
+		if unmErr := json.Unmarshal(line, &expected); unmErr != nil {
+			t.Fatalf("unable to decode %s with the felt package: %v", line, unmErr)
+		}
+
+		if event.BlockNumber != expected.BlockNumber || !event.BlockHash.Equal(expected.BlockHash) || !event.TransactionHash.Equal(expected.TransactionHash) || !event.FromAddress.Equal(expected.FromAddress) || !event.PrimaryKey.Equal(expected.PrimaryKey) {
+			t.Fatalf("decoded %s as %+v, expected %+v", line, event, expected)
+		}
+		if len(event.Parameters) != len(expected.Parameters) || len(event.Keys) != len(expected.Keys) {
+			t.Fatalf("decoded %s with %d parameters, expected %d", line, len(event.Parameters), len(expected.Parameters))
+		}
+		for j := range event.Parameters {
+			if !event.Parameters[j].Equal(expected.Parameters[j]) {
+				t.Fatalf("decoded parameter %d of %s as %s, expected %s", j, line, event.Parameters[j].String(), expected.Parameters[j].String())
+			}
+		}
+	}
+}
+
+func TestRawEventUnmarshalFallsBack(t *testing.T) {
+	for _, line := range []string{
+		`{"BlockNumber":1,"Keys":null,"Parameters":[ "0x1", "0x2" ]}`,
+		`{"BlockNumber":1,"Keys":["0x1",null],"Parameters":["2","0x2"]}`,
+		`{"BlockNumber":1,"Keys":[],"Parameters":["0x1","0X2"]}`,
+	} {
+		var event RawEvent
+		if unmErr := json.Unmarshal([]byte(line), &event); unmErr != nil {
+			t.Fatalf("unable to decode %s: %v", line, unmErr)
+		}
+		var expected bigIntRawEvent
+		if unmErr := json.Unmarshal([]byte(line), &expected); unmErr != nil {
+			t.Fatalf("unable to decode %s with the felt package: %v", line, unmErr)
+		}
+		if len(event.Keys) != len(expected.Keys) || len(event.Parameters) != len(expected.Parameters) {
+			t.Fatalf("decoded %s as %+v, expected %+v", line, event, expected)
+		}
+		for i := range event.Keys {
+			if (event.Keys[i] == nil) != (expected.Keys[i] == nil) || (event.Keys[i] != nil && !event.Keys[i].Equal(expected.Keys[i])) {
+				t.Errorf("decoded key %d of %s as %v, expected %v", i, line, event.Keys[i], expected.Keys[i])
+			}
+		}
+		for i := range event.Parameters {
+			if !event.Parameters[i].Equal(expected.Parameters[i]) {
+				t.Errorf("decoded parameter %d of %s as %s, expected %s", i, line, event.Parameters[i].String(), expected.Parameters[i].String())
+			}
+		}
+	}
+
+	var invalid RawEvent
+	if json.Unmarshal([]byte(`{"Parameters":["0x1",]}`), &invalid) == nil {
+		t.Error("expected an invalid array to be rejected")
+	}
+}
+
+func TestSetFeltFromHexRejectsInvalidDigits(t *testing.T) {
+	for _, digits := range []string{"", "xyz", strings.Repeat("f", 64), strings.Repeat("1", 65)} {
+		var f felt.Felt
+		if SetFeltFromHex(&f, []byte(digits)) == nil {
+			t.Errorf("expected %q to be rejected", digits)
+		}
+	}
+}
+
+func benchmarkLines(b *testing.B) [][]byte {
+	r := rand.New(rand.NewSource(7))
+	lines := make([][]byte, 256)
+	for i := range lines {
+		lines[i] = eventLine(r, 10)
+	}
+	b.ResetTimer()
+	return lines
+}
+
+// BenchmarkParseRawEvent decodes events of an events file with RawEvent.UnmarshalJSON, compare with
+// BenchmarkParseRawEventBigInt.
+func BenchmarkParseRawEvent(b *testing.B) {
+	lines := benchmarkLines(b)
+	for i := 0; i < b.N; i++ {
+		var event RawEvent
+		if unmErr := json.Unmarshal(lines[i%len(lines)], &event); unmErr != nil {
+			b.Fatal(unmErr)
+		}
+	}
+}
+
+// BenchmarkParseRawEventBigInt is the baseline of BenchmarkParseRawEvent, decoding the felts of
+// events with the felt package.
+func BenchmarkParseRawEventBigInt(b *testing.B) {
+	lines := benchmarkLines(b)
+	for i := 0; i < b.N; i++ {
+		var event bigIntRawEvent
+		if unmErr := json.Unmarshal(lines[i%len(lines)], &event); unmErr != nil {
+			b.Fatal(unmErr)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package influence
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// FeltComparison compares a felt of a raw event, e.g. keys[1] or parameters[0], with a value.
+type FeltComparison struct {
+	// keys, parameters, from_address, block_number or transaction_hash
+	Field string
+	// Position in keys or parameters, unused for other fields
+	Index    int
+	Operator string
+	Value    *big.Int
+}
+
+// FeltFilter is an expression on the felts of raw events, in disjunctive normal form: it matches
+// an event if all comparisons of any of its clauses match.
+type FeltFilter struct {
+	Expression string
+	Clauses    [][]FeltComparison
+}
+
+var feltComparisonPattern = regexp.MustCompile(`^\s*(keys|parameters|data|from_address|block_number|transaction_hash)\s*(?:\[\s*(\d+)\s*\])?\s*(==|!=|<=|>=|<|>)\s*(0x[0-9a-fA-F]+|\d+)\s*$`)
+
+// ParseFeltFilter parses a filter expression of comparisons joined with && and ||, where && binds
+// tighter, e.g. "keys[1]==0x3 && parameters[0]>=100 || from_address==0x42". keys and parameters
+// (or data) take the position of the felt to compare, values are hexadecimal with a 0x prefix or
+// decimal. Events without a felt at the position do not match.
+func ParseFeltFilter(expression string) (FeltFilter, error) {
+	filter := FeltFilter{Expression: expression}
+	for _, clauseExpression := range strings.Split(expression, "||") {
+		clause := []FeltComparison{}
+		for _, comparisonExpression := range strings.Split(clauseExpression, "&&") {
+			match := feltComparisonPattern.FindStringSubmatch(comparisonExpression)
+			if match == nil {
+				return filter, fmt.Errorf("invalid comparison \"%s\" in filter %s, expected e.g. keys[1]==0x3", strings.TrimSpace(comparisonExpression), expression)
+			}
+
+			comparison := FeltComparison{Field: match[1], Operator: match[3]}
+			if comparison.Field == "data" {
+				comparison.Field = "parameters"
+			}
+			indexed := comparison.Field == "keys" || comparison.Field == "parameters"
+			if indexed != (match[2] != "") {
+				return filter, fmt.Errorf("invalid comparison \"%s\" in filter %s, only keys and parameters take a position", strings.TrimSpace(comparisonExpression), expression)
+			}
+			if indexed {
+				index, indexErr := strconv.Atoi(match[2])
+				if indexErr != nil {
+					return filter, indexErr
+				}
+				comparison.Index = index
+			}
+
+			value, ok := new(big.Int).SetString(match[4], 0)
+			if !ok {
+				return filter, fmt.Errorf("invalid value %s in filter %s", match[4], expression)
+			}
+			comparison.Value = value
+			clause = append(clause, comparison)
+		}
+		filter.Clauses = append(filter.Clauses, clause)
+	}
+	return filter, nil
+}
+
+// ParseFeltFilters parses filter expressions, e.g. of repeated --filter flags.
+func ParseFeltFilters(expressions []string) ([]FeltFilter, error) {
+	filters := make([]FeltFilter, 0, len(expressions))
+	for _, expression := range expressions {
+		filter, parseErr := ParseFeltFilter(expression)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+func (c FeltComparison) operand(event RawEvent) (*big.Int, bool) {
+	var value *felt.Felt
+	switch c.Field {
+	case "keys":
+		if c.Index >= len(event.Keys) {
+			return nil, false
+		}
+		value = event.Keys[c.Index]
+	case "parameters":
+		if c.Index >= len(event.Parameters) {
+			return nil, false
+		}
+		value = event.Parameters[c.Index]
+	case "from_address":
+		value = event.FromAddress
+	case "transaction_hash":
+		value = event.TransactionHash
+	case "block_number":
+		return new(big.Int).SetUint64(event.BlockNumber), true
+	}
+	if value == nil {
+		return nil, false
+	}
+	return value.BigInt(new(big.Int)), true
+}
+
+func (c FeltComparison) Matches(event RawEvent) bool {
+	operand, ok := c.operand(event)
+	if !ok {
+		return false
+	}
+	cmp := operand.Cmp(c.Value)
+	switch c.Operator {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func (f FeltFilter) Matches(event RawEvent) bool {
+	for _, clause := range f.Clauses {
+		matches := true
+		for _, comparison := range clause {
+			if !comparison.Matches(event) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesFeltFilters returns whether an event matches all filters.
+func MatchesFeltFilters(filters []FeltFilter, event RawEvent) bool {
+	for _, filter := range filters {
+		if !filter.Matches(event) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package influence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Modes of decoding felts which the generated decoders parse as hex strings or integers.
+const (
+	// Minimal hex, as ParseString returns felts
+	DECODING_HEX = "hex"
+	// Hex padded to 64 digits, the way wallets and explorers show addresses
+	DECODING_ADDRESS = "address"
+	// Cairo short string of up to 31 ASCII characters. Felts which are not printable ASCII are kept
+	// as hex, so values which are not strings are not mangled.
+	DECODING_SHORT_STRING = "short-string"
+)
+
+// FIELD_DECODINGS maps event names to the decoding modes of their fields, by field path (nested
+// fields are separated with dots, e.g. Name.Value). Fields which are not listed keep the output of
+// their decoder.
+var FIELD_DECODINGS = map[string]map[string]string{
+	Event_NameChanged: {
+		"Name.Value": DECODING_SHORT_STRING,
+	},
+	Event_Influence_Contracts_Dispatcher_Dispatcher_ConstantRegistered: {
+		"Name": DECODING_SHORT_STRING,
+	},
+	Event_Influence_Contracts_Dispatcher_Dispatcher_ContractRegistered: {
+		"Name":    DECODING_SHORT_STRING,
+		"Address": DECODING_ADDRESS,
+	},
+	Event_Influence_Contracts_Dispatcher_Dispatcher_SystemRegistered: {
+		"Name": DECODING_SHORT_STRING,
+	},
+}
+
+// SetFieldDecoding sets the decoding mode of a field, given as <event>.<field path>=<mode>, e.g.
+// NameChanged.Name.Value=hex.
+func SetFieldDecoding(spec string) error {
+	path, mode, ok := strings.Cut(spec, "=")
+	if !ok {
+		return fmt.Errorf("invalid field decoding %s, expected <event>.<field>=<mode>", spec)
+	}
+	switch mode {
+	case DECODING_HEX, DECODING_ADDRESS, DECODING_SHORT_STRING:
+	default:
+		return fmt.Errorf("unknown decoding mode %s of field %s, supported modes: %s, %s, %s", mode, path, DECODING_HEX, DECODING_ADDRESS, DECODING_SHORT_STRING)
+	}
+
+	eventName, field, ok := strings.Cut(path, ".")
+	if !ok || field == "" {
+		return fmt.Errorf("invalid field decoding %s, expected <event>.<field>=<mode>", spec)
+	}
+	if !REGISTERED_EVENT_NAMES[eventName] {
+		return fmt.Errorf("unknown event %s in field decoding %s", eventName, spec)
+	}
+
+	if FIELD_DECODINGS[eventName] == nil {
+		FIELD_DECODINGS[eventName] = make(map[string]string)
+	}
+	FIELD_DECODINGS[eventName][field] = mode
+	return nil
+}
+
+// DecodeFelt renders a felt in the given decoding mode.
+func DecodeFelt(value *felt.Felt, mode string) string {
+	switch mode {
+	case DECODING_ADDRESS:
+		raw := value.Bytes()
+		return fmt.Sprintf("0x%x", raw[:])
+	case DECODING_SHORT_STRING:
+		raw := value.Bytes()
+		decoded := strings.TrimLeft(string(raw[:]), "\x00")
+		for _, r := range decoded {
+			if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+				return value.String()
+			}
+		}
+		return decoded
+	default:
+		return value.String()
+	}
+}
+
+func feltOfJSONValue(value any) (*felt.Felt, bool) {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, "0x") {
+			return nil, false
+		}
+		parsed, parseErr := new(felt.Felt).SetString(v)
+		return parsed, parseErr == nil
+	case json.Number:
+		integer, ok := new(big.Int).SetString(v.String(), 10)
+		if !ok || integer.Sign() < 0 {
+			return nil, false
+		}
+		return new(felt.Felt).SetBytes(integer.Bytes()), true
+	}
+	return nil, false
+}
+
+// DecodeFields applies the decoding modes of FIELD_DECODINGS to a parsed event. Events with
+// decoded fields are returned as JSON objects, since decoded values do not fit the types of the
+// generated structs; other events are returned unchanged.
+func DecodeFields(eventName string, event any) any {
+	decodings := FIELD_DECODINGS[eventName]
+	if len(decodings) == 0 {
+		return event
+	}
+
+	encoded, marshalErr := json.Marshal(event)
+	if marshalErr != nil {
+		return event
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var fields map[string]any
+	if decodeErr := decoder.Decode(&fields); decodeErr != nil {
+		return event
+	}
+
+	for path, mode := range decodings {
+		parent := fields
+		segments := strings.Split(path, ".")
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := parent[segment].(map[string]any)
+			if !ok {
+				parent = nil
+				break
+			}
+			parent = child
+		}
+		if parent == nil {
+			continue
+		}
+		last := segments[len(segments)-1]
+		if value, ok := feltOfJSONValue(parent[last]); ok {
+			parent[last] = DecodeFelt(value, mode)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,106 @@
+package influence
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Number of fractional bits of Cubit fixed-point types: f64 values are 32.32 and f128 values are
+// 64.64 fixed-point numbers.
+var (
+	CUBIT_F64_FRACTION_BITS  = uint(32)
+	CUBIT_F128_FRACTION_BITS = uint(64)
+)
+
+// FixedPoint is a signed Cubit fixed-point number, its value is Mag / 2^FractionBits, negated if
+// Negative is set. The generated Cubit structs keep the raw magnitude and sign, FixedPoint converts
+// them to real-world values.
+type FixedPoint struct {
+	Mag          *big.Int
+	Negative     bool
+	FractionBits uint
+}
+
+func NewFixedPoint(mag *big.Int, sign Core_Bool, fractionBits uint) FixedPoint {
+	if mag == nil {
+		mag = big.NewInt(0)
+	}
+	return FixedPoint{Mag: mag, Negative: sign != 0 && mag.Sign() != 0, FractionBits: fractionBits}
+}
+
+func (f Cubit_F64_Types_Fixed_Fixed) FixedPoint() FixedPoint {
+	return NewFixedPoint(new(big.Int).SetUint64(f.Mag), f.Sign, CUBIT_F64_FRACTION_BITS)
+}
+
+func (f Cubit_F128_Types_Fixed_Fixed) FixedPoint() FixedPoint {
+	return NewFixedPoint(f.Mag, f.Sign, CUBIT_F128_FRACTION_BITS)
+}
+
+// ParseFixedPoint64 parses a cubit::f64::types::fixed::Fixed from a list of felts, see
+// ParseCubit_F64_Types_Fixed_Fixed.
+func ParseFixedPoint64(parameters []*felt.Felt) (FixedPoint, int, error) {
+	value, consumed, err := ParseCubit_F64_Types_Fixed_Fixed(parameters)
+	if err != nil {
+		return FixedPoint{}, 0, err
+	}
+	return value.FixedPoint(), consumed, nil
+}
+
+// ParseFixedPoint128 parses a cubit::f128::types::fixed::Fixed from a list of felts, see
+// ParseCubit_F128_Types_Fixed_Fixed.
+func ParseFixedPoint128(parameters []*felt.Felt) (FixedPoint, int, error) {
+	value, consumed, err := ParseCubit_F128_Types_Fixed_Fixed(parameters)
+	if err != nil {
+		return FixedPoint{}, 0, err
+	}
+	return value.FixedPoint(), consumed, nil
+}
+
+// Rat returns the exact value of the fixed-point number.
+func (f FixedPoint) Rat() *big.Rat {
+	mag := f.Mag
+	if mag == nil {
+		mag = big.NewInt(0)
+	}
+	denominator := new(big.Int).Lsh(big.NewInt(1), f.FractionBits)
+	value := new(big.Rat).SetFrac(mag, denominator)
+	if f.Negative {
+		value.Neg(value)
+	}
+	return value
+}
+
+// Float64 returns the nearest float64 to the value of the fixed-point number.
+func (f FixedPoint) Float64() float64 {
+	value, _ := f.Rat().Float64()
+	return value
+}
+
+// Scaled returns the value multiplied by scale and rounded toward zero, e.g. to keep 6 decimals of
+// a value as an integer with a scale of 1000000.
+func (f FixedPoint) Scaled(scale uint64) *big.Int {
+	value := f.Rat()
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).SetUint64(scale)))
+	return new(big.Int).Quo(value.Num(), value.Denom())
+}
+
+// String formats the value as a decimal. Cubit values have at most 64 fractional bits, so 20
+// decimals represent them without visible rounding; trailing zeros are removed.
+func (f FixedPoint) String() string {
+	formatted := f.Rat().FloatString(20)
+	end := len(formatted)
+	for end > 0 && formatted[end-1] == '0' {
+		end--
+	}
+	if end > 0 && formatted[end-1] == '.' {
+		end--
+	}
+	return formatted[:end]
+}
+
+// MarshalJSON writes the value as a decimal string, so it is not rounded by JSON number parsers.
+func (f FixedPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
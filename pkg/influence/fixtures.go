@@ -0,0 +1,252 @@
+package influence
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+type abiMember struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// "key" or "data" for members of events
+	Kind string `json:"kind"`
+}
+
+type abiDefinition struct {
+	Type     string      `json:"type"`
+	Name     string      `json:"name"`
+	Members  []abiMember `json:"members"`
+	Variants []abiMember `json:"variants"`
+}
+
+// Labels given to generated entities by the name of the member holding them, so fixtures of
+// different events refer to the same kinds of entities as real events. Other entities get a label
+// between 1 and 6.
+var FIXTURE_ENTITY_LABELS = map[string]uint64{
+	"caller_crew": 1,
+	"crew":        1,
+	"crewmate":    2,
+	"asteroid":    3,
+	"origin":      3,
+	"destination": 3,
+	"lot":         4,
+	"building":    5,
+	"extractor":   5,
+	"processor":   5,
+	"dry_dock":    5,
+	"ship":        6,
+}
+
+// FixtureGenerator generates random events which the registered decoders accept, following the
+// ABI definitions of the events, for seeding integration tests without real chain data. Integers
+// are kept below MaxValue, so the same crews, asteroids and buildings appear in many events.
+type FixtureGenerator struct {
+	Rand           *rand.Rand
+	MaxValue       uint64
+	MaxArrayLength int
+
+	definitions map[string]abiDefinition
+}
+
+// NewFixtureGenerator reads the struct, enum and event definitions of an ABI, either a plain ABI
+// list or an object of ABIs by contract like abis/starknet_combined.json.
+func NewFixtureGenerator(abi []byte, seed int64) (*FixtureGenerator, error) {
+	definitions, parseErr := parseABIDefinitions(abi)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return &FixtureGenerator{
+		Rand:           rand.New(rand.NewSource(seed)),
+		MaxValue:       100,
+		MaxArrayLength: 3,
+		definitions:    definitions,
+	}, nil
+}
+
+// parseABIDefinitions returns the struct, enum and event definitions of an ABI by name. The first
+// definition of a name is kept.
+func parseABIDefinitions(data []byte) (map[string]abiDefinition, error) {
+	var entries []json.RawMessage
+	var byContract map[string]json.RawMessage
+	if unmErr := json.Unmarshal(data, &byContract); unmErr == nil {
+		for _, value := range byContract {
+			var list []json.RawMessage
+			if json.Unmarshal(value, &list) == nil {
+				entries = append(entries, list...)
+			} else {
+				entries = append(entries, value)
+			}
+		}
+	} else if unmErr := json.Unmarshal(data, &entries); unmErr != nil {
+		return nil, fmt.Errorf("Unable to parse ABI, err: %v", unmErr)
+	}
+
+	definitions := make(map[string]abiDefinition)
+	for _, entry := range entries {
+		var definition abiDefinition
+		if json.Unmarshal(entry, &definition) != nil {
+			continue
+		}
+		switch definition.Type {
+		case "struct", "enum", "event":
+			if _, ok := definitions[definition.Name]; !ok {
+				definitions[definition.Name] = definition
+			}
+		}
+	}
+	return definitions, nil
+}
+
+func (g *FixtureGenerator) randomFelt(bytes int) *felt.Felt {
+	value := make([]byte, bytes)
+	g.Rand.Read(value)
+	return new(felt.Felt).SetBytes(value)
+}
+
+func (g *FixtureGenerator) smallFelt() *felt.Felt {
+	return new(felt.Felt).SetUint64(1 + uint64(g.Rand.Int63n(int64(g.MaxValue))))
+}
+
+// generate appends parameters of the given type, encoded the way the generated decoders read them.
+// u256 values are read from a single felt by the decoders, so they are generated as one.
+func (g *FixtureGenerator) generate(memberName, typeName string, parameters []*felt.Felt, depth int) ([]*felt.Felt, error) {
+	if depth > 8 {
+		return nil, fmt.Errorf("type %s is nested too deeply", typeName)
+	}
+	typeName = strings.TrimPrefix(typeName, "@")
+
+	switch typeName {
+	case "core::integer::u8", "core::integer::u16", "core::integer::u32", "core::integer::u64":
+		return append(parameters, g.smallFelt()), nil
+	case "core::integer::u128":
+		return append(parameters, g.randomFelt(8)), nil
+	case "core::integer::u256", "core::felt252", "core::starknet::contract_address::ContractAddress", "core::starknet::class_hash::ClassHash":
+		return append(parameters, g.randomFelt(31)), nil
+	case "core::starknet::eth_address::EthAddress":
+		return append(parameters, g.randomFelt(20)), nil
+	case "influence::common::types::entity::Entity":
+		label, ok := FIXTURE_ENTITY_LABELS[memberName]
+		if !ok {
+			label = 1 + uint64(g.Rand.Intn(6))
+		}
+		return append(parameters, new(felt.Felt).SetUint64(label), g.smallFelt()), nil
+	}
+
+	for _, prefix := range []string{"core::array::Span::<", "core::array::Array::<"} {
+		if strings.HasPrefix(typeName, prefix) && strings.HasSuffix(typeName, ">") {
+			itemType := strings.TrimSuffix(strings.TrimPrefix(typeName, prefix), ">")
+			length := g.Rand.Intn(g.MaxArrayLength + 1)
+			parameters = append(parameters, new(felt.Felt).SetUint64(uint64(length)))
+			for i := 0; i < length; i++ {
+				var itemErr error
+				parameters, itemErr = g.generate(memberName, itemType, parameters, depth+1)
+				if itemErr != nil {
+					return nil, itemErr
+				}
+			}
+			return parameters, nil
+		}
+	}
+
+	definition, ok := g.definitions[typeName]
+	if !ok {
+		return nil, fmt.Errorf("no ABI definition of type %s", typeName)
+	}
+	switch definition.Type {
+	case "enum":
+		if len(definition.Variants) == 0 {
+			return nil, fmt.Errorf("enum %s has no variants", typeName)
+		}
+		index := g.Rand.Intn(len(definition.Variants))
+		parameters = append(parameters, new(felt.Felt).SetUint64(uint64(index)))
+		if variantType := definition.Variants[index].Type; variantType != "()" {
+			return g.generate(memberName, variantType, parameters, depth+1)
+		}
+		return parameters, nil
+	default:
+		for _, member := range definition.Members {
+			var memberErr error
+			parameters, memberErr = g.generate(member.Name, member.Type, parameters, depth+1)
+			if memberErr != nil {
+				return nil, memberErr
+			}
+		}
+		return parameters, nil
+	}
+}
+
+// Parameters generates the data of an event with the given ABI name.
+func (g *FixtureGenerator) Parameters(eventName string) ([]*felt.Felt, error) {
+	definition, ok := g.definitions[eventName]
+	if !ok || definition.Type != "event" {
+		return nil, fmt.Errorf("no ABI definition of event %s", eventName)
+	}
+	return g.generate("", eventName, []*felt.Felt{}, 0)
+}
+
+// Event generates a raw event of the registered event, as the crawler would read it from the chain.
+func (g *FixtureGenerator) Event(info EventInfo, blockNumber uint64) (RawEvent, error) {
+	primaryKey, keyErr := FeltFromHexString(info.Hash)
+	if keyErr != nil {
+		return RawEvent{}, keyErr
+	}
+	parameters, parametersErr := g.Parameters(info.Name)
+	if parametersErr != nil {
+		return RawEvent{}, parametersErr
+	}
+	return RawEvent{
+		BlockNumber:     blockNumber,
+		BlockHash:       g.randomFelt(31),
+		TransactionHash: g.randomFelt(31),
+		FromAddress:     g.randomFelt(31),
+		PrimaryKey:      primaryKey,
+		Keys:            []*felt.Felt{primaryKey},
+		Parameters:      parameters,
+	}, nil
+}
+
+// FixtureEvents returns the registered events with the given names, or all registered events if
+// names is empty, ordered by name.
+func FixtureEvents(names []string) ([]EventInfo, error) {
+	byName := make(map[string]EventInfo, len(EVENT_REGISTRY))
+	for _, info := range EVENT_REGISTRY {
+		if info.Hash != "" {
+			byName[info.Name] = info
+		}
+	}
+
+	var infos []EventInfo
+	if len(names) == 0 {
+		for _, info := range byName {
+			infos = append(infos, info)
+		}
+	} else {
+		for _, name := range names {
+			info, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown event %s", name)
+			}
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// CheckFixture parses the generated event with the registered decoders, so fixtures only hold
+// events the pipeline decodes as the intended event.
+func CheckFixture(parser *EventParser, info EventInfo, event RawEvent) error {
+	parsed, parseErr := parser.Decode(event)
+	if parseErr != nil {
+		return parseErr
+	}
+	if parsed.Name != info.Name {
+		return fmt.Errorf("fixture of %s was decoded as %s", info.Name, parsed.Name)
+	}
+	return nil
+}
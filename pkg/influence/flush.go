@@ -0,0 +1,35 @@
+package influence
+
+import "time"
+
+// Default flushing of the output of the events command, set with the --flush-every and
+// --flush-interval flags.
+var FLUSH_EVERY = 0
+var FLUSH_INTERVAL = time.Second
+
+// FlushPolicy decides when buffered event lines are flushed to the output, so consumers which
+// follow the output (e.g. tail -f or an inline parser) see events soon after they are crawled.
+// Lines are flushed after every Every lines if it is positive, and the caller flushes pending lines
+// every Interval if it is positive. Buffers are also flushed whenever they are full.
+type FlushPolicy struct {
+	Every    int
+	Interval time.Duration
+
+	pending int
+}
+
+// Wrote records a line written to the buffer and returns whether the output should be flushed.
+func (p *FlushPolicy) Wrote() bool {
+	p.pending++
+	return p.Every > 0 && p.pending >= p.Every
+}
+
+// Pending returns whether lines were written since the last flush.
+func (p *FlushPolicy) Pending() bool {
+	return p.pending > 0
+}
+
+// Flushed records that the output was flushed.
+func (p *FlushPolicy) Flushed() {
+	p.pending = 0
+}
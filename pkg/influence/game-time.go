@@ -0,0 +1,96 @@
+package influence
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// In-game time on Adalia runs TIME_ACCELERATION times faster than real time, from the launch of the
+// game at ADALIAN_EPOCH (unix seconds). An Adalian day passes in 86400 / TIME_ACCELERATION = 3600
+// real seconds. Event time fields such as FinishTime hold chain (unix) timestamps in seconds.
+var (
+	ADALIAN_EPOCH     = uint64(1618668000)
+	TIME_ACCELERATION = uint64(24)
+)
+
+// ChainTime converts a chain timestamp to a time.
+func ChainTime(timestamp uint64) time.Time {
+	return time.Unix(int64(timestamp), 0).UTC()
+}
+
+// AdalianDays returns the in-game time of a chain timestamp as Adalian days since the epoch, the
+// way the game clock shows it. Timestamps before the epoch give negative days.
+func AdalianDays(timestamp uint64) float64 {
+	realSeconds := float64(timestamp) - float64(ADALIAN_EPOCH)
+	return realSeconds * float64(TIME_ACCELERATION) / 86400
+}
+
+// ChainTimestamp converts Adalian days since the epoch back to a chain timestamp.
+func ChainTimestamp(adalianDays float64) uint64 {
+	realSeconds := adalianDays * 86400 / float64(TIME_ACCELERATION)
+	if realSeconds < -float64(ADALIAN_EPOCH) {
+		return 0
+	}
+	return uint64(float64(ADALIAN_EPOCH) + realSeconds)
+}
+
+// AdalianDuration converts a real duration, e.g. between two chain timestamps, to in-game time.
+func AdalianDuration(real time.Duration) time.Duration {
+	return real * time.Duration(TIME_ACCELERATION)
+}
+
+// RealDuration converts an in-game duration to real time.
+func RealDuration(adalian time.Duration) time.Duration {
+	return adalian / time.Duration(TIME_ACCELERATION)
+}
+
+// GameTime is a chain timestamp with its time and in-game time.
+type GameTime struct {
+	Timestamp   uint64  `json:"timestamp"`
+	Time        string  `json:"time"`
+	AdalianDays float64 `json:"adalian_days"`
+}
+
+func NewGameTime(timestamp uint64) GameTime {
+	return GameTime{Timestamp: timestamp, Time: ChainTime(timestamp).Format(time.RFC3339), AdalianDays: AdalianDays(timestamp)}
+}
+
+// EventTimes returns the time fields of a parsed event, its top level uint64 fields named *Time
+// (e.g. FinishTime), by field name. Fields which are not set are left out.
+func EventTimes(event any) map[string]GameTime {
+	value := reflect.ValueOf(event)
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var times map[string]GameTime
+	for _, field := range timeFields(value.Type()) {
+		timestamp := value.FieldByName(field).Uint()
+		if timestamp == 0 {
+			continue
+		}
+		if times == nil {
+			times = make(map[string]GameTime)
+		}
+		times[field] = NewGameTime(timestamp)
+	}
+	return times
+}
+
+func timeFields(eventType reflect.Type) []string {
+	var fields []string
+	for i := 0; i < eventType.NumField(); i++ {
+		field := eventType.Field(i)
+		if field.Type.Kind() == reflect.Uint64 && strings.HasSuffix(field.Name, "Time") {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
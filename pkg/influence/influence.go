@@ -1,9 +1,9 @@
 // This file was generated by seer: https://github.com/moonstream-to/seer.
 // seer version: 0.1.1
-// seer command: seer starknet generate --package main
+// seer command: seer starknet generate --package influence
 // Warning: Edit at your own risk. Any edits you make will NOT survive the next code generation.
 
-package main
+package influence
 
 import (
 	"context"
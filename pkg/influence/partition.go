@@ -0,0 +1,77 @@
+package influence
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var PARTITION_BY_EVENT = "event"
+
+// PartitionFileName returns the name of the file which holds events with the given name in a
+// directory partitioned by event type. Namespaced event names (e.g.
+// influence::contracts::crew::Crew::Transfer) are written with dots instead of "::".
+func PartitionFileName(eventName string) string {
+	return fmt.Sprintf("%s.jsonl", strings.ReplaceAll(eventName, "::", "."))
+}
+
+// PartitionedWriter writes events into a directory with one JSON lines file per event type.
+type PartitionedWriter struct {
+	Dir        string
+	AppendMode bool
+
+	files map[string]*os.File
+}
+
+func NewPartitionedWriter(dir string, appendMode bool) (*PartitionedWriter, error) {
+	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
+		return nil, mkdirErr
+	}
+	return &PartitionedWriter{Dir: dir, AppendMode: appendMode, files: make(map[string]*os.File)}, nil
+}
+
+func (w *PartitionedWriter) Write(eventName string, line []byte) error {
+	ofp, ok := w.files[eventName]
+	if !ok {
+		flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if w.AppendMode {
+			flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		var openErr error
+		ofp, openErr = os.OpenFile(filepath.Join(w.Dir, PartitionFileName(eventName)), flag, 0644)
+		if openErr != nil {
+			return openErr
+		}
+		w.files[eventName] = ofp
+	}
+
+	if _, writeErr := ofp.Write(line); writeErr != nil {
+		return writeErr
+	}
+	_, writeErr := ofp.Write([]byte("\n"))
+	return writeErr
+}
+
+func (w *PartitionedWriter) Close() error {
+	var closeErr error
+	for _, ofp := range w.files {
+		if err := ofp.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// EventFilePaths lists the files which make up an events input. A regular file is returned as is,
+// a directory partitioned by event type is expanded into its files in lexical order.
+func EventFilePaths(filePath string) ([]string, error) {
+	info, statErr := os.Stat(filePath)
+	if statErr != nil {
+		return nil, statErr
+	}
+	if !info.IsDir() {
+		return []string{filePath}, nil
+	}
+	return filepath.Glob(filepath.Join(filePath, "*.jsonl"))
+}
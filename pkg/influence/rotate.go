@@ -0,0 +1,197 @@
+package influence
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Multipliers of size suffixes accepted by ParseSize.
+var SIZE_UNITS = map[string]int64{
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// ParseSize parses sizes such as "1GB", "500MB" or a plain number of bytes.
+func ParseSize(sizeString string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(sizeString))
+	multiplier := int64(1)
+	for suffix, unitSize := range SIZE_UNITS {
+		if strings.HasSuffix(value, suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, suffix))
+			multiplier = unitSize
+			break
+		}
+	}
+	value = strings.TrimSuffix(value, "B")
+	size, parseErr := strconv.ParseInt(value, 10, 64)
+	if parseErr != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %s, expected e.g. 1GB, 500MB or a number of bytes", sizeString)
+	}
+	return size * multiplier, nil
+}
+
+// Segment is a file written by RotatingWriter and the range of blocks of the events in it.
+type Segment struct {
+	File      string `json:"file"`
+	FromBlock uint64 `json:"from_block"`
+	ToBlock   uint64 `json:"to_block"`
+	Events    uint64 `json:"events"`
+	Started   string `json:"started"`
+}
+
+// Number of events after which the index is rewritten, so the range of the current segment is
+// recorded even if the crawl is killed.
+var INDEX_FLUSH_EVENTS uint64 = 1000
+
+// RotatingWriter writes event lines into numbered files, starting a new file once the current one
+// reaches MaxSize bytes or, if Daily is set, when the UTC date changes. The segments written so far
+// are listed in a JSON index file. Lines are buffered until Flush, see FlushPolicy.
+type RotatingWriter struct {
+	// Pattern of file names with a %d placeholder for the segment number, e.g. events-%d.jsonl
+	Pattern   string
+	MaxSize   int64
+	Daily     bool
+	IndexPath string
+
+	Segments []Segment
+
+	current *os.File
+	buffer  *bufio.Writer
+	size    int64
+	day     string
+}
+
+// IndexPathFor returns the path of the index of segments written with the pattern, e.g.
+// events-index.json for events-%d.jsonl.
+func IndexPathFor(pattern string) string {
+	indexPath := strings.Replace(pattern, "%d", "index", 1)
+	if strings.HasSuffix(indexPath, ".jsonl") {
+		indexPath = strings.TrimSuffix(indexPath, ".jsonl") + ".json"
+	}
+	return indexPath
+}
+
+// NewRotatingWriter continues after the segments listed in an existing index, so restarted crawls
+// do not overwrite earlier segments.
+func NewRotatingWriter(pattern string, maxSize int64, daily bool) (*RotatingWriter, error) {
+	if strings.Count(pattern, "%d") != 1 {
+		return nil, fmt.Errorf("output file pattern %s should contain exactly one %%d placeholder for the segment number", pattern)
+	}
+	if maxSize == 0 && !daily {
+		return nil, errors.New("rotating output needs a maximum segment size or daily rotation")
+	}
+
+	w := &RotatingWriter{Pattern: pattern, MaxSize: maxSize, Daily: daily, IndexPath: IndexPathFor(pattern)}
+	indexData, readErr := os.ReadFile(w.IndexPath)
+	if readErr == nil {
+		if unmErr := json.Unmarshal(indexData, &w.Segments); unmErr != nil {
+			return nil, fmt.Errorf("Unable to parse index %s, err: %v", w.IndexPath, unmErr)
+		}
+	} else if !os.IsNotExist(readErr) {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", w.IndexPath, readErr)
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) rotate(now time.Time) error {
+	if w.current != nil {
+		if flushErr := w.buffer.Flush(); flushErr != nil {
+			return flushErr
+		}
+		if closeErr := w.current.Close(); closeErr != nil {
+			return closeErr
+		}
+	}
+
+	fileName := fmt.Sprintf(w.Pattern, len(w.Segments))
+	ofp, openErr := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if openErr != nil {
+		return openErr
+	}
+	w.current = ofp
+	w.buffer = bufio.NewWriter(ofp)
+	w.size = 0
+	w.day = now.UTC().Format("2006-01-02")
+	w.Segments = append(w.Segments, Segment{File: fileName, Started: now.UTC().Format(time.RFC3339)})
+	return w.WriteIndex()
+}
+
+func (w *RotatingWriter) Write(blockNumber uint64, line []byte) error {
+	now := time.Now()
+	needsRotation := w.current == nil ||
+		(w.MaxSize > 0 && w.size > 0 && w.size+int64(len(line))+1 > w.MaxSize) ||
+		(w.Daily && now.UTC().Format("2006-01-02") != w.day)
+	if needsRotation {
+		if rotateErr := w.rotate(now); rotateErr != nil {
+			return rotateErr
+		}
+	}
+
+	if _, writeErr := w.buffer.Write(line); writeErr != nil {
+		return writeErr
+	}
+	if writeErr := w.buffer.WriteByte('\n'); writeErr != nil {
+		return writeErr
+	}
+	w.size += int64(len(line)) + 1
+
+	segment := &w.Segments[len(w.Segments)-1]
+	if segment.Events == 0 || blockNumber < segment.FromBlock {
+		segment.FromBlock = blockNumber
+	}
+	if blockNumber > segment.ToBlock {
+		segment.ToBlock = blockNumber
+	}
+	segment.Events++
+	if segment.Events%INDEX_FLUSH_EVENTS == 0 {
+		// Events listed in the index are in the segment file
+		if flushErr := w.buffer.Flush(); flushErr != nil {
+			return flushErr
+		}
+		return w.WriteIndex()
+	}
+	return nil
+}
+
+// Flush writes buffered lines to the current segment file.
+func (w *RotatingWriter) Flush() error {
+	if w.buffer == nil {
+		return nil
+	}
+	return w.buffer.Flush()
+}
+
+// WriteIndex replaces the index file with the current list of segments.
+func (w *RotatingWriter) WriteIndex() error {
+	indexData, marshErr := json.MarshalIndent(w.Segments, "", "  ")
+	if marshErr != nil {
+		return marshErr
+	}
+	tempPath := w.IndexPath + ".tmp"
+	if writeErr := os.WriteFile(tempPath, indexData, 0644); writeErr != nil {
+		return writeErr
+	}
+	return os.Rename(tempPath, w.IndexPath)
+}
+
+func (w *RotatingWriter) Close() error {
+	if w.current == nil {
+		return nil
+	}
+	if flushErr := w.buffer.Flush(); flushErr != nil {
+		return flushErr
+	}
+	if closeErr := w.current.Close(); closeErr != nil {
+		return closeErr
+	}
+	w.current = nil
+	return w.WriteIndex()
+}
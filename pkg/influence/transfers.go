@@ -0,0 +1,99 @@
+package influence
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Transfer events of the token contracts by contract name. All of them have the same selector, so
+// EventParser.Parse decodes every Transfer as the first of them in the ABI, the asteroid Transfer.
+var TRANSFER_EVENTS = map[string]string{
+	"asteroid": Event_Influence_Contracts_Asteroid_Asteroid_Transfer,
+	"crew":     Event_Influence_Contracts_Crew_Crew_Transfer,
+	"crewmate": Event_Influence_Contracts_Crewmate_Crewmate_Transfer,
+	"ship":     Event_Influence_Contracts_Ship_Ship_Transfer,
+	"sway":     Event_Influence_Contracts_Sway_Sway_Transfer,
+}
+
+// TransferResolver names Transfer events by the token contract which emitted them, e.g. a Transfer
+// of the Crewmate contract as a crewmate Transfer rather than an asteroid Transfer.
+type TransferResolver struct {
+	contracts map[felt.Felt]string
+}
+
+// NewTransferResolver takes the names of token contracts (see TRANSFER_EVENTS) by address.
+func NewTransferResolver(contracts map[string]string) (*TransferResolver, error) {
+	resolver := &TransferResolver{contracts: make(map[felt.Felt]string, len(contracts))}
+	for address, name := range contracts {
+		if setErr := resolver.SetContract(name, address); setErr != nil {
+			return nil, setErr
+		}
+	}
+	return resolver, nil
+}
+
+// SetContract names the Transfer events of the address after the token contract, replacing the
+// contract it was set to before.
+func (r *TransferResolver) SetContract(name, address string) error {
+	if _, ok := TRANSFER_EVENTS[name]; !ok {
+		return fmt.Errorf("unknown token contract %s, known token contracts: %s", name, strings.Join(TransferContractNames(), ", "))
+	}
+	addressFelt, parseErr := FeltFromHexString(address)
+	if parseErr != nil {
+		return fmt.Errorf("invalid address %s of token contract %s: %v", address, name, parseErr)
+	}
+	r.contracts[*addressFelt] = name
+	return nil
+}
+
+// TransferContractNames returns the names of the token contracts in alphabetical order.
+func TransferContractNames() []string {
+	names := make([]string, 0, len(TRANSFER_EVENTS))
+	for name := range TRANSFER_EVENTS {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve decodes the Transfer event again as the Transfer of the contract it was emitted by.
+// Other events, and Transfer events of unknown contracts, are returned as they were parsed.
+func (r *TransferResolver) Resolve(event RawEvent, parsed ParsedEvent) (ParsedEvent, error) {
+	if parsed.Name != Event_Influence_Contracts_Asteroid_Asteroid_Transfer || event.FromAddress == nil {
+		return parsed, nil
+	}
+
+	var resolved any
+	var parseErr error
+	switch r.contracts[*event.FromAddress] {
+	case "crew":
+		var transfer Influence_Contracts_Crew_Crew_Transfer
+		transfer, _, parseErr = ParseInfluence_Contracts_Crew_Crew_Transfer(event.Parameters)
+		transfer.BlockNumber = event.BlockNumber
+		resolved = transfer
+	case "crewmate":
+		var transfer Influence_Contracts_Crewmate_Crewmate_Transfer
+		transfer, _, parseErr = ParseInfluence_Contracts_Crewmate_Crewmate_Transfer(event.Parameters)
+		transfer.BlockNumber = event.BlockNumber
+		resolved = transfer
+	case "ship":
+		var transfer Influence_Contracts_Ship_Ship_Transfer
+		transfer, _, parseErr = ParseInfluence_Contracts_Ship_Ship_Transfer(event.Parameters)
+		transfer.BlockNumber = event.BlockNumber
+		resolved = transfer
+	case "sway":
+		var transfer Influence_Contracts_Sway_Sway_Transfer
+		transfer, _, parseErr = ParseInfluence_Contracts_Sway_Sway_Transfer(event.Parameters)
+		transfer.BlockNumber = event.BlockNumber
+		resolved = transfer
+	default:
+		return parsed, nil
+	}
+	if parseErr != nil {
+		return ParsedEvent{Name: EVENT_UNKNOWN, Event: event}, NewDecodeError(event, parseErr)
+	}
+	return ParsedEvent{Name: TRANSFER_EVENTS[r.contracts[*event.FromAddress]], Event: resolved}, nil
+}
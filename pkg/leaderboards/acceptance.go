@@ -0,0 +1,117 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/chaos"
+)
+
+// The portal may lag behind a push briefly, so the check is retried before the push is reported
+// as diverged.
+var (
+	VERIFY_PUSH_ATTEMPTS = 3
+	VERIFY_PUSH_DELAY    = 2 * time.Second
+)
+
+// Allowed difference between the local clock and the clock of the portal when comparing the last
+// update of a leaderboard against the time of the push.
+var VERIFY_PUSH_CLOCK_SKEW = time.Minute
+
+// LeaderboardInfo is the summary of a leaderboard returned by the Moonstream.to portal.
+type LeaderboardInfo struct {
+	Id            string `json:"id"`
+	Title         string `json:"title"`
+	UsersCount    uint64 `json:"users_count"`
+	LastUpdatedAt string `json:"last_updated_at"`
+}
+
+// Layouts of last_updated_at timestamps, the portal omits the time zone of UTC timestamps.
+var portalTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999", "2006-01-02 15:04:05.999999"}
+
+// LastUpdated parses the time of the last update of the leaderboard.
+func (i LeaderboardInfo) LastUpdated() (time.Time, error) {
+	for _, layout := range portalTimeLayouts {
+		if updatedAt, parseErr := time.Parse(layout, i.LastUpdatedAt); parseErr == nil {
+			return updatedAt, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid last_updated_at %q of leaderboard %s", i.LastUpdatedAt, i.Id)
+}
+
+// FetchLeaderboardInfo returns the entry count and last update of the leaderboard at the
+// Moonstream.to portal.
+func FetchLeaderboardInfo(apiURL, accessToken, leaderboardId string) (LeaderboardInfo, error) {
+	var info LeaderboardInfo
+
+	request, requestErr := http.NewRequest("GET", fmt.Sprintf("%s/leaderboard/info?leaderboard_id=%s", PortalAPIURL(apiURL), leaderboardId), nil)
+	if requestErr != nil {
+		return info, fmt.Errorf("error making requests: %v", requestErr)
+	}
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	request.Header.Add("Accept", "application/json")
+
+	httpClient := http.Client{Timeout: 10 * time.Second, Transport: chaos.APITransport()}
+	response, responseErr := httpClient.Do(request)
+	if responseErr != nil {
+		return info, fmt.Errorf("error parsing response: %v", responseErr)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return info, fmt.Errorf("unable to get info of leaderboard %s, status code: %d", leaderboardId, response.StatusCode)
+	}
+	if decodeErr := json.NewDecoder(response.Body).Decode(&info); decodeErr != nil {
+		return info, fmt.Errorf("error parsing response: %v", decodeErr)
+	}
+	return info, nil
+}
+
+// PushedEntries returns the number of leaderboard entries the scores make up, scores of the same
+// address replace each other.
+func PushedEntries(scores []LeaderboardScore) uint64 {
+	addresses := make(map[string]bool, len(scores))
+	for _, score := range scores {
+		addresses[score.Address] = true
+	}
+	return uint64(len(addresses))
+}
+
+func checkLeaderboardPush(apiURL, accessToken, leaderboardId string, entries uint64, pushedAt time.Time) error {
+	info, infoErr := FetchLeaderboardInfo(apiURL, accessToken, leaderboardId)
+	if infoErr != nil {
+		return infoErr
+	}
+	if info.UsersCount != entries {
+		return fmt.Errorf("leaderboard %s has %d entries at the portal, %d were pushed", leaderboardId, info.UsersCount, entries)
+	}
+	updatedAt, timeErr := info.LastUpdated()
+	if timeErr != nil {
+		return timeErr
+	}
+	if updatedAt.Before(pushedAt.Add(-VERIFY_PUSH_CLOCK_SKEW)) {
+		return fmt.Errorf("leaderboard %s was last updated at %s, before the push at %s", leaderboardId, info.LastUpdatedAt, pushedAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// VerifyLeaderboardPush checks that the entry count of the leaderboard at the portal matches the
+// pushed entries and that it was updated no earlier than the push, retrying while the portal
+// catches up.
+func VerifyLeaderboardPush(apiURL, accessToken, leaderboardId string, entries uint64, pushedAt time.Time) error {
+	var checkErr error
+	for attempt := 1; attempt <= VERIFY_PUSH_ATTEMPTS; attempt++ {
+		checkErr = checkLeaderboardPush(apiURL, accessToken, leaderboardId, entries, pushedAt)
+		if checkErr == nil {
+			return nil
+		}
+		if attempt < VERIFY_PUSH_ATTEMPTS {
+			log.Printf("Push to leaderboard %s not visible yet, retrying check: %v", leaderboardId, checkErr)
+			time.Sleep(VERIFY_PUSH_DELAY)
+		}
+	}
+	return fmt.Errorf("portal state of leaderboard %s diverges from the push: %v", leaderboardId, checkErr)
+}
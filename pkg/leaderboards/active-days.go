@@ -0,0 +1,139 @@
+package leaderboards
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// DayStart returns the start of the UTC day (00:00 UTC) of the timestamp.
+func DayStart(timestamp uint64) time.Time {
+	t := time.Unix(int64(timestamp), 0).UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// LongestStreak returns the largest number of consecutive days in a set of day starts.
+func LongestStreak(days map[time.Time]bool) int {
+	longest := 0
+	for day := range days {
+		// Streaks are only counted from their first day
+		if days[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		streak := 1
+		for days[day.AddDate(0, 0, streak)] {
+			streak++
+		}
+		if streak > longest {
+			longest = streak
+		}
+	}
+	return longest
+}
+
+// GenerateActiveDaysToScores ranks crews by the number of distinct UTC days on which they emitted
+// any event in the block window. Days are taken from block timestamps, events crawled without
+// --block-timestamps can not be placed in a day and are not counted.
+func GenerateActiveDaysToScores(ctx context.Context, opts MissionOptions, filePath string, window BlockWindow) ([]LeaderboardScore, error) {
+	files, filesErr := influence.EventFilePaths(filePath)
+	if filesErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, filesErr)
+	}
+
+	activeDays := make(map[uint64]map[time.Time]bool)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	untimed := 0
+
+	for _, file := range files {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		inputFile, openErr := os.Open(file)
+		if openErr != nil {
+			return nil, fmt.Errorf("Unable to read file %s, err: %v", file, openErr)
+		}
+
+		scanner := bufio.NewScanner(inputFile)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var line PartialEventLine
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+
+			var event struct {
+				BlockNumber uint64
+				CallerCrew  *influence.Influence_Common_Types_Entity_Entity
+			}
+			if unmErr := json.Unmarshal(line.Event, &event); unmErr != nil || event.CallerCrew == nil {
+				continue
+			}
+			if event.CallerCrew.Label != influence.CREW_ENTITY_LABEL || event.CallerCrew.Id == 0 || !window.Contains(event.BlockNumber) {
+				continue
+			}
+			if line.Timestamp == 0 {
+				untimed++
+				continue
+			}
+
+			crew := event.CallerCrew.Id
+			if activeDays[crew] == nil {
+				activeDays[crew] = make(map[time.Time]bool)
+			}
+			activeDays[crew][DayStart(line.Timestamp)] = true
+			eventCounts[crew]++
+			transactions.Record(crew, line.TransactionHash)
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return nil, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	if untimed > 0 {
+		log.Printf("Skipped %d crew events without a block timestamp, crawl with --block-timestamps to count them", untimed)
+	}
+	EmitAggregation(opts, "active_days_by_crew", activeDays)
+
+	scores := []LeaderboardScore{}
+	for crew, days := range activeDays {
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(days)),
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(len(days) > 0),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " day(s)",
+					AddressName: "Crew",
+				},
+				Extra: map[string]any{
+					"longest_streak": LongestStreak(days),
+				},
+			},
+		})
+	}
+	return scores, nil
+}
+
+// LActiveDays is the streak leaderboard of days crews were active on in the block window, set with
+// flags of the active-days command.
+func LActiveDays(window BlockWindow) LeaderboardCommandCreator {
+	return func(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+		scores, scoresErr := GenerateActiveDaysToScores(ctx, opts, *infile, window)
+		if scoresErr != nil {
+			return scoresErr
+		}
+
+		return PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	}
+}
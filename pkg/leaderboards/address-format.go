@@ -0,0 +1,63 @@
+package leaderboards
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Formats of the addresses of scores, set with the --address-format flag or the address_format of
+// leaderboards map targets. Addresses are left as the generators write them, decimal crew and
+// asteroid IDs and hex wallets, if no format is set.
+const (
+	ADDRESS_FORMAT_DECIMAL    = "decimal"
+	ADDRESS_FORMAT_HEX        = "hex"
+	ADDRESS_FORMAT_PADDED_HEX = "padded-hex"
+)
+
+func CheckAddressFormat(format string) error {
+	switch format {
+	case "", ADDRESS_FORMAT_DECIMAL, ADDRESS_FORMAT_HEX, ADDRESS_FORMAT_PADDED_HEX:
+		return nil
+	}
+	return fmt.Errorf("unknown address format %s, supported formats: %s, %s, %s", format, ADDRESS_FORMAT_DECIMAL, ADDRESS_FORMAT_HEX, ADDRESS_FORMAT_PADDED_HEX)
+}
+
+// FormatAddress writes a hex or decimal address in the format. Other addresses, such as team
+// labels and the community entry, are returned unchanged.
+func FormatAddress(address, format string) string {
+	if format == "" {
+		return address
+	}
+
+	value := new(big.Int)
+	var ok bool
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		_, ok = value.SetString(address[2:], 16)
+	} else if address != "" && strings.Trim(address, "0123456789") == "" {
+		_, ok = value.SetString(address, 10)
+	}
+	if !ok {
+		return address
+	}
+
+	switch format {
+	case ADDRESS_FORMAT_DECIMAL:
+		return value.Text(10)
+	case ADDRESS_FORMAT_HEX:
+		return "0x" + value.Text(16)
+	case ADDRESS_FORMAT_PADDED_HEX:
+		return fmt.Sprintf("0x%064x", value)
+	}
+	return address
+}
+
+// FormatAddresses writes the addresses of the scores in the format.
+func FormatAddresses(scores []LeaderboardScore, format string) {
+	if format == "" {
+		return
+	}
+	for i := range scores {
+		scores[i].Address = FormatAddress(scores[i].Address, format)
+	}
+}
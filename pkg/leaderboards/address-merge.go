@@ -0,0 +1,130 @@
+package leaderboards
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Policies for scores of the same address, set with the --duplicate-addresses flag.
+const (
+	// Duplicates are merged into one entry with the sum of their scores and event counts
+	ADDRESS_MERGE_SUM = "sum"
+	// The highest score of the address is kept
+	ADDRESS_MERGE_MAX = "max"
+	// The mission fails
+	ADDRESS_MERGE_ERROR = "error"
+)
+
+func CheckAddressMergePolicy(policy string) error {
+	switch policy {
+	case ADDRESS_MERGE_SUM, ADDRESS_MERGE_MAX, ADDRESS_MERGE_ERROR:
+		return nil
+	}
+	return fmt.Errorf("unknown --duplicate-addresses policy %s, supported policies: %s, %s, %s", policy, ADDRESS_MERGE_SUM, ADDRESS_MERGE_MAX, ADDRESS_MERGE_ERROR)
+}
+
+// NormalizeAddress returns the key under which scores of an address are merged. Hex addresses are
+// compared case insensitively and without leading zeros, decimal IDs without leading zeros.
+func NormalizeAddress(address string) string {
+	lower := strings.ToLower(address)
+	if strings.HasPrefix(lower, "0x") {
+		digits := strings.TrimLeft(lower[2:], "0")
+		if digits == "" {
+			digits = "0"
+		}
+		return "0x" + digits
+	}
+	if address != "" && strings.Trim(address, "0123456789") == "" {
+		digits := strings.TrimLeft(address, "0")
+		if digits == "" {
+			digits = "0"
+		}
+		return digits
+	}
+	return address
+}
+
+// MergeDuplicateAddresses applies the policy to scores of the same normalized address. Merged
+// entries keep the address and position of the first score of the address, the portal would
+// otherwise keep one of them depending on the order it processes them in.
+func MergeDuplicateAddresses(scores []LeaderboardScore, policy string) ([]LeaderboardScore, error) {
+	merged := make([]LeaderboardScore, 0, len(scores))
+	positions := make(map[string]int, len(scores))
+	duplicates := 0
+	for _, score := range scores {
+		key := NormalizeAddress(score.Address)
+		i, seen := positions[key]
+		if !seen {
+			positions[key] = len(merged)
+			merged = append(merged, score)
+			continue
+		}
+
+		duplicates++
+		switch policy {
+		case ADDRESS_MERGE_ERROR:
+			return nil, fmt.Errorf("address %s has several scores (%d and %d), pass --duplicate-addresses sum or max to merge them", score.Address, merged[i].Score, score.Score)
+		case ADDRESS_MERGE_MAX:
+			if score.Score > merged[i].Score {
+				address := merged[i].Address
+				merged[i] = score
+				merged[i].Address = address
+			}
+		default:
+			merged[i] = SumScores(merged[i], score)
+		}
+	}
+	if duplicates > 0 {
+		log.Printf("Merged %d duplicate scores of %d addresses with the %s policy", duplicates, len(merged), policy)
+	}
+	return merged, nil
+}
+
+// SumScores combines two scores of the same entry. Scores, event counts and the progress of
+// requirements add up, and completion is recomputed from the combined requirements. Other points
+// data is that of the higher score.
+func SumScores(a, b LeaderboardScore) LeaderboardScore {
+	sum := a
+	if b.Score > a.Score {
+		sum = b
+		sum.Address = a.Address
+	}
+	sum.Score = a.Score + b.Score
+	if sum.Score < a.Score {
+		sum.Score = ^uint64(0)
+	}
+	sum.EventCount = a.EventCount + b.EventCount
+	sum.Requirements = SumRequirements(a.Requirements, b.Requirements)
+
+	aComplete := a.PointsData.Complete != nil && *a.PointsData.Complete
+	bComplete := b.PointsData.Complete != nil && *b.PointsData.Complete
+	if len(sum.Requirements) > 0 {
+		sum.PointsData.Complete = Completed(RequirementsMet(sum.Requirements))
+	} else if a.PointsData.Complete != nil || b.PointsData.Complete != nil {
+		sum.PointsData.Complete = Completed(aComplete || bComplete)
+	}
+	return sum
+}
+
+// SumRequirements adds up the progress of requirements with the same name.
+func SumRequirements(a, b []Requirement) []Requirement {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	sum := make([]Requirement, 0, len(a)+len(b))
+	positions := make(map[string]int, len(a)+len(b))
+	for _, requirement := range append(append([]Requirement{}, a...), b...) {
+		i, ok := positions[requirement.Name]
+		if !ok {
+			positions[requirement.Name] = len(sum)
+			sum = append(sum, requirement)
+			continue
+		}
+		sum[i].Have += requirement.Have
+		if requirement.Need > sum[i].Need {
+			sum[i].Need = requirement.Need
+		}
+	}
+	return sum
+}
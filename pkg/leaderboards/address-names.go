@@ -0,0 +1,64 @@
+package leaderboards
+
+import "strings"
+
+// Aggregation modes of missions: what the addresses of their scores stand for.
+const (
+	AGGREGATION_CREW     = "crew"
+	AGGREGATION_WALLET   = "wallet"
+	AGGREGATION_ASTEROID = "asteroid"
+)
+
+// AGGREGATION_ADDRESS_NAMES are the entity labels shown as address_name for each aggregation mode.
+var AGGREGATION_ADDRESS_NAMES = map[string]string{
+	AGGREGATION_CREW:     "Crew",
+	AGGREGATION_WALLET:   "Wallet",
+	AGGREGATION_ASTEROID: "Asteroid ID",
+}
+
+// AddressNames labels the addresses of a leaderboard's scores. AddressName replaces the label set
+// by the generator. Otherwise scores without a label get the label of Aggregation, or if it is not
+// set, of the address: wallets for 0x addresses and crews for IDs.
+type AddressNames struct {
+	Aggregation string
+	AddressName string
+}
+
+// MissionAddressNames returns the address names of the mission from the registry.
+func MissionAddressNames(lm LeaderboardCommandFunc) AddressNames {
+	return AddressNames{Aggregation: lm.Aggregation, AddressName: lm.AddressName}
+}
+
+// Label returns the entity label of the address.
+func (n AddressNames) Label(address string) string {
+	if n.AddressName != "" {
+		return n.AddressName
+	}
+	if name, ok := AGGREGATION_ADDRESS_NAMES[n.Aggregation]; ok {
+		return name
+	}
+	if strings.HasPrefix(address, "0x") {
+		return AGGREGATION_ADDRESS_NAMES[AGGREGATION_WALLET]
+	}
+	return AGGREGATION_ADDRESS_NAMES[AGGREGATION_CREW]
+}
+
+// Apply sets the address_name of the scores. The community entry and the entries of the teams of
+// groups keep their own label.
+func (n AddressNames) Apply(scores []LeaderboardScore, groups *CrewGroups) {
+	for i, score := range scores {
+		if score.Address == COMMUNITY_ADDRESS || groups.IsTeam(score.Address) {
+			continue
+		}
+
+		details := ScoreDetails{}
+		if score.PointsData.ScoreDetails != nil {
+			details = *score.PointsData.ScoreDetails
+		}
+		if details.AddressName != "" && n.AddressName == "" {
+			continue
+		}
+		details.AddressName = n.Label(score.Address)
+		scores[i].PointsData.ScoreDetails = &details
+	}
+}
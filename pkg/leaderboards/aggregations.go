@@ -0,0 +1,119 @@
+package leaderboards
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Writer of the intermediate aggregations of generators, set with the --aggregations-outfile flag.
+// Aggregations are not written if it is nil.
+var AGGREGATIONS_WRITER *AggregationsWriter
+
+// AggregationRecord is a line of the aggregations stream: the value of one key of an aggregation
+// map of a generator, e.g. the set of crews on an asteroid or the extracted yield of a crew.
+type AggregationRecord struct {
+	Mission     string `json:"mission"`
+	Aggregation string `json:"aggregation"`
+	Key         string `json:"key"`
+	Value       any    `json:"value"`
+}
+
+// AggregationsWriter writes aggregation records as JSON lines.
+type AggregationsWriter struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func NewAggregationsWriter(filePath string) (*AggregationsWriter, error) {
+	file, createErr := os.Create(filePath)
+	if createErr != nil {
+		return nil, fmt.Errorf("Unable to create file %s, err: %v", filePath, createErr)
+	}
+	return &AggregationsWriter{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (w *AggregationsWriter) Write(record AggregationRecord) error {
+	line, marshErr := json.Marshal(record)
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling aggregation %s: %v", record.Aggregation, marshErr)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writer.Write(line)
+	return w.writer.WriteByte('\n')
+}
+
+func (w *AggregationsWriter) Close() error {
+	flushErr := w.writer.Flush()
+	closeErr := w.file.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+func SetAggregationsOutfile(filePath string) error {
+	if filePath == "" {
+		AGGREGATIONS_WRITER = nil
+		return nil
+	}
+	writer, writerErr := NewAggregationsWriter(filePath)
+	if writerErr != nil {
+		return writerErr
+	}
+	AGGREGATIONS_WRITER = writer
+	return nil
+}
+
+// aggregationValue turns sets, maps of keys to true, into sorted lists of their keys.
+func aggregationValue(value any) any {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Elem().Kind() != reflect.Bool {
+		return value
+	}
+	members := []string{}
+	iter := v.MapRange()
+	for iter.Next() {
+		if iter.Value().Bool() {
+			members = append(members, fmt.Sprint(iter.Key().Interface()))
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// EmitAggregation writes every key of an aggregation map of the mission of opts to
+// AGGREGATIONS_WRITER, ordered by key, so dashboards can compute their own views of the events
+// the mission matched. Does nothing if aggregations are not written.
+func EmitAggregation[K comparable, V any](opts MissionOptions, aggregation string, values map[K]V) {
+	if AGGREGATIONS_WRITER == nil {
+		return
+	}
+
+	keys := make([]K, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+	for _, key := range keys {
+		record := AggregationRecord{
+			Mission:     opts.Mission,
+			Aggregation: aggregation,
+			Key:         fmt.Sprint(key),
+			Value:       aggregationValue(values[key]),
+		}
+		if writeErr := AGGREGATIONS_WRITER.Write(record); writeErr != nil {
+			// Aggregations are a side output, they never fail the mission
+			log.Printf("Unable to write aggregation %s of %s, err: %v", aggregation, opts.Mission, writeErr)
+			return
+		}
+	}
+}
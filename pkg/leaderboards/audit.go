@@ -0,0 +1,183 @@
+package leaderboards
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/user"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// File to append a record of every leaderboard push to, set with the --audit-log flag. Pushes are
+// not recorded if it is empty.
+var AUDIT_LOG = "leaderboards-audit.jsonl"
+
+// URL to POST audit records to in addition to the local log, set with the --audit-upload-url flag.
+var AUDIT_UPLOAD_URL = ""
+
+// Version of the program pushing scores, recorded in audit records.
+var VERSION = ""
+
+// Events file the pushed scores were generated from, recorded with its hash and block range.
+var AUDIT_INPUT_FILE = ""
+
+// AuditRecord describes a push of scores to a leaderboard, so contested results can be traced
+// back to the run and the events they were computed from.
+type AuditRecord struct {
+	Time           string `json:"time"`
+	User           string `json:"user"`
+	Host           string `json:"host"`
+	Version        string `json:"version"`
+	Revision       string `json:"revision,omitempty"`
+	LeaderboardId  string `json:"leaderboard_id"`
+	InputFile      string `json:"input_file,omitempty"`
+	InputHash      string `json:"input_hash,omitempty"`
+	FromBlock      uint64 `json:"from_block,omitempty"`
+	ToBlock        uint64 `json:"to_block,omitempty"`
+	ScoresCount    int    `json:"scores_count"`
+	ScoresHash     string `json:"scores_hash"`
+	Overwrite      bool   `json:"overwrite"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	Response       string `json:"response,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type auditInput struct {
+	hash      string
+	fromBlock uint64
+	toBlock   uint64
+}
+
+var (
+	auditInputsMu sync.Mutex
+	// Inputs are hashed once per run, as all leaderboards are usually generated from one file
+	auditInputs = make(map[string]auditInput)
+)
+
+func hashAuditInput(infile string) (auditInput, error) {
+	auditInputsMu.Lock()
+	defer auditInputsMu.Unlock()
+
+	if input, ok := auditInputs[infile]; ok {
+		return input, nil
+	}
+	hash, fromBlock, toBlock, hashErr := HashEventsInput(infile)
+	if hashErr != nil {
+		return auditInput{}, hashErr
+	}
+	input := auditInput{hash: hash, fromBlock: fromBlock, toBlock: toBlock}
+	auditInputs[infile] = input
+	return input, nil
+}
+
+// BuildRevision returns the git commit the binary was built from, if it is known.
+func BuildRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	revision := ""
+	modified := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" && modified {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// NewAuditRecord describes a push of the scores body to the leaderboard by the current run.
+func NewAuditRecord(leaderboardId string, body []byte) AuditRecord {
+	record := AuditRecord{
+		Time:          time.Now().UTC().Format(time.RFC3339),
+		Version:       VERSION,
+		Revision:      BuildRevision(),
+		LeaderboardId: leaderboardId,
+	}
+
+	if currentUser, userErr := user.Current(); userErr == nil {
+		record.User = currentUser.Username
+	} else {
+		record.User = os.Getenv("USER")
+	}
+	record.Host, _ = os.Hostname()
+
+	var scores []json.RawMessage
+	if unmErr := json.Unmarshal(body, &scores); unmErr == nil {
+		record.ScoresCount = len(scores)
+	}
+	scoresHash := sha256.Sum256(body)
+	record.ScoresHash = hex.EncodeToString(scoresHash[:])
+
+	if AUDIT_INPUT_FILE != "" {
+		record.InputFile = AUDIT_INPUT_FILE
+		if input, inputErr := hashAuditInput(AUDIT_INPUT_FILE); inputErr == nil {
+			record.InputHash = input.hash
+			record.FromBlock = input.fromBlock
+			record.ToBlock = input.toBlock
+		} else {
+			log.Printf("Unable to hash input file for audit log, err: %v", inputErr)
+		}
+	}
+
+	return record
+}
+
+// WriteAuditRecord appends the record to AUDIT_LOG and uploads it to AUDIT_UPLOAD_URL. Failures
+// are logged, they do not fail the push.
+func WriteAuditRecord(record AuditRecord) {
+	line, marshErr := json.Marshal(record)
+	if marshErr != nil {
+		log.Printf("Unable to marshal audit record, err: %v", marshErr)
+		return
+	}
+
+	if AUDIT_LOG != "" {
+		ofp, openErr := os.OpenFile(AUDIT_LOG, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if openErr != nil {
+			log.Printf("Unable to open audit log %s, err: %v", AUDIT_LOG, openErr)
+		} else {
+			if _, writeErr := ofp.Write(append(line, '\n')); writeErr != nil {
+				log.Printf("Unable to write audit log %s, err: %v", AUDIT_LOG, writeErr)
+			}
+			ofp.Close()
+		}
+	}
+
+	if AUDIT_UPLOAD_URL != "" {
+		httpClient := http.Client{Timeout: 10 * time.Second}
+		response, responseErr := httpClient.Post(AUDIT_UPLOAD_URL, "application/json", bytes.NewReader(line))
+		if responseErr != nil {
+			log.Printf("Unable to upload audit record, err: %v", responseErr)
+			return
+		}
+		response.Body.Close()
+		if response.StatusCode >= 300 {
+			log.Printf("Unable to upload audit record, status code: %d", response.StatusCode)
+		}
+	}
+}
+
+// Maximum length of push responses kept in audit records.
+var AUDIT_RESPONSE_LIMIT = 4096
+
+func truncateAuditResponse(response []byte) string {
+	if len(response) > AUDIT_RESPONSE_LIMIT {
+		return fmt.Sprintf("%s... (%d bytes)", response[:AUDIT_RESPONSE_LIMIT], len(response))
+	}
+	return string(response)
+}
@@ -0,0 +1,157 @@
+package leaderboards
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/chaos"
+)
+
+// Moonstream.to access tokens are issued by the Bugout auth API, which is asked who a token
+// belongs to. Defaults to https://auth.bugout.dev.
+var MOONSTREAM_AUTH_URL = os.Getenv("MOONSTREAM_AUTH_URL")
+
+// ErrInvalidToken is returned when the auth API rejects an access token.
+var ErrInvalidToken = errors.New("access token is invalid or expired")
+
+// TokenUser is the user an access token belongs to.
+type TokenUser struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// OwnedLeaderboard is a leaderboard the user of an access token can push scores to.
+type OwnedLeaderboard struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// LeaderboardAccess reports whether an access token can push scores to a leaderboard.
+type LeaderboardAccess struct {
+	LeaderboardId string
+	Title         string
+	Owned         bool
+}
+
+// Responses of the auth and leaderboards endpoints by API URL and token hash, so checking the
+// targets of a leaderboards map asks once per token without keeping the tokens themselves.
+var (
+	authCacheMutex    sync.Mutex
+	tokenUsers        = map[string]TokenUser{}
+	tokenLeaderboards = map[string][]OwnedLeaderboard{}
+)
+
+// MaskToken shortens an access token to its last characters, for reports which should not leak
+// it.
+func MaskToken(accessToken string) string {
+	if len(accessToken) <= 4 {
+		return "****"
+	}
+	return "..." + accessToken[len(accessToken)-4:]
+}
+
+func authCacheKey(apiURL, accessToken string) string {
+	tokenHash := sha256.Sum256([]byte(accessToken))
+	return apiURL + " " + hex.EncodeToString(tokenHash[:])
+}
+
+// WhoAmI returns the user the access token belongs to, or ErrInvalidToken if the auth API
+// rejects it.
+func WhoAmI(accessToken string) (TokenUser, error) {
+	var user TokenUser
+	if accessToken == "" {
+		return user, errors.New("access token is not set, pass it with --token or set MOONSTREAM_ACCESS_TOKEN")
+	}
+	authURL := strings.TrimRight(MOONSTREAM_AUTH_URL, "/")
+	if authURL == "" {
+		authURL = "https://auth.bugout.dev"
+	}
+	key := authCacheKey(authURL, accessToken)
+
+	authCacheMutex.Lock()
+	defer authCacheMutex.Unlock()
+	if cached, ok := tokenUsers[key]; ok {
+		return cached, nil
+	}
+
+	if getErr := getAuthorized(fmt.Sprintf("%s/user", authURL), accessToken, &user); getErr != nil {
+		return user, getErr
+	}
+	tokenUsers[key] = user
+	return user, nil
+}
+
+// FetchOwnedLeaderboards returns the leaderboards the user of the access token can push scores
+// to at the Moonstream.to portal of the API URL, see PortalAPIURL.
+func FetchOwnedLeaderboards(apiURL, accessToken string) ([]OwnedLeaderboard, error) {
+	apiURL = PortalAPIURL(apiURL)
+	key := authCacheKey(apiURL, accessToken)
+
+	authCacheMutex.Lock()
+	defer authCacheMutex.Unlock()
+	if cached, ok := tokenLeaderboards[key]; ok {
+		return cached, nil
+	}
+
+	var owned []OwnedLeaderboard
+	if getErr := getAuthorized(fmt.Sprintf("%s/leaderboard/leaderboards", apiURL), accessToken, &owned); getErr != nil {
+		return nil, getErr
+	}
+	tokenLeaderboards[key] = owned
+	return owned, nil
+}
+
+// CheckLeaderboardAccess reports which of the leaderboards the user of the access token can push
+// scores to at the Moonstream.to portal of the API URL.
+func CheckLeaderboardAccess(apiURL, accessToken string, leaderboardIds []string) ([]LeaderboardAccess, error) {
+	owned, fetchErr := FetchOwnedLeaderboards(apiURL, accessToken)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	titles := make(map[string]string, len(owned))
+	for _, leaderboard := range owned {
+		titles[leaderboard.Id] = leaderboard.Title
+	}
+
+	access := make([]LeaderboardAccess, len(leaderboardIds))
+	for i, leaderboardId := range leaderboardIds {
+		title, ok := titles[leaderboardId]
+		access[i] = LeaderboardAccess{LeaderboardId: leaderboardId, Title: title, Owned: ok}
+	}
+	return access, nil
+}
+
+func getAuthorized(url, accessToken string, result any) error {
+	request, requestErr := http.NewRequest("GET", url, nil)
+	if requestErr != nil {
+		return fmt.Errorf("error making requests: %v", requestErr)
+	}
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	request.Header.Add("Accept", "application/json")
+
+	httpClient := http.Client{Timeout: 10 * time.Second, Transport: chaos.APITransport()}
+	response, responseErr := httpClient.Do(request)
+	if responseErr != nil {
+		return fmt.Errorf("error parsing response: %v", responseErr)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return ErrInvalidToken
+	}
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unable to get %s, status code: %d", url, response.StatusCode)
+	}
+	if decodeErr := json.NewDecoder(response.Body).Decode(result); decodeErr != nil {
+		return fmt.Errorf("error parsing response: %v", decodeErr)
+	}
+	return nil
+}
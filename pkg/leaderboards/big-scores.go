@@ -0,0 +1,100 @@
+package leaderboards
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// ParseScoreScale parses the scale of big scores set with the --score-scale flag, as a decimal
+// ("0.000001", "1e-6") or a fraction ("1/1000000"). E.g. a scale of 1e-6 reports SWAY volumes in
+// whole SWAY instead of its smallest unit.
+func ParseScoreScale(value string) (*big.Rat, error) {
+	scale, ok := new(big.Rat).SetString(value)
+	if !ok || scale.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid score scale %s, it should be a positive number", value)
+	}
+	return scale, nil
+}
+
+// 2^64, the denominator of cubit f128 fixed point numbers
+var fixedPointOne = new(big.Int).Lsh(big.NewInt(1), 64)
+
+// FixedToRat converts a cubit f128 fixed point felt to an exact rational number.
+func FixedToRat(value influence.Cubit_F128_Types_Fixed_Fixed) *big.Rat {
+	if value.Mag == nil {
+		return new(big.Rat)
+	}
+	result := new(big.Rat).SetFrac(value.Mag, fixedPointOne)
+	if value.Sign != 0 {
+		result.Neg(result)
+	}
+	return result
+}
+
+// BigScoreToUint64 scales the value and rounds it down, values are not scaled if the scale is nil.
+// Values which are negative or do not fit into uint64 after scaling are errors instead of silently
+// wrapping around.
+func BigScoreToUint64(value, scale *big.Rat) (uint64, error) {
+	if scale == nil {
+		scale = big.NewRat(1, 1)
+	}
+	scaled := new(big.Rat).Mul(value, scale)
+	if scaled.Sign() < 0 {
+		return 0, fmt.Errorf("score %s is negative", scaled.FloatString(6))
+	}
+	integer := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+	if !integer.IsUint64() {
+		return 0, fmt.Errorf("score %s overflows uint64 after scaling by %s, pass a smaller --score-scale", integer.String(), scale.RatString())
+	}
+	return integer.Uint64(), nil
+}
+
+// BigScores accumulates scores exactly, for values which may exceed uint64 (e.g. SWAY volumes) or
+// have fractional parts (fixed point felts). Scores are converted to uint64 with BigScoreToUint64
+// once accumulation is done.
+type BigScores[K comparable] map[K]*big.Rat
+
+func (s BigScores[K]) Add(key K, value *big.Rat) {
+	if _, ok := s[key]; !ok {
+		s[key] = new(big.Rat)
+	}
+	s[key].Add(s[key], value)
+}
+
+func (s BigScores[K]) AddInt(key K, value *big.Int) {
+	if value == nil {
+		return
+	}
+	s.Add(key, new(big.Rat).SetInt(value))
+}
+
+func (s BigScores[K]) AddUint64(key K, value uint64) {
+	s.Add(key, new(big.Rat).SetUint64(value))
+}
+
+// AddFraction adds a * b / c, e.g. a price times an amount per unit of time, without overflow or
+// rounding.
+func (s BigScores[K]) AddFraction(key K, a, b, c uint64) {
+	numerator := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+	s.Add(key, new(big.Rat).SetFrac(numerator, new(big.Int).SetUint64(c)))
+}
+
+func (s BigScores[K]) AddFixed(key K, value influence.Cubit_F128_Types_Fixed_Fixed) {
+	s.Add(key, FixedToRat(value))
+}
+
+// Uint64 returns the score of the key scaled with BigScoreToUint64, 0 if nothing was accumulated
+// for it.
+func (s BigScores[K]) Uint64(key K, scale *big.Rat) (uint64, error) {
+	value, ok := s[key]
+	if !ok {
+		return 0, nil
+	}
+	score, convErr := BigScoreToUint64(value, scale)
+	if convErr != nil {
+		return 0, fmt.Errorf("%v: %v", key, convErr)
+	}
+	return score, nil
+}
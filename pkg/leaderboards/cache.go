@@ -0,0 +1,175 @@
+package leaderboards
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// LeaderboardsCache keeps the scores generated for each mission on disk, keyed by the hash of the
+// events file and the block range it covers. If neither changed since the last successful run,
+// the mission can be skipped.
+type LeaderboardsCache struct {
+	Dir       string
+	InputHash string
+	FromBlock uint64
+	ToBlock   uint64
+}
+
+func NewLeaderboardsCache(dir, infile string) (*LeaderboardsCache, error) {
+	inputHash, fromBlock, toBlock, hashErr := HashEventsInput(infile)
+	if hashErr != nil {
+		return nil, hashErr
+	}
+	return &LeaderboardsCache{Dir: dir, InputHash: inputHash, FromBlock: fromBlock, ToBlock: toBlock}, nil
+}
+
+// HashEventsInput returns the hash of an events file, or a directory partitioned by event type,
+// and the range of blocks its events cover.
+func HashEventsInput(infile string) (string, uint64, uint64, error) {
+	filePaths, pathsErr := influence.EventFilePaths(infile)
+	if pathsErr != nil {
+		return "", 0, 0, fmt.Errorf("Unable to read file %s, err: %v", infile, pathsErr)
+	}
+
+	var fromBlock, toBlock uint64
+
+	hasher := sha256.New()
+	firstEvent := true
+
+	for _, filePath := range filePaths {
+		inputFile, openErr := os.Open(filePath)
+		if openErr != nil {
+			return "", 0, 0, fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+		}
+
+		// Names of partition files are part of the hash, so moving events between them is a change
+		hasher.Write([]byte(filepath.Base(filePath)))
+
+		scanner := bufio.NewScanner(io.TeeReader(inputFile, hasher))
+		for scanner.Scan() {
+			var line influence.PartialEvent
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+
+			var block struct {
+				BlockNumber uint64
+			}
+			if unmErr := json.Unmarshal(line.Event, &block); unmErr != nil {
+				continue
+			}
+
+			if firstEvent || block.BlockNumber < fromBlock {
+				fromBlock = block.BlockNumber
+			}
+			if firstEvent || block.BlockNumber > toBlock {
+				toBlock = block.BlockNumber
+			}
+			firstEvent = false
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return "", 0, 0, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), fromBlock, toBlock, nil
+}
+
+// cacheSettings are the settings which change the scores of a mission besides its events: its
+// options and the registries and constants they are generated with.
+type cacheSettings struct {
+	MissionOptions
+	// Entity references are hashed by whether they are enabled, names are looked up on output
+	EntityRefs       bool
+	Version          int
+	Goal             CommunityGoal
+	ProductCatalog   *ProductCatalog
+	AdaliaPrimeId    uint64
+	AdalianEpoch     uint64
+	TimeAcceleration uint64
+}
+
+// SettingsHash returns the hash of the settings the scores of the mission are generated with, so
+// changing a flag or a file of --config-dir invalidates the cached scores.
+func SettingsHash(opts MissionOptions) (string, error) {
+	settings := cacheSettings{
+		MissionOptions:   opts,
+		EntityRefs:       opts.Output.EntityRefs != nil,
+		Goal:             MISSION_GOALS[opts.Mission],
+		ProductCatalog:   PRODUCT_CATALOG,
+		AdaliaPrimeId:    ADALIA_PRIME_ID,
+		AdalianEpoch:     influence.ADALIAN_EPOCH,
+		TimeAcceleration: influence.TIME_ACCELERATION,
+	}
+	settings.Output.EntityRefs = nil
+	if mission, missionErr := FindMission(opts.Mission); missionErr == nil {
+		settings.Version = mission.Version
+	}
+
+	data, marshalErr := json.Marshal(settings)
+	if marshalErr != nil {
+		return "", fmt.Errorf("Unable to hash settings of %s, err: %v", opts.Mission, marshalErr)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// EntryPath returns the path of the scores of the leaderboard, named after the leaderboard so
+// entries of the other leaderboards of the mission are told apart.
+func (c *LeaderboardsCache) EntryPath(opts MissionOptions, leaderboardId string) (string, error) {
+	settingsHash, hashErr := SettingsHash(opts)
+	if hashErr != nil {
+		return "", hashErr
+	}
+	return filepath.Join(c.Dir, opts.Mission, fmt.Sprintf("%s-%s-%d-%d-%s.json", leaderboardId, c.InputHash, c.FromBlock, c.ToBlock, settingsHash)), nil
+}
+
+func (c *LeaderboardsCache) Exists(opts MissionOptions, leaderboardId string) bool {
+	entry, entryErr := c.EntryPath(opts, leaderboardId)
+	if entryErr != nil {
+		return false
+	}
+	_, statErr := os.Stat(entry)
+	return statErr == nil
+}
+
+// Prepare creates the mission cache directory and returns the path the mission scores should be
+// written to.
+func (c *LeaderboardsCache) Prepare(opts MissionOptions, leaderboardId string) (string, error) {
+	if mkdirErr := os.MkdirAll(filepath.Join(c.Dir, opts.Mission), 0755); mkdirErr != nil {
+		return "", mkdirErr
+	}
+	return c.EntryPath(opts, leaderboardId)
+}
+
+// Prune removes stale entries of the leaderboard, keeping only the one for the current inputs.
+// Entries of other leaderboards of the mission are kept.
+func (c *LeaderboardsCache) Prune(opts MissionOptions, leaderboardId string) error {
+	current, entryErr := c.EntryPath(opts, leaderboardId)
+	if entryErr != nil {
+		return entryErr
+	}
+	entries, globErr := filepath.Glob(filepath.Join(c.Dir, opts.Mission, leaderboardId+"-*.json"))
+	if globErr != nil {
+		return globErr
+	}
+	for _, entry := range entries {
+		if entry == current || entry == ProvenancePath(current) {
+			continue
+		}
+		if removeErr := os.Remove(entry); removeErr != nil {
+			return removeErr
+		}
+	}
+	return nil
+}
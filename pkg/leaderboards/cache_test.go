@@ -0,0 +1,59 @@
+package leaderboards
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheEntryPathChangesWithSettings(t *testing.T) {
+	cache := &LeaderboardsCache{Dir: t.TempDir(), InputHash: "input", FromBlock: 1, ToBlock: 2}
+	opts := DefaultMissionOptions("mission")
+
+	entry, entryErr := cache.EntryPath(opts, "leaderboard")
+	if entryErr != nil {
+		t.Fatal(entryErr)
+	}
+	opts.Thresholds.MinScore++
+	changed, entryErr := cache.EntryPath(opts, "leaderboard")
+	if entryErr != nil {
+		t.Fatal(entryErr)
+	}
+	if entry == changed {
+		t.Errorf("expected a different entry with another min score, got %s for both", entry)
+	}
+}
+
+func TestCachePruneKeepsOtherLeaderboards(t *testing.T) {
+	cache := &LeaderboardsCache{Dir: t.TempDir(), InputHash: "input", FromBlock: 1, ToBlock: 2}
+	opts := DefaultMissionOptions("mission")
+
+	write := func(leaderboardId string) string {
+		entry, prepareErr := cache.Prepare(opts, leaderboardId)
+		if prepareErr != nil {
+			t.Fatal(prepareErr)
+		}
+		if writeErr := os.WriteFile(entry, []byte("{}"), 0644); writeErr != nil {
+			t.Fatal(writeErr)
+		}
+		return entry
+	}
+	other := write("other")
+	current := write("current")
+	stale := filepath.Join(cache.Dir, "mission", "current-stale.json")
+	if writeErr := os.WriteFile(stale, []byte("{}"), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	if pruneErr := cache.Prune(opts, "current"); pruneErr != nil {
+		t.Fatal(pruneErr)
+	}
+	for _, entry := range []string{other, current} {
+		if _, statErr := os.Stat(entry); statErr != nil {
+			t.Errorf("expected %s to be kept, err: %v", entry, statErr)
+		}
+	}
+	if _, statErr := os.Stat(stale); !os.IsNotExist(statErr) {
+		t.Errorf("expected %s to be pruned", stale)
+	}
+}
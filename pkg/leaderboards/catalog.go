@@ -0,0 +1,180 @@
+package leaderboards
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed product-catalog.json
+var embeddedProductCatalog []byte
+
+// ProductCatalog names products and lists the products of each category. A product may belong to
+// several categories, e.g. Water is both a volatile and a C-type material.
+type ProductCatalog struct {
+	Products   map[uint64]string
+	Categories map[string][]uint64
+
+	members map[string]map[uint64]bool
+}
+
+func (c *ProductCatalog) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Products   map[string]string   `json:"products"`
+		Categories map[string][]uint64 `json:"categories"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	c.Products = make(map[uint64]string, len(raw.Products))
+	for key, name := range raw.Products {
+		product, parseErr := strconv.ParseUint(key, 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("invalid product ID %s", key)
+		}
+		c.Products[product] = name
+	}
+	c.Categories = raw.Categories
+	c.members = make(map[string]map[uint64]bool, len(c.Categories))
+	for category, products := range c.Categories {
+		c.members[category] = make(map[uint64]bool, len(products))
+		for _, product := range products {
+			c.members[category][product] = true
+		}
+	}
+	return nil
+}
+
+// InCategory reports whether the product belongs to the category.
+func (c *ProductCatalog) InCategory(product uint64, category string) bool {
+	return c.members[category][product]
+}
+
+// CategoryNames returns the names of all categories in alphabetical order.
+func (c *ProductCatalog) CategoryNames() []string {
+	names := make([]string, 0, len(c.Categories))
+	for category := range c.Categories {
+		names = append(names, category)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseProductCatalog(data []byte) (*ProductCatalog, error) {
+	catalog := &ProductCatalog{}
+	if unmErr := json.Unmarshal(data, catalog); unmErr != nil {
+		return nil, fmt.Errorf("Unable to parse product catalog, err: %v", unmErr)
+	}
+	return catalog, nil
+}
+
+// Catalog of products used by generators which filter products by category, the embedded catalog
+// unless replaced with the --product-catalog flag.
+var PRODUCT_CATALOG = func() *ProductCatalog {
+	catalog, parseErr := parseProductCatalog(embeddedProductCatalog)
+	if parseErr != nil {
+		panic(parseErr)
+	}
+	return catalog
+}()
+
+// ReadProductCatalog reads a catalog in the format of the embedded product-catalog.json.
+func ReadProductCatalog(filePath string) (*ProductCatalog, error) {
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+	return parseProductCatalog(data)
+}
+
+// ProductFilter selects the products a generator counts by their categories. Products must belong
+// to one of IncludeCategories (any product if it is empty) and to none of ExcludeCategories.
+type ProductFilter struct {
+	IncludeCategories []string `json:"include_categories,omitempty"`
+	ExcludeCategories []string `json:"exclude_categories,omitempty"`
+}
+
+func (f ProductFilter) Allows(catalog *ProductCatalog, product uint64) bool {
+	for _, category := range f.ExcludeCategories {
+		if catalog.InCategory(product, category) {
+			return false
+		}
+	}
+	if len(f.IncludeCategories) == 0 {
+		return true
+	}
+	for _, category := range f.IncludeCategories {
+		if catalog.InCategory(product, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that all categories of the filter are known to the catalog.
+func (f ProductFilter) Validate(catalog *ProductCatalog) error {
+	for _, category := range append(append([]string{}, f.IncludeCategories...), f.ExcludeCategories...) {
+		if _, ok := catalog.Categories[category]; !ok {
+			return fmt.Errorf("unknown product category %s, known categories: %s", category, strings.Join(catalog.CategoryNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// Product filters of missions, read from the file passed with the --product-filters flag. They
+// replace the default filter of the mission from LEADERBOARD_MISSIONS.
+var PRODUCT_FILTERS = map[string]ProductFilter{}
+
+// SetProductFilters loads the product catalog and mission product filters, and checks that the
+// filters of all missions only use categories of the catalog. Empty paths keep the embedded
+// catalog and mission defaults.
+func SetProductFilters(catalogPath, filtersPath string) error {
+	if catalogPath != "" {
+		catalog, catalogErr := ReadProductCatalog(catalogPath)
+		if catalogErr != nil {
+			return catalogErr
+		}
+		PRODUCT_CATALOG = catalog
+	}
+
+	PRODUCT_FILTERS = map[string]ProductFilter{}
+	if filtersPath != "" {
+		data, readErr := os.ReadFile(filtersPath)
+		if readErr != nil {
+			return fmt.Errorf("Unable to read file %s, err: %v", filtersPath, readErr)
+		}
+		if unmErr := json.Unmarshal(data, &PRODUCT_FILTERS); unmErr != nil {
+			return fmt.Errorf("Unable to parse product filters %s, err: %v", filtersPath, unmErr)
+		}
+	}
+
+	missions := make(map[string]bool, len(LEADERBOARD_MISSIONS))
+	for _, lm := range LEADERBOARD_MISSIONS {
+		missions[lm.Name] = true
+	}
+	for mission := range PRODUCT_FILTERS {
+		if !missions[mission] {
+			return fmt.Errorf("product filters for unknown mission %s in %s", mission, filtersPath)
+		}
+	}
+
+	for _, lm := range LEADERBOARD_MISSIONS {
+		if validateErr := MissionProductFilter(lm).Validate(PRODUCT_CATALOG); validateErr != nil {
+			return fmt.Errorf("invalid product filter of %s: %v", lm.Name, validateErr)
+		}
+	}
+	return nil
+}
+
+// MissionProductFilter returns the product filter configured for the mission, or its default.
+func MissionProductFilter(lm LeaderboardCommandFunc) ProductFilter {
+	if filter, ok := PRODUCT_FILTERS[lm.Name]; ok {
+		return filter
+	}
+	return lm.ProductFilter
+}
@@ -0,0 +1,104 @@
+package leaderboards
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Certificate summarizes the missions of a crew or wallet for badge rendering on the front-end.
+type Certificate struct {
+	Address           string            `json:"address"`
+	MissionsCompleted []string          `json:"missions_completed"`
+	Scores            map[string]uint64 `json:"scores"`
+	FromBlock         uint64            `json:"from_block"`
+	ToBlock           uint64            `json:"to_block"`
+	EventsHash        string            `json:"events_hash"`
+	IssuedAt          string            `json:"issued_at"`
+	// Hash of all other fields except issued_at, so certificates of the same events are equal
+	Hash string `json:"hash"`
+}
+
+func (c *Certificate) ContentHash() (string, error) {
+	content := *c
+	content.IssuedAt = ""
+	content.Hash = ""
+	data, marshErr := json.Marshal(content)
+	if marshErr != nil {
+		return "", marshErr
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// GenerateCertificates runs every mission on the events file, without pushing scores, and collects
+// the scores and completed missions of each address into certificates. Missions run with the
+// settings of opts and the thresholds of the registry.
+func GenerateCertificates(ctx context.Context, opts MissionOptions, infile string) ([]Certificate, error) {
+	eventsHash, fromBlock, toBlock, hashErr := HashEventsInput(infile)
+	if hashErr != nil {
+		return nil, hashErr
+	}
+
+	tempDir, tempErr := os.MkdirTemp("", "influence-eth-certificates-")
+	if tempErr != nil {
+		return nil, tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	certificates := make(map[string]*Certificate)
+	noToken, noLeaderboard := "", ""
+	for _, lm := range LEADERBOARD_MISSIONS {
+		outfile := filepath.Join(tempDir, fmt.Sprintf("%s.json", lm.Name))
+		lmOpts := opts.ForMission(lm, ScoreThresholds{MinScore: lm.MinScore, MinEvents: lm.MinEvents})
+		if missionErr := lm.Func(ctx, lmOpts, &infile, &outfile, &noToken, &noLeaderboard); missionErr != nil {
+			return nil, fmt.Errorf("Failed %s mission, err: %v", lm.Name, missionErr)
+		}
+
+		scoresFile, readErr := ReadScoresFile(outfile, opts.Output.Schema())
+		if readErr != nil {
+			return nil, readErr
+		}
+		for _, score := range scoresFile.Scores {
+			certificate, ok := certificates[score.Address]
+			if !ok {
+				certificate = &Certificate{
+					Address:           score.Address,
+					MissionsCompleted: []string{},
+					Scores:            make(map[string]uint64),
+					FromBlock:         fromBlock,
+					ToBlock:           toBlock,
+					EventsHash:        eventsHash,
+				}
+				certificates[score.Address] = certificate
+			}
+			certificate.Scores[lm.Name] = score.Score
+			if score.PointsData.Complete != nil && *score.PointsData.Complete {
+				certificate.MissionsCompleted = append(certificate.MissionsCompleted, lm.Name)
+			}
+		}
+	}
+
+	issuedAt := time.Now().UTC().Format(time.RFC3339)
+	result := make([]Certificate, 0, len(certificates))
+	for _, certificate := range certificates {
+		hash, hashErr := certificate.ContentHash()
+		if hashErr != nil {
+			return nil, hashErr
+		}
+		certificate.Hash = hash
+		certificate.IssuedAt = issuedAt
+		result = append(result, *certificate)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address < result[j].Address
+	})
+
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package leaderboards
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Path of the missions registry in the repository, read at other revisions by ChangedMissions.
+var MISSIONS_SOURCE_FILE = "pkg/leaderboards/missions.go"
+
+var (
+	missionNamePattern    = regexp.MustCompile(`Name:\s+"([^"]+)"`)
+	missionVersionPattern = regexp.MustCompile(`Version:\s+(\d+)`)
+)
+
+// ParseMissionVersions extracts the version of each mission from the source of the missions
+// registry. Missions without a version, as in revisions before missions were versioned, get 0.
+func ParseMissionVersions(source []byte) map[string]int {
+	versions := make(map[string]int)
+	if start := bytes.Index(source, []byte("var LEADERBOARD_MISSIONS")); start >= 0 {
+		source = source[start:]
+		if end := bytes.Index(source, []byte("\n}\n")); end >= 0 {
+			source = source[:end]
+		}
+	}
+	names := missionNamePattern.FindAllSubmatchIndex(source, -1)
+	for i, name := range names {
+		end := len(source)
+		if i+1 < len(names) {
+			end = names[i+1][0]
+		}
+		version := 0
+		if match := missionVersionPattern.FindSubmatch(source[name[1]:end]); match != nil {
+			version, _ = strconv.Atoi(string(match[1]))
+		}
+		versions[string(source[name[2]:name[3]])] = version
+	}
+	return versions
+}
+
+// ChangedMissions returns the names of the missions whose scoring changed since a date
+// (YYYY-MM-DD) or a git revision. Against a date, missions changed on or after it are returned.
+// Against a revision, the registry of the revision is read with git and missions whose version
+// differs, or which did not exist, are returned. Missions without a change date, e.g. missions
+// registered from a transit missions file, are always returned.
+func ChangedMissions(since string) (map[string]bool, error) {
+	changed := make(map[string]bool)
+
+	if sinceDate, dateErr := time.Parse("2006-01-02", since); dateErr == nil {
+		for _, lm := range LEADERBOARD_MISSIONS {
+			changedDate, parseErr := time.Parse("2006-01-02", lm.Changed)
+			if parseErr != nil || !changedDate.Before(sinceDate) {
+				changed[lm.Name] = true
+			}
+		}
+		return changed, nil
+	}
+
+	source, gitErr := exec.Command("git", "show", fmt.Sprintf("%s:%s", since, MISSIONS_SOURCE_FILE)).Output()
+	if gitErr != nil {
+		if exitErr, ok := gitErr.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("Unable to read missions registry at %s, err: %v: %s", since, gitErr, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("Unable to read missions registry at %s, err: %v", since, gitErr)
+	}
+	versions := ParseMissionVersions(source)
+	for _, lm := range LEADERBOARD_MISSIONS {
+		version, ok := versions[lm.Name]
+		if !ok || version != lm.Version || lm.Changed == "" {
+			changed[lm.Name] = true
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,213 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Building type of habitats, where crews are stationed.
+var HABITAT_BUILDING_TYPE = uint64(9)
+
+// CrewStation records the entity (building or ship) a crew was stationed at.
+type CrewStation struct {
+	Crew            uint64
+	Station         influence.Influence_Common_Types_Entity_Entity
+	BlockNumber     uint64
+	LineNumber      int
+	TransactionHash string
+}
+
+// LoadCrewStations collects the stations of crews, which are set when crewmates are recruited into
+// a crew and when a crew is stationed elsewhere.
+func LoadCrewStations(ctx context.Context, opts EventOptions, infile string) ([]CrewStation, error) {
+	var stations []CrewStation
+
+	stationedEvents, parseEventsErr := LoadEvents[influence.CrewStationed](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	stationedEvents.Each(func(e EventWrapper[influence.CrewStationed]) {
+		stations = append(stations, CrewStation{Crew: e.Event.CallerCrew.Id, Station: e.Event.Station, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber, TransactionHash: e.TransactionHash})
+	})
+	recEvents, parseEventsErr := LoadEvents[influence.CrewmateRecruited](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	recEvents.Each(func(e EventWrapper[influence.CrewmateRecruited]) {
+		stations = append(stations, CrewStation{Crew: e.Event.CallerCrew.Id, Station: e.Event.Station, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber, TransactionHash: e.TransactionHash})
+	})
+	recV1Events, parseEventsErr := LoadEvents[influence.CrewmateRecruitedV1](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	recV1Events.Each(func(e EventWrapper[influence.CrewmateRecruitedV1]) {
+		stations = append(stations, CrewStation{Crew: e.Event.CallerCrew.Id, Station: e.Event.Station, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber, TransactionHash: e.TransactionHash})
+	})
+
+	if streamErr := EventSourcesErr(stationedEvents, recEvents, recV1Events); streamErr != nil {
+		return nil, streamErr
+	}
+	return stations, nil
+}
+
+// LoadCrewCompositions collects the sizes of crews after every change of their composition.
+func LoadCrewCompositions(ctx context.Context, opts EventOptions, infile string) ([]CrewCompositionEvent, error) {
+	var compositions []CrewCompositionEvent
+
+	recV1Events, parseEventsErr := ParseEventFromFile[influence.CrewmateRecruitedV1](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range recV1Events {
+		compositions = append(compositions, CrewCompositionEvent{Crew: e.Event.CallerCrew.Id, Size: CompositionSize(e.Event.Composition.Snapshot), BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	arrEvents, parseEventsErr := ParseEventFromFile[influence.CrewmatesArranged](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range arrEvents {
+		compositions = append(compositions, CrewCompositionEvent{Crew: e.Event.CallerCrew.Id, Size: CompositionSize(e.Event.Composition.Snapshot), BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	arrEventsV1, parseEventsErr := ParseEventFromFile[influence.CrewmatesArrangedV1](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range arrEventsV1 {
+		compositions = append(compositions, CrewCompositionEvent{Crew: e.Event.CallerCrew.Id, Size: CompositionSize(e.Event.CompositionNew.Snapshot), BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	excEvents, parseEventsErr := ParseEventFromFile[influence.CrewmatesExchanged](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range excEvents {
+		compositions = append(compositions, CrewCompositionEvent{Crew: e.Event.Crew1.Id, Size: CompositionSize(e.Event.Crew1CompositionNew.Snapshot), BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+		compositions = append(compositions, CrewCompositionEvent{Crew: e.Event.Crew2.Id, Size: CompositionSize(e.Event.Crew2CompositionNew.Snapshot), BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+
+	return compositions, nil
+}
+
+// LatestCrewSizes returns the size of each crew after its latest composition change.
+func LatestCrewSizes(compositions []CrewCompositionEvent) map[uint64]uint64 {
+	sort.SliceStable(compositions, func(i, j int) bool {
+		if compositions[i].BlockNumber != compositions[j].BlockNumber {
+			return compositions[i].BlockNumber < compositions[j].BlockNumber
+		}
+		return compositions[i].LineNumber < compositions[j].LineNumber
+	})
+	crewSizes := make(map[uint64]uint64)
+	for _, c := range compositions {
+		crewSizes[c.Crew] = c.Size
+	}
+	return crewSizes
+}
+
+type HabitatPopulation struct {
+	Habitat   uint64   `json:"habitat"`
+	Crews     []uint64 `json:"crews"`
+	Crewmates uint64   `json:"crewmates"`
+}
+
+// GenerateLargestColonyToScores ranks crews by the number of crewmates stationed in habitats they
+// built. Each crew counts at the station of its latest station event, with the size of its latest
+// composition. Habitats which were torn down are not counted.
+func GenerateLargestColonyToScores(
+	conPlanEvents EventSource[influence.ConstructionPlanned],
+	conFinEvents EventSource[influence.ConstructionFinished],
+	tornDown TornDownBuildings,
+	stations []CrewStation,
+	compositions []CrewCompositionEvent,
+) []LeaderboardScore {
+	habitats := make(map[uint64]bool)
+	conPlanEvents.Each(func(e EventWrapper[influence.ConstructionPlanned]) {
+		if e.Event.BuildingType == HABITAT_BUILDING_TYPE {
+			habitats[e.Event.Building.Id] = true
+		}
+	})
+
+	habitatOwners := make(map[uint64]uint64)
+	conFinEvents.Each(func(e EventWrapper[influence.ConstructionFinished]) {
+		if !habitats[e.Event.Building.Id] || tornDown.After(e.Event.Building.Id, e.Event.BlockNumber) {
+			return
+		}
+		habitatOwners[e.Event.Building.Id] = e.Event.CallerCrew.Id
+	})
+
+	sort.SliceStable(stations, func(i, j int) bool {
+		if stations[i].BlockNumber != stations[j].BlockNumber {
+			return stations[i].BlockNumber < stations[j].BlockNumber
+		}
+		return stations[i].LineNumber < stations[j].LineNumber
+	})
+	latestStations := make(map[uint64]CrewStation)
+	for _, s := range stations {
+		latestStations[s.Crew] = s
+	}
+
+	crewSizes := LatestCrewSizes(compositions)
+
+	populations := make(map[uint64]map[uint64]*HabitatPopulation)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	// Crews are visited in order, so the lists of stationed crews are sorted
+	crews := make([]uint64, 0, len(latestStations))
+	for crew := range latestStations {
+		crews = append(crews, crew)
+	}
+	sort.Slice(crews, func(i, j int) bool { return crews[i] < crews[j] })
+	for _, crew := range crews {
+		station := latestStations[crew]
+		if station.Station.Label != BUILDING_LABEL {
+			continue
+		}
+		owner, ok := habitatOwners[station.Station.Id]
+		if !ok {
+			continue
+		}
+
+		if _, ok := populations[owner]; !ok {
+			populations[owner] = make(map[uint64]*HabitatPopulation)
+		}
+		population, ok := populations[owner][station.Station.Id]
+		if !ok {
+			population = &HabitatPopulation{Habitat: station.Station.Id, Crews: []uint64{}}
+			populations[owner][station.Station.Id] = population
+		}
+		population.Crews = append(population.Crews, crew)
+		population.Crewmates += crewSizes[crew]
+		eventCounts[owner]++
+		transactions.Record(owner, station.TransactionHash)
+	}
+
+	scores := []LeaderboardScore{}
+	for owner, ownerHabitats := range populations {
+		data := make([]HabitatPopulation, 0, len(ownerHabitats))
+		var crewmates uint64
+		for _, population := range ownerHabitats {
+			data = append(data, *population)
+			crewmates += population.Crewmates
+		}
+		sort.Slice(data, func(i, j int) bool {
+			return data[i].Habitat < data[j].Habitat
+		})
+
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", owner),
+			Score:           crewmates,
+			EventCount:      eventCounts[owner],
+			TransactionHash: transactions[owner],
+			PointsData: PointsData{
+				Complete: Completed(crewmates > 0),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " crewmate(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
@@ -0,0 +1,45 @@
+package leaderboards
+
+import "fmt"
+
+// Address of the synthetic entry which holds the progress of a community mission.
+var COMMUNITY_ADDRESS = "__community__"
+
+// CommunityProgressScore summarizes the must_reach progress shared by the scores of a community
+// mission into an extra entry, added with the --community-entry flag, with the global total, goal
+// and percent complete, so the portal can render a single progress bar. It returns false if the
+// scores are not of a community mission.
+func CommunityProgressScore(scores []LeaderboardScore) (LeaderboardScore, bool) {
+	var mustReach, mustReachCounter uint64
+	for _, score := range scores {
+		if score.PointsData.MustReach > mustReach {
+			mustReach = score.PointsData.MustReach
+		}
+		if score.PointsData.MustReachCounter > mustReachCounter {
+			mustReachCounter = score.PointsData.MustReachCounter
+		}
+	}
+	if mustReach == 0 {
+		return LeaderboardScore{}, false
+	}
+
+	percentComplete := float64(mustReachCounter) / float64(mustReach) * 100
+	if percentComplete > 100 {
+		percentComplete = 100
+	}
+	return LeaderboardScore{
+		Address: COMMUNITY_ADDRESS,
+		Score:   mustReachCounter,
+		PointsData: PointsData{
+			Complete:         Completed(mustReachCounter >= mustReach),
+			MustReach:        mustReach,
+			MustReachCounter: mustReachCounter,
+			ScoreDetails: &ScoreDetails{
+				AddressName: "Community",
+			},
+			Extra: map[string]any{
+				"percent_complete": fmt.Sprintf("%.2f", percentComplete),
+			},
+		},
+	}, true
+}
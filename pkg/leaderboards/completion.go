@@ -0,0 +1,88 @@
+package leaderboards
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CompletionRow holds, for each mission of the matrix in order, whether the address completed it
+// and its score. Scores is nil for missions the address has no score on.
+type CompletionRow struct {
+	Address           string    `json:"address"`
+	MissionsCompleted int       `json:"missions_completed"`
+	Completed         []bool    `json:"completed"`
+	Scores            []*uint64 `json:"scores"`
+}
+
+// CompletionMatrix is the completion of every mission by every crew or wallet with a score, so
+// admins can see who finished all missions without reading each leaderboard.
+type CompletionMatrix struct {
+	Missions []string        `json:"missions"`
+	Rows     []CompletionRow `json:"rows"`
+}
+
+// NewCompletionMatrix builds the matrix of the missions in LEADERBOARD_MISSIONS from certificates,
+// as generated by GenerateCertificates. Addresses which completed fewer than minCompleted missions
+// are left out.
+func NewCompletionMatrix(certificates []Certificate, minCompleted int) CompletionMatrix {
+	matrix := CompletionMatrix{Missions: make([]string, len(LEADERBOARD_MISSIONS)), Rows: []CompletionRow{}}
+	for i, lm := range LEADERBOARD_MISSIONS {
+		matrix.Missions[i] = lm.Name
+	}
+
+	for _, certificate := range certificates {
+		completed := make(map[string]bool, len(certificate.MissionsCompleted))
+		for _, mission := range certificate.MissionsCompleted {
+			completed[mission] = true
+		}
+
+		row := CompletionRow{
+			Address:   certificate.Address,
+			Completed: make([]bool, len(matrix.Missions)),
+			Scores:    make([]*uint64, len(matrix.Missions)),
+		}
+		for i, mission := range matrix.Missions {
+			if score, ok := certificate.Scores[mission]; ok {
+				row.Scores[i] = &score
+			}
+			if completed[mission] {
+				row.Completed[i] = true
+				row.MissionsCompleted++
+			}
+		}
+		if row.MissionsCompleted >= minCompleted {
+			matrix.Rows = append(matrix.Rows, row)
+		}
+	}
+
+	return matrix
+}
+
+// WriteCompletionMatrixCSV writes a row per address, with a completion and a score column for each
+// mission. Scores are empty for missions the address has no score on.
+func WriteCompletionMatrixCSV(w io.Writer, matrix CompletionMatrix) error {
+	writer := csv.NewWriter(w)
+	header := []string{"address", "missions_completed"}
+	for _, mission := range matrix.Missions {
+		header = append(header, mission+"_completed", mission+"_score")
+	}
+	if writeErr := writer.Write(header); writeErr != nil {
+		return writeErr
+	}
+	for _, row := range matrix.Rows {
+		record := []string{row.Address, strconv.Itoa(row.MissionsCompleted)}
+		for i := range matrix.Missions {
+			score := ""
+			if row.Scores[i] != nil {
+				score = strconv.FormatUint(*row.Scores[i], 10)
+			}
+			record = append(record, strconv.FormatBool(row.Completed[i]), score)
+		}
+		if writeErr := writer.Write(record); writeErr != nil {
+			return writeErr
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,93 @@
+package leaderboards
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Chain heads estimated for events files, so files are scanned once for all missions.
+var estimatedChainHeads = make(map[string]uint64)
+
+// EstimateChainHead returns the lowest chain head the events file (or directory partitioned by
+// event type) could have been crawled at: the highest block of its events plus the smallest number
+// of confirmations the crawler waited for, as recorded with the events.
+func EstimateChainHead(infile string) (uint64, error) {
+	if head, ok := estimatedChainHeads[infile]; ok {
+		return head, nil
+	}
+
+	filePaths, pathsErr := influence.EventFilePaths(infile)
+	if pathsErr != nil {
+		return 0, fmt.Errorf("Unable to read file %s, err: %v", infile, pathsErr)
+	}
+
+	var latestBlock uint64
+	confirmations := -1
+	for _, filePath := range filePaths {
+		inputFile, openErr := os.Open(filePath)
+		if openErr != nil {
+			return 0, fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+		}
+
+		scanner := bufio.NewScanner(inputFile)
+		for scanner.Scan() {
+			var line PartialEventLine
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+			var block struct {
+				BlockNumber uint64
+			}
+			if unmErr := json.Unmarshal(line.Event, &block); unmErr != nil {
+				continue
+			}
+
+			if block.BlockNumber > latestBlock {
+				latestBlock = block.BlockNumber
+			}
+			if confirmations < 0 || line.Confirmations < confirmations {
+				confirmations = line.Confirmations
+			}
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return 0, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	head := latestBlock
+	if confirmations > 0 {
+		head += uint64(confirmations)
+	}
+	estimatedChainHeads[infile] = head
+	return head, nil
+}
+
+// ConfirmedBlock returns the latest block with MinConfirmations blocks on top of it, and false if
+// all blocks are counted. Events closer to the chain head may still be reorged, so counting them
+// could briefly flip completion flags. The chain head is estimated from the events file if
+// ChainHead is 0, see EstimateChainHead.
+func (o EventOptions) ConfirmedBlock(infile string) (uint64, bool, error) {
+	if o.MinConfirmations == 0 {
+		return 0, false, nil
+	}
+
+	head := o.ChainHead
+	if head == 0 {
+		var headErr error
+		head, headErr = EstimateChainHead(infile)
+		if headErr != nil {
+			return 0, false, headErr
+		}
+	}
+
+	if head < o.MinConfirmations {
+		return 0, true, nil
+	}
+	return head - o.MinConfirmations, true, nil
+}
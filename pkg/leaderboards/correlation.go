@@ -0,0 +1,76 @@
+package leaderboards
+
+import "sort"
+
+// CorrelatedTransaction is the steps of a composite action a crew performed in a transaction, and
+// the position of its latest event in the events file.
+type CorrelatedTransaction struct {
+	Steps       map[string]bool
+	BlockNumber uint64
+	LineNumber  int
+}
+
+// TransactionSteps correlates events emitted by the same transaction (e.g. a multicall), recording
+// which steps of a composite action each crew performed in each transaction.
+type TransactionSteps map[uint64]map[string]*CorrelatedTransaction
+
+func (t TransactionSteps) Record(crew uint64, transactionHash, step string, blockNumber uint64, lineNumber int) {
+	// Events of dumps without transaction hashes can not be correlated
+	if transactionHash == "" {
+		return
+	}
+	if _, ok := t[crew]; !ok {
+		t[crew] = make(map[string]*CorrelatedTransaction)
+	}
+	transaction, ok := t[crew][transactionHash]
+	if !ok {
+		transaction = &CorrelatedTransaction{Steps: make(map[string]bool)}
+		t[crew][transactionHash] = transaction
+	}
+	transaction.Steps[step] = true
+	if blockNumber > transaction.BlockNumber || (blockNumber == transaction.BlockNumber && lineNumber > transaction.LineNumber) {
+		transaction.BlockNumber = blockNumber
+		transaction.LineNumber = lineNumber
+	}
+}
+
+// Complete returns, for each crew, the hashes of transactions in which the crew performed all of
+// the steps, in the order they were sent: by block, then by the line of their latest event, which
+// follows the order of events within a block. The last hash is the latest transaction.
+func (t TransactionSteps) Complete(steps ...string) map[uint64][]string {
+	complete := make(map[uint64][]string)
+	for crew, transactions := range t {
+		for transactionHash, transaction := range transactions {
+			all := true
+			for _, step := range steps {
+				if !transaction.Steps[step] {
+					all = false
+					break
+				}
+			}
+			if all {
+				complete[crew] = append(complete[crew], transactionHash)
+			}
+		}
+		hashes := complete[crew]
+		sort.Slice(hashes, func(i, j int) bool {
+			a, b := transactions[hashes[i]], transactions[hashes[j]]
+			if a.BlockNumber != b.BlockNumber {
+				return a.BlockNumber < b.BlockNumber
+			}
+			if a.LineNumber != b.LineNumber {
+				return a.LineNumber < b.LineNumber
+			}
+			return hashes[i] < hashes[j]
+		})
+	}
+	return complete
+}
+
+// CorrelateEvents records each event as the step of the crew returned by crew, at the block
+// returned by blockNumber.
+func CorrelateEvents[T any](t TransactionSteps, events EventSource[T], step string, crew func(T) uint64, blockNumber func(T) uint64) {
+	events.Each(func(e EventWrapper[T]) {
+		t.Record(crew(e.Event), e.TransactionHash, step, blockNumber(e.Event), e.EventLineNumber)
+	})
+}
@@ -0,0 +1,22 @@
+package leaderboards
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransactionStepsCompleteInChainOrder(t *testing.T) {
+	steps := make(TransactionSteps)
+	// Hashes sort in the opposite order of the blocks of their transactions
+	for _, step := range []string{"buy", "refine"} {
+		steps.Record(7, "0xc", step, 100, 1)
+		steps.Record(7, "0xb", step, 120, 5)
+		steps.Record(7, "0xa", step, 120, 9)
+	}
+	steps.Record(7, "0xd", "buy", 130, 12)
+
+	complete := steps.Complete("buy", "refine")
+	if expected := []string{"0xc", "0xb", "0xa"}; !reflect.DeepEqual(complete[7], expected) {
+		t.Fatalf("expected transactions %v in chain order, got %v", expected, complete[7])
+	}
+}
@@ -0,0 +1,119 @@
+package leaderboards
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Profile is a named set of Moonstream.to API settings from the credentials file.
+type Profile struct {
+	APIURL      string
+	AccessToken string
+}
+
+// DefaultCredentialsFile returns ~/.influence-eth/credentials.
+func DefaultCredentialsFile() string {
+	homeDir, homeErr := os.UserHomeDir()
+	if homeErr != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".influence-eth", "credentials")
+}
+
+// ReadCredentials reads profiles from a credentials file in INI format:
+//
+//	[default]
+//	api_url = https://engineapi.moonstream.to
+//	token = <access token>
+//
+//	[staging]
+//	api_url = https://engineapi.example.com
+//	token = <access token>
+func ReadCredentials(filePath string) (map[string]Profile, error) {
+	credentialsFile, openErr := os.Open(filePath)
+	if openErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+	}
+	defer credentialsFile.Close()
+
+	profiles := make(map[string]Profile)
+	currentProfile := ""
+	lineNumber := 0
+	scanner := bufio.NewScanner(credentialsFile)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			currentProfile = strings.TrimSpace(line[1 : len(line)-1])
+			profiles[currentProfile] = profiles[currentProfile]
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found || currentProfile == "" {
+			return nil, fmt.Errorf("invalid line %d in credentials file %s", lineNumber, filePath)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		profile := profiles[currentProfile]
+		switch key {
+		case "api_url":
+			profile.APIURL = value
+		case "token":
+			profile.AccessToken = value
+		default:
+			return nil, fmt.Errorf("unknown key %s on line %d in credentials file %s", key, lineNumber, filePath)
+		}
+		profiles[currentProfile] = profile
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, fmt.Errorf("Error reading file: %v", scanErr)
+	}
+
+	return profiles, nil
+}
+
+// ApplyProfile sets the API URL and default access token from a profile of the credentials file.
+// Settings from the MOONSTREAM_API_URL and MOONSTREAM_ACCESS_TOKEN environment variables take
+// precedence, and flags of the commands take precedence over both. A missing credentials file is
+// only an error if a profile other than "default" was requested.
+func ApplyProfile(credentialsFilePath, profileName string) error {
+	if credentialsFilePath == "" {
+		credentialsFilePath = DefaultCredentialsFile()
+	}
+	if profileName == "" {
+		profileName = "default"
+	}
+
+	if _, statErr := os.Stat(credentialsFilePath); os.IsNotExist(statErr) && profileName == "default" {
+		return nil
+	}
+
+	profiles, readErr := ReadCredentials(credentialsFilePath)
+	if readErr != nil {
+		return readErr
+	}
+	profile, ok := profiles[profileName]
+	if !ok {
+		if profileName == "default" {
+			return nil
+		}
+		return fmt.Errorf("unknown profile %s in credentials file %s", profileName, credentialsFilePath)
+	}
+
+	if os.Getenv("MOONSTREAM_API_URL") == "" && profile.APIURL != "" {
+		MOONSTREAM_API_URL = profile.APIURL
+	}
+	if os.Getenv("MOONSTREAM_ACCESS_TOKEN") == "" && profile.AccessToken != "" {
+		MOONSTREAM_ACCESS_TOKEN = profile.AccessToken
+	}
+	return nil
+}
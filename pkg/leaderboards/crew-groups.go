@@ -0,0 +1,105 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Label shown as address_name of team entries.
+const TEAM_ADDRESS_NAME = "Team"
+
+// CrewGroups assigns crews of players who run several of them to teams, which compete as a single
+// leaderboard entry.
+type CrewGroups struct {
+	Teams  map[uint64]string
+	labels map[string]bool
+}
+
+// ReadCrewGroups reads the file passed with the --group-file flag, a JSON object of team labels by
+// crew ID, e.g. {"17": "Alpha", "2301": "Alpha"}. Team labels become the addresses of team entries,
+// so they can not look like crew IDs or wallets.
+func ReadCrewGroups(filePath string) (*CrewGroups, error) {
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+	var rawGroups map[string]string
+	if unmErr := json.Unmarshal(data, &rawGroups); unmErr != nil {
+		return nil, fmt.Errorf("Unable to parse crew groups %s, err: %v", filePath, unmErr)
+	}
+
+	groups := &CrewGroups{Teams: make(map[uint64]string, len(rawGroups)), labels: make(map[string]bool)}
+	for rawCrew, label := range rawGroups {
+		crew, parseErr := strconv.ParseUint(rawCrew, 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid crew ID %s in crew groups %s", rawCrew, filePath)
+		}
+		if _, numericErr := strconv.ParseUint(label, 10, 64); numericErr == nil || label == "" || strings.HasPrefix(label, "0x") || label == COMMUNITY_ADDRESS {
+			return nil, fmt.Errorf("invalid team label \"%s\" of crew %d in crew groups %s, labels can not be empty, numbers or 0x addresses", label, crew, filePath)
+		}
+		groups.Teams[crew] = label
+		groups.labels[label] = true
+	}
+	return groups, nil
+}
+
+// IsTeam returns whether the address is the address of a team entry.
+func (g *CrewGroups) IsTeam(address string) bool {
+	return g != nil && g.labels[address]
+}
+
+// Merge replaces the scores of crews in teams with one entry per team, at the position of its
+// first crew. Team entries are combined with SumScores: scores, event counts and the progress of
+// requirements add up, so a team completes a mission its crews only complete together. They list
+// their crews in points_data, their other points data is that of their highest scoring crew.
+// Scores of missions which are not aggregated by crew are left unchanged.
+func (g *CrewGroups) Merge(scores []LeaderboardScore, names AddressNames) []LeaderboardScore {
+	if g == nil || (names.Aggregation != "" && names.Aggregation != AGGREGATION_CREW) {
+		return scores
+	}
+
+	merged := make([]LeaderboardScore, 0, len(scores))
+	teamIndex := make(map[string]int)
+	teamCrews := make(map[string][]uint64)
+	for _, score := range scores {
+		crew, parseErr := strconv.ParseUint(score.Address, 10, 64)
+		label, ok := g.Teams[crew]
+		if parseErr != nil || !ok {
+			merged = append(merged, score)
+			continue
+		}
+		teamCrews[label] = append(teamCrews[label], crew)
+
+		i, seen := teamIndex[label]
+		if !seen {
+			teamIndex[label] = len(merged)
+			score.Address = label
+			merged = append(merged, score)
+			continue
+		}
+		merged[i] = SumScores(merged[i], score)
+	}
+
+	for label, i := range teamIndex {
+		crews := teamCrews[label]
+		sort.Slice(crews, func(a, b int) bool { return crews[a] < crews[b] })
+
+		extra := make(map[string]any, len(merged[i].PointsData.Extra)+1)
+		for key, value := range merged[i].PointsData.Extra {
+			extra[key] = value
+		}
+		extra["crews"] = crews
+		merged[i].PointsData.Extra = extra
+		details := ScoreDetails{}
+		if merged[i].PointsData.ScoreDetails != nil {
+			details = *merged[i].PointsData.ScoreDetails
+		}
+		details.AddressName = TEAM_ADDRESS_NAME
+		merged[i].PointsData.ScoreDetails = &details
+	}
+	return merged
+}
@@ -0,0 +1,42 @@
+package leaderboards
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCrewGroupsMergeCompletesTogether(t *testing.T) {
+	groups := &CrewGroups{Teams: map[uint64]string{17: "Alpha", 2301: "Alpha"}, labels: map[string]bool{"Alpha": true}}
+	crewScore := func(address string, have uint64) LeaderboardScore {
+		requirements := []Requirement{{Name: "sample types", Have: have, Need: 5}}
+		return LeaderboardScore{
+			Address:      address,
+			Score:        have,
+			EventCount:   have,
+			Requirements: requirements,
+			PointsData:   PointsData{Complete: Completed(RequirementsMet(requirements))},
+		}
+	}
+
+	merged := groups.Merge([]LeaderboardScore{crewScore("17", 3), crewScore("42", 1), crewScore("2301", 2)}, AddressNames{Aggregation: AGGREGATION_CREW})
+
+	if len(merged) != 2 {
+		t.Fatalf("expected a team entry and the ungrouped crew, got %+v", merged)
+	}
+	team := merged[0]
+	if team.Address != "Alpha" || team.Score != 5 || team.EventCount != 5 {
+		t.Errorf("expected team Alpha with a score and event count of 5, got %+v", team)
+	}
+	if len(team.Requirements) != 1 || team.Requirements[0].Have != 5 {
+		t.Errorf("expected the progress of the crews to add up to 5, got %+v", team.Requirements)
+	}
+	if team.PointsData.Complete == nil || !*team.PointsData.Complete {
+		t.Error("expected the team to complete the mission its crews only complete together")
+	}
+	if crews := team.PointsData.Extra["crews"]; !reflect.DeepEqual(crews, []uint64{17, 2301}) {
+		t.Errorf("expected the team to list crews 17 and 2301, got %v", crews)
+	}
+	if merged[1].Address != "42" || *merged[1].PointsData.Complete {
+		t.Errorf("expected crew 42 to be left unchanged, got %+v", merged[1])
+	}
+}
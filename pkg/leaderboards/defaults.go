@@ -0,0 +1,239 @@
+package leaderboards
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Defaults of the missions registry and game constants, embedded so the binary works without any
+// files next to it. The same files in the directory passed with --config-dir override them, and
+// flags of the commands override both.
+var (
+	//go:embed missions.json
+	embeddedMissions []byte
+	//go:embed constants.json
+	embeddedConstants []byte
+)
+
+// File names of the defaults in the directory passed with --config-dir.
+const (
+	MISSIONS_CONFIG_FILE  = "missions.json"
+	CONSTANTS_CONFIG_FILE = "constants.json"
+	CATALOG_CONFIG_FILE   = "product-catalog.json"
+)
+
+// CommunityGoal is the total all crews must reach in a community mission, and its cap.
+type CommunityGoal struct {
+	MustReach uint64 `json:"must_reach"`
+	Cap       uint64 `json:"cap"`
+}
+
+// MissionConfig holds the defaults of a mission in missions.json. Fields which are not set keep
+// the value of the file below it, an override file only lists what it changes.
+type MissionConfig struct {
+	MinScore      *uint64        `json:"min_score,omitempty"`
+	MinEvents     *uint64        `json:"min_events,omitempty"`
+	ProductFilter *ProductFilter `json:"product_filter,omitempty"`
+	Asteroids     *string        `json:"asteroids,omitempty"`
+	AddressName   *string        `json:"address_name,omitempty"`
+	Goal          *CommunityGoal `json:"goal,omitempty"`
+}
+
+// Constants holds the game constants of constants.json.
+type Constants struct {
+	AdaliaPrimeId    *uint64 `json:"adalia_prime_id,omitempty"`
+	AdalianEpoch     *uint64 `json:"adalian_epoch,omitempty"`
+	TimeAcceleration *uint64 `json:"time_acceleration,omitempty"`
+}
+
+// Community goals of community missions by mission name, the total all crews must reach and its
+// cap. They are kept out of LEADERBOARD_MISSIONS as the mission funcs of the registry read them.
+var MISSION_GOALS = map[string]CommunityGoal{}
+
+func init() {
+	var missions map[string]MissionConfig
+	if unmErr := json.Unmarshal(embeddedMissions, &missions); unmErr != nil {
+		panic(fmt.Errorf("Unable to parse embedded %s, err: %v", MISSIONS_CONFIG_FILE, unmErr))
+	}
+	if applyErr := applyMissionsConfig(missions, "embedded "+MISSIONS_CONFIG_FILE, true); applyErr != nil {
+		panic(applyErr)
+	}
+
+	var constants Constants
+	if unmErr := json.Unmarshal(embeddedConstants, &constants); unmErr != nil {
+		panic(fmt.Errorf("Unable to parse embedded %s, err: %v", CONSTANTS_CONFIG_FILE, unmErr))
+	}
+	if applyErr := applyConstants(constants, "embedded "+CONSTANTS_CONFIG_FILE); applyErr != nil {
+		panic(applyErr)
+	}
+}
+
+// applyMissionsConfig sets the fields of the missions of the registry which are set in configs.
+// Only the embedded defaults can make a mission scopable by asteroids, overrides can change the
+// asteroids of missions which already count events by asteroid.
+func applyMissionsConfig(configs map[string]MissionConfig, source string, defaults bool) error {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		config := configs[name]
+		mission, missionErr := FindMission(name)
+		if missionErr != nil {
+			return fmt.Errorf("invalid %s: %v", source, missionErr)
+		}
+
+		if config.MinScore != nil {
+			mission.MinScore = *config.MinScore
+		}
+		if config.MinEvents != nil {
+			mission.MinEvents = *config.MinEvents
+		}
+		if config.ProductFilter != nil {
+			mission.ProductFilter = *config.ProductFilter
+		}
+		if config.Asteroids != nil {
+			if mission.Asteroids == nil && !defaults {
+				return fmt.Errorf("invalid %s: mission %s does not count events by asteroid", source, name)
+			}
+			selector, parseErr := ParseAsteroidSelector(*config.Asteroids)
+			if parseErr != nil {
+				return fmt.Errorf("invalid %s: asteroids of %s: %v", source, name, parseErr)
+			}
+			mission.Asteroids = &selector
+		}
+		if config.AddressName != nil {
+			mission.AddressName = *config.AddressName
+		}
+		if config.Goal != nil {
+			if config.Goal.MustReach == 0 {
+				return fmt.Errorf("invalid %s: goal of %s must have a must_reach above 0", source, name)
+			}
+			MISSION_GOALS[name] = *config.Goal
+		}
+	}
+	return nil
+}
+
+func applyConstants(constants Constants, source string) error {
+	if constants.TimeAcceleration != nil && *constants.TimeAcceleration == 0 {
+		return fmt.Errorf("invalid %s: time_acceleration must be above 0", source)
+	}
+
+	if constants.AdaliaPrimeId != nil {
+		ADALIA_PRIME_ID = *constants.AdaliaPrimeId
+	}
+	if constants.AdalianEpoch != nil {
+		influence.ADALIAN_EPOCH = *constants.AdalianEpoch
+	}
+	if constants.TimeAcceleration != nil {
+		influence.TIME_ACCELERATION = *constants.TimeAcceleration
+	}
+	return nil
+}
+
+// SetConfigDir overrides the embedded defaults with the files of the directory: missions.json is
+// merged into the registry by mission and field, constants.json by constant, and
+// product-catalog.json replaces the embedded catalog. Missing files keep the embedded defaults, an
+// empty path keeps all of them.
+func SetConfigDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if info, statErr := os.Stat(dir); statErr != nil || !info.IsDir() {
+		return fmt.Errorf("config directory %s does not exist", dir)
+	}
+
+	missionsPath := filepath.Join(dir, MISSIONS_CONFIG_FILE)
+	if data, readErr := readConfigFile(missionsPath); readErr != nil {
+		return readErr
+	} else if data != nil {
+		var missions map[string]MissionConfig
+		if unmErr := json.Unmarshal(data, &missions); unmErr != nil {
+			return fmt.Errorf("Unable to parse %s, err: %v", missionsPath, unmErr)
+		}
+		if applyErr := applyMissionsConfig(missions, missionsPath, false); applyErr != nil {
+			return applyErr
+		}
+	}
+
+	constantsPath := filepath.Join(dir, CONSTANTS_CONFIG_FILE)
+	if data, readErr := readConfigFile(constantsPath); readErr != nil {
+		return readErr
+	} else if data != nil {
+		var constants Constants
+		if unmErr := json.Unmarshal(data, &constants); unmErr != nil {
+			return fmt.Errorf("Unable to parse %s, err: %v", constantsPath, unmErr)
+		}
+		if applyErr := applyConstants(constants, constantsPath); applyErr != nil {
+			return applyErr
+		}
+	}
+
+	catalogPath := filepath.Join(dir, CATALOG_CONFIG_FILE)
+	if data, readErr := readConfigFile(catalogPath); readErr != nil {
+		return readErr
+	} else if data != nil {
+		catalog, catalogErr := parseProductCatalog(data)
+		if catalogErr != nil {
+			return fmt.Errorf("invalid %s: %v", catalogPath, catalogErr)
+		}
+		PRODUCT_CATALOG = catalog
+	}
+	return nil
+}
+
+// readConfigFile returns the contents of the file, or nil if it does not exist.
+func readConfigFile(path string) ([]byte, error) {
+	data, readErr := os.ReadFile(path)
+	if errors.Is(readErr, os.ErrNotExist) {
+		return nil, nil
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", path, readErr)
+	}
+	return data, nil
+}
+
+// ExportDefaults writes the embedded defaults to the directory, as a starting point for a
+// directory passed with --config-dir. Existing files are only replaced with overwrite.
+func ExportDefaults(dir string, overwrite bool) ([]string, error) {
+	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
+		return nil, fmt.Errorf("Unable to create directory %s, err: %v", dir, mkdirErr)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{MISSIONS_CONFIG_FILE, embeddedMissions},
+		{CONSTANTS_CONFIG_FILE, embeddedConstants},
+		{CATALOG_CONFIG_FILE, embeddedProductCatalog},
+	}
+	written := []string{}
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if _, statErr := os.Stat(path); statErr == nil && !overwrite {
+			return written, fmt.Errorf("file %s already exists, pass --overwrite to replace it", path)
+		}
+		if writeErr := os.WriteFile(path, file.data, 0644); writeErr != nil {
+			return written, fmt.Errorf("Unable to write file %s, err: %v", path, writeErr)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// MissionGoal returns the community goal of the mission.
+func MissionGoal(missionName string) CommunityGoal {
+	return MISSION_GOALS[missionName]
+}
@@ -0,0 +1,81 @@
+package leaderboards
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReadAuditLog reads the records of the audit log. Lines which are not records are skipped.
+func ReadAuditLog(filePath string) ([]AuditRecord, error) {
+	inputFile, openErr := os.Open(filePath)
+	if openErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+	}
+	defer inputFile.Close()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(inputFile)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record AuditRecord
+		if unmErr := json.Unmarshal(scanner.Bytes(), &record); unmErr != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, fmt.Errorf("Error reading file: %v", scanErr)
+	}
+	return records, nil
+}
+
+// Succeeded reports whether the push of the record was accepted by the portal.
+func (r AuditRecord) Succeeded() bool {
+	return r.Error == "" && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// LastPushedBlock returns the last block of the events behind the latest successful pushes to the
+// leaderboards, as recorded in the audit log, or to any leaderboard if none are given. With several
+// leaderboards it is the lowest of their last blocks, so a crawl from the following block brings
+// all of them up to date. It returns false if one of the leaderboards has no successful push with
+// a known block range.
+func LastPushedBlock(auditLog string, leaderboardIds []string) (uint64, bool, error) {
+	records, readErr := ReadAuditLog(auditLog)
+	if readErr != nil {
+		return 0, false, readErr
+	}
+
+	lastBlocks := make(map[string]uint64)
+	for _, record := range records {
+		if !record.Succeeded() || record.ToBlock == 0 {
+			continue
+		}
+		if record.ToBlock > lastBlocks[record.LeaderboardId] {
+			lastBlocks[record.LeaderboardId] = record.ToBlock
+		}
+	}
+
+	if len(leaderboardIds) == 0 {
+		var lastBlock uint64
+		for _, block := range lastBlocks {
+			if block > lastBlock {
+				lastBlock = block
+			}
+		}
+		return lastBlock, lastBlock > 0, nil
+	}
+
+	var lastBlock uint64
+	for i, leaderboardId := range leaderboardIds {
+		block, ok := lastBlocks[leaderboardId]
+		if !ok {
+			return 0, false, nil
+		}
+		if i == 0 || block < lastBlock {
+			lastBlock = block
+		}
+	}
+	return lastBlock, true, nil
+}
@@ -0,0 +1,91 @@
+package leaderboards
+
+import (
+	"errors"
+	"sort"
+)
+
+// PreviousScores returns the scores of the previous run by address, from the previous score file
+// of the options or pulled from the leaderboard, and false if there are none to compare against.
+func PreviousScores(opts OutputOptions, apiURL, accessToken, leaderboardId string) (map[string]uint64, bool, error) {
+	previous := make(map[string]uint64)
+	switch {
+	case opts.PreviousScoresFile != "":
+		scoresFile, readErr := ReadScoresFile(opts.PreviousScoresFile, opts.Schema())
+		if readErr != nil {
+			return nil, false, readErr
+		}
+		for _, score := range scoresFile.Scores {
+			previous[score.Address] = score.Score
+		}
+	case opts.PreviousFromPortal:
+		if accessToken == "" {
+			accessToken = MOONSTREAM_ACCESS_TOKEN
+		}
+		// Missions without a leaderboard have nothing to compare against
+		if leaderboardId == "" {
+			return nil, false, nil
+		}
+		if accessToken == "" {
+			return nil, false, errors.New("--previous-from-portal needs an access token")
+		}
+		scores, fetchErr := FetchLeaderboardScores(apiURL, accessToken, leaderboardId)
+		if fetchErr != nil {
+			return nil, false, fetchErr
+		}
+		for _, score := range scores {
+			previous[score.Address] = score.Score
+		}
+	default:
+		return nil, false, nil
+	}
+	return previous, true, nil
+}
+
+// ranks returns the rank of each address by descending score. Addresses with the same score share
+// the rank of the first of them.
+func ranks(scores map[string]uint64) map[string]int {
+	addresses := make([]string, 0, len(scores))
+	for address := range scores {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if scores[addresses[i]] != scores[addresses[j]] {
+			return scores[addresses[i]] > scores[addresses[j]]
+		}
+		return addresses[i] < addresses[j]
+	})
+
+	result := make(map[string]int, len(addresses))
+	for i, address := range addresses {
+		result[address] = i + 1
+		if i > 0 && scores[address] == scores[addresses[i-1]] {
+			result[address] = result[addresses[i-1]]
+		}
+	}
+	return result
+}
+
+// ApplyScoreDeltas adds the previous_score of each address, the delta of its score since and its
+// rank_change (positive if it moved up) to points_data, so the portal can show movement arrows.
+// Addresses which are new to the leaderboard get their score as delta and no rank change.
+func ApplyScoreDeltas(scores []LeaderboardScore, previous map[string]uint64) {
+	current := make(map[string]uint64, len(scores))
+	for _, score := range scores {
+		current[score.Address] = score.Score
+	}
+	previousRanks := ranks(previous)
+	currentRanks := ranks(current)
+
+	for i, score := range scores {
+		if scores[i].PointsData.Extra == nil {
+			scores[i].PointsData.Extra = make(map[string]any)
+		}
+		previousScore, ok := previous[score.Address]
+		scores[i].PointsData.Extra["previous_score"] = previousScore
+		scores[i].PointsData.Extra["delta"] = int64(score.Score) - int64(previousScore)
+		if ok {
+			scores[i].PointsData.Extra["rank_change"] = previousRanks[score.Address] - currentRanks[score.Address]
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Share of (address, score) pairs two missions may have in common before they are reported as
+// suspiciously overlapping, set with the --threshold flag.
+var DUPLICATE_OVERLAP_THRESHOLD = 0.9
+
+// Missions with fewer scores are not compared, since small leaderboards overlap by chance. Set with
+// the --min-scores flag.
+var DUPLICATE_MIN_SCORES = 5
+
+// MissionOverlap is a pair of missions which are likely to compute the same leaderboard.
+type MissionOverlap struct {
+	MissionA string `json:"mission_a"`
+	MissionB string `json:"mission_b"`
+	// Jaccard index of the (address, score) pairs of both missions
+	Overlap float64 `json:"overlap"`
+	// Both missions produced exactly the same scores
+	Identical bool `json:"identical"`
+	// Both registry entries point at the same function
+	SameFunc bool `json:"same_func"`
+}
+
+func (o MissionOverlap) String() string {
+	switch {
+	case o.SameFunc:
+		return fmt.Sprintf("%s and %s are registered with the same function", o.MissionA, o.MissionB)
+	case o.Identical:
+		return fmt.Sprintf("%s and %s produced identical scores", o.MissionA, o.MissionB)
+	}
+	return fmt.Sprintf("%s and %s share %.1f%% of their scores", o.MissionA, o.MissionB, o.Overlap*100)
+}
+
+// RegistryDuplicates finds entries of LEADERBOARD_MISSIONS which point at the same function, e.g.
+// a registry entry copied without changing its Func.
+func RegistryDuplicates() []MissionOverlap {
+	duplicates := []MissionOverlap{}
+	for i, a := range LEADERBOARD_MISSIONS {
+		for _, b := range LEADERBOARD_MISSIONS[i+1:] {
+			if reflect.ValueOf(a.Func).Pointer() == reflect.ValueOf(b.Func).Pointer() {
+				duplicates = append(duplicates, MissionOverlap{MissionA: a.Name, MissionB: b.Name, Overlap: 1, SameFunc: true})
+			}
+		}
+	}
+	return duplicates
+}
+
+func scoreSet(scores []LeaderboardScore) map[string]bool {
+	set := make(map[string]bool, len(scores))
+	for _, score := range scores {
+		set[fmt.Sprintf("%s:%d", score.Address, score.Score)] = true
+	}
+	return set
+}
+
+// ScoreOverlaps compares the scores of every pair of missions and returns the pairs which share at
+// least threshold of their (address, score) pairs. Missions with fewer than DUPLICATE_MIN_SCORES
+// scores are not compared.
+func ScoreOverlaps(missionScores map[string][]LeaderboardScore, threshold float64) []MissionOverlap {
+	names := make([]string, 0, len(missionScores))
+	sets := make(map[string]map[string]bool)
+	for name, scores := range missionScores {
+		if len(scores) == 0 || len(scores) < DUPLICATE_MIN_SCORES {
+			continue
+		}
+		names = append(names, name)
+		sets[name] = scoreSet(scores)
+	}
+	sort.Strings(names)
+
+	overlaps := []MissionOverlap{}
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			var common int
+			for key := range sets[a] {
+				if sets[b][key] {
+					common++
+				}
+			}
+			union := len(sets[a]) + len(sets[b]) - common
+			overlap := float64(common) / float64(union)
+			if overlap < threshold {
+				continue
+			}
+			overlaps = append(overlaps, MissionOverlap{MissionA: a, MissionB: b, Overlap: overlap, Identical: common == union})
+		}
+	}
+	return overlaps
+}
+
+// CheckDuplicateMissions runs every mission on the events file, without writing or pushing scores,
+// and reports registry entries sharing a function and missions with overlapping scores. Missions run
+// with the settings of opts.
+func CheckDuplicateMissions(ctx context.Context, opts MissionOptions, infile string, threshold float64) ([]MissionOverlap, error) {
+	missionScores := make(map[string][]LeaderboardScore)
+
+	noOutfile, noToken, noLeaderboard := "", "", ""
+	for _, lm := range LEADERBOARD_MISSIONS {
+		var scores []LeaderboardScore
+		lmOpts := opts.ForMission(lm, ScoreThresholds{})
+		lmOpts.Capture = &scores
+		if missionErr := lm.Func(ctx, lmOpts, &infile, &noOutfile, &noToken, &noLeaderboard); missionErr != nil {
+			return nil, fmt.Errorf("Failed %s mission, err: %v", lm.Name, missionErr)
+		}
+		missionScores[lm.Name] = scores
+	}
+
+	return append(RegistryDuplicates(), ScoreOverlaps(missionScores, threshold)...), nil
+}
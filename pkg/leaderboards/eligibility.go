@@ -0,0 +1,123 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Requirement is the progress of a crew towards one of the requirements of a mission, e.g. 4 of 5
+// buy orders.
+type Requirement struct {
+	Name string `json:"name"`
+	Have uint64 `json:"have"`
+	Need uint64 `json:"need"`
+}
+
+func (r Requirement) Met() bool {
+	return r.Have >= r.Need
+}
+
+func (r Requirement) String() string {
+	return fmt.Sprintf("%d/%d %s", r.Have, r.Need, r.Name)
+}
+
+// RequirementsMet reports whether all requirements are met, generators use it to set completion
+// flags so that the eligibility command reports the same counters.
+func RequirementsMet(requirements []Requirement) bool {
+	for _, requirement := range requirements {
+		if !requirement.Met() {
+			return false
+		}
+	}
+	return true
+}
+
+// Eligibility is the progress of an address (crew ID or wallet) towards completing a mission.
+type Eligibility struct {
+	Mission  string `json:"mission"`
+	Address  string `json:"address"`
+	Score    uint64 `json:"score"`
+	Complete bool   `json:"complete"`
+	// False if none of the address' events counted towards the mission
+	Participated bool          `json:"participated"`
+	Requirements []Requirement `json:"requirements"`
+}
+
+func (e Eligibility) String() string {
+	status := "not complete"
+	if e.Complete {
+		status = "complete"
+	}
+	lines := []string{fmt.Sprintf("%s, %s: %s, score %d", e.Mission, e.Address, status, e.Score)}
+	if !e.Participated {
+		lines = append(lines, "  no events counted towards the mission yet")
+	}
+	for _, requirement := range e.Requirements {
+		mark := " "
+		if requirement.Met() {
+			mark = "x"
+		}
+		lines = append(lines, fmt.Sprintf("  [%s] %s", mark, requirement))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// scoreRequirements returns the requirements reported by the generator, or falls back to the
+// must_reach counter of community and progress missions.
+func scoreRequirements(score LeaderboardScore) []Requirement {
+	if len(score.Requirements) > 0 {
+		return score.Requirements
+	}
+	if score.PointsData.MustReach > 0 {
+		return []Requirement{{Name: "progress", Have: score.PointsData.MustReachCounter, Need: score.PointsData.MustReach}}
+	}
+	return []Requirement{}
+}
+
+// CheckEligibility runs the mission's generator on the events file, without writing or pushing
+// scores, and reports the requirements the address has and has not met yet. The mission runs with
+// the settings of opts.
+func CheckEligibility(ctx context.Context, opts MissionOptions, missionName, infile, address string) (Eligibility, error) {
+	eligibility := Eligibility{Mission: missionName, Address: address, Requirements: []Requirement{}}
+
+	mission, missionErr := FindMission(missionName)
+	if missionErr != nil {
+		return eligibility, missionErr
+	}
+
+	var scores []LeaderboardScore
+	opts = opts.ForMission(*mission, ScoreThresholds{})
+	opts.Capture = &scores
+
+	noOutfile, noToken, noLeaderboard := "", "", ""
+	if missionErr := mission.Func(ctx, opts, &infile, &noOutfile, &noToken, &noLeaderboard); missionErr != nil {
+		return eligibility, fmt.Errorf("Failed %s mission, err: %v", mission.Name, missionErr)
+	}
+
+	for _, score := range scores {
+		if score.Address != address {
+			continue
+		}
+		eligibility.Participated = true
+		eligibility.Score = score.Score
+		eligibility.Complete = score.PointsData.Complete != nil && *score.PointsData.Complete
+		eligibility.Requirements = scoreRequirements(score)
+		return eligibility, nil
+	}
+
+	// Addresses without events have no score, their requirements are those of other addresses
+	// with nothing done yet
+	for _, score := range scores {
+		requirements := scoreRequirements(score)
+		if len(requirements) == 0 {
+			continue
+		}
+		for _, requirement := range requirements {
+			requirement.Have = 0
+			eligibility.Requirements = append(eligibility.Requirements, requirement)
+		}
+		break
+	}
+	return eligibility, nil
+}
@@ -0,0 +1,189 @@
+package leaderboards
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Number of addresses resolved concurrently, set with the --enrichment-workers flag.
+var ENRICHMENT_WORKERS = 8
+
+// Requests per second sent to each external API host, set with the --enrichment-rate flag. Workers
+// share the limit, so raising the number of workers never hammers an API harder than this.
+var ENRICHMENT_RATE_PER_HOST = 5.0
+
+// Requests which fail with a network error, a rate limit or a server error are retried with
+// exponential backoff, starting at ENRICHMENT_BACKOFF and doubling up to ENRICHMENT_MAX_BACKOFF.
+var (
+	ENRICHMENT_ATTEMPTS    = 5
+	ENRICHMENT_BACKOFF     = 500 * time.Millisecond
+	ENRICHMENT_MAX_BACKOFF = 30 * time.Second
+)
+
+// Resolved names are written to the cache file every this many lookups, so an interrupted run
+// keeps the names it resolved.
+var ENRICHMENT_SAVE_EVERY = 500
+
+type hostLimiter struct {
+	mu       sync.Mutex
+	next     time.Time
+	interval time.Duration
+}
+
+// wait blocks until the next request to the host may be sent.
+func (l *hostLimiter) wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// EnrichmentClient sends requests to external APIs used to enrich scores, with a rate limit per
+// host and retries with exponential backoff. It is safe for concurrent use.
+type EnrichmentClient struct {
+	RatePerHost float64
+	Attempts    int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+
+	client   *http.Client
+	mu       sync.Mutex
+	limiters map[string]*hostLimiter
+}
+
+func NewEnrichmentClient(ratePerHost float64) *EnrichmentClient {
+	return &EnrichmentClient{
+		RatePerHost: ratePerHost,
+		Attempts:    ENRICHMENT_ATTEMPTS,
+		Backoff:     ENRICHMENT_BACKOFF,
+		MaxBackoff:  ENRICHMENT_MAX_BACKOFF,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		limiters:    make(map[string]*hostLimiter),
+	}
+}
+
+func (c *EnrichmentClient) limiter(host string) *hostLimiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limiter, ok := c.limiters[host]
+	if !ok {
+		limiter = &hostLimiter{}
+		if c.RatePerHost > 0 {
+			limiter.interval = time.Duration(float64(time.Second) / c.RatePerHost)
+		}
+		c.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// retryAfter returns the delay asked for by the Retry-After header of a response, in seconds, or 0.
+func retryAfter(response *http.Response) time.Duration {
+	seconds, parseErr := strconv.Atoi(response.Header.Get("Retry-After"))
+	if parseErr != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Get requests the URL once the rate limit of its host allows it. Network errors, rate limits
+// (429) and server errors are retried, other responses are returned to the caller as they are.
+func (c *EnrichmentClient) Get(rawURL string) (*http.Response, error) {
+	parsedURL, parseErr := url.Parse(rawURL)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	limiter := c.limiter(parsedURL.Host)
+
+	backoff := c.Backoff
+	var requestErr error
+	for attempt := 1; ; attempt++ {
+		limiter.wait()
+		response, responseErr := c.client.Get(rawURL)
+
+		delay := backoff
+		if responseErr != nil {
+			requestErr = responseErr
+		} else if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+			requestErr = fmt.Errorf("status code: %d", response.StatusCode)
+			if wait := retryAfter(response); wait > delay {
+				delay = wait
+			}
+			response.Body.Close()
+		} else {
+			return response, nil
+		}
+
+		if attempt >= c.Attempts {
+			return nil, requestErr
+		}
+		time.Sleep(delay)
+		backoff *= 2
+		if backoff > c.MaxBackoff {
+			backoff = c.MaxBackoff
+		}
+	}
+}
+
+// ResolveConcurrently resolves the addresses with ENRICHMENT_WORKERS workers and returns their
+// names, skipping addresses without one. The cache file of the resolver is saved every
+// ENRICHMENT_SAVE_EVERY lookups.
+func ResolveConcurrently(resolver *NameResolver, addresses []string) map[string]string {
+	workers := ENRICHMENT_WORKERS
+	if workers < 1 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	names := make(map[string]string)
+	resolved := 0
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range jobs {
+				name := resolver.Resolve(address)
+
+				mu.Lock()
+				if name != "" {
+					names[address] = name
+				}
+				resolved++
+				save := ENRICHMENT_SAVE_EVERY > 0 && resolved%ENRICHMENT_SAVE_EVERY == 0
+				if save {
+					log.Printf("Resolved names of %d of %d addresses", resolved, len(addresses))
+				}
+				mu.Unlock()
+
+				if save {
+					if saveErr := resolver.Save(); saveErr != nil {
+						log.Printf("Unable to save names cache, err: %v", saveErr)
+					}
+				}
+			}
+		}()
+	}
+
+	for _, address := range addresses {
+		jobs <- address
+	}
+	close(jobs)
+	wg.Wait()
+
+	return names
+}
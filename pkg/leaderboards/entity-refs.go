@@ -0,0 +1,178 @@
+package leaderboards
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Type labels of Influence_Common_Types_Entity_Entity, by their numeric label.
+var ENTITY_TYPE_LABELS = map[uint64]string{
+	1: "Crew",
+	2: "Crewmate",
+	3: "Asteroid",
+	4: "Lot",
+	5: "Building",
+	6: "Ship",
+	7: "Deposit",
+	8: "Delivery",
+	9: "Space",
+}
+
+// EntityRef is an entity reference as shown in points_data with --entity-refs.
+type EntityRef struct {
+	Id        uint64 `json:"id"`
+	TypeLabel string `json:"type_label"`
+	Name      string `json:"name,omitempty"`
+}
+
+// EntityKey identifies an entity by its label and ID.
+type EntityKey struct {
+	Label uint64
+	Id    uint64
+}
+
+// EntityRefs converts the entity references of points_data into EntityRef objects, named with the
+// names given to the entities in game.
+type EntityRefs struct {
+	Names map[EntityKey]string
+
+	namesFile string
+}
+
+// NewEntityRefs returns the entity reference serializer enabled with the --entity-refs flag. Names
+// are loaded from the events file with LoadNames once it is known.
+func NewEntityRefs() *EntityRefs {
+	return &EntityRefs{Names: make(map[EntityKey]string)}
+}
+
+// LoadNames reads the latest name of every entity from the NameChanged events of the events file.
+// Files which were already read are not read again.
+func (r *EntityRefs) LoadNames(infile string) error {
+	if r == nil || infile == "" || infile == r.namesFile {
+		return nil
+	}
+
+	filePath := infile
+	if info, statErr := os.Stat(filePath); statErr == nil && info.IsDir() {
+		filePath = filepath.Join(filePath, influence.PartitionFileName(influence.Event_NameChanged))
+		if _, statErr := os.Stat(filePath); os.IsNotExist(statErr) {
+			r.namesFile = infile
+			return nil
+		}
+	}
+	inputFile, openErr := os.Open(filePath)
+	if openErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+	}
+	defer inputFile.Close()
+
+	names := make(map[EntityKey]string)
+	scanner := bufio.NewScanner(inputFile)
+	for scanner.Scan() {
+		var line PartialEventLine
+		if json.Unmarshal(scanner.Bytes(), &line) != nil || line.Name != influence.Event_NameChanged {
+			continue
+		}
+		// Names are decoded into short strings when events are parsed, so they do not fit the
+		// *big.Int of the generated struct
+		var event struct {
+			Entity influence.Influence_Common_Types_Entity_Entity
+			Name   struct {
+				Value any
+			}
+		}
+		if json.Unmarshal(line.Event, &event) != nil {
+			continue
+		}
+		if name, ok := event.Name.Value.(string); ok {
+			names[EntityKey{Label: event.Entity.Label, Id: event.Entity.Id}] = name
+		}
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return fmt.Errorf("Error reading file: %v", scanErr)
+	}
+
+	r.Names = names
+	r.namesFile = infile
+	return nil
+}
+
+// Ref returns the reference of an entity.
+func (r *EntityRefs) Ref(label, id uint64) EntityRef {
+	typeLabel, ok := ENTITY_TYPE_LABELS[label]
+	if !ok {
+		typeLabel = strconv.FormatUint(label, 10)
+	}
+	return EntityRef{Id: id, TypeLabel: typeLabel, Name: r.Names[EntityKey{Label: label, Id: id}]}
+}
+
+// Convert returns points_data data with every entity reference, an object with only numeric
+// Label and Id, replaced by its EntityRef. Data without entity references is returned unchanged.
+func (r *EntityRefs) Convert(data any) any {
+	if r == nil || data == nil {
+		return data
+	}
+
+	encoded, marshalErr := json.Marshal(data)
+	if marshalErr != nil || !bytes.Contains(encoded, []byte(`"Label"`)) {
+		return data
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded any
+	if decodeErr := decoder.Decode(&decoded); decodeErr != nil {
+		return data
+	}
+	return r.convertValue(decoded)
+}
+
+func (r *EntityRefs) convertValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if label, id, ok := entityOfJSONObject(v); ok {
+			return r.Ref(label, id)
+		}
+		for key, item := range v {
+			v[key] = r.convertValue(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.convertValue(item)
+		}
+	}
+	return value
+}
+
+func entityOfJSONObject(object map[string]any) (uint64, uint64, bool) {
+	if len(object) != 2 {
+		return 0, 0, false
+	}
+	rawLabel, labelOk := object["Label"].(json.Number)
+	rawId, idOk := object["Id"].(json.Number)
+	if !labelOk || !idOk {
+		return 0, 0, false
+	}
+	label, labelErr := strconv.ParseUint(rawLabel.String(), 10, 64)
+	id, idErr := strconv.ParseUint(rawId.String(), 10, 64)
+	if labelErr != nil || idErr != nil {
+		return 0, 0, false
+	}
+	return label, id, true
+}
+
+// Apply converts the entity references of the points_data data of the scores.
+func (r *EntityRefs) Apply(scores []LeaderboardScore) {
+	if r == nil {
+		return
+	}
+	for i := range scores {
+		scores[i].PointsData.Data = r.Convert(scores[i].PointsData.Data)
+	}
+}
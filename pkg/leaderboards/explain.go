@@ -0,0 +1,220 @@
+package leaderboards
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// When set, LatestTransactions.Record collects every transaction which contributed to each score,
+// by normalized address, not only the latest one. See ExplainScore. Generators record from the
+// goroutines of MatchByCrew, so the map is guarded by contributionsMutex.
+var contributingTransactions map[string]map[string]bool
+var contributionsMutex sync.Mutex
+
+func recordContribution(key any, transactionHash string) {
+	address := NormalizeAddress(fmt.Sprint(key))
+	contributionsMutex.Lock()
+	defer contributionsMutex.Unlock()
+	if contributingTransactions[address] == nil {
+		contributingTransactions[address] = make(map[string]bool)
+	}
+	contributingTransactions[address][transactionHash] = true
+}
+
+// ExplainedEvent is an event which contributed to a score.
+type ExplainedEvent struct {
+	Name            string          `json:"name"`
+	BlockNumber     uint64          `json:"block_number"`
+	TransactionHash string          `json:"transaction_hash"`
+	File            string          `json:"file"`
+	LineNumber      int             `json:"line_number"`
+	Event           json.RawMessage `json:"event"`
+}
+
+// ScoreExplanation lists the events behind the score of an address in a mission.
+type ScoreExplanation struct {
+	Mission      string           `json:"mission"`
+	Address      string           `json:"address"`
+	Score        uint64           `json:"score"`
+	EventCount   uint64           `json:"event_count"`
+	Complete     *bool            `json:"complete,omitempty"`
+	Requirements []Requirement    `json:"requirements,omitempty"`
+	Transactions []string         `json:"transactions"`
+	Events       []ExplainedEvent `json:"events"`
+}
+
+// ExplainScore runs the mission and returns the events which contributed to the score of the
+// address. Contributions are tracked in the aggregation itself, as the transactions generators
+// record for each score, and the events of those transactions are then read again from the events
+// file, limited to the events the mission reads and, as a transaction may carry the events of
+// several crews, to those which name the address. See eventNames. The mission runs with the
+// settings of opts.
+func ExplainScore(ctx context.Context, opts MissionOptions, missionName, infile, address string) (ScoreExplanation, error) {
+	explanation := ScoreExplanation{Mission: missionName, Address: address, Transactions: []string{}, Events: []ExplainedEvent{}}
+
+	mission, missionErr := FindMission(missionName)
+	if missionErr != nil {
+		return explanation, missionErr
+	}
+
+	var scores []LeaderboardScore
+	opts = opts.ForMission(*mission, ScoreThresholds{})
+	opts.Capture = &scores
+	requestedEvents = make(map[string]bool)
+	contributingTransactions = make(map[string]map[string]bool)
+	missionEvents := requestedEvents
+	contributions := contributingTransactions
+	defer func() {
+		requestedEvents = nil
+		contributingTransactions = nil
+	}()
+
+	noOutfile, noToken, noLeaderboard := "", "", ""
+	if runErr := mission.Func(ctx, opts, &infile, &noOutfile, &noToken, &noLeaderboard); runErr != nil {
+		return explanation, fmt.Errorf("Failed %s mission, err: %v", mission.Name, runErr)
+	}
+
+	key := NormalizeAddress(address)
+	found := false
+	for _, score := range scores {
+		if NormalizeAddress(score.Address) != key {
+			continue
+		}
+		found = true
+		explanation.Score = score.Score
+		explanation.EventCount = score.EventCount
+		explanation.Complete = score.PointsData.Complete
+		explanation.Requirements = score.Requirements
+		break
+	}
+	if !found {
+		return explanation, fmt.Errorf("address %s has no score in mission %s", address, missionName)
+	}
+
+	transactions := contributions[key]
+	for transactionHash := range transactions {
+		explanation.Transactions = append(explanation.Transactions, transactionHash)
+	}
+	sort.Strings(explanation.Transactions)
+
+	events, eventsErr := transactionEvents(infile, key, missionEvents, transactions)
+	if eventsErr != nil {
+		return explanation, eventsErr
+	}
+	explanation.Events = events
+	return explanation, nil
+}
+
+// transactionEvents reads the events with the given names emitted by the given transactions which
+// name the normalized address, in block order. The events of a transaction none of which name the
+// address are all kept, as the score was still recorded for it.
+func transactionEvents(infile, address string, names, transactions map[string]bool) ([]ExplainedEvent, error) {
+	events := []ExplainedEvent{}
+	if len(transactions) == 0 {
+		return events, nil
+	}
+	named := make(map[string]bool)
+
+	files, filesErr := influence.EventFilePaths(infile)
+	if filesErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", infile, filesErr)
+	}
+	for _, file := range files {
+		inputFile, openErr := os.Open(file)
+		if openErr != nil {
+			return nil, fmt.Errorf("Unable to read file %s, err: %v", file, openErr)
+		}
+
+		lineNumber := 0
+		scanner := bufio.NewScanner(inputFile)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			lineNumber++
+			var line PartialEventLine
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+			if !names[line.Name] || !transactions[line.TransactionHash] {
+				continue
+			}
+
+			var block struct {
+				BlockNumber uint64
+			}
+			json.Unmarshal(line.Event, &block)
+			if eventNames(line.Event, address) {
+				named[line.TransactionHash] = true
+			}
+			events = append(events, ExplainedEvent{
+				Name:            line.Name,
+				BlockNumber:     block.BlockNumber,
+				TransactionHash: line.TransactionHash,
+				File:            file,
+				LineNumber:      lineNumber,
+				Event:           append(json.RawMessage{}, line.Event...),
+			})
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return nil, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	kept := events[:0]
+	for _, event := range events {
+		if !named[event.TransactionHash] || eventNames(event.Event, address) {
+			kept = append(kept, event)
+		}
+	}
+	events = kept
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].BlockNumber < events[j].BlockNumber
+	})
+	return events, nil
+}
+
+// eventNames checks if the event names the normalized address, as an ID (the Id of an entity, a
+// TokenId) or as an account.
+func eventNames(event json.RawMessage, address string) bool {
+	decoder := json.NewDecoder(bytes.NewReader(event))
+	decoder.UseNumber()
+	var value any
+	if decodeErr := decoder.Decode(&value); decodeErr != nil {
+		return false
+	}
+
+	var names func(field string, value any) bool
+	names = func(field string, value any) bool {
+		switch v := value.(type) {
+		case map[string]any:
+			for f, child := range v {
+				if names(f, child) {
+					return true
+				}
+			}
+		case []any:
+			for _, child := range v {
+				if names(field, child) {
+					return true
+				}
+			}
+		case json.Number:
+			return strings.HasSuffix(field, "Id") && NormalizeAddress(v.String()) == address
+		case string:
+			return (strings.HasSuffix(field, "Id") || strings.HasPrefix(v, "0x")) && NormalizeAddress(v) == address
+		}
+		return false
+	}
+	return names("", value)
+}
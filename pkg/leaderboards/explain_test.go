@@ -0,0 +1,39 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestEventNames(t *testing.T) {
+	event := json.RawMessage(`{"BlockNumber":17,"Amount":42,"CallerCrew":{"Label":1,"Id":42},"Caller":"0x00ab"}`)
+	cases := map[string]bool{"42": true, "0xab": true, "17": false, "1": false, "43": false}
+	for address, expected := range cases {
+		if got := eventNames(event, address); got != expected {
+			t.Errorf("address %s: expected %v, got %v", address, expected, got)
+		}
+	}
+}
+
+func TestRecordContributionConcurrently(t *testing.T) {
+	contributingTransactions = make(map[string]map[string]bool)
+	defer func() { contributingTransactions = nil }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(crew uint64) {
+			defer wg.Done()
+			transactions := make(LatestTransactions[uint64])
+			for j := 0; j < 100; j++ {
+				transactions.Record(crew, "0x1")
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+
+	if len(contributingTransactions) != 8 {
+		t.Errorf("expected the contributions of 8 crews, got %d", len(contributingTransactions))
+	}
+}
@@ -0,0 +1,99 @@
+package leaderboards
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Transaction URL formats of supported Starknet block explorers.
+var EXPLORERS = map[string]string{
+	"voyager":   "https://voyager.online/tx/%s",
+	"starkscan": "https://starkscan.co/tx/%s",
+}
+
+// CheckExplorer returns an error if the explorer, set with the --explorer flag to link score
+// entries to their transactions, is not supported. An empty explorer disables links.
+func CheckExplorer(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := EXPLORERS[name]; !ok {
+		var names []string
+		for n := range EXPLORERS {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown explorer %s, supported explorers: %s", name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// ExplorerTransactionURL returns the link to the transaction in the explorer, or an empty string
+// if links are disabled or the transaction hash is unknown.
+func ExplorerTransactionURL(explorer, transactionHash string) string {
+	if explorer == "" || transactionHash == "" {
+		return ""
+	}
+	return fmt.Sprintf(EXPLORERS[explorer], transactionHash)
+}
+
+// EventLine is a line of an events dump. Parsed events do not carry the hash of the transaction
+// which emitted them, so it is stored next to the event. Confirmations is the number of blocks the
+// crawler waited for on top of the event's block before reading it. Timestamp is the timestamp of
+// the event's block, if the crawl was run with --block-timestamps. TransactionFee is the fee of the
+// transaction, if the crawl was run with --transaction-fees.
+type EventLine struct {
+	Name            string
+	Event           any
+	TransactionHash string          `json:",omitempty"`
+	Confirmations   int             `json:",omitempty"`
+	Timestamp       uint64          `json:",omitempty"`
+	TransactionFee  *TransactionFee `json:",omitempty"`
+	// Contract which emitted the event, by name if it is an Influence contract, set by "events"
+	Contract string `json:",omitempty"`
+	// Missions which read the event, set by "parse --annotate-missions"
+	Missions []string `json:",omitempty"`
+	// Time fields of the event (e.g. FinishTime) as times and Adalian days, set by "parse --event-times"
+	Times map[string]influence.GameTime `json:",omitempty"`
+}
+
+// NewEventLine renders a parsed event for event files, with its fields decoded as registered in
+// influence.FIELD_DECODINGS.
+func NewEventLine(parsedEvent influence.ParsedEvent, transactionHash *felt.Felt) EventLine {
+	eventLine := EventLine{Name: parsedEvent.Name, Event: influence.DecodeFields(parsedEvent.Name, parsedEvent.Event)}
+	if transactionHash != nil {
+		eventLine.TransactionHash = transactionHash.String()
+	}
+	return eventLine
+}
+
+// PartialEventLine reads lines of an events dump with the transaction hash, confirmations, block
+// timestamp and transaction fee, if they are present.
+type PartialEventLine struct {
+	influence.PartialEvent
+	TransactionHash string          `json:",omitempty"`
+	Confirmations   int             `json:",omitempty"`
+	Timestamp       uint64          `json:",omitempty"`
+	TransactionFee  *TransactionFee `json:",omitempty"`
+	Contract        string          `json:",omitempty"`
+	// Missions which read the event, set by "parse --annotate-missions"
+	Missions []string                      `json:",omitempty"`
+	Times    map[string]influence.GameTime `json:",omitempty"`
+}
+
+// LatestTransactions keeps the hash of the latest transaction which contributed to each score.
+type LatestTransactions[K comparable] map[K]string
+
+func (t LatestTransactions[K]) Record(key K, transactionHash string) {
+	if transactionHash != "" {
+		t[key] = transactionHash
+		if contributingTransactions != nil {
+			recordContribution(key, transactionHash)
+		}
+	}
+}
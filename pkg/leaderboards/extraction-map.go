@@ -0,0 +1,76 @@
+package leaderboards
+
+import (
+	"sort"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// LotExtractions is the yield extracted on a lot, by resource ID.
+type LotExtractions struct {
+	Lot uint64 `json:"lot"`
+	// Index of the lot on its asteroid, the upper bits of the lot ID
+	LotIndex    uint64            `json:"lot_index"`
+	Yield       map[uint64]uint64 `json:"yield"`
+	TotalYield  uint64            `json:"total_yield"`
+	Extractions uint64            `json:"extractions"`
+}
+
+type AsteroidExtractions struct {
+	Asteroid   uint64           `json:"asteroid"`
+	TotalYield uint64           `json:"total_yield"`
+	Lots       []LotExtractions `json:"lots"`
+}
+
+// ExtractionMap is a heatmap of extracted resources by asteroid and lot, for community mapping tools.
+type ExtractionMap struct {
+	Asteroids []AsteroidExtractions `json:"asteroids"`
+	// Extractions by extractors whose lot could not be found, e.g. planned before the crawl started
+	Unlocated uint64 `json:"unlocated"`
+}
+
+// ComputeExtractionMap sums the yield of finished extractions by the lot their extractor was
+// planned on, and by resource.
+func ComputeExtractionMap(plannedEvents EventSource[influence.ConstructionPlanned], extractionEvents EventSource[influence.ResourceExtractionFinished]) ExtractionMap {
+	buildingLots := make(map[uint64]uint64)
+	plannedEvents.Each(func(e EventWrapper[influence.ConstructionPlanned]) {
+		buildingLots[e.Event.Building.Id] = e.Event.Lot.Id
+	})
+
+	result := ExtractionMap{Asteroids: []AsteroidExtractions{}}
+	lots := make(map[uint64]*LotExtractions)
+	extractionEvents.Each(func(e EventWrapper[influence.ResourceExtractionFinished]) {
+		lot, ok := buildingLots[e.Event.Extractor.Id]
+		if e.Event.Extractor.Label != BUILDING_LABEL || !ok {
+			result.Unlocated++
+			return
+		}
+		if _, ok := lots[lot]; !ok {
+			lots[lot] = &LotExtractions{Lot: lot, LotIndex: lot >> 32, Yield: make(map[uint64]uint64)}
+		}
+		lots[lot].Yield[e.Event.Resource] += e.Event.Yield
+		lots[lot].TotalYield += e.Event.Yield
+		lots[lot].Extractions++
+	})
+
+	byAsteroid := make(map[uint64]*AsteroidExtractions)
+	for lot, extractions := range lots {
+		asteroid := LotAsteroidId(lot)
+		if _, ok := byAsteroid[asteroid]; !ok {
+			byAsteroid[asteroid] = &AsteroidExtractions{Asteroid: asteroid}
+		}
+		byAsteroid[asteroid].Lots = append(byAsteroid[asteroid].Lots, *extractions)
+		byAsteroid[asteroid].TotalYield += extractions.TotalYield
+	}
+
+	for _, asteroid := range byAsteroid {
+		sort.Slice(asteroid.Lots, func(i, j int) bool {
+			return asteroid.Lots[i].LotIndex < asteroid.Lots[j].LotIndex
+		})
+		result.Asteroids = append(result.Asteroids, *asteroid)
+	}
+	sort.Slice(result.Asteroids, func(i, j int) bool {
+		return result.Asteroids[i].Asteroid < result.Asteroids[j].Asteroid
+	})
+	return result
+}
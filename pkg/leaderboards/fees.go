@@ -0,0 +1,130 @@
+package leaderboards
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// TransactionFee is the fee paid by the transaction which emitted an event, added to events by
+// crawls run with --transaction-fees. Amount is a decimal integer in the smallest unit of the fee
+// token (WEI for ETH, FRI for STRK).
+type TransactionFee struct {
+	Amount string
+	Unit   string
+}
+
+// BlockWindow limits events to blocks From to To inclusive, 0 leaves a side unbounded.
+type BlockWindow struct {
+	From uint64
+	To   uint64
+}
+
+func (w BlockWindow) Contains(blockNumber uint64) bool {
+	return blockNumber >= w.From && (w.To == 0 || blockNumber <= w.To)
+}
+
+// GenerateTransactionFeesToScores ranks wallets by the fees, paid in unit, of transactions which
+// emitted events with a Caller in the block window. A transaction emitting several events is only
+// counted once, for the caller of its first event. Events crawled without --transaction-fees are
+// not counted. Fees are scaled with the scale of the options.
+func GenerateTransactionFeesToScores(ctx context.Context, opts MissionOptions, filePath string, window BlockWindow, unit string) ([]LeaderboardScore, error) {
+	files, filesErr := influence.EventFilePaths(filePath)
+	if filesErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, filesErr)
+	}
+
+	fees := make(BigScores[string])
+	transactionCounts := make(map[string]uint64)
+	transactions := make(LatestTransactions[string])
+	counted := make(map[string]bool)
+
+	for _, file := range files {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		inputFile, openErr := os.Open(file)
+		if openErr != nil {
+			return nil, fmt.Errorf("Unable to read file %s, err: %v", file, openErr)
+		}
+
+		scanner := bufio.NewScanner(inputFile)
+		for scanner.Scan() {
+			var line PartialEventLine
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+			if line.TransactionFee == nil || line.TransactionHash == "" || counted[line.TransactionHash] {
+				continue
+			}
+			if !strings.EqualFold(line.TransactionFee.Unit, unit) {
+				continue
+			}
+
+			var event struct {
+				BlockNumber uint64
+				Caller      string
+			}
+			if unmErr := json.Unmarshal(line.Event, &event); unmErr != nil || event.Caller == "" || !window.Contains(event.BlockNumber) {
+				continue
+			}
+			amount, ok := new(big.Int).SetString(line.TransactionFee.Amount, 10)
+			if !ok {
+				inputFile.Close()
+				return nil, fmt.Errorf("invalid fee %s of transaction %s", line.TransactionFee.Amount, line.TransactionHash)
+			}
+
+			counted[line.TransactionHash] = true
+			wallet := NormalizeAddress(event.Caller)
+			fees.AddInt(wallet, amount)
+			transactionCounts[wallet]++
+			transactions.Record(wallet, line.TransactionHash)
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return nil, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	scores := []LeaderboardScore{}
+	for wallet := range fees {
+		fee, feeErr := fees.Uint64(wallet, opts.ScoreScale)
+		if feeErr != nil {
+			return nil, fmt.Errorf("transaction fees of wallet %v", feeErr)
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         wallet,
+			Score:           fee,
+			EventCount:      transactionCounts[wallet],
+			TransactionHash: transactions[wallet],
+			PointsData: PointsData{
+				Complete: Completed(fee > 0),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " " + strings.ToUpper(unit),
+					AddressName: "Wallet",
+				},
+			},
+		})
+	}
+	return scores, nil
+}
+
+// LTransactionFees is the leaderboard of transaction fees in the block window, set with flags of
+// the transaction-fees command.
+func LTransactionFees(window BlockWindow, unit string) LeaderboardCommandCreator {
+	return func(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+		scores, scoresErr := GenerateTransactionFeesToScores(ctx, opts, *infile, window, unit)
+		if scoresErr != nil {
+			return scoresErr
+		}
+
+		return PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	}
+}
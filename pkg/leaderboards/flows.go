@@ -0,0 +1,212 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Labels of Influence_Common_Types_Entity_Entity which can hold products.
+var (
+	ASTEROID_LABEL = uint64(3)
+	LOT_LABEL      = uint64(4)
+	BUILDING_LABEL = uint64(5)
+	SHIP_LABEL     = uint64(6)
+)
+
+type shipArrival struct {
+	blockNumber uint64
+	asteroid    uint64
+}
+
+// EntityLocator finds the asteroid of buildings, from the lots they were planned on, and of ships,
+// from the destination of their latest transit.
+type EntityLocator struct {
+	buildings map[uint64]uint64
+	ships     map[uint64][]shipArrival
+}
+
+func NewEntityLocator(plannedEvents EventSource[influence.ConstructionPlanned], transitEvents EventSource[influence.TransitFinished]) *EntityLocator {
+	locator := &EntityLocator{
+		buildings: make(map[uint64]uint64),
+		ships:     make(map[uint64][]shipArrival),
+	}
+	plannedEvents.Each(func(e EventWrapper[influence.ConstructionPlanned]) {
+		locator.buildings[e.Event.Building.Id] = e.Event.Asteroid.Id
+	})
+	transitEvents.Each(func(e EventWrapper[influence.TransitFinished]) {
+		locator.ships[e.Event.Ship.Id] = append(locator.ships[e.Event.Ship.Id], shipArrival{blockNumber: e.Event.BlockNumber, asteroid: e.Event.Destination.Id})
+	})
+	for _, arrivals := range locator.ships {
+		sort.SliceStable(arrivals, func(i, j int) bool {
+			return arrivals[i].blockNumber < arrivals[j].blockNumber
+		})
+	}
+	return locator
+}
+
+// Asteroid returns the asteroid the entity was on at the given block.
+func (l *EntityLocator) Asteroid(entity influence.Influence_Common_Types_Entity_Entity, blockNumber uint64) (uint64, bool) {
+	switch entity.Label {
+	case ASTEROID_LABEL:
+		return entity.Id, true
+	case LOT_LABEL:
+		// Lot IDs hold the asteroid ID in their lower 32 bits
+		return entity.Id & 0xffffffff, true
+	case BUILDING_LABEL:
+		asteroid, ok := l.buildings[entity.Id]
+		return asteroid, ok
+	case SHIP_LABEL:
+		arrivals := l.ships[entity.Id]
+		i := sort.Search(len(arrivals), func(i int) bool {
+			return arrivals[i].blockNumber > blockNumber
+		})
+		if i == 0 {
+			return 0, false
+		}
+		return arrivals[i-1].asteroid, true
+	}
+	return 0, false
+}
+
+// ProductCategories maps product IDs to categories. Products which are not listed are their own
+// category.
+type ProductCategories map[uint64]string
+
+func (c ProductCategories) Category(product uint64) string {
+	if category, ok := c[product]; ok {
+		return category
+	}
+	return fmt.Sprintf("product-%d", product)
+}
+
+// ReadProductCategories reads categories from a JSON object of product IDs to category names.
+func ReadProductCategories(filePath string) (ProductCategories, error) {
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+	var raw map[string]string
+	if unmErr := json.Unmarshal(data, &raw); unmErr != nil {
+		return nil, fmt.Errorf("Unable to parse product categories %s, err: %v", filePath, unmErr)
+	}
+	categories := make(ProductCategories, len(raw))
+	for key, category := range raw {
+		product, parseErr := strconv.ParseUint(key, 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid product ID %s in %s", key, filePath)
+		}
+		categories[product] = category
+	}
+	return categories, nil
+}
+
+// ProductFlow is the amount of a category of products delivered from one asteroid to another.
+type ProductFlow struct {
+	Category    string `json:"category"`
+	Origin      uint64 `json:"origin"`
+	Destination uint64 `json:"destination"`
+	Amount      uint64 `json:"amount"`
+	Deliveries  uint64 `json:"deliveries"`
+}
+
+// ProductFlows holds the origin to destination matrix of received deliveries, and the amounts each
+// crew exported from one asteroid to another, by category.
+type ProductFlows struct {
+	Flows     []ProductFlow                `json:"flows"`
+	Exporters map[uint64]map[string]uint64 `json:"exporters"`
+	// Deliveries of which the origin or destination asteroid could not be found
+	Unlocated uint64 `json:"unlocated"`
+}
+
+type flowKey struct {
+	category    string
+	origin      uint64
+	destination uint64
+}
+
+// ComputeProductFlows correlates received deliveries with the crews which sent them, and locates
+// their origins and destinations on asteroids.
+func ComputeProductFlows(sentEvents EventSource[influence.DeliverySent], receivedEvents EventSource[influence.DeliveryReceived], locator *EntityLocator, categories ProductCategories) ProductFlows {
+	senders := make(map[uint64]uint64)
+	sentEvents.Each(func(e EventWrapper[influence.DeliverySent]) {
+		senders[e.Event.Delivery.Id] = e.Event.CallerCrew.Id
+	})
+
+	result := ProductFlows{Exporters: make(map[uint64]map[string]uint64)}
+	flows := make(map[flowKey]*ProductFlow)
+	receivedEvents.Each(func(e EventWrapper[influence.DeliveryReceived]) {
+		origin, originOk := locator.Asteroid(e.Event.Origin, e.Event.BlockNumber)
+		destination, destinationOk := locator.Asteroid(e.Event.Dest, e.Event.BlockNumber)
+		if !originOk || !destinationOk {
+			result.Unlocated++
+			return
+		}
+
+		sender, sent := senders[e.Event.Delivery.Id]
+		counted := make(map[flowKey]bool)
+		for _, item := range e.Event.Products.Snapshot {
+			key := flowKey{category: categories.Category(item.Product), origin: origin, destination: destination}
+			if _, ok := flows[key]; !ok {
+				flows[key] = &ProductFlow{Category: key.category, Origin: origin, Destination: destination}
+			}
+			flows[key].Amount += item.Amount
+			if !counted[key] {
+				flows[key].Deliveries++
+				counted[key] = true
+			}
+
+			if sent && origin != destination {
+				if _, ok := result.Exporters[sender]; !ok {
+					result.Exporters[sender] = make(map[string]uint64)
+				}
+				result.Exporters[sender][key.category] += item.Amount
+			}
+		}
+	})
+
+	for _, flow := range flows {
+		result.Flows = append(result.Flows, *flow)
+	}
+	sort.Slice(result.Flows, func(i, j int) bool {
+		a, b := result.Flows[i], result.Flows[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Origin != b.Origin {
+			return a.Origin < b.Origin
+		}
+		return a.Destination < b.Destination
+	})
+	return result
+}
+
+// GenerateTopExportersToScores ranks crews by the amount of products they delivered from one
+// asteroid to another.
+func GenerateTopExportersToScores(flows ProductFlows) []LeaderboardScore {
+	scores := []LeaderboardScore{}
+	for crew, byCategory := range flows.Exporters {
+		var total uint64
+		for _, amount := range byCategory {
+			total += amount
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:    fmt.Sprintf("%d", crew),
+			Score:      total,
+			EventCount: uint64(len(byCategory)),
+			PointsData: PointsData{
+				Complete: Completed(total > 0),
+				Data:     byCategory,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " unit(s) exported",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
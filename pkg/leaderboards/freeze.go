@@ -0,0 +1,286 @@
+package leaderboards
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Version of the freeze file format, part of the hash so files of later formats never verify
+// against earlier ones.
+const FREEZE_VERSION = 1
+
+// FrozenStanding is the final rank and score of an address in a frozen mission.
+type FrozenStanding struct {
+	Rank     int    `json:"rank"`
+	Address  string `json:"address"`
+	Score    uint64 `json:"score"`
+	Complete bool   `json:"complete"`
+}
+
+// Freeze is a snapshot of the final standings of a mission at the end of a round. Its hash only
+// covers the mission, the block and the standings, so anyone can recompute the standings from raw
+// events, e.g. of their own crawl, and confirm the published results.
+type Freeze struct {
+	FreezeVersion  int              `json:"freeze_version"`
+	Mission        string           `json:"mission"`
+	MissionVersion int              `json:"mission_version"`
+	AsOfBlock      uint64           `json:"as_of_block"`
+	Standings      []FrozenStanding `json:"standings"`
+	Hash           string           `json:"hash"`
+	// Not hashed, crawls of the same blocks may differ in metadata like confirmations
+	EventsHash string `json:"events_hash"`
+	Revision   string `json:"revision,omitempty"`
+	FrozenAt   string `json:"frozen_at"`
+}
+
+// ContentHash is the SHA-256 of the canonical JSON of the hashed fields.
+func (f *Freeze) ContentHash() (string, error) {
+	content := struct {
+		FreezeVersion  int              `json:"freeze_version"`
+		Mission        string           `json:"mission"`
+		MissionVersion int              `json:"mission_version"`
+		AsOfBlock      uint64           `json:"as_of_block"`
+		Standings      []FrozenStanding `json:"standings"`
+	}{f.FreezeVersion, f.Mission, f.MissionVersion, f.AsOfBlock, f.Standings}
+	data, marshErr := json.Marshal(content)
+	if marshErr != nil {
+		return "", marshErr
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// FindMission returns the mission of LEADERBOARD_MISSIONS with the given name.
+func FindMission(missionName string) (*LeaderboardCommandFunc, error) {
+	missionNames := []string{}
+	for i, lm := range LEADERBOARD_MISSIONS {
+		if lm.Name == missionName {
+			return &LEADERBOARD_MISSIONS[i], nil
+		}
+		missionNames = append(missionNames, lm.Name)
+	}
+	sort.Strings(missionNames)
+	return nil, fmt.Errorf("unknown mission %s, available missions: %s", missionName, strings.Join(missionNames, ", "))
+}
+
+// SnapshotEvents copies the events of blocks up to asOfBlock from the events file, or directory
+// partitioned by event type, into dir, and returns the path to read them from.
+func SnapshotEvents(infile string, asOfBlock uint64, dir string) (string, error) {
+	filePaths, pathsErr := influence.EventFilePaths(infile)
+	if pathsErr != nil {
+		return "", fmt.Errorf("Unable to read file %s, err: %v", infile, pathsErr)
+	}
+
+	// Directories partitioned by event type are copied file by file, so missions read the same
+	// partitions
+	snapshot := filepath.Join(dir, "events.jsonl")
+	partitioned := false
+	if info, statErr := os.Stat(infile); statErr == nil && info.IsDir() {
+		partitioned = true
+		snapshot = filepath.Join(dir, "events")
+		if mkdirErr := os.MkdirAll(snapshot, 0755); mkdirErr != nil {
+			return "", mkdirErr
+		}
+	}
+
+	for _, filePath := range filePaths {
+		outPath := snapshot
+		if partitioned {
+			outPath = filepath.Join(snapshot, filepath.Base(filePath))
+		}
+		if copyErr := copyEventsUpTo(filePath, outPath, asOfBlock); copyErr != nil {
+			return "", copyErr
+		}
+	}
+	return snapshot, nil
+}
+
+func copyEventsUpTo(filePath, outPath string, asOfBlock uint64) error {
+	inputFile, openErr := os.Open(filePath)
+	if openErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+	}
+	defer inputFile.Close()
+
+	outputFile, createErr := os.Create(outPath)
+	if createErr != nil {
+		return createErr
+	}
+	defer outputFile.Close()
+	writer := bufio.NewWriter(outputFile)
+
+	scanner := bufio.NewScanner(inputFile)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line PartialEventLine
+		if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr == nil {
+			var block struct {
+				BlockNumber uint64
+			}
+			if json.Unmarshal(line.Event, &block) == nil && block.BlockNumber > asOfBlock {
+				continue
+			}
+		}
+		// Lines which do not parse are kept, so missions report them as they would on the full file
+		writer.Write(scanner.Bytes())
+		writer.WriteByte('\n')
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return fmt.Errorf("Error reading file: %v", scanErr)
+	}
+	return writer.Flush()
+}
+
+// FreezeMission runs the mission on the events of blocks up to asOfBlock, with the thresholds of
+// the mission, and returns its standings ordered by score and address.
+func FreezeMission(ctx context.Context, opts MissionOptions, missionName, infile string, asOfBlock uint64) (Freeze, error) {
+	freeze := Freeze{FreezeVersion: FREEZE_VERSION, Mission: missionName, AsOfBlock: asOfBlock, Standings: []FrozenStanding{}}
+
+	mission, missionErr := FindMission(missionName)
+	if missionErr != nil {
+		return freeze, missionErr
+	}
+	freeze.MissionVersion = mission.Version
+
+	tempDir, tempErr := os.MkdirTemp("", "influence-eth-freeze-")
+	if tempErr != nil {
+		return freeze, tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	snapshot, snapshotErr := SnapshotEvents(infile, asOfBlock, tempDir)
+	if snapshotErr != nil {
+		return freeze, snapshotErr
+	}
+	eventsHash, _, _, hashErr := HashEventsInput(snapshot)
+	if hashErr != nil {
+		return freeze, hashErr
+	}
+	freeze.EventsHash = eventsHash
+
+	var scores []LeaderboardScore
+	opts = opts.ForMission(*mission, ScoreThresholds{})
+	opts.Capture = &scores
+	// Events past the block are already cut off, confirmations would be counted against the head
+	// of the snapshot instead of the chain
+	opts.Events.MinConfirmations = 0
+
+	noOutfile, noToken, noLeaderboard := "", "", ""
+	if runErr := mission.Func(ctx, opts, &snapshot, &noOutfile, &noToken, &noLeaderboard); runErr != nil {
+		return freeze, fmt.Errorf("Failed %s mission, err: %v", mission.Name, runErr)
+	}
+	thresholds := ScoreThresholds{MinScore: mission.MinScore, MinEvents: mission.MinEvents}
+	scores = thresholds.Filter(scores)
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Address < scores[j].Address
+	})
+	for i, score := range scores {
+		rank := i + 1
+		// Addresses with the same score share the rank of the first of them
+		if i > 0 && score.Score == scores[i-1].Score {
+			rank = freeze.Standings[i-1].Rank
+		}
+		freeze.Standings = append(freeze.Standings, FrozenStanding{
+			Rank:     rank,
+			Address:  score.Address,
+			Score:    score.Score,
+			Complete: score.PointsData.Complete != nil && *score.PointsData.Complete,
+		})
+	}
+
+	hash, contentErr := freeze.ContentHash()
+	if contentErr != nil {
+		return freeze, contentErr
+	}
+	freeze.Hash = hash
+	freeze.Revision = BuildRevision()
+	freeze.FrozenAt = time.Now().UTC().Format(time.RFC3339)
+	return freeze, nil
+}
+
+func ReadFreeze(filePath string) (Freeze, error) {
+	var freeze Freeze
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return freeze, fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+	if unmErr := json.Unmarshal(data, &freeze); unmErr != nil {
+		return freeze, fmt.Errorf("Unable to parse freeze file %s, err: %v", filePath, unmErr)
+	}
+	return freeze, nil
+}
+
+// FreezeMismatch describes how recomputed standings differ from a freeze file.
+type FreezeMismatch struct {
+	StoredHash     string
+	RecomputedHash string
+	// Addresses whose rank, score or completion differ, or which are only in one of the standings
+	Addresses []string
+}
+
+func (m *FreezeMismatch) Error() string {
+	shown := m.Addresses
+	if len(shown) > 10 {
+		shown = shown[:10]
+	}
+	return fmt.Sprintf("freeze hash %s does not match recomputed hash %s, %d address(es) differ: %s", m.StoredHash, m.RecomputedHash, len(m.Addresses), strings.Join(shown, ", "))
+}
+
+// VerifyFreeze recomputes the standings of a freeze file from the events file and checks them
+// against its hash. The stored hash is checked against the stored standings as well, so edited
+// freeze files are detected even if the events produce the edited standings.
+func VerifyFreeze(ctx context.Context, opts MissionOptions, stored Freeze, infile string) (Freeze, error) {
+	storedHash, hashErr := stored.ContentHash()
+	if hashErr != nil {
+		return Freeze{}, hashErr
+	}
+	if storedHash != stored.Hash {
+		return Freeze{}, fmt.Errorf("freeze file was modified, its hash %s does not match its content hash %s", stored.Hash, storedHash)
+	}
+	if stored.FreezeVersion != FREEZE_VERSION {
+		return Freeze{}, fmt.Errorf("unsupported freeze version %d, expected %d", stored.FreezeVersion, FREEZE_VERSION)
+	}
+
+	recomputed, freezeErr := FreezeMission(ctx, opts, stored.Mission, infile, stored.AsOfBlock)
+	if freezeErr != nil {
+		return recomputed, freezeErr
+	}
+	if recomputed.Hash == stored.Hash {
+		return recomputed, nil
+	}
+
+	standings := make(map[string]FrozenStanding, len(stored.Standings))
+	for _, standing := range stored.Standings {
+		standings[standing.Address] = standing
+	}
+	mismatch := &FreezeMismatch{StoredHash: stored.Hash, RecomputedHash: recomputed.Hash, Addresses: []string{}}
+	for _, standing := range recomputed.Standings {
+		if previous, ok := standings[standing.Address]; !ok || previous != standing {
+			mismatch.Addresses = append(mismatch.Addresses, standing.Address)
+		}
+		delete(standings, standing.Address)
+	}
+	for address := range standings {
+		mismatch.Addresses = append(mismatch.Addresses, address)
+	}
+	sort.Strings(mismatch.Addresses)
+	if recomputed.MissionVersion != stored.MissionVersion {
+		return recomputed, fmt.Errorf("%v (mission version changed from %d to %d)", mismatch, stored.MissionVersion, recomputed.MissionVersion)
+	}
+	return recomputed, mismatch
+}
@@ -0,0 +1,48 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// Time each mission of the leaderboards command may run for, set with the --mission-timeout flag.
+// Missions are not limited if it is 0.
+var MISSION_TIMEOUT time.Duration = 0
+
+// RunMission runs the generator of a mission, returning an error instead if it panics or runs for
+// longer than timeout (if positive), so one failing mission does not stop the others. The context
+// of a mission which timed out is cancelled, and RunMission waits for its generator to return so it
+// never runs alongside the next mission. Generators stop reading and matching events and never
+// write scores once their context is done, so the wait is short.
+func RunMission(ctx context.Context, lm LeaderboardCommandFunc, opts MissionOptions, timeout time.Duration, infile, outfile, accessToken, leaderboardId *string) error {
+	missionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Mission %s panicked: %v\n%s", lm.Name, r, debug.Stack())
+				result <- fmt.Errorf("mission %s panicked: %v", lm.Name, r)
+			}
+		}()
+		result <- lm.Func(missionCtx, opts, infile, outfile, accessToken, leaderboardId)
+	}()
+
+	if timeout <= 0 {
+		return <-result
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		cancel()
+		<-result
+		return fmt.Errorf("mission %s timed out after %s", lm.Name, timeout)
+	}
+}
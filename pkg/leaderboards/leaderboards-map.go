@@ -0,0 +1,96 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LeaderboardTarget describes where the scores of a mission are pushed to. AccessToken and APIURL
+// are optional, if they are empty the values passed to the leaderboards command are used.
+type LeaderboardTarget struct {
+	LeaderboardId string `json:"leaderboard_id"`
+	AccessToken   string `json:"token,omitempty"`
+	APIURL        string `json:"api_url,omitempty"`
+	// Score sinks of the target, see NewScoreSink; the --sink flag applies if unset
+	Sinks []string `json:"sinks,omitempty"`
+	// Format of the addresses pushed to the target, see FormatAddress; the --address-format flag
+	// applies if unset
+	AddressFormat string `json:"address_format,omitempty"`
+}
+
+// LeaderboardTargets is a value of the leaderboards map. It may be written in the map file as a
+// plain leaderboard ID string, as a single target object or as a list of target objects, so that
+// one mission can be pushed to leaderboards of multiple communities.
+type LeaderboardTargets []LeaderboardTarget
+
+func (t *LeaderboardTargets) UnmarshalJSON(data []byte) error {
+	var leaderboardId string
+	if err := json.Unmarshal(data, &leaderboardId); err == nil {
+		*t = LeaderboardTargets{{LeaderboardId: leaderboardId}}
+		return nil
+	}
+
+	var target LeaderboardTarget
+	if err := json.Unmarshal(data, &target); err == nil {
+		*t = LeaderboardTargets{target}
+		return t.validate()
+	}
+
+	var targets []LeaderboardTarget
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return errors.New("leaderboards map value must be a leaderboard ID, a target object or a list of target objects")
+	}
+	*t = targets
+	return t.validate()
+}
+
+func (t LeaderboardTargets) validate() error {
+	for _, target := range t {
+		if target.LeaderboardId == "" {
+			return errors.New("leaderboard_id is required for each leaderboards map target")
+		}
+		if formatErr := CheckAddressFormat(target.AddressFormat); formatErr != nil {
+			return formatErr
+		}
+	}
+	return nil
+}
+
+func ReadLeaderboardsMap(filePath string) (map[string]LeaderboardTargets, error) {
+	byteValue, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+
+	leaderboardsMap := make(map[string]LeaderboardTargets)
+	if unmErr := json.Unmarshal(byteValue, &leaderboardsMap); unmErr != nil {
+		return nil, fmt.Errorf("Error unmarshalling JSON, err: %v", unmErr)
+	}
+
+	return leaderboardsMap, nil
+}
+
+// WriteLeaderboardsMap writes the leaderboards map readable by its owner only, as targets may
+// hold access tokens.
+func WriteLeaderboardsMap(filePath string, leaderboardsMap map[string]LeaderboardTargets) error {
+	byteValue, marshErr := json.MarshalIndent(leaderboardsMap, "", "    ")
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling leaderboards map: %v", marshErr)
+	}
+	if writeErr := os.WriteFile(filePath, append(byteValue, '\n'), 0600); writeErr != nil {
+		return writeErr
+	}
+	// WriteFile keeps the permissions of an existing file
+	return os.Chmod(filePath, 0600)
+}
+
+// LeaderboardTargetLabel names a target in logs, missions pushed to several leaderboards are
+// distinguished by leaderboard ID.
+func LeaderboardTargetLabel(mission string, target LeaderboardTarget, targets LeaderboardTargets) string {
+	if len(targets) > 1 {
+		return fmt.Sprintf("%s (%s)", mission, target.LeaderboardId)
+	}
+	return mission
+}
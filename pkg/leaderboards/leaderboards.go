@@ -0,0 +1,2504 @@
+// Package leaderboards computes scores of Influence.eth missions from parsed events and pushes them
+// to Moonstream.to leaderboards.
+package leaderboards
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"math/bits"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/chaos"
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+var (
+	// Moonstream.to API of commands and leaderboards map targets without an API URL, see
+	// PortalAPIURL
+	MOONSTREAM_API_URL = os.Getenv("MOONSTREAM_API_URL")
+	// Access token used by commands without a --token flag, see ApplyProfile
+	MOONSTREAM_ACCESS_TOKEN = os.Getenv("MOONSTREAM_ACCESS_TOKEN")
+)
+
+type LeaderboardScore struct {
+	Address    string     `json:"address"`
+	Score      uint64     `json:"score"`
+	PointsData PointsData `json:"points_data"`
+	// Number of events behind the score, checked against minimum participation thresholds
+	EventCount uint64 `json:"-"`
+	// Latest transaction behind the score, linked with --explorer
+	TransactionHash string `json:"-"`
+	// Progress towards the requirements of the mission, reported by the eligibility command
+	Requirements []Requirement `json:"-"`
+}
+
+type ScoreDetails struct {
+	Prefix           string `json:"prefix,omitempty"`
+	Postfix          string `json:"postfix,omitempty"`
+	Conversion       uint64 `json:"conversion,omitempty"`
+	ConversionVector string `json:"conversion_vector,omitempty"`
+	AddressName      string `json:"address_name,omitempty"`
+}
+
+type TokenKey struct {
+	Str    string
+	BigInt *big.Int
+}
+
+type EventWrapper[T any] struct {
+	EventLineNumber int
+	TransactionHash string
+	Event           T
+}
+
+// RequireUnpartitionedEvents returns an error if filePath is a directory of events partitioned by
+// type. Missions which pair events of different types by their position in the events file need a
+// single file, as line numbers of separate partition files do not correlate.
+func RequireUnpartitionedEvents(filePath, mission string) error {
+	if info, statErr := os.Stat(filePath); statErr == nil && info.IsDir() {
+		return fmt.Errorf("Mission %s pairs events by their order in the events file and cannot read events partitioned by type in %s, parse them into a single file instead", mission, filePath)
+	}
+	return nil
+}
+
+// EventOptions set which events missions read from event files.
+type EventOptions struct {
+	// Events with fewer blocks on top of them are skipped, see ConfirmedBlock. All events are read
+	// if it is 0.
+	MinConfirmations uint64
+	// Chain head confirmations are counted against, estimated from the events file if it is 0
+	ChainHead uint64
+	// Events of generators which only keep per-crew accumulators are read from file on every pass
+	// instead of being loaded into memory, see LoadEvents
+	Stream bool `json:"-"`
+}
+
+func ParseEventFromFile[T any](ctx context.Context, opts EventOptions, filePath string) ([]EventWrapper[T], error) {
+	var events []EventWrapper[T]
+	streamErr := StreamEventsFromFile(ctx, opts, filePath, func(eventWrapper EventWrapper[T]) {
+		events = append(events, eventWrapper)
+	})
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	return events, nil
+}
+
+// StreamEventsFromFile reads events of type T one by one and passes them to handle without keeping
+// them in memory. The name of the events is looked up in EVENT_REGISTRY. Events which are not
+// confirmed by the options are skipped. Reading stops with the error of ctx once it is done.
+func StreamEventsFromFile[T any](ctx context.Context, opts EventOptions, filePath string, handle func(EventWrapper[T])) error {
+	eventInfo, eventInfoErr := influence.EventInfoOf[T]()
+	if eventInfoErr != nil {
+		return eventInfoErr
+	}
+	expectedEventName := eventInfo.Name
+	if requestedEvents != nil {
+		requestedEvents[expectedEventName] = true
+	}
+
+	confirmedBlock, checkConfirmations, confirmedErr := opts.ConfirmedBlock(filePath)
+	if confirmedErr != nil {
+		return confirmedErr
+	}
+
+	var inputFile *os.File
+	var readErr error
+
+	if filePath != "" {
+		// Events partitioned by type are read from the file of the expected event only
+		if info, statErr := os.Stat(filePath); statErr == nil && info.IsDir() {
+			filePath = filepath.Join(filePath, influence.PartitionFileName(expectedEventName))
+			if _, statErr := os.Stat(filePath); os.IsNotExist(statErr) {
+				return nil
+			}
+		}
+		inputFile, readErr = os.Open(filePath)
+		if readErr != nil {
+			return fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+		}
+	} else {
+		return fmt.Errorf("Please specify file with events with --input flag")
+	}
+
+	defer inputFile.Close()
+
+	// Missions read the same files many times per run, lines are counted on the first read of the
+	// file and events on the first read of their type from it
+	countLines := PARSE_STATS.firstRead(filePath)
+	countEvents := PARSE_STATS.firstRead(filePath + "#" + expectedEventName)
+
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(inputFile)
+	for scanner.Scan() {
+		lineNumber++
+		if lineNumber%CONTEXT_CHECK_LINES == 0 {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+		}
+
+		if countLines {
+			PARSE_STATS.Lines++
+		}
+
+		var line PartialEventLine
+		unmErr := json.Unmarshal(chaos.MalformLine(scanner.Bytes()), &line)
+		if unmErr != nil {
+			if countLines {
+				log.Printf("Error parsing JSON line: %v", unmErr)
+				PARSE_STATS.Skipped++
+			}
+			continue
+		}
+
+		if countLines && !influence.REGISTERED_EVENT_NAMES[line.Name] {
+			PARSE_STATS.Unmatched++
+		}
+
+		if line.Name != expectedEventName {
+			continue
+		}
+
+		var event T
+		unmEventErr := json.Unmarshal(line.Event, &event)
+		if unmEventErr != nil {
+			if countEvents {
+				log.Printf("Error parsing Event: %v", unmEventErr)
+				PARSE_STATS.DecodeFailures++
+			}
+			continue
+		}
+
+		if checkConfirmations {
+			var block struct {
+				BlockNumber uint64
+			}
+			if unmErr := json.Unmarshal(line.Event, &block); unmErr == nil && block.BlockNumber > confirmedBlock {
+				if countEvents {
+					PARSE_STATS.Unconfirmed++
+				}
+				continue
+			}
+		}
+
+		handle(EventWrapper[T]{
+			EventLineNumber: lineNumber,
+			TransactionHash: line.TransactionHash,
+			Event:           event,
+		})
+	}
+
+	if scanErr := scanner.Err(); scanErr != nil {
+		return fmt.Errorf("Error reading file: %v", scanErr)
+	}
+
+	return nil
+}
+
+// Number of lines of event files read between checks whether the mission was cancelled.
+const CONTEXT_CHECK_LINES = 4096
+
+// ParseStats counts lines of event files which could not be used.
+type ParseStats struct {
+	Lines uint64
+	// Lines which are not valid JSON
+	Skipped uint64
+	// Lines with event names which are not in EVENT_REGISTRY
+	Unmatched uint64
+	// Events which could not be decoded into their Go structs
+	DecodeFailures uint64
+	// Events skipped for having fewer confirmations than the missions require
+	Unconfirmed uint64
+
+	// Files, and event types of files, already read during the run
+	read map[string]bool
+}
+
+// firstRead records a read of the file or event type key and returns whether it is the first one
+// of the run.
+func (s *ParseStats) firstRead(key string) bool {
+	if s.read == nil {
+		s.read = make(map[string]bool)
+	}
+	if s.read[key] {
+		return false
+	}
+	s.read[key] = true
+	return true
+}
+
+// Statistics of event files read during the run, reported by the leaderboard commands on exit.
+var PARSE_STATS ParseStats
+
+// Ratio of failed lines above which the leaderboard commands exit with an error, set with the
+// --max-failure-ratio flag.
+var MAX_PARSE_FAILURE_RATIO = 0.05
+
+// FailureRatio returns the share of lines which were skipped or could not be decoded.
+func (s ParseStats) FailureRatio() float64 {
+	if s.Lines == 0 {
+		return 0
+	}
+	return float64(s.Skipped+s.DecodeFailures) / float64(s.Lines)
+}
+
+// Report logs the summary of the run and returns an error if the failure ratio exceeds
+// MAX_PARSE_FAILURE_RATIO.
+func (s ParseStats) Report() error {
+	// Commands which do not read event files have nothing to report
+	if s.Lines == 0 {
+		return nil
+	}
+	log.Printf("Read %d event lines: %d skipped as invalid JSON, %d with unmatched event names, %d failed to decode", s.Lines, s.Skipped, s.Unmatched, s.DecodeFailures)
+	if s.Unconfirmed > 0 {
+		log.Printf("Skipped %d events with fewer confirmations than --min-confirmations", s.Unconfirmed)
+	}
+	if ratio := s.FailureRatio(); ratio > MAX_PARSE_FAILURE_RATIO {
+		return fmt.Errorf("%.2f%% of event lines failed to parse, more than the allowed %.2f%%", ratio*100, MAX_PARSE_FAILURE_RATIO*100)
+	}
+	return nil
+}
+
+// EventSource passes events to generators in order.
+type EventSource[T any] interface {
+	Each(handle func(EventWrapper[T]))
+	Err() error
+}
+
+// SliceEventSource serves events loaded into memory.
+type SliceEventSource[T any] []EventWrapper[T]
+
+func (s SliceEventSource[T]) Each(handle func(EventWrapper[T])) {
+	for _, e := range s {
+		handle(e)
+	}
+}
+
+func (s SliceEventSource[T]) Err() error {
+	return nil
+}
+
+// FileEventSource reads events from file on each pass. Errors of the passes are collected and
+// reported by Err, so they should be checked after the generator returns.
+type FileEventSource[T any] struct {
+	Ctx      context.Context
+	Options  EventOptions
+	FilePath string
+
+	err error
+}
+
+func (s *FileEventSource[T]) Each(handle func(EventWrapper[T])) {
+	if streamErr := StreamEventsFromFile(s.Ctx, s.Options, s.FilePath, handle); streamErr != nil && s.err == nil {
+		s.err = streamErr
+	}
+}
+
+func (s *FileEventSource[T]) Err() error {
+	return s.err
+}
+
+// LoadEvents returns a source of events of type T, streamed from file in the streaming mode of the
+// options, set with the --stream flag for machines with limited RAM, or loaded into memory
+// otherwise.
+func LoadEvents[T any](ctx context.Context, opts EventOptions, filePath string) (EventSource[T], error) {
+	if opts.Stream {
+		if filePath == "" {
+			return nil, fmt.Errorf("Please specify file with events with --input flag")
+		}
+		if _, statErr := os.Stat(filePath); statErr != nil {
+			return nil, fmt.Errorf("Unable to read file %s, err: %v", filePath, statErr)
+		}
+		return &FileEventSource[T]{Ctx: ctx, Options: opts, FilePath: filePath}, nil
+	}
+
+	events, parseEventsErr := ParseEventFromFile[T](ctx, opts, filePath)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	return SliceEventSource[T](events), nil
+}
+
+// EventSourcesErr returns the first error of the event sources.
+func EventSourcesErr(sources ...interface{ Err() error }) error {
+	for _, source := range sources {
+		if sourceErr := source.Err(); sourceErr != nil {
+			return sourceErr
+		}
+	}
+	return nil
+}
+
+// UpdateLeaderboardScores pushes the scores body to the leaderboard. Unless overwrite is set, the
+// scores are added to the scores of the leaderboard, replacing those of the same addresses.
+func UpdateLeaderboardScores(apiURL, accessToken, leaderboardId string, body io.Reader, overwrite bool) (int, error) {
+	return UpdateLeaderboardScoresIdempotent(apiURL, accessToken, leaderboardId, body, overwrite, "")
+}
+
+// PortalAPIURL returns the Moonstream.to API to send requests to: the API URL of a leaderboards
+// map target, or MOONSTREAM_API_URL if it is empty, or the public API if both are.
+func PortalAPIURL(apiURL string) string {
+	if apiURL == "" {
+		apiURL = MOONSTREAM_API_URL
+	}
+	if apiURL == "" {
+		return "https://engineapi.moonstream.to"
+	}
+	return strings.TrimRight(apiURL, "/")
+}
+
+// UpdateLeaderboardScoresIdempotent pushes the scores body like UpdateLeaderboardScores, with an
+// Idempotency-Key header so the portal applies retries of the same push only once.
+func UpdateLeaderboardScoresIdempotent(apiURL, accessToken, leaderboardId string, body io.Reader, overwrite bool, idempotencyKey string) (int, error) {
+	bodyData, readErr := io.ReadAll(body)
+	if readErr != nil {
+		return 0, fmt.Errorf("error reading scores: %v", readErr)
+	}
+
+	record := NewAuditRecord(leaderboardId, bodyData)
+	record.Overwrite = overwrite
+	record.IdempotencyKey = idempotencyKey
+	defer func() { WriteAuditRecord(record) }()
+
+	request, requestErr := http.NewRequest("PUT", fmt.Sprintf("%s/leaderboard/%s/scores?normalize_addresses=false&overwrite=%t", PortalAPIURL(apiURL), leaderboardId, overwrite), bytes.NewReader(bodyData))
+	if requestErr != nil {
+		record.Error = requestErr.Error()
+		return 0, fmt.Errorf("error making requests: %v", requestErr)
+	}
+
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	request.Header.Add("Accept", "application/json")
+	request.Header.Add("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		request.Header.Add("Idempotency-Key", idempotencyKey)
+	}
+
+	timeout := time.Duration(10) * time.Second
+	httpClient := http.Client{Timeout: timeout, Transport: chaos.APITransport()}
+	response, responseErr := httpClient.Do(request)
+	if responseErr != nil {
+		record.Error = responseErr.Error()
+		return 0, fmt.Errorf("error parsing response: %v", responseErr)
+	}
+	defer response.Body.Close()
+
+	record.StatusCode = response.StatusCode
+	if responseData, readErr := io.ReadAll(response.Body); readErr == nil {
+		record.Response = truncateAuditResponse(responseData)
+	}
+
+	return response.StatusCode, nil
+
+}
+
+type LeaderboardCreateRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Public      bool   `json:"public"`
+}
+
+// CreateLeaderboard creates a new leaderboard at the Moonstream.to portal and returns its ID.
+func CreateLeaderboard(apiURL, accessToken string, leaderboard LeaderboardCreateRequest) (string, error) {
+	body, marshErr := json.Marshal(leaderboard)
+	if marshErr != nil {
+		return "", fmt.Errorf("Error marshaling leaderboard: %v", marshErr)
+	}
+
+	request, requestErr := http.NewRequest("POST", fmt.Sprintf("%s/leaderboard/", PortalAPIURL(apiURL)), bytes.NewBuffer(body))
+	if requestErr != nil {
+		return "", fmt.Errorf("error making requests: %v", requestErr)
+	}
+
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	request.Header.Add("Accept", "application/json")
+	request.Header.Add("Content-Type", "application/json")
+
+	timeout := time.Duration(10) * time.Second
+	httpClient := http.Client{Timeout: timeout, Transport: chaos.APITransport()}
+	response, responseErr := httpClient.Do(request)
+	if responseErr != nil {
+		return "", fmt.Errorf("error parsing response: %v", responseErr)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return "", fmt.Errorf("unable to create leaderboard %s, status code: %d", leaderboard.Title, response.StatusCode)
+	}
+
+	var created struct {
+		Id string `json:"id"`
+	}
+	if decodeErr := json.NewDecoder(response.Body).Decode(&created); decodeErr != nil {
+		return "", fmt.Errorf("error parsing response: %v", decodeErr)
+	}
+	if created.Id == "" {
+		return "", fmt.Errorf("leaderboard ID is missing in response for %s", leaderboard.Title)
+	}
+
+	return created.Id, nil
+}
+
+// ResetLeaderboardScores removes all scores of the leaderboard at the Moonstream.to portal.
+func ResetLeaderboardScores(apiURL, accessToken, leaderboardId string) error {
+	statusCode, reqErr := UpdateLeaderboardScores(apiURL, accessToken, leaderboardId, bytes.NewBufferString("[]"), true)
+	if reqErr != nil {
+		return reqErr
+	}
+	if statusCode >= 300 {
+		return fmt.Errorf("unable to reset leaderboard %s, status code: %d", leaderboardId, statusCode)
+	}
+	return nil
+}
+
+// PortalScore is a score as returned by the Moonstream.to portal. Points data is kept as is, so
+// scores can be pushed back unchanged.
+type PortalScore struct {
+	Address    string          `json:"address"`
+	Score      uint64          `json:"score"`
+	PointsData json.RawMessage `json:"points_data"`
+}
+
+// Number of scores requested from the portal per page.
+var PORTAL_SCORES_PAGE_SIZE = 1000
+
+// FetchLeaderboardScores returns all scores of the leaderboard at the Moonstream.to portal.
+func FetchLeaderboardScores(apiURL, accessToken, leaderboardId string) ([]PortalScore, error) {
+	timeout := time.Duration(10) * time.Second
+	httpClient := http.Client{Timeout: timeout, Transport: chaos.APITransport()}
+
+	scores := []PortalScore{}
+	for offset := 0; ; offset += PORTAL_SCORES_PAGE_SIZE {
+		request, requestErr := http.NewRequest("GET", fmt.Sprintf("%s/leaderboard/?leaderboard_id=%s&limit=%d&offset=%d", PortalAPIURL(apiURL), leaderboardId, PORTAL_SCORES_PAGE_SIZE, offset), nil)
+		if requestErr != nil {
+			return nil, fmt.Errorf("error making requests: %v", requestErr)
+		}
+
+		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+		request.Header.Add("Accept", "application/json")
+
+		response, responseErr := httpClient.Do(request)
+		if responseErr != nil {
+			return nil, fmt.Errorf("error parsing response: %v", responseErr)
+		}
+
+		var page []PortalScore
+		decodeErr := json.NewDecoder(response.Body).Decode(&page)
+		response.Body.Close()
+		if response.StatusCode >= 300 {
+			return nil, fmt.Errorf("unable to get scores of leaderboard %s, status code: %d", leaderboardId, response.StatusCode)
+		}
+		if decodeErr != nil {
+			return nil, fmt.Errorf("error parsing response: %v", decodeErr)
+		}
+
+		scores = append(scores, page...)
+		if len(page) < PORTAL_SCORES_PAGE_SIZE {
+			return scores, nil
+		}
+	}
+}
+
+// DeleteLeaderboardScores removes scores of the given addresses from the leaderboard at the
+// Moonstream.to portal. The portal only supports overwriting all scores, so the remaining scores
+// are fetched and pushed back. It returns the number of deleted scores.
+func DeleteLeaderboardScores(apiURL, accessToken, leaderboardId string, addresses []string) (int, error) {
+	deleted := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		deleted[strings.ToLower(address)] = true
+	}
+
+	scores, fetchErr := FetchLeaderboardScores(apiURL, accessToken, leaderboardId)
+	if fetchErr != nil {
+		return 0, fetchErr
+	}
+
+	remaining := []PortalScore{}
+	for _, score := range scores {
+		if !deleted[strings.ToLower(score.Address)] {
+			remaining = append(remaining, score)
+		}
+	}
+	if len(remaining) == len(scores) {
+		return 0, nil
+	}
+
+	jsonData, marshErr := json.Marshal(remaining)
+	if marshErr != nil {
+		return 0, fmt.Errorf("Error marshaling scores: %v", marshErr)
+	}
+	statusCode, reqErr := UpdateLeaderboardScores(apiURL, accessToken, leaderboardId, bytes.NewBuffer(jsonData), true)
+	if reqErr != nil {
+		return 0, reqErr
+	}
+	if statusCode >= 300 {
+		return 0, fmt.Errorf("unable to update leaderboard %s, status code: %d", leaderboardId, statusCode)
+	}
+	return len(scores) - len(remaining), nil
+}
+
+// ScoreThresholds drop scores of addresses which did not reach a minimum score or did not
+// participate in enough events. Zero values disable the corresponding threshold.
+type ScoreThresholds struct {
+	MinScore  uint64
+	MinEvents uint64
+}
+
+func (t ScoreThresholds) Filter(scores []LeaderboardScore) []LeaderboardScore {
+	if t.MinScore == 0 && t.MinEvents == 0 {
+		return scores
+	}
+
+	filtered := []LeaderboardScore{}
+	for _, score := range scores {
+		if score.Score < t.MinScore || score.EventCount < t.MinEvents {
+			continue
+		}
+		filtered = append(filtered, score)
+	}
+	return filtered
+}
+
+// PrepareLeaderboardOutput applies the options of the mission to its scores and writes them to the
+// outfile and the sinks of the options. Nothing is written once ctx is done, e.g. after the mission
+// timed out.
+func PrepareLeaderboardOutput(ctx context.Context, opts MissionOptions, scores []LeaderboardScore, outfile, accessToken, leaderboardId string) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if opts.Capture != nil {
+		*opts.Capture = scores
+		return nil
+	}
+
+	scores, mergeErr := MergeDuplicateAddresses(scores, opts.Output.AddressMerge)
+	if mergeErr != nil {
+		return mergeErr
+	}
+	// Teams compete as a single entry, thresholds apply to their combined score
+	scores = opts.Output.CrewGroups.Merge(scores, opts.AddressNames)
+	scores = opts.Thresholds.Filter(scores)
+	// Thresholds apply to the raw metric of the mission, before it is converted into points
+	if opts.ScoringPolicy != nil {
+		opts.ScoringPolicy.Apply(scores)
+	}
+
+	opts.AddressNames.Apply(scores, opts.Output.CrewGroups)
+	if resolver := opts.Output.NameResolver; resolver != nil {
+		ResolveAddressNames(scores, resolver)
+		if saveErr := resolver.Save(); saveErr != nil {
+			log.Printf("Unable to save names cache, err: %v", saveErr)
+		}
+	}
+
+	for i, score := range scores {
+		if transactionURL := ExplorerTransactionURL(opts.Output.Explorer, score.TransactionHash); transactionURL != "" {
+			if scores[i].PointsData.Extra == nil {
+				scores[i].PointsData.Extra = make(map[string]any)
+			}
+			scores[i].PointsData.Extra["transaction_url"] = transactionURL
+		}
+	}
+
+	// Names and explorer links are looked up by the addresses as generated, previous scores were
+	// pushed in the output format
+	FormatAddresses(scores, opts.AddressFormat)
+
+	previous, hasPrevious, previousErr := PreviousScores(opts.Output, opts.APIURL, accessToken, leaderboardId)
+	if previousErr != nil {
+		return previousErr
+	}
+	if hasPrevious {
+		ApplyScoreDeltas(scores, previous)
+	}
+
+	if opts.Output.CommunityEntry {
+		if communityScore, ok := CommunityProgressScore(scores); ok {
+			scores = append(scores, communityScore)
+		}
+	}
+
+	opts.Output.EntityRefs.Apply(scores)
+	fullData := TruncateData(scores, opts.Output.MaxDataItems)
+	if opts.Output.FullDataFile != "" {
+		if writeErr := WriteFullData(opts.Output.FullDataFile, fullData); writeErr != nil {
+			return writeErr
+		}
+	}
+
+	for _, score := range scores {
+		if validateErr := score.PointsData.Validate(); validateErr != nil {
+			return fmt.Errorf("Invalid points_data for address %s: %v", score.Address, validateErr)
+		}
+	}
+
+	jsonData, marshErr := MarshalScores(scores, opts.Output.Schema())
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling scores: %v", marshErr)
+	}
+
+	// Scores of missions which timed out while they were prepared are discarded
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("Discarded scores of leaderboard %s, err: %v", leaderboardId, ctxErr)
+	}
+
+	batch := ScoreBatch{
+		Mission:       opts.Mission,
+		LeaderboardId: leaderboardId,
+		AccessToken:   accessToken,
+		APIURL:        opts.APIURL,
+		Scores:        scores,
+		Payload:       jsonData,
+		Output:        opts.Output,
+		Push:          opts.Push,
+	}
+	sinks := opts.Sinks
+	if sinks == nil {
+		sinks = []ScoreSink{MoonstreamScoreSink{}}
+	}
+	// The outfile is always written, leaderboards caches read scores back from it
+	if outfile != "" {
+		sinks = append([]ScoreSink{FileScoreSink{Path: outfile}}, sinks...)
+	}
+	return WriteScoreSinks(ctx, sinks, batch)
+}
+
+func FindAndDeleteBigInt(original []*big.Int, delItem *big.Int) []*big.Int {
+	idx := 0
+	for _, val := range original {
+		if val.Cmp(delItem) != 0 {
+			original[idx] = val
+			idx++
+		}
+	}
+	return original[:idx]
+}
+
+func GenerateC1BaseCampToScores(opts MissionOptions, events EventSource[influence.TransitFinished], mustReach, cap uint64) []LeaderboardScore {
+	asteroidAPId := ADALIA_PRIME_ID
+
+	byAsteroidId := make(map[uint64]map[uint64]bool)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.TransitFinished]) {
+		if _, ok := byAsteroidId[e.Event.Destination.Id]; !ok {
+			byAsteroidId[e.Event.Destination.Id] = make(map[uint64]bool)
+		}
+		if e.Event.Destination.Id != asteroidAPId {
+			byAsteroidId[e.Event.Destination.Id][e.Event.CallerCrew.Id] = true
+			transactions.Record(e.Event.Destination.Id, e.TransactionHash)
+		}
+		// Remove from list who left the asteroid
+		delete(byAsteroidId[e.Event.Origin.Id], e.Event.CallerCrew.Id)
+	})
+
+	EmitAggregation(opts, "crews_by_asteroid", byAsteroidId)
+
+	scores := []LeaderboardScore{}
+	mustReachCounter := 0
+	for asteroid, crews := range byAsteroidId {
+		numOfCrews := len(crews)
+		if numOfCrews == 0 {
+			continue
+		}
+
+		isRequirementComplete := false
+		if numOfCrews >= 1 {
+			isRequirementComplete = true
+			if asteroid != asteroidAPId {
+				mustReachCounter++
+			}
+		}
+
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", asteroid),
+			Score:           uint64(numOfCrews),
+			EventCount:      uint64(numOfCrews),
+			TransactionHash: transactions[asteroid],
+			PointsData: PointsData{
+				Complete:  Completed(isRequirementComplete),
+				MustReach: mustReach,
+				Cap:       cap,
+				Data:      crews,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " crew(s)",
+					AddressName: "Asteroid ID",
+				},
+			},
+		})
+	}
+	for i := range scores {
+		scores[i].PointsData.MustReachCounter = uint64(mustReachCounter)
+	}
+	return scores
+}
+
+type ConstructionScore struct {
+	CallerCrew   influence.Influence_Common_Types_Entity_Entity
+	Asteroid     influence.Influence_Common_Types_Entity_Entity
+	Building     influence.Influence_Common_Types_Entity_Entity
+	BuildingType uint64
+}
+
+type ConstructionsScore struct {
+	Constructions []ConstructionScore
+	BuildingTypes map[uint64]bool
+}
+
+// TornDownBuildings holds the blocks at which buildings were abandoned or deconstructed, so that
+// construction leaderboards do not credit buildings which no longer stand.
+type TornDownBuildings map[uint64][]uint64
+
+func NewTornDownBuildings(abandonedEvents []EventWrapper[influence.ConstructionAbandoned], deconstructedEvents []EventWrapper[influence.ConstructionDeconstructed]) TornDownBuildings {
+	tornDown := make(TornDownBuildings)
+	for _, e := range abandonedEvents {
+		tornDown[e.Event.Building.Id] = append(tornDown[e.Event.Building.Id], e.Event.BlockNumber)
+	}
+	for _, e := range deconstructedEvents {
+		tornDown[e.Event.Building.Id] = append(tornDown[e.Event.Building.Id], e.Event.BlockNumber)
+	}
+	return tornDown
+}
+
+// After reports whether the building finished at the given block was torn down later on. A
+// building has to be deconstructed before its lot is built on again, so any teardown at or after
+// the finish block removes it.
+func (t TornDownBuildings) After(building, blockNumber uint64) bool {
+	for _, tornDownBlock := range t[building] {
+		if tornDownBlock >= blockNumber {
+			return true
+		}
+	}
+	return false
+}
+
+func ParseTornDownBuildings(ctx context.Context, opts EventOptions, filePath string) (TornDownBuildings, error) {
+	abandonedEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionAbandoned](ctx, opts, filePath)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	deconstructedEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionDeconstructed](ctx, opts, filePath)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	return NewTornDownBuildings(abandonedEvents, deconstructedEvents), nil
+}
+
+func GenerateCommunityConstructionsToScores(
+	ctx context.Context,
+	conPlanEvents []EventWrapper[influence.ConstructionPlanned],
+	conFinEvents []EventWrapper[influence.ConstructionFinished],
+	tornDown TornDownBuildings,
+	buildingTypes map[uint64]bool,
+	asteroids AsteroidSelector,
+	mustReach uint64,
+	cap uint64,
+) []LeaderboardScore {
+	var mustReachCounter uint64
+
+	byCrews := make(map[uint64]ConstructionsScore)
+	tornDownCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	for _, cpe := range conPlanEvents {
+		// Partial scores of missions which timed out are discarded by PrepareLeaderboardOutput
+		if ctx.Err() != nil {
+			break
+		}
+		if buildingTypes != nil {
+			if _, ok := buildingTypes[cpe.Event.BuildingType]; !ok {
+				// Pass by building type
+				continue
+			}
+		}
+		if !asteroids.Matches(cpe.Event.Asteroid.Id) {
+			// Pass by asteroid ID
+			continue
+		}
+	CONSTRUCTION_FINISHED_LOOP:
+		for _, cfe := range conFinEvents {
+			if cfe.Event.CallerCrew.Id == cpe.Event.CallerCrew.Id && cfe.Event.Building.Id == cpe.Event.Building.Id {
+				// Match ConstructionPlanned and ConstructionFinished events
+				if tornDown.After(cfe.Event.Building.Id, cfe.Event.BlockNumber) {
+					tornDownCounts[cfe.Event.CallerCrew.Id]++
+					break CONSTRUCTION_FINISHED_LOOP
+				}
+
+				var constructionsScores ConstructionsScore
+				if cs, ok := byCrews[cfe.Event.CallerCrew.Id]; ok {
+					constructionsScores = cs
+				} else {
+					constructionsScores = ConstructionsScore{
+						BuildingTypes: make(map[uint64]bool),
+					}
+				}
+
+				constructionsScores.Constructions = append(constructionsScores.Constructions, ConstructionScore{
+					CallerCrew:   cpe.Event.CallerCrew,
+					Asteroid:     cpe.Event.Asteroid,
+					Building:     cpe.Event.Building,
+					BuildingType: cpe.Event.BuildingType,
+				})
+				constructionsScores.BuildingTypes[cpe.Event.BuildingType] = true
+				byCrews[cfe.Event.CallerCrew.Id] = constructionsScores
+				transactions.Record(cfe.Event.CallerCrew.Id, cfe.TransactionHash)
+				mustReachCounter++
+
+				break CONSTRUCTION_FINISHED_LOOP
+			}
+		}
+	}
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		var buildingTypes []uint64
+		for buildingType, include := range data.BuildingTypes {
+			if include {
+				buildingTypes = append(buildingTypes, buildingType)
+			}
+		}
+
+		pointsData := PointsData{
+			Complete:         Completed(len(data.Constructions) >= 1),
+			MustReachCounter: mustReachCounter,
+			MustReach:        mustReach,
+			Cap:              cap,
+			Data:             data,
+			ScoreDetails: &ScoreDetails{
+				Postfix:     " building(s)",
+				AddressName: "Crew",
+			},
+			Extra: map[string]any{
+				"building_types":      buildingTypes,
+				"torn_down_buildings": tornDownCounts[crew],
+			},
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(data.Constructions)),
+			EventCount:      uint64(len(data.Constructions)),
+			TransactionHash: transactions[crew],
+			PointsData:      pointsData,
+		})
+	}
+	return scores
+}
+
+func GenerateC6TheFleet(events EventSource[influence.ShipAssemblyFinished], mustReach, cap uint64) []LeaderboardScore {
+	var mustReachCounter uint64
+
+	byCrews := make(map[uint64][]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.ShipAssemblyFinished]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = []uint64{}
+		}
+		byCrews[e.Event.CallerCrew.Id] = append(byCrews[e.Event.CallerCrew.Id], e.Event.Ship.Id)
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+		mustReachCounter++
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		isRequirementComplete := false
+		if len(data) >= 1 {
+			isRequirementComplete = true
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(data)),
+			EventCount:      uint64(len(data)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete:         Completed(isRequirementComplete),
+				MustReachCounter: mustReachCounter,
+				MustReach:        mustReach,
+				Cap:              cap,
+				Data:             data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " ship(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func GenerateC7RockBreaker(events EventSource[influence.ResourceExtractionFinished], mustReach, cap uint64) []LeaderboardScore {
+	var mustReachCounter uint64
+
+	byCrews := make(map[uint64]uint64)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.ResourceExtractionFinished]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += e.Event.Yield
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+		mustReachCounter += e.Event.Yield
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		isRequirementComplete := false
+		if data >= 1000 {
+			isRequirementComplete = true
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete:         Completed(isRequirementComplete),
+				MustReachCounter: mustReachCounter,
+				MustReach:        mustReach,
+				Cap:              cap,
+				ScoreDetails: &ScoreDetails{
+					Postfix:          " ton(s)",
+					Conversion:       1000,
+					ConversionVector: "divide",
+					AddressName:      "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func GenerateC8GoodNewsEveryoneToScores(trFinEvents []EventWrapper[influence.TransitFinished], unknownEvents []EventWrapper[influence.RawEvent], catalog *ProductCatalog, filter ProductFilter, mustReach, cap uint64) []LeaderboardScore {
+	asteroidAPId := ADALIA_PRIME_ID
+	var mustReachCounter uint64
+
+	byCrews := make(map[uint64]uint64)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	for _, tre := range trFinEvents {
+		if tre.Event.Destination.Id != asteroidAPId {
+			continue
+		}
+
+		var possibleProductsAmount uint64
+
+		cnt := tre.EventLineNumber
+		for _, ue := range unknownEvents {
+			// Check following UNKNOWN events after TransitFinished to find ComponentUpdated with Products
+			if cnt == ue.EventLineNumber-1 {
+				if len(ue.Event.Parameters) < 12 { // Next following items is a pair of ProductId and Amount
+					cnt++ // Try next line
+				} else {
+					cargoParams := ue.Event.Parameters[10:]
+					if len(cargoParams)%2 == 0 {
+					PRODUCTS_LOOP:
+						for i := 0; i <= len(cargoParams)-1; i += 2 {
+							// i = ProductId, i+1 = Amount
+							if cargoParams[i+1].Uint64() == 0 {
+								continue PRODUCTS_LOOP
+							}
+
+							if !filter.Allows(catalog, cargoParams[i].Uint64()) {
+								// Filter out products of excluded categories (C-Type materials by default)
+								continue PRODUCTS_LOOP
+							}
+							possibleProductsAmount += cargoParams[i+1].Uint64()
+						}
+					}
+					cnt++ // Try next line
+				}
+			}
+		}
+		if possibleProductsAmount == 0 {
+			continue
+		}
+		if _, ok := byCrews[tre.Event.CallerCrew.Id]; !ok {
+			byCrews[tre.Event.CallerCrew.Id] = 0
+		}
+		byCrews[tre.Event.CallerCrew.Id] += possibleProductsAmount
+		eventCounts[tre.Event.CallerCrew.Id]++
+		transactions.Record(tre.Event.CallerCrew.Id, tre.TransactionHash)
+		mustReachCounter += possibleProductsAmount
+	}
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		isRequirementComplete := false
+		if data >= 500000 {
+			isRequirementComplete = true
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete:         Completed(isRequirementComplete),
+				MustReachCounter: mustReachCounter,
+				MustReach:        mustReach,
+				Cap:              cap,
+				ScoreDetails: &ScoreDetails{
+					Postfix:          " ton(s)",
+					Conversion:       1000,
+					ConversionVector: "divide",
+					AddressName:      "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func GenerateC9ProspectingPaysOff(events EventSource[influence.SamplingDepositFinished], mustReach, cap uint64) []LeaderboardScore {
+	var mustReachCounter uint64
+
+	byCrews := make(map[uint64]uint64)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.SamplingDepositFinished]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += e.Event.InitialYield
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+		mustReachCounter += e.Event.InitialYield
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		isRequirementComplete := false
+		if data >= 1 {
+			isRequirementComplete = true
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete:         Completed(isRequirementComplete),
+				MustReachCounter: mustReachCounter,
+				MustReach:        mustReach,
+				Cap:              cap,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " sample(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func GenerateC10Potluck(ctx context.Context, stEventsV1 []EventWrapper[influence.MaterialProcessingStartedV1], finEvents []EventWrapper[influence.MaterialProcessingFinished], mustReach, cap uint64) []LeaderboardScore {
+	foodFilterId := uint64(129) // Food
+
+	stByCrew := GroupByCrew(stEventsV1, func(e influence.MaterialProcessingStartedV1) uint64 { return e.CallerCrew.Id })
+	finByCrew := GroupByCrew(finEvents, func(e influence.MaterialProcessingFinished) uint64 { return e.CallerCrew.Id })
+	matches := MatchByCrew(ctx, CrewIds(stByCrew), func(m *CrewMatches[uint64], crew uint64) {
+		for _, ste := range stByCrew[crew] {
+			for _, fine := range finByCrew[crew] {
+				if fine.Event.BlockNumber < ste.Event.BlockNumber {
+					continue
+				}
+				if ste.Event.Processor.Id == fine.Event.Processor.Id && ste.Event.ProcessorSlot == fine.Event.ProcessorSlot {
+					for _, p := range ste.Event.Outputs.Snapshot {
+						if p.Product == foodFilterId {
+							m.ByCrews[crew] += p.Amount
+							m.Counts[crew]++
+							m.Transactions.Record(crew, fine.TransactionHash)
+							m.Total += p.Amount
+						}
+					}
+				}
+			}
+		}
+	})
+	byCrews, eventCounts, transactions, mustReachCounter := matches.ByCrews, matches.Counts, matches.Transactions, matches.Total
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		isRequirementComplete := false
+		if data >= 5000 {
+			isRequirementComplete = true
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete:         Completed(isRequirementComplete),
+				MustReachCounter: mustReachCounter,
+				MustReach:        mustReach,
+				Cap:              cap,
+				ScoreDetails: &ScoreDetails{
+					Postfix:          " ton(s)",
+					Conversion:       1000,
+					ConversionVector: "divide",
+					AddressName:      "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func GenerateCrewOwnersToScores(events EventSource[influence.Influence_Contracts_Crew_Crew_Transfer]) []LeaderboardScore {
+	// Prepare crew owners map in format (390: 0x123)
+	crewOwners := make(map[string]string)
+	crewOwnerKeys := []TokenKey{}
+	transfers := make(map[string]uint64)
+	transactions := make(LatestTransactions[string])
+
+	events.Each(func(event EventWrapper[influence.Influence_Contracts_Crew_Crew_Transfer]) {
+		tokenIdStr := event.Event.TokenId.String()
+		transfers[tokenIdStr]++
+		transactions.Record(tokenIdStr, event.TransactionHash)
+
+		if event.Event.To != "0x0" {
+			delete(crewOwners, tokenIdStr)
+		}
+		crewOwners[tokenIdStr] = event.Event.To
+
+		is_found := false
+		for _, tk := range crewOwnerKeys {
+			if tk.Str == tokenIdStr {
+				is_found = true
+				break
+			}
+		}
+		if !is_found {
+			crewOwnerKeys = append(crewOwnerKeys, TokenKey{Str: tokenIdStr, BigInt: event.Event.TokenId})
+		}
+	})
+
+	sort.Slice(crewOwnerKeys, func(i, j int) bool {
+		return crewOwnerKeys[i].BigInt.Cmp(crewOwnerKeys[j].BigInt) < 0
+	})
+
+	scores := []LeaderboardScore{}
+	for i, k := range crewOwnerKeys {
+		scores = append(scores, LeaderboardScore{
+			Address:         k.Str,
+			Score:           uint64(i + 1),
+			EventCount:      transfers[k.Str],
+			TransactionHash: transactions[k.Str],
+			PointsData: PointsData{
+				Data: crewOwners[k.Str],
+			},
+		})
+	}
+
+	return scores
+}
+
+func GenerateOwnerCrewsToScores(events EventSource[influence.Influence_Contracts_Crew_Crew_Transfer]) []LeaderboardScore {
+	// Prepare owner crews map in format (0x123: [390, 428])
+	ownerCrews := make(map[string][]*big.Int)
+	transactions := make(LatestTransactions[string])
+	events.Each(func(event EventWrapper[influence.Influence_Contracts_Crew_Crew_Transfer]) {
+		transactions.Record(event.Event.To, event.TransactionHash)
+		if vals, ok := ownerCrews[event.Event.To]; ok {
+			ownerCrews[event.Event.To] = append(vals, event.Event.TokenId)
+			if event.Event.From != "0x0" {
+				ownerCrews[event.Event.From] = FindAndDeleteBigInt(ownerCrews[event.Event.From], event.Event.TokenId)
+			}
+		} else {
+			ownerCrews[event.Event.To] = []*big.Int{event.Event.TokenId}
+			if event.Event.From != "0x0" {
+				ownerCrews[event.Event.From] = FindAndDeleteBigInt(ownerCrews[event.Event.From], event.Event.TokenId)
+			}
+		}
+	})
+
+	scores := []LeaderboardScore{}
+	for owner, crews := range ownerCrews {
+		requirements := []Requirement{{Name: "crews", Have: uint64(len(crews)), Need: 5}}
+		scores = append(scores, LeaderboardScore{
+			Address:         owner,
+			Requirements:    requirements,
+			Score:           uint64(len(crews)),
+			EventCount:      uint64(len(crews)),
+			TransactionHash: transactions[owner],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				Data:     crews,
+			},
+		})
+	}
+
+	return scores
+}
+
+func Generate1NewRecruitsR1(recEvents EventSource[influence.CrewmateRecruited], recV1Events EventSource[influence.CrewmateRecruitedV1]) []LeaderboardScore {
+	byCrews := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	recEvents.Each(func(e EventWrapper[influence.CrewmateRecruited]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += 1
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+	recV1Events.Each(func(e EventWrapper[influence.CrewmateRecruitedV1]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += 1
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "crewmates recruited", Have: data, Need: 5}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data,
+			EventCount:      data,
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " crewmate(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+
+	return scores
+}
+
+type CrewmateScore struct {
+	TotalAmount   uint64
+	CrewmateTypes map[uint64]bool
+}
+
+func Generate1NewRecruitsR2(recEvents EventSource[influence.CrewmateRecruited], recV1Events EventSource[influence.CrewmateRecruitedV1]) []LeaderboardScore {
+	byCrews := make(map[uint64]CrewmateScore)
+	transactions := make(LatestTransactions[uint64])
+	recEvents.Each(func(e EventWrapper[influence.CrewmateRecruited]) {
+		var cremateScore CrewmateScore
+		if cs, ok := byCrews[e.Event.CallerCrew.Id]; ok {
+			cremateScore = cs
+		} else {
+			cremateScore = CrewmateScore{
+				CrewmateTypes: make(map[uint64]bool),
+			}
+		}
+		cremateScore.TotalAmount += 1
+		cremateScore.CrewmateTypes[e.Event.Class] = true
+		byCrews[e.Event.CallerCrew.Id] = cremateScore
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+	recV1Events.Each(func(e EventWrapper[influence.CrewmateRecruitedV1]) {
+		var cremateScore CrewmateScore
+		if cs, ok := byCrews[e.Event.CallerCrew.Id]; ok {
+			cremateScore = cs
+		} else {
+			cremateScore = CrewmateScore{
+				CrewmateTypes: make(map[uint64]bool),
+			}
+		}
+		cremateScore.TotalAmount += 1
+		cremateScore.CrewmateTypes[e.Event.Class] = true
+		byCrews[e.Event.CallerCrew.Id] = cremateScore
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		var crewmateTypes []uint64
+		for crewmateType, include := range data.CrewmateTypes {
+			if include {
+				crewmateTypes = append(crewmateTypes, crewmateType)
+			}
+		}
+
+		requirements := []Requirement{{Name: "crewmate types", Have: uint64(len(data.CrewmateTypes)), Need: 2}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data.TotalAmount,
+			EventCount:      data.TotalAmount,
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " crewmate(s)",
+					AddressName: "Crew",
+				},
+				Extra: map[string]any{
+					"crewmate_types": crewmateTypes,
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func Generate2BuriedTreasureR1(ctx context.Context, stEventsV1 []EventWrapper[influence.MaterialProcessingStartedV1], finEvents []EventWrapper[influence.MaterialProcessingFinished], sofEvents []EventWrapper[influence.SellOrderFilled]) []LeaderboardScore {
+	cdFilterId := uint64(175) // Core Drill
+
+	stByCrew := GroupByCrew(stEventsV1, func(e influence.MaterialProcessingStartedV1) uint64 { return e.CallerCrew.Id })
+	finByCrew := GroupByCrew(finEvents, func(e influence.MaterialProcessingFinished) uint64 { return e.CallerCrew.Id })
+	matches := MatchByCrew(ctx, CrewIds(stByCrew), func(m *CrewMatches[uint64], crew uint64) {
+		for _, ste := range stByCrew[crew] {
+			for _, fine := range finByCrew[crew] {
+				if fine.Event.BlockNumber < ste.Event.BlockNumber {
+					continue
+				}
+				if ste.Event.Processor.Id == fine.Event.Processor.Id && ste.Event.ProcessorSlot == fine.Event.ProcessorSlot {
+					for _, p := range ste.Event.Outputs.Snapshot {
+						if p.Product == cdFilterId {
+							m.ByCrews[crew] += p.Amount
+							m.Counts[crew]++
+							m.Transactions.Record(crew, fine.TransactionHash)
+						}
+					}
+				}
+			}
+		}
+	})
+	byCrews, eventCounts, transactions := matches.ByCrews, matches.Counts, matches.Transactions
+
+	for _, sof := range sofEvents {
+		if sof.Event.Product != cdFilterId {
+			continue
+		}
+		if _, ok := byCrews[sof.Event.CallerCrew.Id]; !ok {
+			byCrews[sof.Event.CallerCrew.Id] = 0
+		}
+		byCrews[sof.Event.CallerCrew.Id] += sof.Event.Amount
+		eventCounts[sof.Event.CallerCrew.Id]++
+		transactions.Record(sof.Event.CallerCrew.Id, sof.TransactionHash)
+	}
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "Core Drills", Have: data, Need: 5}}
+
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " Core Drill(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+type SampleScore struct {
+	TotalAmount uint64
+	SampleTypes map[uint64]bool
+}
+
+func Generate2BuriedTreasureR2(ctx context.Context, sdsEvents []EventWrapper[influence.SamplingDepositStarted], sdsEventsV1 []EventWrapper[influence.SamplingDepositStartedV1], sdfEvents []EventWrapper[influence.SamplingDepositFinished]) []LeaderboardScore {
+	sdsByCrew := GroupByCrew(sdsEvents, func(e influence.SamplingDepositStarted) uint64 { return e.CallerCrew.Id })
+	sdsV1ByCrew := GroupByCrew(sdsEventsV1, func(e influence.SamplingDepositStartedV1) uint64 { return e.CallerCrew.Id })
+	sdfByCrew := GroupByCrew(sdfEvents, func(e influence.SamplingDepositFinished) uint64 { return e.CallerCrew.Id })
+
+	crews := CrewIds(sdsByCrew)
+	for crew := range sdsV1ByCrew {
+		if _, ok := sdsByCrew[crew]; !ok {
+			crews = append(crews, crew)
+		}
+	}
+
+	matches := MatchByCrew(ctx, crews, func(m *CrewMatches[SampleScore], crew uint64) {
+		record := func(resource uint64, sdf EventWrapper[influence.SamplingDepositFinished]) {
+			sampleScore, ok := m.ByCrews[crew]
+			if !ok {
+				sampleScore = SampleScore{
+					SampleTypes: make(map[uint64]bool),
+				}
+			}
+			sampleScore.TotalAmount += 1
+			sampleScore.SampleTypes[resource] = true
+			m.ByCrews[crew] = sampleScore
+			m.Transactions.Record(crew, sdf.TransactionHash)
+		}
+
+		for _, sds := range sdsByCrew[crew] {
+			for _, sdf := range sdfByCrew[crew] {
+				if sdf.Event.BlockNumber >= sds.Event.BlockNumber && sds.Event.Deposit.Id == sdf.Event.Deposit.Id {
+					record(sds.Event.Resource, sdf)
+					break
+				}
+			}
+		}
+
+		for _, sds := range sdsV1ByCrew[crew] {
+			for _, sdf := range sdfByCrew[crew] {
+				if sdf.Event.BlockNumber >= sds.Event.BlockNumber && sds.Event.Deposit.Id == sdf.Event.Deposit.Id {
+					record(sds.Event.Resource, sdf)
+					break
+				}
+			}
+		}
+	})
+	byCrews, transactions := matches.ByCrews, matches.Transactions
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		var sampleTypes []uint64
+		for sampleType, include := range data.SampleTypes {
+			if include {
+				sampleTypes = append(sampleTypes, sampleType)
+			}
+		}
+
+		requirements := []Requirement{{Name: "sample types", Have: uint64(len(data.SampleTypes)), Need: 5}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data.TotalAmount,
+			EventCount:      data.TotalAmount,
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " sample(s)",
+					AddressName: "Crew",
+				},
+				Extra: map[string]any{
+					"sample_types": sampleTypes,
+				},
+			},
+		})
+	}
+	return scores
+}
+
+type OrderScore struct {
+	Product uint64
+	Amount  uint64
+}
+
+type CrewOrdersScore struct {
+	CallerCrew influence.Influence_Common_Types_Entity_Entity
+	BuyOrders  []OrderScore
+	SellOrders []OrderScore
+}
+
+func Generate3MarketMakerR1(opts MissionOptions, buyEvents EventSource[influence.BuyOrderFilled], sellEvents EventSource[influence.SellOrderFilled]) []LeaderboardScore {
+	byCrews := make(map[uint64]CrewOrdersScore)
+	transactions := make(LatestTransactions[uint64])
+	buyEvents.Each(func(e EventWrapper[influence.BuyOrderFilled]) {
+		crewOrdersScore, ok := byCrews[e.Event.CallerCrew.Id]
+		if !ok {
+			byCrews[e.Event.CallerCrew.Id] = CrewOrdersScore{}
+		}
+		crewOrdersScore.BuyOrders = append(crewOrdersScore.BuyOrders, OrderScore{
+			Product: e.Event.Product,
+			Amount:  e.Event.Amount,
+		})
+		byCrews[e.Event.CallerCrew.Id] = crewOrdersScore
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	sellEvents.Each(func(e EventWrapper[influence.SellOrderFilled]) {
+		crewOrdersScore, ok := byCrews[e.Event.CallerCrew.Id]
+		if !ok {
+			byCrews[e.Event.CallerCrew.Id] = CrewOrdersScore{}
+		}
+		crewOrdersScore.SellOrders = append(crewOrdersScore.SellOrders, OrderScore{
+			Product: e.Event.Product,
+			Amount:  e.Event.Amount,
+		})
+		byCrews[e.Event.CallerCrew.Id] = crewOrdersScore
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	EmitAggregation(opts, "orders_by_crew", byCrews)
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{
+			{Name: "buy orders", Have: uint64(len(data.BuyOrders)), Need: 5},
+			{Name: "sell orders", Have: uint64(len(data.SellOrders)), Need: 1},
+		}
+
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           uint64(len(data.BuyOrders) + len(data.SellOrders)),
+			EventCount:      uint64(len(data.BuyOrders) + len(data.SellOrders)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " order(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func Generate3MarketMakerR2(opts MissionOptions, buyEvents EventSource[influence.BuyOrderCreated], sellEvents EventSource[influence.SellOrderCreated]) []LeaderboardScore {
+	byCrews := make(map[uint64]CrewOrdersScore)
+	transactions := make(LatestTransactions[uint64])
+	buyEvents.Each(func(e EventWrapper[influence.BuyOrderCreated]) {
+		crewOrdersScore, ok := byCrews[e.Event.CallerCrew.Id]
+		if !ok {
+			byCrews[e.Event.CallerCrew.Id] = CrewOrdersScore{}
+		}
+		crewOrdersScore.BuyOrders = append(crewOrdersScore.BuyOrders, OrderScore{
+			Product: e.Event.Product,
+			Amount:  e.Event.Amount,
+		})
+		byCrews[e.Event.CallerCrew.Id] = crewOrdersScore
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	sellEvents.Each(func(e EventWrapper[influence.SellOrderCreated]) {
+		crewOrdersScore, ok := byCrews[e.Event.CallerCrew.Id]
+		if !ok {
+			byCrews[e.Event.CallerCrew.Id] = CrewOrdersScore{}
+		}
+		crewOrdersScore.SellOrders = append(crewOrdersScore.SellOrders, OrderScore{
+			Product: e.Event.Product,
+			Amount:  e.Event.Amount,
+		})
+		byCrews[e.Event.CallerCrew.Id] = crewOrdersScore
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	EmitAggregation(opts, "orders_by_crew", byCrews)
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{
+			{Name: "buy orders", Have: uint64(len(data.BuyOrders)), Need: 5},
+			{Name: "sell orders", Have: uint64(len(data.SellOrders)), Need: 1},
+		}
+
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           uint64(len(data.BuyOrders) + len(data.SellOrders)),
+			EventCount:      uint64(len(data.BuyOrders) + len(data.SellOrders)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " order(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func Generate4BreakingGroundR1(opts MissionOptions, events EventSource[influence.ResourceExtractionFinished]) []LeaderboardScore {
+	byCrews := make(map[uint64]uint64)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.ResourceExtractionFinished]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += e.Event.Yield
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	EmitAggregation(opts, "yield_by_crew", byCrews)
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "extracted amount", Have: data, Need: 10000}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:          " ton(s)",
+					Conversion:       1000,
+					ConversionVector: "divide",
+					AddressName:      "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+type MineScore struct {
+	Resource uint64
+	Yield    uint64
+}
+
+func Generate4BreakingGroundR2(opts MissionOptions, events EventSource[influence.ResourceExtractionFinished]) []LeaderboardScore {
+	byCrews := make(map[uint64][]MineScore)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.ResourceExtractionFinished]) {
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = []MineScore{}
+		}
+		is_added := false
+		for i, d := range byCrews[e.Event.CallerCrew.Id] {
+			if d.Resource == e.Event.Resource {
+				byCrews[e.Event.CallerCrew.Id][i].Yield += e.Event.Yield
+				is_added = true
+				break
+			}
+		}
+		if !is_added {
+			byCrews[e.Event.CallerCrew.Id] = append(byCrews[e.Event.CallerCrew.Id], MineScore{
+				Resource: e.Event.Resource,
+				Yield:    e.Event.Yield,
+			})
+		}
+	})
+
+	EmitAggregation(opts, "yields_by_crew", byCrews)
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "resource types", Have: uint64(len(data)), Need: 4}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           uint64(len(data)),
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " resource type(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func Generate5CityBuilder(ctx context.Context, conFinEvents []EventWrapper[influence.ConstructionFinished], conPlanEvents []EventWrapper[influence.ConstructionPlanned], tornDown TornDownBuildings) []LeaderboardScore {
+	buildingWarehouseType := uint64(1)
+	buildingExtractorType := uint64(2)
+
+	cpeByCrew := GroupByCrew(conPlanEvents, func(e influence.ConstructionPlanned) uint64 { return e.CallerCrew.Id })
+	cfeByCrew := GroupByCrew(conFinEvents, func(e influence.ConstructionFinished) uint64 { return e.CallerCrew.Id })
+	matches := MatchByCrew(ctx, CrewIds(cpeByCrew), func(m *CrewMatches[[]ConstructionScore], crew uint64) {
+		for _, cpe := range cpeByCrew[crew] {
+			if cpe.Event.BuildingType == buildingWarehouseType || cpe.Event.BuildingType == buildingExtractorType {
+				continue
+			}
+			for _, cfe := range cfeByCrew[crew] {
+				if cfe.Event.Building.Id != cpe.Event.Building.Id {
+					continue
+				}
+				if tornDown.After(cfe.Event.Building.Id, cfe.Event.BlockNumber) {
+					m.Counts[crew]++
+					continue
+				}
+				m.ByCrews[crew] = append(m.ByCrews[crew], ConstructionScore{
+					CallerCrew:   cpe.Event.CallerCrew,
+					Asteroid:     cpe.Event.Asteroid,
+					Building:     cpe.Event.Building,
+					BuildingType: cpe.Event.BuildingType,
+				})
+				m.Transactions.Record(crew, cfe.TransactionHash)
+			}
+		}
+	})
+	byCrews, tornDownCounts, transactions := matches.ByCrews, matches.Counts, matches.Transactions
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(data)),
+			EventCount:      uint64(len(data)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(true),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " building(s)",
+					AddressName: "Crew",
+				},
+				Extra: map[string]any{
+					"torn_down_buildings": tornDownCounts[crew],
+				},
+			},
+		})
+	}
+	return scores
+}
+
+type ShipAssemblyFinishedScore struct {
+	Caller      string
+	FinishTime  uint64
+	Finish      influence.GameTime
+	Destination influence.Influence_Common_Types_Entity_Entity
+	Ship        influence.Influence_Common_Types_Entity_Entity
+}
+
+func Generate6ExploreTheStarsR1(opts MissionOptions, events EventSource[influence.ShipAssemblyFinished]) []LeaderboardScore {
+	byCrews := make(map[uint64][]ShipAssemblyFinishedScore)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(event EventWrapper[influence.ShipAssemblyFinished]) {
+		if _, ok := byCrews[event.Event.CallerCrew.Id]; !ok {
+			byCrews[event.Event.CallerCrew.Id] = []ShipAssemblyFinishedScore{}
+		}
+		byCrews[event.Event.CallerCrew.Id] = append(byCrews[event.Event.CallerCrew.Id], ShipAssemblyFinishedScore{Caller: event.Event.Caller,
+			FinishTime:  event.Event.FinishTime,
+			Finish:      influence.NewGameTime(event.Event.FinishTime),
+			Destination: event.Event.Destination,
+			Ship:        event.Event.Ship,
+		})
+		transactions.Record(event.Event.CallerCrew.Id, event.TransactionHash)
+	})
+
+	EmitAggregation(opts, "ships_by_crew", byCrews)
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(data)),
+			EventCount:      uint64(len(data)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(true),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " ship(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+
+	return scores
+}
+
+func Generate6ExploreTheStarsR2(opts MissionOptions, events EventSource[influence.TransitFinished]) []LeaderboardScore {
+	asteroidAPId := ADALIA_PRIME_ID
+	byCrews := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.TransitFinished]) {
+		if e.Event.Destination.Id == asteroidAPId {
+			return
+		}
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += 1
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	EmitAggregation(opts, "transits_by_crew", byCrews)
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "transits", Have: data, Need: 1}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data,
+			EventCount:      data,
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+
+	return scores
+}
+
+func Generate7ExpandTheColony(ctx context.Context, conFinEvents []EventWrapper[influence.ConstructionFinished], conPlanEvents []EventWrapper[influence.ConstructionPlanned], tornDown TornDownBuildings) []LeaderboardScore {
+	asteroidAPId := ADALIA_PRIME_ID
+
+	cpeByCrew := GroupByCrew(conPlanEvents, func(e influence.ConstructionPlanned) uint64 { return e.CallerCrew.Id })
+	cfeByCrew := GroupByCrew(conFinEvents, func(e influence.ConstructionFinished) uint64 { return e.CallerCrew.Id })
+	matches := MatchByCrew(ctx, CrewIds(cpeByCrew), func(m *CrewMatches[[]ConstructionScore], crew uint64) {
+		for _, cpe := range cpeByCrew[crew] {
+			if cpe.Event.Asteroid.Id == asteroidAPId {
+				continue
+			}
+			for _, cfe := range cfeByCrew[crew] {
+				if cfe.Event.Building.Id != cpe.Event.Building.Id {
+					continue
+				}
+				if tornDown.After(cfe.Event.Building.Id, cfe.Event.BlockNumber) {
+					m.Counts[crew]++
+					continue
+				}
+				m.ByCrews[crew] = append(m.ByCrews[crew], ConstructionScore{
+					CallerCrew:   cpe.Event.CallerCrew,
+					Asteroid:     cpe.Event.Asteroid,
+					Building:     cpe.Event.Building,
+					BuildingType: cpe.Event.BuildingType,
+				})
+				m.Transactions.Record(crew, cfe.TransactionHash)
+			}
+		}
+	})
+	byCrews, tornDownCounts, transactions := matches.ByCrews, matches.Counts, matches.Transactions
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(data)),
+			EventCount:      uint64(len(data)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(true),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " building(s)",
+					AddressName: "Crew",
+				},
+				Extra: map[string]any{
+					"torn_down_buildings": tornDownCounts[crew],
+				},
+			},
+		})
+	}
+	return scores
+}
+
+func Generate8SpecialDelivery(trEvents []EventWrapper[influence.TransitFinished], unknownEvents []EventWrapper[influence.RawEvent]) []LeaderboardScore {
+	byCrews := make(map[uint64]uint64)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	for _, tre := range trEvents {
+
+		var possibleProductsAmount uint64
+
+		cnt := tre.EventLineNumber
+		for _, ue := range unknownEvents {
+			// Check following UNKNOWN events after TransitFinished to find ComponentUpdated with Products
+			if cnt == ue.EventLineNumber-1 {
+				if len(ue.Event.Parameters) < 12 { // Next following items is a pair of ProductId and Amount
+					cnt++ // Try next line
+				} else {
+					cargoParams := ue.Event.Parameters[10:]
+					if len(cargoParams)%2 == 0 {
+					PRODUCTS_LOOP:
+						for i := 0; i <= len(cargoParams)-1; i += 2 {
+							// i = ProductId, i+1 = Amount
+							if cargoParams[i+1].Uint64() == 0 {
+								continue PRODUCTS_LOOP
+							}
+							possibleProductsAmount += cargoParams[i+1].Uint64()
+						}
+					}
+					cnt++ // Try next line
+				}
+			}
+		}
+		if possibleProductsAmount == 0 {
+			continue
+		}
+		if _, ok := byCrews[tre.Event.CallerCrew.Id]; !ok {
+			byCrews[tre.Event.CallerCrew.Id] = 0
+		}
+		byCrews[tre.Event.CallerCrew.Id] += possibleProductsAmount
+		eventCounts[tre.Event.CallerCrew.Id]++
+		transactions.Record(tre.Event.CallerCrew.Id, tre.TransactionHash)
+	}
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "delivered amount", Have: data, Need: 1000000}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+
+	return scores
+}
+
+func Generate9DinnerIsServed(events EventSource[influence.FoodSupplied], eventsV1 EventSource[influence.FoodSuppliedV1]) []LeaderboardScore {
+	byCrews := make(map[uint64]uint64)
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.FoodSupplied]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += e.Event.Food
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	eventsV1.Each(func(e EventWrapper[influence.FoodSuppliedV1]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = 0
+		}
+		byCrews[e.Event.CallerCrew.Id] += e.Event.Food
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "food supplied", Have: data, Need: 10000}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           data,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					Postfix:          " ton(s)",
+					Conversion:       1000,
+					ConversionVector: "divide",
+					AddressName:      "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+type AsteroidLotsScore struct {
+	Lots  uint64 `json:"lots"`
+	Spend uint64 `json:"spend"`
+}
+
+type CrewLotsScore struct {
+	Lots       map[uint64]bool
+	Asteroids  map[uint64]*AsteroidLotsScore
+	TotalSpend uint64
+}
+
+// Lot IDs pack the asteroid ID into the lower 32 bits and the lot index into the upper bits.
+func LotAsteroidId(lotId uint64) uint64 {
+	return lotId & 0xFFFFFFFF
+}
+
+func GenerateLotControlToScores(
+	opts MissionOptions,
+	accEvents []EventWrapper[influence.PrepaidAgreementAccepted],
+	accMerkleEvents []EventWrapper[influence.PrepaidMerkleAgreementAccepted],
+	extEvents []EventWrapper[influence.PrepaidAgreementExtended],
+	canEvents []EventWrapper[influence.PrepaidAgreementCancelled],
+	recEvents []EventWrapper[influence.LotReclaimed],
+) ([]LeaderboardScore, error) {
+	lotLabel := uint64(4)
+
+	type leaseAction struct {
+		blockNumber uint64
+		lineNumber  int
+		crew        uint64
+		lot         uint64
+		rate        uint64
+		term        uint64
+		release     bool
+		transaction string
+	}
+
+	// Rate is quoted per hour of term, term is in seconds. Only leases permitting crews are counted,
+	// agreements may also permit other entities
+	var actions []leaseAction
+	for _, e := range accEvents {
+		if e.Event.Target.Label != lotLabel || e.Event.Permitted.Label != influence.CREW_ENTITY_LABEL {
+			continue
+		}
+		actions = append(actions, leaseAction{blockNumber: e.Event.BlockNumber, lineNumber: e.EventLineNumber, transaction: e.TransactionHash, crew: e.Event.Permitted.Id, lot: e.Event.Target.Id, rate: e.Event.Rate, term: e.Event.Term})
+	}
+	for _, e := range accMerkleEvents {
+		if e.Event.Target.Label != lotLabel || e.Event.Permitted.Label != influence.CREW_ENTITY_LABEL {
+			continue
+		}
+		actions = append(actions, leaseAction{blockNumber: e.Event.BlockNumber, lineNumber: e.EventLineNumber, transaction: e.TransactionHash, crew: e.Event.Permitted.Id, lot: e.Event.Target.Id, rate: e.Event.Rate, term: e.Event.Term})
+	}
+	for _, e := range extEvents {
+		if e.Event.Target.Label != lotLabel || e.Event.Permitted.Label != influence.CREW_ENTITY_LABEL {
+			continue
+		}
+		actions = append(actions, leaseAction{blockNumber: e.Event.BlockNumber, lineNumber: e.EventLineNumber, transaction: e.TransactionHash, crew: e.Event.Permitted.Id, lot: e.Event.Target.Id, rate: e.Event.Rate, term: e.Event.Term})
+	}
+	for _, e := range canEvents {
+		if e.Event.Target.Label != lotLabel || e.Event.Permitted.Label != influence.CREW_ENTITY_LABEL {
+			continue
+		}
+		actions = append(actions, leaseAction{blockNumber: e.Event.BlockNumber, lineNumber: e.EventLineNumber, transaction: e.TransactionHash, crew: e.Event.Permitted.Id, lot: e.Event.Target.Id, release: true})
+	}
+	for _, e := range recEvents {
+		// Reclaimed lot is released by whichever crew controlled it
+		actions = append(actions, leaseAction{blockNumber: e.Event.BlockNumber, lineNumber: e.EventLineNumber, transaction: e.TransactionHash, lot: e.Event.Lot.Id, release: true})
+	}
+
+	// Actions are ordered by block, and within a block by line number. Line numbers only order the
+	// events of an unpartitioned events file: events partitioned by type are read from one file per
+	// type, whose line numbers are unrelated, so actions of different types in the same block are
+	// then in no particular order
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].blockNumber != actions[j].blockNumber {
+			return actions[i].blockNumber < actions[j].blockNumber
+		}
+		return actions[i].lineNumber < actions[j].lineNumber
+	})
+
+	type crewAsteroid struct {
+		crew     uint64
+		asteroid uint64
+	}
+
+	byCrews := make(map[uint64]*CrewLotsScore)
+	// Rate times term easily exceeds uint64, so spend is accumulated exactly and converted at the end
+	crewSpend := make(BigScores[uint64])
+	asteroidSpend := make(BigScores[crewAsteroid])
+	lotControllers := make(map[uint64]uint64)
+	leaseCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+	for _, a := range actions {
+		if a.release {
+			crew, ok := lotControllers[a.lot]
+			if !ok || (a.crew != 0 && a.crew != crew) {
+				continue
+			}
+			delete(byCrews[crew].Lots, a.lot)
+			byCrews[crew].Asteroids[LotAsteroidId(a.lot)].Lots--
+			delete(lotControllers, a.lot)
+			continue
+		}
+
+		asteroid := LotAsteroidId(a.lot)
+		if prevCrew, ok := lotControllers[a.lot]; ok && prevCrew != a.crew {
+			delete(byCrews[prevCrew].Lots, a.lot)
+			byCrews[prevCrew].Asteroids[asteroid].Lots--
+		}
+
+		if _, ok := byCrews[a.crew]; !ok {
+			byCrews[a.crew] = &CrewLotsScore{
+				Lots:      make(map[uint64]bool),
+				Asteroids: make(map[uint64]*AsteroidLotsScore),
+			}
+		}
+		crewScore := byCrews[a.crew]
+		leaseCounts[a.crew]++
+		transactions.Record(a.crew, a.transaction)
+		if _, ok := crewScore.Asteroids[asteroid]; !ok {
+			crewScore.Asteroids[asteroid] = &AsteroidLotsScore{}
+		}
+		if !crewScore.Lots[a.lot] {
+			crewScore.Lots[a.lot] = true
+			crewScore.Asteroids[asteroid].Lots++
+		}
+		crewSpend.AddFraction(a.crew, a.rate, a.term, 3600)
+		asteroidSpend.AddFraction(crewAsteroid{crew: a.crew, asteroid: asteroid}, a.rate, a.term, 3600)
+		lotControllers[a.lot] = a.crew
+	}
+
+	for crew, data := range byCrews {
+		totalSpend, spendErr := crewSpend.Uint64(crew, opts.ScoreScale)
+		if spendErr != nil {
+			return nil, fmt.Errorf("lease spend of crew %v", spendErr)
+		}
+		data.TotalSpend = totalSpend
+		for asteroid, asteroidScore := range data.Asteroids {
+			spend, spendErr := asteroidSpend.Uint64(crewAsteroid{crew: crew, asteroid: asteroid}, opts.ScoreScale)
+			if spendErr != nil {
+				return nil, fmt.Errorf("lease spend of crew %d on asteroid %v", crew, spendErr)
+			}
+			asteroidScore.Spend = spend
+		}
+	}
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		requirements := []Requirement{{Name: "lots", Have: uint64(len(data.Lots)), Need: 1}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           uint64(len(data.Lots)),
+			EventCount:      leaseCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(RequirementsMet(requirements)),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " lot(s)",
+					AddressName: "Crew",
+				},
+				Extra: map[string]any{
+					"lease_spend": data.TotalSpend,
+					"asteroids":   data.Asteroids,
+				},
+			},
+		})
+	}
+	return scores, nil
+}
+
+// CrewActionEvent is a start or finish of a crew action. Target identifies the action instance
+// (e.g. building under construction or processor slot), so that start and finish events can be
+// matched.
+type CrewActionEvent struct {
+	Action          string
+	CallerCrew      uint64
+	Target          string
+	BlockNumber     uint64
+	LineNumber      int
+	TransactionHash string
+	Finish          bool
+}
+
+type CrewCompositionEvent struct {
+	Crew        uint64
+	Size        uint64
+	BlockNumber uint64
+	LineNumber  int
+}
+
+type CrewActionBreakdown struct {
+	Actions    uint64 `json:"actions"`
+	BusyBlocks uint64 `json:"busy_blocks"`
+}
+
+type crewBusyWindow struct {
+	from uint64
+	to   uint64
+}
+
+func CrewActionsFromEvents[T any](events []EventWrapper[T], action string, finish bool, describe func(event T) (uint64, string, uint64)) []CrewActionEvent {
+	actions := make([]CrewActionEvent, 0, len(events))
+	for _, e := range events {
+		crew, target, blockNumber := describe(e.Event)
+		actions = append(actions, CrewActionEvent{
+			Action:          action,
+			CallerCrew:      crew,
+			Target:          target,
+			BlockNumber:     blockNumber,
+			LineNumber:      e.EventLineNumber,
+			TransactionHash: e.TransactionHash,
+			Finish:          finish,
+		})
+	}
+	return actions
+}
+
+// CompositionSize counts occupied slots of a crew composition.
+func CompositionSize(composition []uint64) uint64 {
+	var size uint64
+	for _, crewmate := range composition {
+		if crewmate != 0 {
+			size++
+		}
+	}
+	return size
+}
+
+// GenerateMostActiveCrewsToScores scores crews by the number of blocks in which they were busy
+// with at least one action. Busy windows span from the block of an action start event to the block
+// of its matching finish event, overlapping windows of a crew are counted once.
+func GenerateMostActiveCrewsToScores(actions []CrewActionEvent, compositions []CrewCompositionEvent) []LeaderboardScore {
+	sort.SliceStable(actions, func(i, j int) bool {
+		if actions[i].BlockNumber != actions[j].BlockNumber {
+			return actions[i].BlockNumber < actions[j].BlockNumber
+		}
+		return actions[i].LineNumber < actions[j].LineNumber
+	})
+
+	pendingStarts := make(map[string][]uint64)
+	windows := make(map[uint64][]crewBusyWindow)
+	transactions := make(LatestTransactions[uint64])
+	breakdowns := make(map[uint64]map[string]*CrewActionBreakdown)
+	for _, a := range actions {
+		key := fmt.Sprintf("%s:%d:%s", a.Action, a.CallerCrew, a.Target)
+		if !a.Finish {
+			pendingStarts[key] = append(pendingStarts[key], a.BlockNumber)
+			continue
+		}
+
+		starts := pendingStarts[key]
+		if len(starts) == 0 {
+			// Action started before the beginning of the dump
+			continue
+		}
+		startBlock := starts[0]
+		pendingStarts[key] = starts[1:]
+
+		windows[a.CallerCrew] = append(windows[a.CallerCrew], crewBusyWindow{from: startBlock, to: a.BlockNumber})
+		transactions.Record(a.CallerCrew, a.TransactionHash)
+
+		if _, ok := breakdowns[a.CallerCrew]; !ok {
+			breakdowns[a.CallerCrew] = make(map[string]*CrewActionBreakdown)
+		}
+		if _, ok := breakdowns[a.CallerCrew][a.Action]; !ok {
+			breakdowns[a.CallerCrew][a.Action] = &CrewActionBreakdown{}
+		}
+		breakdowns[a.CallerCrew][a.Action].Actions++
+		breakdowns[a.CallerCrew][a.Action].BusyBlocks += a.BlockNumber - startBlock
+	}
+
+	crewSizes := LatestCrewSizes(compositions)
+
+	scores := []LeaderboardScore{}
+	for crew, crewWindows := range windows {
+		sort.Slice(crewWindows, func(i, j int) bool {
+			return crewWindows[i].from < crewWindows[j].from
+		})
+
+		var busyBlocks uint64
+		current := crewWindows[0]
+		for _, w := range crewWindows[1:] {
+			if w.from <= current.to {
+				if w.to > current.to {
+					current.to = w.to
+				}
+				continue
+			}
+			busyBlocks += current.to - current.from
+			current = w
+		}
+		busyBlocks += current.to - current.from
+
+		var actionsCount uint64
+		for _, breakdown := range breakdowns[crew] {
+			actionsCount += breakdown.Actions
+		}
+
+		var utilization float64
+		activeBlocks := current.to - crewWindows[0].from
+		if activeBlocks > 0 {
+			utilization = float64(busyBlocks) / float64(activeBlocks)
+		}
+
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           busyBlocks,
+			EventCount:      actionsCount,
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(busyBlocks > 0),
+				Data:     breakdowns[crew],
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " busy block(s)",
+					AddressName: "Crew",
+				},
+				Extra: map[string]any{
+					"crew_size":   crewSizes[crew],
+					"utilization": utilization,
+				},
+			},
+		})
+	}
+	return scores
+}
+
+type AsteroidScansScore struct {
+	SurfaceScans  []uint64 `json:"surface_scans"`
+	ResourceScans []uint64 `json:"resource_scans"`
+}
+
+func GenerateAsteroidsScannedToScores(opts MissionOptions, surfaceEvents EventSource[influence.SurfaceScanFinished], resourceEvents EventSource[influence.ResourceScanFinished]) []LeaderboardScore {
+	byCrews := make(map[uint64]*AsteroidScansScore)
+	transactions := make(LatestTransactions[uint64])
+	scannedAsteroids := make(map[uint64]map[uint64]bool)
+	surfaceEvents.Each(func(e EventWrapper[influence.SurfaceScanFinished]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = &AsteroidScansScore{}
+			scannedAsteroids[e.Event.CallerCrew.Id] = make(map[uint64]bool)
+		}
+		byCrews[e.Event.CallerCrew.Id].SurfaceScans = append(byCrews[e.Event.CallerCrew.Id].SurfaceScans, e.Event.Asteroid.Id)
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+		scannedAsteroids[e.Event.CallerCrew.Id][e.Event.Asteroid.Id] = true
+	})
+	resourceEvents.Each(func(e EventWrapper[influence.ResourceScanFinished]) {
+		if _, ok := byCrews[e.Event.CallerCrew.Id]; !ok {
+			byCrews[e.Event.CallerCrew.Id] = &AsteroidScansScore{}
+			scannedAsteroids[e.Event.CallerCrew.Id] = make(map[uint64]bool)
+		}
+		byCrews[e.Event.CallerCrew.Id].ResourceScans = append(byCrews[e.Event.CallerCrew.Id].ResourceScans, e.Event.Asteroid.Id)
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+		scannedAsteroids[e.Event.CallerCrew.Id][e.Event.Asteroid.Id] = true
+	})
+
+	EmitAggregation(opts, "scanned_asteroids_by_crew", scannedAsteroids)
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(scannedAsteroids[crew])),
+			EventCount:      uint64(len(data.SurfaceScans) + len(data.ResourceScans)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(len(scannedAsteroids[crew]) >= 1),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " asteroid(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+type AsteroidBonusesScore struct {
+	Asteroid uint64 `json:"asteroid"`
+	Bonuses  uint64 `json:"bonuses"`
+}
+
+func GenerateBonusesDiscoveredToScores(events EventSource[influence.SurfaceScanFinished]) []LeaderboardScore {
+	byCrews := make(map[uint64][]AsteroidBonusesScore)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.SurfaceScanFinished]) {
+		// Bonuses are packed as a bit mask, each set bit is a discovered bonus
+		byCrews[e.Event.CallerCrew.Id] = append(byCrews[e.Event.CallerCrew.Id], AsteroidBonusesScore{
+			Asteroid: e.Event.Asteroid.Id,
+			Bonuses:  uint64(bits.OnesCount64(e.Event.Bonuses)),
+		})
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, data := range byCrews {
+		var bonuses uint64
+		for _, d := range data {
+			bonuses += d.Bonuses
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           bonuses,
+			EventCount:      uint64(len(data)),
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(bonuses >= 1),
+				Data:     data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " bonus(es)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+// GenerateOneStopShopToScores ranks crews by transactions in which they bought goods at a
+// marketplace, delivered them and started refining them, all in a single multicall.
+func GenerateOneStopShopToScores(buyEvents EventSource[influence.SellOrderFilled], deliveryEvents EventSource[influence.DeliverySent], processEvents EventSource[influence.MaterialProcessingStartedV1]) []LeaderboardScore {
+	steps := make(TransactionSteps)
+	CorrelateEvents(steps, buyEvents, "buy",
+		func(e influence.SellOrderFilled) uint64 { return e.CallerCrew.Id },
+		func(e influence.SellOrderFilled) uint64 { return e.BlockNumber })
+	CorrelateEvents(steps, deliveryEvents, "deposit",
+		func(e influence.DeliverySent) uint64 { return e.CallerCrew.Id },
+		func(e influence.DeliverySent) uint64 { return e.BlockNumber })
+	CorrelateEvents(steps, processEvents, "refine",
+		func(e influence.MaterialProcessingStartedV1) uint64 { return e.CallerCrew.Id },
+		func(e influence.MaterialProcessingStartedV1) uint64 { return e.BlockNumber })
+
+	scores := []LeaderboardScore{}
+	for crew, transactions := range steps.Complete("buy", "deposit", "refine") {
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           uint64(len(transactions)),
+			EventCount:      uint64(len(transactions)),
+			TransactionHash: transactions[len(transactions)-1],
+			PointsData: PointsData{
+				Complete: Completed(len(transactions) >= 1),
+				Data:     transactions,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " transaction(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+// GenerateMarketVolumeToScores ranks crews by the SWAY volume (amount times price) of buy and sell
+// orders they filled, scaled with the scale of the options.
+func GenerateMarketVolumeToScores(opts MissionOptions, buyEvents EventSource[influence.BuyOrderFilled], sellEvents EventSource[influence.SellOrderFilled]) ([]LeaderboardScore, error) {
+	volumes := make(BigScores[uint64])
+	eventCounts := make(map[uint64]uint64)
+	transactions := make(LatestTransactions[uint64])
+
+	buyEvents.Each(func(e EventWrapper[influence.BuyOrderFilled]) {
+		volumes.AddFraction(e.Event.CallerCrew.Id, e.Event.Amount, e.Event.Price, 1)
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+	sellEvents.Each(func(e EventWrapper[influence.SellOrderFilled]) {
+		volumes.AddFraction(e.Event.CallerCrew.Id, e.Event.Amount, e.Event.Price, 1)
+		eventCounts[e.Event.CallerCrew.Id]++
+		transactions.Record(e.Event.CallerCrew.Id, e.TransactionHash)
+	})
+
+	scores := []LeaderboardScore{}
+	for crew := range volumes {
+		volume, volumeErr := volumes.Uint64(crew, opts.ScoreScale)
+		if volumeErr != nil {
+			return nil, fmt.Errorf("market volume of crew %v", volumeErr)
+		}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Score:           volume,
+			EventCount:      eventCounts[crew],
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete: Completed(volume > 0),
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " SWAY",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores, nil
+}
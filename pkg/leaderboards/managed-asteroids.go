@@ -0,0 +1,149 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Modes of the --managed-asteroids flag.
+const (
+	// Only activity on asteroids managed by the acting crew counts
+	MANAGED_ASTEROIDS_OWN = "own"
+	// Activity on asteroids managed by the acting crew does not count
+	MANAGED_ASTEROIDS_OTHERS = "others"
+)
+
+// CheckManagedAsteroidsMode returns an error if the mode of the --managed-asteroids flag is not
+// supported. Missions count activity on all asteroids if it is empty.
+func CheckManagedAsteroidsMode(mode string) error {
+	switch mode {
+	case "", MANAGED_ASTEROIDS_OWN, MANAGED_ASTEROIDS_OTHERS:
+		return nil
+	}
+	return fmt.Errorf("unknown --managed-asteroids mode %s, supported modes: %s, %s", mode, MANAGED_ASTEROIDS_OWN, MANAGED_ASTEROIDS_OTHERS)
+}
+
+// CheckManagedAsteroids returns an error if --managed-asteroids was set but the mission does not
+// count constructions or extractions.
+func CheckManagedAsteroids(lm LeaderboardCommandFunc, mode string) error {
+	if mode != "" && !lm.ManagedAsteroids {
+		return fmt.Errorf("mission %s does not count constructions or extractions, it can not be restricted with --managed-asteroids", lm.Name)
+	}
+	return nil
+}
+
+type managerChange struct {
+	blockNumber uint64
+	crew        uint64
+}
+
+// AsteroidManagers is the history of the crews managing each asteroid, from AsteroidManaged events.
+type AsteroidManagers map[uint64][]managerChange
+
+func NewAsteroidManagers(events EventSource[influence.AsteroidManaged]) AsteroidManagers {
+	managers := make(AsteroidManagers)
+	events.Each(func(e EventWrapper[influence.AsteroidManaged]) {
+		asteroid := e.Event.Asteroid.Id
+		managers[asteroid] = append(managers[asteroid], managerChange{blockNumber: e.Event.BlockNumber, crew: e.Event.CallerCrew.Id})
+	})
+	for _, changes := range managers {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].blockNumber < changes[j].blockNumber
+		})
+	}
+	return managers
+}
+
+// LoadAsteroidManagers reads the managers of asteroids from the events file.
+func LoadAsteroidManagers(ctx context.Context, opts EventOptions, filePath string) (AsteroidManagers, error) {
+	events, loadErr := LoadEvents[influence.AsteroidManaged](ctx, opts, filePath)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	managers := NewAsteroidManagers(events)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return nil, streamErr
+	}
+	return managers, nil
+}
+
+// ManagerAt returns the crew which managed the asteroid at the given block. Asteroids are managed
+// by the crew of their last AsteroidManaged event up to the block.
+func (m AsteroidManagers) ManagerAt(asteroid, blockNumber uint64) (uint64, bool) {
+	changes := m[asteroid]
+	i := sort.Search(len(changes), func(i int) bool {
+		return changes[i].blockNumber > blockNumber
+	})
+	if i == 0 {
+		return 0, false
+	}
+	return changes[i-1].crew, true
+}
+
+// Keeps reports whether activity of the crew on the asteroid at the given block counts in the
+// --managed-asteroids mode.
+func (m AsteroidManagers) Keeps(mode string, asteroid, crew, blockNumber uint64) bool {
+	manager, ok := m.ManagerAt(asteroid, blockNumber)
+	managed := ok && manager == crew
+	switch mode {
+	case MANAGED_ASTEROIDS_OWN:
+		return managed
+	case MANAGED_ASTEROIDS_OTHERS:
+		return !managed
+	}
+	return true
+}
+
+// ScopeManagedConstructions keeps the constructions planned on asteroids which count in the
+// ManagedAsteroids mode of the options, by the manager of the asteroid when they were planned.
+// Finished constructions are matched with planned ones, so they follow.
+func ScopeManagedConstructions(ctx context.Context, opts MissionOptions, events []EventWrapper[influence.ConstructionPlanned], filePath string) ([]EventWrapper[influence.ConstructionPlanned], error) {
+	if opts.ManagedAsteroids == "" {
+		return events, nil
+	}
+	managers, loadErr := LoadAsteroidManagers(ctx, opts.Events, filePath)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	scoped := make([]EventWrapper[influence.ConstructionPlanned], 0, len(events))
+	for _, e := range events {
+		if managers.Keeps(opts.ManagedAsteroids, e.Event.Asteroid.Id, e.Event.CallerCrew.Id, e.Event.BlockNumber) {
+			scoped = append(scoped, e)
+		}
+	}
+	return scoped, nil
+}
+
+// ScopeManagedExtractions keeps the extractions on asteroids which count in the ManagedAsteroids
+// mode of the options, by the manager of the asteroid at the time of the extraction. Extractors are
+// located by the lots they were planned on, extractions which can not be located are dropped.
+func ScopeManagedExtractions(ctx context.Context, opts MissionOptions, events EventSource[influence.ResourceExtractionFinished], filePath string) (EventSource[influence.ResourceExtractionFinished], error) {
+	if opts.ManagedAsteroids == "" {
+		return events, nil
+	}
+	managers, loadErr := LoadAsteroidManagers(ctx, opts.Events, filePath)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	plannedEvents, loadErr := LoadEvents[influence.ConstructionPlanned](ctx, opts.Events, filePath)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	locator := NewEntityLocator(plannedEvents, SliceEventSource[influence.TransitFinished]{})
+	if streamErr := EventSourcesErr(plannedEvents); streamErr != nil {
+		return nil, streamErr
+	}
+
+	return FilteredEventSource[influence.ResourceExtractionFinished]{
+		Source: events,
+		Keep: func(e EventWrapper[influence.ResourceExtractionFinished]) bool {
+			asteroid, ok := locator.Asteroid(e.Event.Extractor, e.Event.BlockNumber)
+			return ok && managers.Keeps(opts.ManagedAsteroids, asteroid, e.Event.CallerCrew.Id, e.Event.BlockNumber)
+		},
+	}, nil
+}
@@ -0,0 +1,86 @@
+package leaderboards
+
+import (
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// MarketplaceActivity counts crews and crewmates a wallet received from or sent to other wallets.
+type MarketplaceActivity struct {
+	CrewsBought     uint64 `json:"crews_bought"`
+	CrewsSold       uint64 `json:"crews_sold"`
+	CrewmatesBought uint64 `json:"crewmates_bought"`
+	CrewmatesSold   uint64 `json:"crewmates_sold"`
+}
+
+func (a *MarketplaceActivity) Total() uint64 {
+	return a.CrewsBought + a.CrewsSold + a.CrewmatesBought + a.CrewmatesSold
+}
+
+// GenerateMarketplaceActivityToScores ranks wallets by crews and crewmates they bought and sold.
+// Mints and burns are not trades, and marketplace contracts only pass tokens between the seller
+// and the buyer, so neither is counted.
+func GenerateMarketplaceActivityToScores(crewEvents EventSource[influence.Influence_Contracts_Crew_Crew_Transfer], crewmateEvents EventSource[influence.Influence_Contracts_Crewmate_Crewmate_Transfer], marketplaceAddresses []string) []LeaderboardScore {
+	zeroAddress := NormalizeAddress("0x0")
+	excluded := map[string]bool{zeroAddress: true}
+	for _, address := range marketplaceAddresses {
+		excluded[NormalizeAddress(address)] = true
+	}
+
+	byWallets := make(map[string]*MarketplaceActivity)
+	transactions := make(LatestTransactions[string])
+	record := func(from, to, transactionHash string, crewmate bool) {
+		from, to = NormalizeAddress(from), NormalizeAddress(to)
+		if from == zeroAddress || to == zeroAddress {
+			return
+		}
+		for _, wallet := range []string{from, to} {
+			if excluded[wallet] {
+				continue
+			}
+			if _, ok := byWallets[wallet]; !ok {
+				byWallets[wallet] = &MarketplaceActivity{}
+			}
+			transactions.Record(wallet, transactionHash)
+		}
+		if !excluded[from] {
+			if crewmate {
+				byWallets[from].CrewmatesSold++
+			} else {
+				byWallets[from].CrewsSold++
+			}
+		}
+		if !excluded[to] {
+			if crewmate {
+				byWallets[to].CrewmatesBought++
+			} else {
+				byWallets[to].CrewsBought++
+			}
+		}
+	}
+
+	crewEvents.Each(func(e EventWrapper[influence.Influence_Contracts_Crew_Crew_Transfer]) {
+		record(e.Event.From, e.Event.To, e.TransactionHash, false)
+	})
+	crewmateEvents.Each(func(e EventWrapper[influence.Influence_Contracts_Crewmate_Crewmate_Transfer]) {
+		record(e.Event.From, e.Event.To, e.TransactionHash, true)
+	})
+
+	scores := []LeaderboardScore{}
+	for wallet, activity := range byWallets {
+		total := activity.Total()
+		scores = append(scores, LeaderboardScore{
+			Address:         wallet,
+			Score:           total,
+			EventCount:      total,
+			TransactionHash: transactions[wallet],
+			PointsData: PointsData{
+				Complete: Completed(total > 0),
+				Data:     activity,
+				ScoreDetails: &ScoreDetails{
+					Postfix: " trade(s)",
+				},
+			},
+		})
+	}
+	return scores
+}
@@ -0,0 +1,1235 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+type LeaderboardCommandCreator func(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error
+
+// LeaderboardCommandFunc is a mission of the registry. Defaults of its thresholds, products,
+// asteroids and address name are not set here but read from the embedded missions.json, see
+// MissionConfig. Community goals are kept apart from the registry in MISSION_GOALS.
+type LeaderboardCommandFunc struct {
+	Name        string
+	Description string
+	Func        LeaderboardCommandCreator
+	// Default thresholds of the mission, scores below them are not pushed to the leaderboard
+	MinScore  uint64
+	MinEvents uint64
+	// Default filter of the products counted by the mission, see ProductFilter
+	ProductFilter ProductFilter
+	// Default asteroids of missions which count events by asteroid, nil for missions which can
+	// not be scoped with the --asteroids flag
+	Asteroids *AsteroidSelector
+	// Version of the scoring of the mission and the date (YYYY-MM-DD) it last changed. Both are
+	// bumped with every change to the scores the mission generates, see ChangedMissions.
+	Version int
+	Changed string
+	// What the addresses of the scores stand for (crew, wallet or asteroid) and the label shown
+	// for them, replacing the label set by the generator. See AddressNames.
+	Aggregation string
+	AddressName string
+	// Missions counting constructions or extractions, which can be restricted to asteroids managed
+	// by the acting crew with the --managed-asteroids flag
+	ManagedAsteroids bool
+}
+
+var LEADERBOARD_MISSIONS = []LeaderboardCommandFunc{
+	{
+		Name:        "c-1-base-camp",
+		Description: "Prepare community leaderboard",
+		Func:        CL1BaseCamp,
+		Aggregation: AGGREGATION_ASTEROID,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:             "c-2-romulus-remus-and-the-rest",
+		Description:      "Prepare community leaderboard",
+		Func:             CL2RomulusRemusAndTheRest,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:             "c-3-learn-by-doing",
+		Description:      "Prepare community leaderboard",
+		Func:             CL3LearnByDoing,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:             "c-4-four-pillars",
+		Description:      "Prepare community leaderboard",
+		Func:             CL4FourPillars,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:             "c-5-together-we-can-rise",
+		Description:      "Prepare community leaderboard",
+		Func:             CL5TogetherWeCanRise,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:        "c-6-the-fleet",
+		Description: "Prepare community leaderboard",
+		Func:        CL6TheFleet,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:             "c-7-rock-breaker",
+		Description:      "Prepare community leaderboard",
+		Func:             CL7RockBreaker,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:        "c-8-good-news-everyone",
+		Description: "Prepare community leaderboard",
+		Func:        CL8GoodNewsEveryone,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "c-9-prospecting-pays-off",
+		Description: "Prepare community leaderboard",
+		Func:        CL9ProspectingPaysOff,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "c-10-potluck",
+		Description: "Prepare community leaderboard",
+		Func:        CL10Potluck,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "1-new-recruits-r1",
+		Description: "Prepare leaderboard",
+		Func:        L1NewRecruitsR1,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "1-new-recruits-r2",
+		Description: "Prepare leaderboard",
+		Func:        L1NewRecruitsR2,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "2-buried-treasure-r1",
+		Description: "Prepare leaderboard",
+		Func:        L2BuriedTreasureR1,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "2-buried-treasure-r2",
+		Description: "Prepare leaderboard",
+		Func:        L2BuriedTreasureR2,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "3-market-maker-r1",
+		Description: "Prepare leaderboard",
+		Func:        L3MarketMakerR1,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "3-market-maker-r2",
+		Description: "Prepare leaderboard",
+		Func:        L3MarketMakerR2,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:             "4-breaking-ground-r1",
+		Description:      "Prepare leaderboard",
+		Func:             L4BreakingGroundR1,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:             "4-breaking-ground-r2",
+		Description:      "Prepare leaderboard",
+		Func:             L4BreakingGroundR2,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:             "5-city-builder",
+		Description:      "Prepare leaderboard",
+		Func:             L5CityBuilder,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:        "6-explore-the-stars-r1",
+		Description: "Prepare leaderboard",
+		Func:        L6ExploreTheStarsR1,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "6-explore-the-stars-r2",
+		Description: "Prepare leaderboard",
+		Func:        L6ExploreTheStarsR2,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:             "7-expand-the-colony",
+		Description:      "Prepare leaderboard",
+		Func:             L7ExpandTheColony,
+		ManagedAsteroids: true,
+		Version:          1,
+		Changed:          "2026-10-16",
+	},
+	{
+		Name:        "8-special-delivery",
+		Description: "Prepare leaderboard",
+		Func:        L8SpecialDelivery,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "9-dinner-is-served",
+		Description: "Prepare leaderboard",
+		Func:        L9DinnerIsServed,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "lot-control",
+		Description: "Prepare leaderboard with lots controlled by crews",
+		Func:        LLotControl,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "most-active-crews",
+		Description: "Prepare leaderboard with crews ranked by busy time",
+		Func:        LMostActiveCrews,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "asteroids-scanned",
+		Description: "Prepare leaderboard with crews ranked by asteroids scanned",
+		Func:        LAsteroidsScanned,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "bonuses-discovered",
+		Description: "Prepare leaderboard with crews ranked by asteroid bonuses discovered in surface scans",
+		Func:        LBonusesDiscovered,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "one-stop-shop",
+		Description: "Prepare leaderboard with crews ranked by transactions which buy, deliver and refine goods at once",
+		Func:        LOneStopShop,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "top-exporters",
+		Description: "Prepare leaderboard with crews ranked by amount of products delivered from one asteroid to another",
+		Func:        LTopExporters,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "market-volume",
+		Description: "Prepare leaderboard with crews ranked by SWAY volume of filled market orders",
+		Func:        LMarketVolume,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "marketplace-activity",
+		Description: "Prepare leaderboard with wallets ranked by crews and crewmates bought and sold",
+		Func:        LMarketplaceActivity,
+		Aggregation: AGGREGATION_WALLET,
+		Version:     2,
+		Changed:     "2026-10-16",
+	},
+	{
+		Name:        "largest-colony",
+		Description: "Prepare leaderboard with crews ranked by crewmates stationed in habitats they built",
+		Func:        LLargestColony,
+		Version:     1,
+		Changed:     "2026-10-16",
+	},
+}
+
+func CL1BaseCamp(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.TransitFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	goal := MissionGoal("c-1-base-camp")
+	scores := GenerateC1BaseCampToScores(opts, events, goal.MustReach, goal.Cap)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL2RomulusRemusAndTheRest(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	conPlanEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	conPlanEvents, scopeErr := ScopeManagedConstructions(ctx, opts, conPlanEvents, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	conFinEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	tornDown, tornDownErr := ParseTornDownBuildings(ctx, opts.Events, *infile)
+	if tornDownErr != nil {
+		return tornDownErr
+	}
+
+	goal := MissionGoal("c-2-romulus-remus-and-the-rest")
+	scores := GenerateCommunityConstructionsToScores(ctx, conPlanEvents, conFinEvents, tornDown, nil, opts.AsteroidScope, goal.MustReach, goal.Cap)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL3LearnByDoing(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	conPlanEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	conPlanEvents, scopeErr := ScopeManagedConstructions(ctx, opts, conPlanEvents, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	conFinEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	tornDown, tornDownErr := ParseTornDownBuildings(ctx, opts.Events, *infile)
+	if tornDownErr != nil {
+		return tornDownErr
+	}
+
+	buildingTypes := map[uint64]bool{
+		1: true, // Warehouse
+		2: true, // Extractor
+	}
+	goal := MissionGoal("c-3-learn-by-doing")
+	scores := GenerateCommunityConstructionsToScores(ctx, conPlanEvents, conFinEvents, tornDown, buildingTypes, opts.AsteroidScope, goal.MustReach, goal.Cap)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL4FourPillars(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	conPlanEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	conPlanEvents, scopeErr := ScopeManagedConstructions(ctx, opts, conPlanEvents, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	conFinEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	tornDown, tornDownErr := ParseTornDownBuildings(ctx, opts.Events, *infile)
+	if tornDownErr != nil {
+		return tornDownErr
+	}
+
+	buildingTypes := map[uint64]bool{
+		3: true, // Refinery
+		4: true, // Bioreactor
+		5: true, // Factory
+		6: true, // Shipyard
+	}
+	goal := MissionGoal("c-4-four-pillars")
+	scores := GenerateCommunityConstructionsToScores(ctx, conPlanEvents, conFinEvents, tornDown, buildingTypes, opts.AsteroidScope, goal.MustReach, goal.Cap)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL5TogetherWeCanRise(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	conPlanEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	conPlanEvents, scopeErr := ScopeManagedConstructions(ctx, opts, conPlanEvents, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	conFinEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	tornDown, tornDownErr := ParseTornDownBuildings(ctx, opts.Events, *infile)
+	if tornDownErr != nil {
+		return tornDownErr
+	}
+
+	buildingTypes := map[uint64]bool{
+		7: true, // Spaceport
+		8: true, // Marketplace
+		9: true, // Habitat
+	}
+	goal := MissionGoal("c-5-together-we-can-rise")
+	scores := GenerateCommunityConstructionsToScores(ctx, conPlanEvents, conFinEvents, tornDown, buildingTypes, opts.AsteroidScope, goal.MustReach, goal.Cap)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL6TheFleet(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.ShipAssemblyFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	goal := MissionGoal("c-6-the-fleet")
+	scores := GenerateC6TheFleet(events, goal.MustReach, goal.Cap)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL7RockBreaker(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.ResourceExtractionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	events, scopeErr := ScopeExtractions(ctx, opts.Events, events, opts.AsteroidScope, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	events, scopeErr = ScopeManagedExtractions(ctx, opts, events, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+
+	goal := MissionGoal("c-7-rock-breaker")
+	scores := GenerateC7RockBreaker(events, goal.MustReach, goal.Cap)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL8GoodNewsEveryone(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	if partitionErr := RequireUnpartitionedEvents(*infile, opts.Mission); partitionErr != nil {
+		return partitionErr
+	}
+	unknownEvents, parseEventsErr := ParseEventFromFile[influence.RawEvent](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	trFinEvents, parseEventsErr := ParseEventFromFile[influence.TransitFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	goal := MissionGoal("c-8-good-news-everyone")
+	scores := GenerateC8GoodNewsEveryoneToScores(trFinEvents, unknownEvents, PRODUCT_CATALOG, opts.ProductFilter, goal.MustReach, goal.Cap)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL9ProspectingPaysOff(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.SamplingDepositFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	goal := MissionGoal("c-9-prospecting-pays-off")
+	scores := GenerateC9ProspectingPaysOff(events, goal.MustReach, goal.Cap)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func CL10Potluck(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	stEventsV1, parseEventsErr := ParseEventFromFile[influence.MaterialProcessingStartedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	finEvents, parseEventsErr := ParseEventFromFile[influence.MaterialProcessingFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	goal := MissionGoal("c-10-potluck")
+	scores := GenerateC10Potluck(ctx, stEventsV1, finEvents, goal.MustReach, goal.Cap)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L1NewRecruitsR1(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	recEvents, parseEventsErr := LoadEvents[influence.CrewmateRecruited](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	recV1Events, parseEventsErr := LoadEvents[influence.CrewmateRecruitedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate1NewRecruitsR1(recEvents, recV1Events)
+	if streamErr := EventSourcesErr(recEvents, recV1Events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L1NewRecruitsR2(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	recEvents, parseEventsErr := LoadEvents[influence.CrewmateRecruited](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	recV1Events, parseEventsErr := LoadEvents[influence.CrewmateRecruitedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate1NewRecruitsR2(recEvents, recV1Events)
+	if streamErr := EventSourcesErr(recEvents, recV1Events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L2BuriedTreasureR1(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	stEventsV1, parseEventsErr := ParseEventFromFile[influence.MaterialProcessingStartedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	finEvents, parseEventsErr := ParseEventFromFile[influence.MaterialProcessingFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	sofEvents, parseEventsErr := ParseEventFromFile[influence.SellOrderFilled](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate2BuriedTreasureR1(ctx, stEventsV1, finEvents, sofEvents)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L2BuriedTreasureR2(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	sdsEvents, parseEventsErr := ParseEventFromFile[influence.SamplingDepositStarted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	sdsEventsV1, parseEventsErr := ParseEventFromFile[influence.SamplingDepositStartedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	sdfEvents, parseEventsErr := ParseEventFromFile[influence.SamplingDepositFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate2BuriedTreasureR2(ctx, sdsEvents, sdsEventsV1, sdfEvents)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L3MarketMakerR1(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	if checkErr := WarnOrderBookGaps(ctx, opts.Events, *infile); checkErr != nil {
+		return checkErr
+	}
+
+	buyEvents, parseEventsErr := LoadEvents[influence.BuyOrderFilled](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	sellEvents, parseEventsErr := LoadEvents[influence.SellOrderFilled](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate3MarketMakerR1(opts, buyEvents, sellEvents)
+	if streamErr := EventSourcesErr(buyEvents, sellEvents); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L3MarketMakerR2(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	if checkErr := WarnOrderBookGaps(ctx, opts.Events, *infile); checkErr != nil {
+		return checkErr
+	}
+
+	buyEvents, parseEventsErr := LoadEvents[influence.BuyOrderCreated](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	sellEvents, parseEventsErr := LoadEvents[influence.SellOrderCreated](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate3MarketMakerR2(opts, buyEvents, sellEvents)
+	if streamErr := EventSourcesErr(buyEvents, sellEvents); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L4BreakingGroundR1(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.ResourceExtractionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	events, scopeErr := ScopeExtractions(ctx, opts.Events, events, opts.AsteroidScope, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	events, scopeErr = ScopeManagedExtractions(ctx, opts, events, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+
+	scores := Generate4BreakingGroundR1(opts, events)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L4BreakingGroundR2(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.ResourceExtractionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	events, scopeErr := ScopeExtractions(ctx, opts.Events, events, opts.AsteroidScope, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+	events, scopeErr = ScopeManagedExtractions(ctx, opts, events, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+
+	scores := Generate4BreakingGroundR2(opts, events)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L5CityBuilder(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	conFinEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	tornDown, tornDownErr := ParseTornDownBuildings(ctx, opts.Events, *infile)
+	if tornDownErr != nil {
+		return tornDownErr
+	}
+
+	conPlanEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	conPlanEvents, scopeErr := ScopeManagedConstructions(ctx, opts, conPlanEvents, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+
+	scores := Generate5CityBuilder(ctx, conFinEvents, conPlanEvents, tornDown)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L6ExploreTheStarsR1(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.ShipAssemblyFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate6ExploreTheStarsR1(opts, events)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L6ExploreTheStarsR2(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.TransitFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate6ExploreTheStarsR2(opts, ScopeTransits(events, opts.AsteroidScope))
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L7ExpandTheColony(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	conFinEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	tornDown, tornDownErr := ParseTornDownBuildings(ctx, opts.Events, *infile)
+	if tornDownErr != nil {
+		return tornDownErr
+	}
+
+	conPlanEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	conPlanEvents, scopeErr := ScopeManagedConstructions(ctx, opts, conPlanEvents, *infile)
+	if scopeErr != nil {
+		return scopeErr
+	}
+
+	scores := Generate7ExpandTheColony(ctx, conFinEvents, conPlanEvents, tornDown)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L8SpecialDelivery(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	if partitionErr := RequireUnpartitionedEvents(*infile, opts.Mission); partitionErr != nil {
+		return partitionErr
+	}
+	unknownEvents, parseEventsErr := ParseEventFromFile[influence.RawEvent](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	trEvents, parseEventsErr := ParseEventFromFile[influence.TransitFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate8SpecialDelivery(trEvents, unknownEvents)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func L9DinnerIsServed(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.FoodSupplied](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	eventsV1, parseEventsErr := LoadEvents[influence.FoodSuppliedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := Generate9DinnerIsServed(events, eventsV1)
+	if streamErr := EventSourcesErr(events, eventsV1); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LLotControl(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	accEvents, parseEventsErr := ParseEventFromFile[influence.PrepaidAgreementAccepted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	accMerkleEvents, parseEventsErr := ParseEventFromFile[influence.PrepaidMerkleAgreementAccepted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	extEvents, parseEventsErr := ParseEventFromFile[influence.PrepaidAgreementExtended](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	canEvents, parseEventsErr := ParseEventFromFile[influence.PrepaidAgreementCancelled](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	recEvents, parseEventsErr := ParseEventFromFile[influence.LotReclaimed](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores, generateErr := GenerateLotControlToScores(opts, accEvents, accMerkleEvents, extEvents, canEvents, recEvents)
+	if generateErr != nil {
+		return generateErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LMostActiveCrews(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	var actions []CrewActionEvent
+
+	conStEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionStarted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(conStEvents, "construction", false, func(e influence.ConstructionStarted) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d", e.Building.Id), e.BlockNumber
+	})...)
+	conFinEvents, parseEventsErr := ParseEventFromFile[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(conFinEvents, "construction", true, func(e influence.ConstructionFinished) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d", e.Building.Id), e.BlockNumber
+	})...)
+
+	extStEvents, parseEventsErr := ParseEventFromFile[influence.ResourceExtractionStarted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(extStEvents, "extraction", false, func(e influence.ResourceExtractionStarted) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d:%d", e.Extractor.Id, e.ExtractorSlot), e.BlockNumber
+	})...)
+	extFinEvents, parseEventsErr := ParseEventFromFile[influence.ResourceExtractionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(extFinEvents, "extraction", true, func(e influence.ResourceExtractionFinished) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d:%d", e.Extractor.Id, e.ExtractorSlot), e.BlockNumber
+	})...)
+
+	procStEvents, parseEventsErr := ParseEventFromFile[influence.MaterialProcessingStartedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(procStEvents, "processing", false, func(e influence.MaterialProcessingStartedV1) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d:%d", e.Processor.Id, e.ProcessorSlot), e.BlockNumber
+	})...)
+	procFinEvents, parseEventsErr := ParseEventFromFile[influence.MaterialProcessingFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(procFinEvents, "processing", true, func(e influence.MaterialProcessingFinished) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d:%d", e.Processor.Id, e.ProcessorSlot), e.BlockNumber
+	})...)
+
+	sdsEvents, parseEventsErr := ParseEventFromFile[influence.SamplingDepositStarted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(sdsEvents, "sampling", false, func(e influence.SamplingDepositStarted) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d", e.Deposit.Id), e.BlockNumber
+	})...)
+	sdsEventsV1, parseEventsErr := ParseEventFromFile[influence.SamplingDepositStartedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(sdsEventsV1, "sampling", false, func(e influence.SamplingDepositStartedV1) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d", e.Deposit.Id), e.BlockNumber
+	})...)
+	sdfEvents, parseEventsErr := ParseEventFromFile[influence.SamplingDepositFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(sdfEvents, "sampling", true, func(e influence.SamplingDepositFinished) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d", e.Deposit.Id), e.BlockNumber
+	})...)
+
+	shipStEvents, parseEventsErr := ParseEventFromFile[influence.ShipAssemblyStarted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(shipStEvents, "ship_assembly", false, func(e influence.ShipAssemblyStarted) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d:%d", e.DryDock.Id, e.DryDockSlot), e.BlockNumber
+	})...)
+	shipStEventsV1, parseEventsErr := ParseEventFromFile[influence.ShipAssemblyStartedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(shipStEventsV1, "ship_assembly", false, func(e influence.ShipAssemblyStartedV1) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d:%d", e.DryDock.Id, e.DryDockSlot), e.BlockNumber
+	})...)
+	shipFinEvents, parseEventsErr := ParseEventFromFile[influence.ShipAssemblyFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(shipFinEvents, "ship_assembly", true, func(e influence.ShipAssemblyFinished) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d:%d", e.DryDock.Id, e.DryDockSlot), e.BlockNumber
+	})...)
+
+	trStEvents, parseEventsErr := ParseEventFromFile[influence.TransitStarted](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(trStEvents, "transit", false, func(e influence.TransitStarted) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d", e.Ship.Id), e.BlockNumber
+	})...)
+	trFinEvents, parseEventsErr := ParseEventFromFile[influence.TransitFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	actions = append(actions, CrewActionsFromEvents(trFinEvents, "transit", true, func(e influence.TransitFinished) (uint64, string, uint64) {
+		return e.CallerCrew.Id, fmt.Sprintf("%d", e.Ship.Id), e.BlockNumber
+	})...)
+
+	compositions, compositionsErr := LoadCrewCompositions(ctx, opts.Events, *infile)
+	if compositionsErr != nil {
+		return compositionsErr
+	}
+
+	scores := GenerateMostActiveCrewsToScores(actions, compositions)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LAsteroidsScanned(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	surfaceEvents, parseEventsErr := LoadEvents[influence.SurfaceScanFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	resourceEvents, parseEventsErr := LoadEvents[influence.ResourceScanFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := GenerateAsteroidsScannedToScores(opts, surfaceEvents, resourceEvents)
+	if streamErr := EventSourcesErr(surfaceEvents, resourceEvents); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LBonusesDiscovered(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	events, parseEventsErr := LoadEvents[influence.SurfaceScanFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := GenerateBonusesDiscoveredToScores(events)
+	if streamErr := EventSourcesErr(events); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LOneStopShop(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	buyEvents, parseEventsErr := LoadEvents[influence.SellOrderFilled](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	deliveryEvents, parseEventsErr := LoadEvents[influence.DeliverySent](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	processEvents, parseEventsErr := LoadEvents[influence.MaterialProcessingStartedV1](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := GenerateOneStopShopToScores(buyEvents, deliveryEvents, processEvents)
+	if streamErr := EventSourcesErr(buyEvents, deliveryEvents, processEvents); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LTopExporters(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	plannedEvents, parseEventsErr := LoadEvents[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	transitEvents, parseEventsErr := LoadEvents[influence.TransitFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	sentEvents, parseEventsErr := LoadEvents[influence.DeliverySent](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	receivedEvents, parseEventsErr := LoadEvents[influence.DeliveryReceived](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	locator := NewEntityLocator(plannedEvents, transitEvents)
+	flows := ComputeProductFlows(sentEvents, receivedEvents, locator, ProductCategories{})
+	if streamErr := EventSourcesErr(plannedEvents, transitEvents, sentEvents, receivedEvents); streamErr != nil {
+		return streamErr
+	}
+	scores := GenerateTopExportersToScores(flows)
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LMarketVolume(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	buyEvents, parseEventsErr := LoadEvents[influence.BuyOrderFilled](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	sellEvents, parseEventsErr := LoadEvents[influence.SellOrderFilled](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores, generateErr := GenerateMarketVolumeToScores(opts, buyEvents, sellEvents)
+	if streamErr := EventSourcesErr(buyEvents, sellEvents); streamErr != nil {
+		return streamErr
+	}
+	if generateErr != nil {
+		return generateErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LMarketplaceActivity(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	crewEvents, parseEventsErr := LoadEvents[influence.Influence_Contracts_Crew_Crew_Transfer](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	crewmateEvents, parseEventsErr := LoadEvents[influence.Influence_Contracts_Crewmate_Crewmate_Transfer](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+
+	scores := GenerateMarketplaceActivityToScores(crewEvents, crewmateEvents, opts.Marketplaces)
+	if streamErr := EventSourcesErr(crewEvents, crewmateEvents); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
+
+func LLargestColony(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+	conPlanEvents, parseEventsErr := LoadEvents[influence.ConstructionPlanned](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	conFinEvents, parseEventsErr := LoadEvents[influence.ConstructionFinished](ctx, opts.Events, *infile)
+	if parseEventsErr != nil {
+		return parseEventsErr
+	}
+	tornDown, tornDownErr := ParseTornDownBuildings(ctx, opts.Events, *infile)
+	if tornDownErr != nil {
+		return tornDownErr
+	}
+	stations, stationsErr := LoadCrewStations(ctx, opts.Events, *infile)
+	if stationsErr != nil {
+		return stationsErr
+	}
+	compositions, compositionsErr := LoadCrewCompositions(ctx, opts.Events, *infile)
+	if compositionsErr != nil {
+		return compositionsErr
+	}
+
+	scores := GenerateLargestColonyToScores(conPlanEvents, conFinEvents, tornDown, stations, compositions)
+	if streamErr := EventSourcesErr(conPlanEvents, conFinEvents); streamErr != nil {
+		return streamErr
+	}
+
+	outErr := PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	if outErr != nil {
+		return outErr
+	}
+
+	return nil
+}
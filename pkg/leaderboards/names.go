@@ -0,0 +1,216 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	STARKNET_ID_API_URL = "https://api.starknet.id"
+	ENS_API_URL         = "https://api.ensideas.com"
+)
+
+const (
+	NAME_SOURCE_STARKNET_ID = "starknet-id"
+	NAME_SOURCE_ENS         = "ens"
+)
+
+// Resolved names are kept in cache for a day, addresses without a name are cached as well, so they
+// are not looked up on every run.
+var NAMES_CACHE_TTL = 24 * time.Hour
+
+type ResolvedName struct {
+	Name       string `json:"name"`
+	ResolvedAt int64  `json:"resolved_at"`
+}
+
+// NameResolver looks up Starknet ID domains (and ENS names for addresses which fit into an
+// Ethereum address) and keeps results in an optional JSON cache file.
+type NameResolver struct {
+	Sources   []string
+	CachePath string
+
+	mu     sync.Mutex
+	saveMu sync.Mutex
+	cache  map[string]ResolvedName
+	client *EnrichmentClient
+}
+
+// NewNameResolver returns a resolver of the sources set with the --resolve-names flag.
+func NewNameResolver(sources []string, cachePath string) (*NameResolver, error) {
+	for _, source := range sources {
+		if source != NAME_SOURCE_STARKNET_ID && source != NAME_SOURCE_ENS {
+			return nil, fmt.Errorf("unknown names source %s, supported sources: %s, %s", source, NAME_SOURCE_STARKNET_ID, NAME_SOURCE_ENS)
+		}
+	}
+
+	resolver := &NameResolver{
+		Sources:   sources,
+		CachePath: cachePath,
+		cache:     make(map[string]ResolvedName),
+		client:    NewEnrichmentClient(ENRICHMENT_RATE_PER_HOST),
+	}
+
+	if cachePath != "" {
+		byteValue, readErr := os.ReadFile(cachePath)
+		if readErr != nil && !os.IsNotExist(readErr) {
+			return nil, fmt.Errorf("Unable to read file %s, err: %v", cachePath, readErr)
+		}
+		if readErr == nil {
+			if unmErr := json.Unmarshal(byteValue, &resolver.cache); unmErr != nil {
+				return nil, fmt.Errorf("Error unmarshalling JSON, err: %v", unmErr)
+			}
+		}
+	}
+
+	return resolver, nil
+}
+
+// Resolve returns the name of the address from the first source which knows it, or an empty string.
+func (r *NameResolver) Resolve(address string) string {
+	key := strings.ToLower(address)
+
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Since(time.Unix(cached.ResolvedAt, 0)) < NAMES_CACHE_TTL {
+		return cached.Name
+	}
+
+	name := ""
+	for _, source := range r.Sources {
+		var resolveErr error
+		switch source {
+		case NAME_SOURCE_STARKNET_ID:
+			name, resolveErr = r.resolveStarknetId(address)
+		case NAME_SOURCE_ENS:
+			name, resolveErr = r.resolveENS(address)
+		}
+		if resolveErr != nil {
+			// Address is looked up again on the next run
+			log.Printf("Unable to resolve %s name of %s, err: %v", source, address, resolveErr)
+			return ""
+		}
+		if name != "" {
+			break
+		}
+	}
+
+	r.mu.Lock()
+	r.cache[key] = ResolvedName{Name: name, ResolvedAt: time.Now().Unix()}
+	r.mu.Unlock()
+
+	return name
+}
+
+func (r *NameResolver) resolveStarknetId(address string) (string, error) {
+	response, responseErr := r.client.Get(fmt.Sprintf("%s/addr_to_domain?addr=%s", STARKNET_ID_API_URL, url.QueryEscape(address)))
+	if responseErr != nil {
+		return "", responseErr
+	}
+	defer response.Body.Close()
+
+	// Addresses without a domain are reported with client errors
+	if response.StatusCode >= 400 && response.StatusCode < 500 {
+		return "", nil
+	}
+	if response.StatusCode >= 300 {
+		return "", fmt.Errorf("status code: %d", response.StatusCode)
+	}
+
+	var result struct {
+		Domain string `json:"domain"`
+	}
+	if decodeErr := json.NewDecoder(response.Body).Decode(&result); decodeErr != nil {
+		return "", decodeErr
+	}
+	return result.Domain, nil
+}
+
+func (r *NameResolver) resolveENS(address string) (string, error) {
+	// Only owners bridged from Ethereum have addresses which fit into 20 bytes
+	value, ok := new(big.Int).SetString(strings.TrimPrefix(strings.ToLower(address), "0x"), 16)
+	if !ok || value.BitLen() > 160 {
+		return "", nil
+	}
+
+	response, responseErr := r.client.Get(fmt.Sprintf("%s/ens/resolve/0x%040x", ENS_API_URL, value))
+	if responseErr != nil {
+		return "", responseErr
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 && response.StatusCode < 500 {
+		return "", nil
+	}
+	if response.StatusCode >= 300 {
+		return "", fmt.Errorf("status code: %d", response.StatusCode)
+	}
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if decodeErr := json.NewDecoder(response.Body).Decode(&result); decodeErr != nil {
+		return "", decodeErr
+	}
+	return result.Name, nil
+}
+
+// Save writes the cache file, if one is configured. It is written to a temporary file first, so a
+// crash while saving keeps the previous cache intact.
+func (r *NameResolver) Save() error {
+	if r.CachePath == "" {
+		return nil
+	}
+
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.Lock()
+	byteValue, marshErr := json.MarshalIndent(r.cache, "", "    ")
+	r.mu.Unlock()
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling names cache: %v", marshErr)
+	}
+
+	if writeErr := os.WriteFile(r.CachePath+".tmp", byteValue, 0644); writeErr != nil {
+		return writeErr
+	}
+	return os.Rename(r.CachePath+".tmp", r.CachePath)
+}
+
+// ResolveAddressNames puts names of wallet addresses into AddressName of score details. Scores
+// addressed by crew, asteroid or token IDs are left as is. Addresses are resolved concurrently,
+// each of them once.
+func ResolveAddressNames(scores []LeaderboardScore, resolver *NameResolver) {
+	var addresses []string
+	requested := make(map[string]bool)
+	for _, score := range scores {
+		if strings.HasPrefix(score.Address, "0x") && !requested[score.Address] {
+			requested[score.Address] = true
+			addresses = append(addresses, score.Address)
+		}
+	}
+	names := ResolveConcurrently(resolver, addresses)
+
+	for i, score := range scores {
+		name := names[score.Address]
+		if name == "" {
+			continue
+		}
+
+		details := ScoreDetails{}
+		if score.PointsData.ScoreDetails != nil {
+			details = *score.PointsData.ScoreDetails
+		}
+		details.AddressName = name
+		scores[i].PointsData.ScoreDetails = &details
+	}
+}
@@ -0,0 +1,162 @@
+package leaderboards
+
+import "math/big"
+
+// MissionOptions are the settings of one run of a mission: its thresholds, products, asteroids,
+// address names and scoring policy from the registry and flags, how its events are read and the
+// output of the leaderboard it is prepared for. They are set once from the flags of the leaderboard
+// commands and passed by value to the mission, its generators and its sinks, so a mission never
+// sees the settings of the missions which run after it. Settings which do not change the scores
+// are left out of the cache key of the scores, see SettingsHash.
+type MissionOptions struct {
+	Mission       string
+	Thresholds    ScoreThresholds
+	ProductFilter ProductFilter
+	// Scores are not converted if it is nil
+	ScoringPolicy *ScoringPolicy
+	AsteroidScope AsteroidSelector
+	// Asteroids set with the --asteroids flag. They replace the default asteroids of missions which
+	// count events by asteroid, mission defaults are kept if it is nil.
+	Asteroids *AsteroidSelector
+	// Restriction of construction and extraction missions to asteroids by their manager, see
+	// MANAGED_ASTEROIDS_OWN. Missions count activity on all asteroids if it is empty.
+	ManagedAsteroids string
+	// Marketplace contracts which hold crews and crewmates in escrow while they are listed, left out
+	// of the marketplace activity leaderboard
+	Marketplaces []string
+	// Scale applied to big scores before they are converted to uint64, see BigScoreToUint64
+	ScoreScale    *big.Rat
+	Events        EventOptions
+	AddressNames  AddressNames
+	AddressFormat string
+	Output        OutputOptions
+	// Moonstream.to API the scores are pushed to, MOONSTREAM_API_URL if it is empty
+	APIURL string `json:"-"`
+	// Sinks the scores are written to besides the outfile. Scores are pushed to Moonstream.to if
+	// it is nil.
+	Sinks []ScoreSink `json:"-"`
+	Push  PushOptions `json:"-"`
+	// When set, PrepareLeaderboardOutput stores the scores in it, before thresholds are applied,
+	// instead of writing or pushing them.
+	Capture *[]LeaderboardScore `json:"-"`
+}
+
+// OutputOptions set how PrepareLeaderboardOutput merges, enriches and serializes the scores of a
+// mission.
+type OutputOptions struct {
+	// How scores of the same address are merged, see ADDRESS_MERGE_SUM
+	AddressMerge string
+	// Teams of crews which compete as a single entry, crews are not grouped if it is nil
+	CrewGroups *CrewGroups
+	// Names of wallet addresses are not resolved if it is nil
+	NameResolver *NameResolver `json:"-"`
+	// Explorer the scores are linked to their latest transaction in, see EXPLORERS. Links are not
+	// added if it is empty.
+	Explorer string
+	// Scores are compared against the scores of this score file, or against the current scores of
+	// the leaderboard at the portal if PreviousFromPortal is set, to add their movement to
+	// points_data
+	PreviousScoresFile string
+	PreviousFromPortal bool
+	// Scores of community missions get an extra entry with their progress, see
+	// CommunityProgressScore
+	CommunityEntry bool
+	// Entity references are output as {"Label": ..., "Id": ...} if it is nil
+	EntityRefs *EntityRefs
+	// Maximum number of items of list valued points_data "data", see TruncateData
+	MaxDataItems int
+	// File to write the complete "data" values of all scores to, keyed by address
+	FullDataFile string `json:"-"`
+	// Name of the schema points_data is serialized with, see Schema
+	PointsDataSchema string
+	// Version of score files, LATEST_SCORES_FILE_VERSION if it is 0
+	FileVersion int
+	// Format of score files, SCORES_FILE_FORMAT_JSON if it is empty
+	FileFormat string
+	// Provenance of scores which were read from a score file instead of being generated, kept in
+	// the files they are written to
+	Provenance *Provenance `json:"-"`
+}
+
+// Schema returns the points_data schema of the output, v1 if none is set.
+func (o OutputOptions) Schema() PointsDataSchema {
+	if schema, ok := POINTS_DATA_SCHEMAS[o.PointsDataSchema]; ok {
+		return schema
+	}
+	return POINTS_DATA_SCHEMAS["v1"]
+}
+
+// PushOptions set how MoonstreamScoreSink pushes scores to the leaderboards at the portal.
+type PushOptions struct {
+	// UPLOAD_MODE_OVERWRITE or UPLOAD_MODE_APPEND, scores are overwritten if it is empty
+	Mode string
+	// Push missions which generated no scores, see MoonstreamScoreSink
+	AllowEmpty bool
+	// Check the leaderboard at the portal after pushing, see VerifyLeaderboardPush
+	Verify bool
+	// Number of scores pushed per request, see UploadScoresInChunks. Scores are pushed in a single
+	// request if it is 0.
+	ChunkSize int
+	// Directory holding the state of chunked uploads which have not finished yet,
+	// DEFAULT_UPLOAD_STATE_DIR if it is empty
+	StateDir string
+}
+
+// Overwrite reports whether pushes replace all scores of the leaderboard.
+func (o PushOptions) Overwrite() bool {
+	return o.Mode != UPLOAD_MODE_APPEND
+}
+
+// DefaultMissionOptions returns the settings of leaderboards which are not prepared by a mission
+// of the registry, e.g. converted score files, with the defaults of the flags of the leaderboard
+// commands. Mission settings are derived from them with ForMission.
+func DefaultMissionOptions(mission string) MissionOptions {
+	return MissionOptions{
+		Mission:       mission,
+		AsteroidScope: AsteroidSelector{Spec: "any"},
+		ScoreScale:    big.NewRat(1, 1),
+		Output: OutputOptions{
+			AddressMerge:     ADDRESS_MERGE_SUM,
+			PointsDataSchema: "v1",
+			FileVersion:      LATEST_SCORES_FILE_VERSION,
+			FileFormat:       SCORES_FILE_FORMAT_JSON,
+		},
+		Push: PushOptions{
+			Mode:     UPLOAD_MODE_OVERWRITE,
+			Verify:   true,
+			StateDir: DEFAULT_UPLOAD_STATE_DIR,
+		},
+	}
+}
+
+// ForMission returns the options of a mission of the registry with the given thresholds. Its
+// products, asteroids, address names and scoring policy are looked up in the registry, the other
+// settings are kept.
+func (o MissionOptions) ForMission(lm LeaderboardCommandFunc, thresholds ScoreThresholds) MissionOptions {
+	o.Mission = lm.Name
+	o.Thresholds = thresholds
+	o.ProductFilter = MissionProductFilter(lm)
+	o.ScoringPolicy = MissionScoringPolicy(lm)
+	o.AsteroidScope = MissionAsteroidScope(lm, o.Asteroids)
+	o.AddressNames = MissionAddressNames(lm)
+	return o
+}
+
+// ForTarget returns the options with the API, sinks and address format of a target of the
+// leaderboards map.
+func (o MissionOptions) ForTarget(target LeaderboardTarget) (MissionOptions, error) {
+	if target.APIURL != "" {
+		o.APIURL = target.APIURL
+	}
+	if len(target.Sinks) > 0 {
+		sinks, sinksErr := NewScoreSinks(target.Sinks)
+		if sinksErr != nil {
+			return o, sinksErr
+		}
+		o.Sinks = sinks
+	}
+	if target.AddressFormat != "" {
+		o.AddressFormat = target.AddressFormat
+	}
+	return o, nil
+}
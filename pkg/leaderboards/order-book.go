@@ -0,0 +1,162 @@
+package leaderboards
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+const (
+	ORDER_SIDE_BUY  = "buy"
+	ORDER_SIDE_SELL = "sell"
+)
+
+// OrderKey identifies an order of the order book: orders of a crew on an exchange are keyed by
+// product, price and the storage inventory they are filled from or into.
+type OrderKey struct {
+	Side        string `json:"side"`
+	Crew        uint64 `json:"crew"`
+	Exchange    uint64 `json:"exchange"`
+	Product     uint64 `json:"product"`
+	Price       uint64 `json:"price"`
+	Storage     uint64 `json:"storage"`
+	StorageSlot uint64 `json:"storage_slot"`
+}
+
+// OrphanedOrder is an order which was filled or cancelled without being created before in the
+// events file, which indicates events missing from the crawl.
+type OrphanedOrder struct {
+	OrderKey
+	FirstBlock      uint64 `json:"first_block"`
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	Fills           uint64 `json:"fills"`
+	Cancellations   uint64 `json:"cancellations"`
+}
+
+type OrderBookReport struct {
+	CreatedOrders uint64          `json:"created_orders"`
+	Orphaned      []OrphanedOrder `json:"orphaned"`
+}
+
+type orderBookChecker struct {
+	created  map[OrderKey]uint64
+	orphaned map[OrderKey]*OrphanedOrder
+}
+
+func (c *orderBookChecker) create(key OrderKey, blockNumber uint64) {
+	if first, ok := c.created[key]; !ok || blockNumber < first {
+		c.created[key] = blockNumber
+	}
+}
+
+func (c *orderBookChecker) close(key OrderKey, blockNumber uint64, transactionHash string, fill bool) {
+	order, ok := c.orphaned[key]
+	if !ok {
+		order = &OrphanedOrder{OrderKey: key, FirstBlock: blockNumber, TransactionHash: transactionHash}
+		c.orphaned[key] = order
+	}
+	if blockNumber < order.FirstBlock {
+		order.FirstBlock = blockNumber
+		order.TransactionHash = transactionHash
+	}
+	if fill {
+		order.Fills++
+	} else {
+		order.Cancellations++
+	}
+}
+
+// CheckOrderBook matches fills and cancellations of buy and sell orders with their creation, and
+// reports orders which were filled or cancelled before any creation in the events file.
+func CheckOrderBook(ctx context.Context, opts EventOptions, infile string) (OrderBookReport, error) {
+	report := OrderBookReport{Orphaned: []OrphanedOrder{}}
+	checker := &orderBookChecker{created: make(map[OrderKey]uint64), orphaned: make(map[OrderKey]*OrphanedOrder)}
+
+	buyCreated, loadErr := LoadEvents[influence.BuyOrderCreated](ctx, opts, infile)
+	if loadErr != nil {
+		return report, loadErr
+	}
+	buyFilled, loadErr := LoadEvents[influence.BuyOrderFilled](ctx, opts, infile)
+	if loadErr != nil {
+		return report, loadErr
+	}
+	buyCancelled, loadErr := LoadEvents[influence.BuyOrderCancelled](ctx, opts, infile)
+	if loadErr != nil {
+		return report, loadErr
+	}
+	sellCreated, loadErr := LoadEvents[influence.SellOrderCreated](ctx, opts, infile)
+	if loadErr != nil {
+		return report, loadErr
+	}
+	sellFilled, loadErr := LoadEvents[influence.SellOrderFilled](ctx, opts, infile)
+	if loadErr != nil {
+		return report, loadErr
+	}
+	sellCancelled, loadErr := LoadEvents[influence.SellOrderCancelled](ctx, opts, infile)
+	if loadErr != nil {
+		return report, loadErr
+	}
+
+	buyCreated.Each(func(e EventWrapper[influence.BuyOrderCreated]) {
+		checker.create(OrderKey{ORDER_SIDE_BUY, e.Event.CallerCrew.Id, e.Event.Exchange.Id, e.Event.Product, e.Event.Price, e.Event.Storage.Id, e.Event.StorageSlot}, e.Event.BlockNumber)
+	})
+	sellCreated.Each(func(e EventWrapper[influence.SellOrderCreated]) {
+		checker.create(OrderKey{ORDER_SIDE_SELL, e.Event.CallerCrew.Id, e.Event.Exchange.Id, e.Event.Product, e.Event.Price, e.Event.Storage.Id, e.Event.StorageSlot}, e.Event.BlockNumber)
+	})
+	report.CreatedOrders = uint64(len(checker.created))
+
+	// Fills and cancellations are only orphaned if no creation of their order precedes them
+	closed := func(key OrderKey, blockNumber uint64, transactionHash string, fill bool) {
+		if first, ok := checker.created[key]; !ok || first > blockNumber {
+			checker.close(key, blockNumber, transactionHash, fill)
+		}
+	}
+	buyFilled.Each(func(e EventWrapper[influence.BuyOrderFilled]) {
+		closed(OrderKey{ORDER_SIDE_BUY, e.Event.BuyerCrew.Id, e.Event.Exchange.Id, e.Event.Product, e.Event.Price, e.Event.Storage.Id, e.Event.StorageSlot}, e.Event.BlockNumber, e.TransactionHash, true)
+	})
+	buyCancelled.Each(func(e EventWrapper[influence.BuyOrderCancelled]) {
+		closed(OrderKey{ORDER_SIDE_BUY, e.Event.BuyerCrew.Id, e.Event.Exchange.Id, e.Event.Product, e.Event.Price, e.Event.Storage.Id, e.Event.StorageSlot}, e.Event.BlockNumber, e.TransactionHash, false)
+	})
+	sellFilled.Each(func(e EventWrapper[influence.SellOrderFilled]) {
+		closed(OrderKey{ORDER_SIDE_SELL, e.Event.SellerCrew.Id, e.Event.Exchange.Id, e.Event.Product, e.Event.Price, e.Event.Storage.Id, e.Event.StorageSlot}, e.Event.BlockNumber, e.TransactionHash, true)
+	})
+	sellCancelled.Each(func(e EventWrapper[influence.SellOrderCancelled]) {
+		closed(OrderKey{ORDER_SIDE_SELL, e.Event.SellerCrew.Id, e.Event.Exchange.Id, e.Event.Product, e.Event.Price, e.Event.Storage.Id, e.Event.StorageSlot}, e.Event.BlockNumber, e.TransactionHash, false)
+	})
+
+	if streamErr := EventSourcesErr(buyCreated, buyFilled, buyCancelled, sellCreated, sellFilled, sellCancelled); streamErr != nil {
+		return report, streamErr
+	}
+
+	for _, order := range checker.orphaned {
+		report.Orphaned = append(report.Orphaned, *order)
+	}
+	sort.Slice(report.Orphaned, func(i, j int) bool {
+		a, b := report.Orphaned[i], report.Orphaned[j]
+		if a.FirstBlock != b.FirstBlock {
+			return a.FirstBlock < b.FirstBlock
+		}
+		if a.Side != b.Side {
+			return a.Side < b.Side
+		}
+		return a.Crew < b.Crew
+	})
+	return report, nil
+}
+
+// WarnOrderBookGaps logs a warning if orders in the events file were filled or cancelled without
+// being created, before Market Maker leaderboards are generated from a crawl which misses events.
+func WarnOrderBookGaps(ctx context.Context, opts EventOptions, infile string) error {
+	report, checkErr := CheckOrderBook(ctx, opts, infile)
+	if checkErr != nil {
+		return checkErr
+	}
+	if len(report.Orphaned) == 0 {
+		return nil
+	}
+	first, last := report.Orphaned[0].FirstBlock, report.Orphaned[len(report.Orphaned)-1].FirstBlock
+	log.Printf("Warning: %d orders were filled or cancelled without being created in the events file (blocks %d to %d), the crawl may miss events; run \"influence-eth analytics order-book\" for the list", len(report.Orphaned), first, last)
+	return nil
+}
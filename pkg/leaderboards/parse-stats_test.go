@@ -0,0 +1,43 @@
+package leaderboards
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+func TestParseStatsCountFilesOnce(t *testing.T) {
+	defer func() { PARSE_STATS = ParseStats{} }()
+	PARSE_STATS = ParseStats{}
+
+	infile := filepath.Join(t.TempDir(), "events.jsonl")
+	lines := `{"Name":"TransitFinished","Event":{"BlockNumber":1,"CallerCrew":{"Label":1,"Id":7}}}
+not json
+{"Name":"NoSuchEvent","Event":{}}
+{"Name":"TransitFinished","Event":{"BlockNumber":"one"}}
+`
+	if writeErr := os.WriteFile(infile, []byte(lines), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	// Missions read the same file once per event type and pass
+	for i := 0; i < 3; i++ {
+		if _, parseErr := ParseEventFromFile[influence.TransitFinished](context.Background(), EventOptions{}, infile); parseErr != nil {
+			t.Fatal(parseErr)
+		}
+		if _, parseErr := ParseEventFromFile[influence.TransitStarted](context.Background(), EventOptions{}, infile); parseErr != nil {
+			t.Fatal(parseErr)
+		}
+	}
+
+	expected := ParseStats{Lines: 4, Skipped: 1, Unmatched: 1, DecodeFailures: 1}
+	got := PARSE_STATS
+	got.read = nil
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
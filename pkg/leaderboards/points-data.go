@@ -0,0 +1,209 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// PointsData is the points_data object attached to every leaderboard score. Generator specific
+// values go into Extra, keyed by snake_case names. The keys under which the values appear in the
+// output are defined by the selected PointsDataSchema.
+type PointsData struct {
+	Complete         *bool
+	MustReach        uint64
+	MustReachCounter uint64
+	Cap              uint64
+	Data             any
+	ScoreDetails     *ScoreDetails
+	Extra            map[string]any
+}
+
+func Completed(isComplete bool) *bool {
+	return &isComplete
+}
+
+// SetTime adds a chain timestamp to Extra under the key, with its time and Adalian days, e.g.
+// {"timestamp": 1714521600, "time": "2024-05-01T00:00:00Z", "adalian_days": 26626}.
+func (pd *PointsData) SetTime(key string, timestamp uint64) {
+	if pd.Extra == nil {
+		pd.Extra = make(map[string]any)
+	}
+	pd.Extra[key] = influence.NewGameTime(timestamp)
+}
+
+// PointsDataSchema maps PointsData fields to the keys expected by a version of the Moonstream
+// portal.
+type PointsDataSchema struct {
+	Name             string
+	Complete         string
+	MustReach        string
+	MustReachCounter string
+	Cap              string
+	Data             string
+	ScoreDetails     string
+	// Output keys for Extra values, values which are not listed keep their snake_case name
+	Extra map[string]string
+}
+
+var POINTS_DATA_SCHEMAS = map[string]PointsDataSchema{
+	"v1": {
+		Name:             "v1",
+		Complete:         "complete",
+		MustReach:        "must_reach",
+		MustReachCounter: "must_reach_counter",
+		Cap:              "cap",
+		Data:             "data",
+		ScoreDetails:     "score_details",
+		Extra: map[string]string{
+			"building_types": "buildingTypes",
+			"crewmate_types": "crewmateTypes",
+			"sample_types":   "sampleTypes",
+		},
+	},
+	"v2": {
+		Name:             "v2",
+		Complete:         "complete",
+		MustReach:        "must_reach",
+		MustReachCounter: "must_reach_counter",
+		Cap:              "cap",
+		Data:             "data",
+		ScoreDetails:     "score_details",
+	},
+}
+
+// CheckPointsDataSchema returns an error if there is no points_data schema of the name, set with
+// the --points-data-schema flag.
+func CheckPointsDataSchema(name string) error {
+	if _, ok := POINTS_DATA_SCHEMAS[name]; !ok {
+		var names []string
+		for n := range POINTS_DATA_SCHEMAS {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown points_data schema %s, supported schemas: %s", name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+var extraKeyRegexp = regexp.MustCompile("^[a-z][a-z0-9_]*$")
+
+// Validate checks that points data is consistent before it is sent to the portal.
+func (pd PointsData) Validate() error {
+	if pd.MustReachCounter > 0 && pd.MustReach == 0 {
+		return fmt.Errorf("must_reach_counter is set without must_reach")
+	}
+	if pd.Cap != 0 && pd.Cap < pd.MustReach {
+		return fmt.Errorf("cap %d is less than must_reach %d", pd.Cap, pd.MustReach)
+	}
+
+	core := map[string]bool{"complete": true, "must_reach": true, "must_reach_counter": true, "cap": true, "data": true, "score_details": true}
+	for key := range pd.Extra {
+		if !extraKeyRegexp.MatchString(key) {
+			return fmt.Errorf("points_data key %s is not snake_case", key)
+		}
+		if core[key] {
+			return fmt.Errorf("points_data key %s must be set with the corresponding PointsData field", key)
+		}
+	}
+	return nil
+}
+
+func (pd PointsData) Map(schema PointsDataSchema) map[string]any {
+	result := make(map[string]any, len(pd.Extra)+6)
+	for key, value := range pd.Extra {
+		if renamed, ok := schema.Extra[key]; ok {
+			key = renamed
+		}
+		result[key] = value
+	}
+
+	if pd.Complete != nil {
+		result[schema.Complete] = *pd.Complete
+	}
+	if pd.MustReach != 0 {
+		result[schema.MustReach] = pd.MustReach
+		result[schema.MustReachCounter] = pd.MustReachCounter
+	}
+	if pd.Cap != 0 {
+		result[schema.Cap] = pd.Cap
+	}
+	if pd.Data != nil {
+		result[schema.Data] = pd.Data
+	}
+	if pd.ScoreDetails != nil {
+		result[schema.ScoreDetails] = pd.ScoreDetails
+	}
+	return result
+}
+
+// MarshalJSON serializes points data in the v1 schema, scores are serialized in other schemas with
+// MarshalScores.
+func (pd PointsData) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pd.Map(POINTS_DATA_SCHEMAS["v1"]))
+}
+
+// schemaScore is a score with its points_data in a schema.
+type schemaScore struct {
+	Address    string         `json:"address"`
+	Score      uint64         `json:"score"`
+	PointsData map[string]any `json:"points_data"`
+}
+
+func schemaScores(scores []LeaderboardScore, schema PointsDataSchema) []schemaScore {
+	if scores == nil {
+		return nil
+	}
+	result := make([]schemaScore, len(scores))
+	for i, score := range scores {
+		result[i] = schemaScore{Address: score.Address, Score: score.Score, PointsData: score.PointsData.Map(schema)}
+	}
+	return result
+}
+
+// MarshalScores serializes scores as a JSON array, as pushed to the Moonstream.to portal, with
+// their points_data in the schema.
+func MarshalScores(scores []LeaderboardScore, schema PointsDataSchema) ([]byte, error) {
+	return json.Marshal(schemaScores(scores, schema))
+}
+
+// TruncateData cuts list valued "data" of the scores to maxItems items, as the portal limits the
+// size of a score entry, and reports their full length as total_count. Lists are not truncated if
+// maxItems is 0. It returns the complete "data" values keyed by address.
+func TruncateData(scores []LeaderboardScore, maxItems int) map[string]any {
+	fullData := make(map[string]any)
+	for i, score := range scores {
+		if score.PointsData.Data == nil {
+			continue
+		}
+		fullData[score.Address] = score.PointsData.Data
+
+		data := reflect.ValueOf(score.PointsData.Data)
+		if maxItems <= 0 || data.Kind() != reflect.Slice || data.Len() <= maxItems {
+			continue
+		}
+		scores[i].PointsData.Data = data.Slice(0, maxItems).Interface()
+		if scores[i].PointsData.Extra == nil {
+			scores[i].PointsData.Extra = make(map[string]any)
+		}
+		scores[i].PointsData.Extra["total_count"] = data.Len()
+	}
+	return fullData
+}
+
+func WriteFullData(outfile string, fullData map[string]any) error {
+	fileData, marshErr := json.Marshal(fullData)
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling full data: %v", marshErr)
+	}
+	if writeErr := os.WriteFile(outfile, fileData, 0644); writeErr != nil {
+		return fmt.Errorf("Error writing full data to file: %v", writeErr)
+	}
+	return nil
+}
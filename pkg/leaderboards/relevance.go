@@ -0,0 +1,63 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// When set, StreamEventsFromFile records the names of the events it is asked for, see
+// MissionEvents.
+var requestedEvents map[string]bool
+
+// MissionEvents returns the names of the events each registered mission reads. They are found by
+// running the missions on an empty directory of events partitioned by type, so they are the events
+// the missions actually ask for and can not drift from a hand maintained list.
+func MissionEvents() (map[string][]string, error) {
+	emptyDir, tempErr := os.MkdirTemp("", "influence-eth-relevance-")
+	if tempErr != nil {
+		return nil, fmt.Errorf("Unable to create temporary directory, err: %v", tempErr)
+	}
+	defer os.RemoveAll(emptyDir)
+
+	defer func() { requestedEvents = nil }()
+
+	base := DefaultMissionOptions("")
+	missionEvents := make(map[string][]string, len(LEADERBOARD_MISSIONS))
+	noOutfile, noToken, noLeaderboard := "", "", ""
+	for _, lm := range LEADERBOARD_MISSIONS {
+		var scores []LeaderboardScore
+		opts := base.ForMission(lm, ScoreThresholds{})
+		opts.Capture = &scores
+		requestedEvents = make(map[string]bool)
+		if missionErr := lm.Func(context.Background(), opts, &emptyDir, &noOutfile, &noToken, &noLeaderboard); missionErr != nil {
+			return nil, fmt.Errorf("Failed %s mission, err: %v", lm.Name, missionErr)
+		}
+
+		names := make([]string, 0, len(requestedEvents))
+		for name := range requestedEvents {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		missionEvents[lm.Name] = names
+	}
+	return missionEvents, nil
+}
+
+// EventMissions returns the names of the registered missions which read each event, in registry
+// order. Events no mission reads are not in the map.
+func EventMissions() (map[string][]string, error) {
+	missionEvents, eventsErr := MissionEvents()
+	if eventsErr != nil {
+		return nil, eventsErr
+	}
+
+	eventMissions := make(map[string][]string)
+	for _, lm := range LEADERBOARD_MISSIONS {
+		for _, name := range missionEvents[lm.Name] {
+			eventMissions[name] = append(eventMissions[name], lm.Name)
+		}
+	}
+	return eventMissions, nil
+}
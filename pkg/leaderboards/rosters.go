@@ -0,0 +1,221 @@
+package leaderboards
+
+import (
+	"context"
+	"sort"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Kinds of inconsistencies between crew rosters and token ownership found by ReconcileRosters.
+// Each of them points to events missing from the events file, usually a gap in the crawl of the
+// Dispatcher, Crew or Crewmate contract.
+const (
+	// A crew has crewmates but no Transfer of the Crew contract, so it has no known owner
+	ROSTER_CREW_WITHOUT_TRANSFER = "crew_without_transfer"
+	// A crewmate of a crew has no Transfer of the Crewmate contract
+	ROSTER_CREWMATE_WITHOUT_TRANSFER = "crewmate_without_transfer"
+	// A crewmate is owned by another wallet than the crew it serves in
+	ROSTER_OWNER_MISMATCH = "owner_mismatch"
+	// A crewmate is in the roster of more than one crew
+	ROSTER_CREWMATE_IN_SEVERAL_CREWS = "crewmate_in_several_crews"
+)
+
+// RosterIssue is an inconsistency of the roster of a crew.
+type RosterIssue struct {
+	Kind          string `json:"kind"`
+	Crew          uint64 `json:"crew"`
+	Crewmate      uint64 `json:"crewmate,omitempty"`
+	OtherCrew     uint64 `json:"other_crew,omitempty"`
+	CrewOwner     string `json:"crew_owner,omitempty"`
+	CrewmateOwner string `json:"crewmate_owner,omitempty"`
+	BlockNumber   uint64 `json:"block_number"`
+}
+
+// RosterReport compares the crew rosters of Dispatcher events with the owners of crews and
+// crewmates from Transfer events of the Crew and Crewmate contracts.
+type RosterReport struct {
+	Crews             int               `json:"crews"`
+	Crewmates         int               `json:"crewmates"`
+	CrewTransfers     int               `json:"crew_transfers"`
+	CrewmateTransfers int               `json:"crewmate_transfers"`
+	IssueCounts       map[string]uint64 `json:"issue_counts"`
+	Issues            []RosterIssue     `json:"issues"`
+}
+
+// RosterChange sets the crewmates of a crew, or adds one crewmate to it for recruitments which do
+// not carry the composition of the crew.
+type RosterChange struct {
+	Crew        uint64
+	Crewmates   []uint64
+	Added       bool
+	BlockNumber uint64
+	LineNumber  int
+}
+
+// TokenOwnership is the owner of a token after a Transfer.
+type TokenOwnership struct {
+	TokenId     uint64
+	Owner       string
+	BlockNumber uint64
+	LineNumber  int
+}
+
+// LoadRosterChanges collects the changes of crew rosters from recruitments, arrangements and
+// exchanges of crewmates.
+func LoadRosterChanges(ctx context.Context, opts EventOptions, infile string) ([]RosterChange, error) {
+	var changes []RosterChange
+
+	recEvents, parseEventsErr := ParseEventFromFile[influence.CrewmateRecruited](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range recEvents {
+		changes = append(changes, RosterChange{Crew: e.Event.CallerCrew.Id, Crewmates: []uint64{e.Event.Crewmate.Id}, Added: true, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	recV1Events, parseEventsErr := ParseEventFromFile[influence.CrewmateRecruitedV1](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range recV1Events {
+		changes = append(changes, RosterChange{Crew: e.Event.CallerCrew.Id, Crewmates: e.Event.Composition.Snapshot, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	arrEvents, parseEventsErr := ParseEventFromFile[influence.CrewmatesArranged](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range arrEvents {
+		changes = append(changes, RosterChange{Crew: e.Event.CallerCrew.Id, Crewmates: e.Event.Composition.Snapshot, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	arrEventsV1, parseEventsErr := ParseEventFromFile[influence.CrewmatesArrangedV1](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range arrEventsV1 {
+		changes = append(changes, RosterChange{Crew: e.Event.CallerCrew.Id, Crewmates: e.Event.CompositionNew.Snapshot, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	excEvents, parseEventsErr := ParseEventFromFile[influence.CrewmatesExchanged](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, parseEventsErr
+	}
+	for _, e := range excEvents {
+		changes = append(changes, RosterChange{Crew: e.Event.Crew1.Id, Crewmates: e.Event.Crew1CompositionNew.Snapshot, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+		changes = append(changes, RosterChange{Crew: e.Event.Crew2.Id, Crewmates: e.Event.Crew2CompositionNew.Snapshot, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+
+	return changes, nil
+}
+
+// LoadTokenOwnerships collects the owners of crews and crewmates after each of their Transfer
+// events. Transfer events are only named after the Crew and Crewmate contracts by
+// "influence-eth parse" when the addresses of the contracts are known, see --token-contract.
+func LoadTokenOwnerships(ctx context.Context, opts EventOptions, infile string) (crews, crewmates []TokenOwnership, err error) {
+	crewEvents, parseEventsErr := ParseEventFromFile[influence.Influence_Contracts_Crew_Crew_Transfer](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, nil, parseEventsErr
+	}
+	for _, e := range crewEvents {
+		crews = append(crews, TokenOwnership{TokenId: e.Event.TokenId.Uint64(), Owner: e.Event.To, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	crewmateEvents, parseEventsErr := ParseEventFromFile[influence.Influence_Contracts_Crewmate_Crewmate_Transfer](ctx, opts, infile)
+	if parseEventsErr != nil {
+		return nil, nil, parseEventsErr
+	}
+	for _, e := range crewmateEvents {
+		crewmates = append(crewmates, TokenOwnership{TokenId: e.Event.TokenId.Uint64(), Owner: e.Event.To, BlockNumber: e.Event.BlockNumber, LineNumber: e.EventLineNumber})
+	}
+	return crews, crewmates, nil
+}
+
+// ReconcileRosters compares the latest roster of every crew with the latest owners of the crew and
+// its crewmates. Crewmates serve in the crews of their owner, so a crewmate owned by another
+// wallet, a token without any Transfer or a crewmate listed in two crews means events are missing.
+func ReconcileRosters(changes []RosterChange, crewOwnerships, crewmateOwnerships []TokenOwnership) RosterReport {
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].BlockNumber != changes[j].BlockNumber {
+			return changes[i].BlockNumber < changes[j].BlockNumber
+		}
+		return changes[i].LineNumber < changes[j].LineNumber
+	})
+	rosters := make(map[uint64][]uint64)
+	rosterBlocks := make(map[uint64]uint64)
+	for _, change := range changes {
+		if change.Added {
+			rosters[change.Crew] = append(rosters[change.Crew], change.Crewmates...)
+		} else {
+			rosters[change.Crew] = append([]uint64{}, change.Crewmates...)
+		}
+		rosterBlocks[change.Crew] = change.BlockNumber
+	}
+
+	crewOwners := latestOwners(crewOwnerships)
+	crewmateOwners := latestOwners(crewmateOwnerships)
+
+	crews := make([]uint64, 0, len(rosters))
+	for crew := range rosters {
+		crews = append(crews, crew)
+	}
+	sort.Slice(crews, func(i, j int) bool { return crews[i] < crews[j] })
+
+	report := RosterReport{
+		CrewTransfers:     len(crewOwnerships),
+		CrewmateTransfers: len(crewmateOwnerships),
+		IssueCounts:       make(map[string]uint64),
+		Issues:            []RosterIssue{},
+	}
+	addIssue := func(issue RosterIssue) {
+		report.IssueCounts[issue.Kind]++
+		report.Issues = append(report.Issues, issue)
+	}
+
+	crewmateCrews := make(map[uint64]uint64)
+	for _, crew := range crews {
+		crewmates := rosters[crew]
+		if CompositionSize(crewmates) == 0 {
+			continue
+		}
+		report.Crews++
+
+		crewOwner, crewKnown := crewOwners[crew]
+		if !crewKnown {
+			addIssue(RosterIssue{Kind: ROSTER_CREW_WITHOUT_TRANSFER, Crew: crew, BlockNumber: rosterBlocks[crew]})
+		}
+
+		for _, crewmate := range crewmates {
+			if crewmate == 0 {
+				continue
+			}
+			report.Crewmates++
+
+			if otherCrew, ok := crewmateCrews[crewmate]; ok && otherCrew != crew {
+				addIssue(RosterIssue{Kind: ROSTER_CREWMATE_IN_SEVERAL_CREWS, Crew: crew, Crewmate: crewmate, OtherCrew: otherCrew, BlockNumber: rosterBlocks[crew]})
+			}
+			crewmateCrews[crewmate] = crew
+
+			crewmateOwner, crewmateKnown := crewmateOwners[crewmate]
+			if !crewmateKnown {
+				addIssue(RosterIssue{Kind: ROSTER_CREWMATE_WITHOUT_TRANSFER, Crew: crew, Crewmate: crewmate, CrewOwner: crewOwner, BlockNumber: rosterBlocks[crew]})
+				continue
+			}
+			if crewKnown && crewmateOwner != crewOwner {
+				addIssue(RosterIssue{Kind: ROSTER_OWNER_MISMATCH, Crew: crew, Crewmate: crewmate, CrewOwner: crewOwner, CrewmateOwner: crewmateOwner, BlockNumber: rosterBlocks[crew]})
+			}
+		}
+	}
+	return report
+}
+
+// latestOwners returns the normalized owner of each token after its latest Transfer.
+func latestOwners(ownerships []TokenOwnership) map[uint64]string {
+	sort.SliceStable(ownerships, func(i, j int) bool {
+		if ownerships[i].BlockNumber != ownerships[j].BlockNumber {
+			return ownerships[i].BlockNumber < ownerships[j].BlockNumber
+		}
+		return ownerships[i].LineNumber < ownerships[j].LineNumber
+	})
+	owners := make(map[uint64]string)
+	for _, ownership := range ownerships {
+		owners[ownership.TokenId] = NormalizeAddress(ownership.Owner)
+	}
+	return owners
+}
@@ -0,0 +1,211 @@
+package leaderboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Round is an entry of the rounds registry. Leaderboards of a round are titled with its title and
+// the mission name. Its first and last block, if set, can be passed to commands as block aliases,
+// see BlockAliases.
+type Round struct {
+	Name       string `json:"name"`
+	Title      string `json:"title,omitempty"`
+	StartBlock uint64 `json:"start_block,omitempty"`
+	EndBlock   uint64 `json:"end_block,omitempty"`
+}
+
+// RoundsRegistry lists rounds in the order they are played, Current is the name of the round the
+// leaderboards map currently points to.
+type RoundsRegistry struct {
+	Current string  `json:"current"`
+	Rounds  []Round `json:"rounds"`
+}
+
+func ReadRoundsRegistry(filePath string) (RoundsRegistry, error) {
+	var registry RoundsRegistry
+
+	byteValue, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return registry, fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+	if unmErr := json.Unmarshal(byteValue, &registry); unmErr != nil {
+		return registry, fmt.Errorf("Error unmarshalling JSON, err: %v", unmErr)
+	}
+
+	return registry, nil
+}
+
+func WriteRoundsRegistry(filePath string, registry RoundsRegistry) error {
+	byteValue, marshErr := json.MarshalIndent(registry, "", "    ")
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling rounds registry: %v", marshErr)
+	}
+	return os.WriteFile(filePath, append(byteValue, '\n'), 0644)
+}
+
+// CurrentAndNext returns the current round and the round following it.
+func (r RoundsRegistry) CurrentAndNext() (Round, Round, error) {
+	for i, round := range r.Rounds {
+		if round.Name != r.Current {
+			continue
+		}
+		if i+1 >= len(r.Rounds) {
+			return round, Round{}, fmt.Errorf("round %s is the last round in the registry, add the next round to roll over", round.Name)
+		}
+		return round, r.Rounds[i+1], nil
+	}
+	return Round{}, Round{}, fmt.Errorf("current round %s is not found in the registry", r.Current)
+}
+
+// BlockAliases returns the blocks rounds start and end at by alias, <round>-start and <round>-end,
+// and current-start and current-end for the current round.
+func (r RoundsRegistry) BlockAliases() map[string]uint64 {
+	aliases := make(map[string]uint64)
+	for _, round := range r.Rounds {
+		names := []string{round.Name}
+		if round.Name == r.Current {
+			names = append(names, "current")
+		}
+		for _, name := range names {
+			if round.StartBlock > 0 {
+				aliases[name+"-start"] = round.StartBlock
+			}
+			if round.EndBlock > 0 {
+				aliases[name+"-end"] = round.EndBlock
+			}
+		}
+	}
+	return aliases
+}
+
+// RoundsRollover freezes leaderboards of the current round and moves the leaderboards map to the
+// next round:
+//  1. scores of each mission are pushed for the last time and archived in archiveDir/<round>
+//  2. the leaderboards map of the ending round is archived next to its scores
+//  3. leaderboards of the next round are created at the Moonstream.to portal and written to the
+//     leaderboards map, which is then marked in the registry as the map of the next round
+//
+// Leaderboards of the next round are only created if the final push of every mission succeeded.
+// Leaderboards titled for the next round which the token already owns are reused instead, so a
+// rollover which failed half way can be retried. Missions run with the settings of opts, the
+// thresholds of the registry and the targets of the leaderboards map.
+func RoundsRollover(ctx context.Context, opts MissionOptions, registryPath, leaderboardsMapPath, infile, accessToken, archiveDir string) error {
+	registry, registryErr := ReadRoundsRegistry(registryPath)
+	if registryErr != nil {
+		return registryErr
+	}
+	currentRound, nextRound, roundErr := registry.CurrentAndNext()
+	if roundErr != nil {
+		return roundErr
+	}
+
+	leaderboardsMap, mapErr := ReadLeaderboardsMap(leaderboardsMapPath)
+	if mapErr != nil {
+		return mapErr
+	}
+
+	roundArchiveDir := filepath.Join(archiveDir, currentRound.Name)
+	if mkdirErr := os.MkdirAll(roundArchiveDir, 0755); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	for _, lm := range LEADERBOARD_MISSIONS {
+		for _, target := range leaderboardsMap[lm.Name] {
+			lId := target.LeaderboardId
+			lAccessToken := accessToken
+			if target.AccessToken != "" {
+				lAccessToken = target.AccessToken
+			}
+			lmOpts, optsErr := opts.ForMission(lm, ScoreThresholds{MinScore: lm.MinScore, MinEvents: lm.MinEvents}).ForTarget(target)
+			if optsErr != nil {
+				return fmt.Errorf("invalid sinks of %s leaderboard %s, round is not rolled over: %v", lm.Name, lId, optsErr)
+			}
+
+			snapshot := filepath.Join(roundArchiveDir, fmt.Sprintf("%s-%s.json", lm.Name, lId))
+			if err := RunMission(ctx, lm, lmOpts, MISSION_TIMEOUT, &infile, &snapshot, &lAccessToken, &lId); err != nil {
+				return fmt.Errorf("final push of %s leaderboard %s failed, round is not rolled over: %v", lm.Name, lId, err)
+			}
+			log.Printf("Froze %s leaderboard known as %s, snapshot: %s", lId, lm.Name, snapshot)
+		}
+	}
+
+	if writeErr := WriteLeaderboardsMap(filepath.Join(roundArchiveDir, "leaderboards-map.json"), leaderboardsMap); writeErr != nil {
+		return writeErr
+	}
+
+	nextTitle := nextRound.Title
+	if nextTitle == "" {
+		nextTitle = nextRound.Name
+	}
+
+	missions := make([]string, 0, len(leaderboardsMap))
+	for mission := range leaderboardsMap {
+		missions = append(missions, mission)
+	}
+	sort.Strings(missions)
+
+	// Leaderboards of the next round created by an earlier, failed rollover are found by title and
+	// reused, so retrying the rollover does not create them twice
+	reused := make(map[string]bool)
+	nextLeaderboardsMap := make(map[string]LeaderboardTargets, len(leaderboardsMap))
+	for _, mission := range missions {
+		var nextTargets LeaderboardTargets
+		for _, target := range leaderboardsMap[mission] {
+			lAccessToken := accessToken
+			if target.AccessToken != "" {
+				lAccessToken = target.AccessToken
+			}
+			if lAccessToken == "" {
+				lAccessToken = MOONSTREAM_ACCESS_TOKEN
+			}
+			title := fmt.Sprintf("%s: %s", nextTitle, mission)
+
+			owned, fetchErr := FetchOwnedLeaderboards(target.APIURL, lAccessToken)
+			if fetchErr != nil {
+				return fmt.Errorf("unable to list leaderboards at %s for round %s: %v", PortalAPIURL(target.APIURL), nextRound.Name, fetchErr)
+			}
+			lId := ""
+			for _, leaderboard := range owned {
+				if leaderboard.Title == title && !reused[leaderboard.Id] {
+					lId = leaderboard.Id
+					break
+				}
+			}
+			if lId != "" {
+				log.Printf("Reused %s leaderboard known as %s for round %s", lId, mission, nextRound.Name)
+			} else {
+				var createErr error
+				lId, createErr = CreateLeaderboard(target.APIURL, lAccessToken, LeaderboardCreateRequest{
+					Title:       title,
+					Description: fmt.Sprintf("Influence.eth %s leaderboard for %s", mission, nextTitle),
+					Public:      true,
+				})
+				if createErr != nil {
+					// Leaderboards map and registry are left untouched, so the rollover can be retried
+					return fmt.Errorf("unable to create %s leaderboard for round %s, leaderboards created so far are logged above: %v", mission, nextRound.Name, createErr)
+				}
+				log.Printf("Created %s leaderboard known as %s for round %s", lId, mission, nextRound.Name)
+			}
+			reused[lId] = true
+
+			// The next round keeps every setting of the target, e.g. its address format
+			next := target
+			next.LeaderboardId = lId
+			nextTargets = append(nextTargets, next)
+		}
+		nextLeaderboardsMap[mission] = nextTargets
+	}
+
+	if writeErr := WriteLeaderboardsMap(leaderboardsMapPath, nextLeaderboardsMap); writeErr != nil {
+		return writeErr
+	}
+
+	registry.Current = nextRound.Name
+	return WriteRoundsRegistry(registryPath, registry)
+}
@@ -0,0 +1,84 @@
+package leaderboards
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// MissionAsteroidScope returns the asteroids the mission counts events on. Asteroids set with the
+// --asteroids flag replace the default asteroids of missions which count events by asteroid, other
+// missions are not affected.
+func MissionAsteroidScope(lm LeaderboardCommandFunc, override *AsteroidSelector) AsteroidSelector {
+	if lm.Asteroids == nil {
+		return AsteroidSelector{Spec: "any"}
+	}
+	if override != nil {
+		return *override
+	}
+	return *lm.Asteroids
+}
+
+// CheckAsteroidScope returns an error if asteroids were set with the --asteroids flag but the
+// mission can not be scoped to them.
+func CheckAsteroidScope(lm LeaderboardCommandFunc, override *AsteroidSelector) error {
+	if override != nil && lm.Asteroids == nil {
+		return fmt.Errorf("mission %s does not count events by asteroid, it can not be scoped with --asteroids", lm.Name)
+	}
+	return nil
+}
+
+// FilteredEventSource passes on the events of Source which Keep returns true for.
+type FilteredEventSource[T any] struct {
+	Source EventSource[T]
+	Keep   func(EventWrapper[T]) bool
+}
+
+func (s FilteredEventSource[T]) Each(handle func(EventWrapper[T])) {
+	s.Source.Each(func(e EventWrapper[T]) {
+		if s.Keep(e) {
+			handle(e)
+		}
+	})
+}
+
+func (s FilteredEventSource[T]) Err() error {
+	return s.Source.Err()
+}
+
+// ScopeTransits keeps the transits which arrived on asteroids of the scope.
+func ScopeTransits(events EventSource[influence.TransitFinished], scope AsteroidSelector) EventSource[influence.TransitFinished] {
+	return FilteredEventSource[influence.TransitFinished]{
+		Source: events,
+		Keep: func(e EventWrapper[influence.TransitFinished]) bool {
+			return scope.Matches(e.Event.Destination.Id)
+		},
+	}
+}
+
+// ScopeExtractions keeps the extractions of extractors on asteroids of the scope. Extractors are
+// located by the lots they were planned on, so planned constructions are only read if the scope
+// is not "any".
+func ScopeExtractions(ctx context.Context, opts EventOptions, events EventSource[influence.ResourceExtractionFinished], scope AsteroidSelector, filePath string) (EventSource[influence.ResourceExtractionFinished], error) {
+	if scope.String() == "any" {
+		return events, nil
+	}
+
+	plannedEvents, loadErr := LoadEvents[influence.ConstructionPlanned](ctx, opts, filePath)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	locator := NewEntityLocator(plannedEvents, SliceEventSource[influence.TransitFinished]{})
+	if streamErr := EventSourcesErr(plannedEvents); streamErr != nil {
+		return nil, streamErr
+	}
+
+	return FilteredEventSource[influence.ResourceExtractionFinished]{
+		Source: events,
+		Keep: func(e EventWrapper[influence.ResourceExtractionFinished]) bool {
+			asteroid, ok := locator.Asteroid(e.Event.Extractor, e.Event.BlockNumber)
+			return ok && scope.Matches(asteroid)
+		},
+	}, nil
+}
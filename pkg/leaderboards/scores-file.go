@@ -0,0 +1,318 @@
+package leaderboards
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Versions of score files written to --outfile:
+//   - 1 is a plain JSON array of scores, as sent to the Moonstream.to API
+//   - 2 wraps the scores into an object which records the format version and the points_data
+//     schema the scores were serialized with
+//   - 3 adds the provenance of the scores to the object of version 2
+var SCORES_FILE_VERSIONS = []int{1, 2, 3}
+
+const LATEST_SCORES_FILE_VERSION = 3
+
+type ScoresFile struct {
+	Version          int                `json:"version"`
+	PointsDataSchema string             `json:"points_data_schema"`
+	Provenance       *Provenance        `json:"provenance,omitempty"`
+	Scores           []LeaderboardScore `json:"scores"`
+}
+
+// Provenance traces scores to the events and the program they were generated from. Score files
+// which can not hold it (version 1 and moonstream-csv files) get it in a sidecar file, see
+// ProvenancePath.
+type Provenance struct {
+	InputFile        string `json:"input_file,omitempty"`
+	InputHash        string `json:"input_hash,omitempty"`
+	FromBlock        uint64 `json:"from_block"`
+	ToBlock          uint64 `json:"to_block"`
+	GeneratorVersion string `json:"generator_version"`
+	Revision         string `json:"revision,omitempty"`
+	LeaderboardId    string `json:"leaderboard_id,omitempty"`
+	GeneratedAt      string `json:"generated_at"`
+}
+
+// NewProvenance describes scores generated by the current run from AUDIT_INPUT_FILE for the
+// leaderboard. The input is hashed once per run, like for audit records.
+func NewProvenance(leaderboardId string) (Provenance, error) {
+	provenance := Provenance{
+		InputFile:        AUDIT_INPUT_FILE,
+		GeneratorVersion: VERSION,
+		Revision:         BuildRevision(),
+		LeaderboardId:    leaderboardId,
+		GeneratedAt:      time.Now().UTC().Format(time.RFC3339),
+	}
+	if AUDIT_INPUT_FILE != "" {
+		input, inputErr := hashAuditInput(AUDIT_INPUT_FILE)
+		if inputErr != nil {
+			return provenance, inputErr
+		}
+		provenance.InputHash = input.hash
+		provenance.FromBlock = input.fromBlock
+		provenance.ToBlock = input.toBlock
+	}
+	return provenance, nil
+}
+
+// ProvenancePath returns the path of the sidecar file holding the provenance of a score file.
+func ProvenancePath(outfile string) string {
+	return outfile + ".provenance.json"
+}
+
+// WriteProvenance writes the provenance of a score file which can not hold it to its sidecar file.
+func WriteProvenance(outfile string, provenance Provenance) error {
+	data, marshErr := json.MarshalIndent(provenance, "", "  ")
+	if marshErr != nil {
+		return marshErr
+	}
+	return os.WriteFile(ProvenancePath(outfile), append(data, '\n'), 0644)
+}
+
+// CheckScoresFileVersion returns an error if the version, set with the --output-version flag, is
+// not supported.
+func CheckScoresFileVersion(version int) error {
+	for _, v := range SCORES_FILE_VERSIONS {
+		if v == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output version %d, supported versions: %v", version, SCORES_FILE_VERSIONS)
+}
+
+// MarshalScoresFile serializes scores in the given score file version with the points_data schema.
+// The provenance is only kept by version 3.
+func MarshalScoresFile(scores []LeaderboardScore, version int, schema PointsDataSchema, provenance *Provenance) ([]byte, error) {
+	type scoresFile struct {
+		Version          int           `json:"version"`
+		PointsDataSchema string        `json:"points_data_schema"`
+		Provenance       *Provenance   `json:"provenance,omitempty"`
+		Scores           []schemaScore `json:"scores"`
+	}
+
+	switch version {
+	case 1:
+		return MarshalScores(scores, schema)
+	case 2:
+		return json.Marshal(scoresFile{
+			Version:          version,
+			PointsDataSchema: schema.Name,
+			Scores:           schemaScores(scores, schema),
+		})
+	case 3:
+		return json.Marshal(scoresFile{
+			Version:          version,
+			PointsDataSchema: schema.Name,
+			Provenance:       provenance,
+			Scores:           schemaScores(scores, schema),
+		})
+	}
+	return nil, fmt.Errorf("unknown output version %d, supported versions: %v", version, SCORES_FILE_VERSIONS)
+}
+
+// Formats of score files written to --outfile, set with the --format flag. Score files in the
+// moonstream-csv format can be uploaded by hand to the Moonstream.to portal, they can not be read
+// back by the convert command.
+const (
+	SCORES_FILE_FORMAT_JSON           = "json"
+	SCORES_FILE_FORMAT_MOONSTREAM_CSV = "moonstream-csv"
+)
+
+func CheckScoresFileFormat(format string) error {
+	if format != SCORES_FILE_FORMAT_JSON && format != SCORES_FILE_FORMAT_MOONSTREAM_CSV {
+		return fmt.Errorf("unknown output format %s, supported formats: %s, %s", format, SCORES_FILE_FORMAT_JSON, SCORES_FILE_FORMAT_MOONSTREAM_CSV)
+	}
+	return nil
+}
+
+// MOONSTREAM_CSV_HEADER is the header of CSV bulk uploads accepted by the Moonstream.to portal.
+var MOONSTREAM_CSV_HEADER = []string{"address", "score", "points_data"}
+
+// MarshalMoonstreamCSV serializes scores as a CSV bulk upload: one row per score with its address,
+// score and points_data as a JSON object in the points_data schema, exactly as the scores would be
+// pushed through the API.
+func MarshalMoonstreamCSV(scores []LeaderboardScore, schema PointsDataSchema) ([]byte, error) {
+	jsonData, marshErr := MarshalScores(scores, schema)
+	if marshErr != nil {
+		return nil, marshErr
+	}
+	var portalScores []PortalScore
+	if unmErr := json.Unmarshal(jsonData, &portalScores); unmErr != nil {
+		return nil, unmErr
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if writeErr := writer.Write(MOONSTREAM_CSV_HEADER); writeErr != nil {
+		return nil, writeErr
+	}
+	for _, score := range portalScores {
+		pointsData := string(score.PointsData)
+		if pointsData == "" || pointsData == "null" {
+			pointsData = "{}"
+		}
+		if writeErr := writer.Write([]string{score.Address, strconv.FormatUint(score.Score, 10), pointsData}); writeErr != nil {
+			return nil, writeErr
+		}
+	}
+	writer.Flush()
+	if flushErr := writer.Error(); flushErr != nil {
+		return nil, flushErr
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalScoresFile reads scores of any supported score file version. Files of version 1 do not
+// record their points_data schema, so they are read with the given one.
+func UnmarshalScoresFile(data []byte, schema PointsDataSchema) (ScoresFile, error) {
+	var rawFile struct {
+		Version          int               `json:"version"`
+		PointsDataSchema string            `json:"points_data_schema"`
+		Provenance       *Provenance       `json:"provenance"`
+		Scores           []json.RawMessage `json:"scores"`
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		rawFile.Version = 1
+		rawFile.PointsDataSchema = schema.Name
+		if unmErr := json.Unmarshal(trimmed, &rawFile.Scores); unmErr != nil {
+			return ScoresFile{}, fmt.Errorf("Error unmarshalling JSON, err: %v", unmErr)
+		}
+	} else {
+		if unmErr := json.Unmarshal(trimmed, &rawFile); unmErr != nil {
+			return ScoresFile{}, fmt.Errorf("Error unmarshalling JSON, err: %v", unmErr)
+		}
+		if rawFile.Version < 2 || rawFile.Version > LATEST_SCORES_FILE_VERSION {
+			return ScoresFile{}, fmt.Errorf("unknown scores file version %d, supported versions: %v", rawFile.Version, SCORES_FILE_VERSIONS)
+		}
+	}
+
+	fileSchema, ok := POINTS_DATA_SCHEMAS[rawFile.PointsDataSchema]
+	if !ok {
+		return ScoresFile{}, fmt.Errorf("unknown points_data schema %s", rawFile.PointsDataSchema)
+	}
+
+	scoresFile := ScoresFile{Version: rawFile.Version, PointsDataSchema: fileSchema.Name, Provenance: rawFile.Provenance}
+	for _, rawScore := range rawFile.Scores {
+		var score struct {
+			Address    string         `json:"address"`
+			Score      uint64         `json:"score"`
+			PointsData map[string]any `json:"points_data"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(rawScore))
+		decoder.UseNumber()
+		if decodeErr := decoder.Decode(&score); decodeErr != nil {
+			return ScoresFile{}, fmt.Errorf("Error unmarshalling score, err: %v", decodeErr)
+		}
+
+		pointsData, pdErr := PointsDataFromMap(score.PointsData, fileSchema)
+		if pdErr != nil {
+			return ScoresFile{}, fmt.Errorf("Invalid points_data for address %s: %v", score.Address, pdErr)
+		}
+		scoresFile.Scores = append(scoresFile.Scores, LeaderboardScore{
+			Address:    score.Address,
+			Score:      score.Score,
+			PointsData: pointsData,
+		})
+	}
+
+	return scoresFile, nil
+}
+
+// ReadScoresFile reads a score file, with its provenance from the sidecar file if the score file
+// does not hold it. Files of version 1 are read with the given points_data schema.
+func ReadScoresFile(filePath string, schema PointsDataSchema) (ScoresFile, error) {
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return ScoresFile{}, fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+	scoresFile, unmErr := UnmarshalScoresFile(data, schema)
+	if unmErr != nil {
+		return scoresFile, unmErr
+	}
+
+	if scoresFile.Provenance == nil {
+		if sidecarData, sidecarErr := os.ReadFile(ProvenancePath(filePath)); sidecarErr == nil {
+			var provenance Provenance
+			if unmErr := json.Unmarshal(sidecarData, &provenance); unmErr != nil {
+				return scoresFile, fmt.Errorf("Unable to parse %s, err: %v", ProvenancePath(filePath), unmErr)
+			}
+			scoresFile.Provenance = &provenance
+		}
+	}
+	return scoresFile, nil
+}
+
+// PointsDataFromMap is the reverse of PointsData.Map, it restores points data serialized with the
+// given schema.
+func PointsDataFromMap(values map[string]any, schema PointsDataSchema) (PointsData, error) {
+	var pd PointsData
+
+	extraNames := make(map[string]string, len(schema.Extra))
+	for name, renamed := range schema.Extra {
+		extraNames[renamed] = name
+	}
+
+	for key, value := range values {
+		var parseErr error
+		switch key {
+		case schema.Complete:
+			isComplete, ok := value.(bool)
+			if !ok {
+				parseErr = fmt.Errorf("%s should be a boolean", key)
+			}
+			pd.Complete = Completed(isComplete)
+		case schema.MustReach:
+			pd.MustReach, parseErr = pointsDataUint(key, value)
+		case schema.MustReachCounter:
+			pd.MustReachCounter, parseErr = pointsDataUint(key, value)
+		case schema.Cap:
+			pd.Cap, parseErr = pointsDataUint(key, value)
+		case schema.Data:
+			pd.Data = value
+		case schema.ScoreDetails:
+			var details ScoreDetails
+			detailsJSON, marshErr := json.Marshal(value)
+			if marshErr == nil {
+				marshErr = json.Unmarshal(detailsJSON, &details)
+			}
+			if marshErr != nil {
+				parseErr = fmt.Errorf("%s is malformed: %v", key, marshErr)
+			}
+			pd.ScoreDetails = &details
+		default:
+			if name, ok := extraNames[key]; ok {
+				key = name
+			}
+			if pd.Extra == nil {
+				pd.Extra = make(map[string]any)
+			}
+			pd.Extra[key] = value
+		}
+		if parseErr != nil {
+			return PointsData{}, parseErr
+		}
+	}
+
+	return pd, nil
+}
+
+func pointsDataUint(key string, value any) (uint64, error) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%s should be a number", key)
+	}
+	parsed, parseErr := strconv.ParseUint(number.String(), 10, 64)
+	if parseErr != nil {
+		return 0, fmt.Errorf("%s should be an unsigned integer: %v", key, parseErr)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,85 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Scores of the fixtures in testdata/scores-files, which hold the same scores in every score file
+// version.
+func fixtureScores() []LeaderboardScore {
+	return []LeaderboardScore{
+		{
+			Address: "17",
+			Score:   4,
+			PointsData: PointsData{
+				Complete:         Completed(false),
+				MustReach:        10,
+				MustReachCounter: 6,
+				ScoreDetails:     &ScoreDetails{Postfix: " building(s)", AddressName: "Crew"},
+				Extra:            map[string]any{"building_types": []any{json.Number("1"), json.Number("5")}},
+			},
+		},
+		{
+			Address:    "2301",
+			Score:      1,
+			PointsData: PointsData{Complete: Completed(true)},
+		},
+	}
+}
+
+func TestReadScoresFileVersions(t *testing.T) {
+	cases := []struct {
+		version          int
+		schema           string
+		generatorVersion string
+	}{
+		// Version 1 files record neither their schema nor their provenance, which is read from
+		// the sidecar file
+		{version: 1, schema: "v1", generatorVersion: "0.0.9"},
+		{version: 2, schema: "v2"},
+		{version: 3, schema: "v1", generatorVersion: "0.1.0"},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("v%d", c.version), func(t *testing.T) {
+			scoresFile, readErr := ReadScoresFile(filepath.Join("testdata", "scores-files", fmt.Sprintf("v%d.json", c.version)), POINTS_DATA_SCHEMAS["v1"])
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if scoresFile.Version != c.version || scoresFile.PointsDataSchema != c.schema {
+				t.Errorf("expected version %d with points_data schema %s, got version %d with %s", c.version, c.schema, scoresFile.Version, scoresFile.PointsDataSchema)
+			}
+			if !reflect.DeepEqual(scoresFile.Scores, fixtureScores()) {
+				t.Errorf("expected scores %+v, got %+v", fixtureScores(), scoresFile.Scores)
+			}
+
+			if c.generatorVersion == "" {
+				if scoresFile.Provenance != nil {
+					t.Errorf("expected no provenance, got %+v", scoresFile.Provenance)
+				}
+			} else if scoresFile.Provenance == nil || scoresFile.Provenance.GeneratorVersion != c.generatorVersion || scoresFile.Provenance.ToBlock != 680000 {
+				t.Errorf("expected the provenance of generator version %s up to block 680000, got %+v", c.generatorVersion, scoresFile.Provenance)
+			}
+		})
+	}
+}
+
+func TestScoresFileVersionsRoundTrip(t *testing.T) {
+	provenance := &Provenance{FromBlock: 600000, ToBlock: 680000, GeneratorVersion: "0.1.0", GeneratedAt: "2024-05-01T00:00:00Z"}
+	for _, version := range SCORES_FILE_VERSIONS {
+		data, marshErr := MarshalScoresFile(fixtureScores(), version, POINTS_DATA_SCHEMAS["v1"], provenance)
+		if marshErr != nil {
+			t.Fatalf("version %d: %v", version, marshErr)
+		}
+		scoresFile, unmErr := UnmarshalScoresFile(data, POINTS_DATA_SCHEMAS["v1"])
+		if unmErr != nil {
+			t.Fatalf("version %d: %v", version, unmErr)
+		}
+		if scoresFile.Version != version || !reflect.DeepEqual(scoresFile.Scores, fixtureScores()) {
+			t.Errorf("version %d: expected the scores to be read back unchanged, got version %d with %+v", version, scoresFile.Version, scoresFile.Scores)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// ScoringPolicy converts the raw metric a mission scores (e.g. kilograms extracted) into points,
+// so scores of different missions are comparable and can be summed into a season total. With
+// Per 1000, Points 1 and Cap 50, a crew gets 1 point per 1000 kg, at most 50.
+type ScoringPolicy struct {
+	// Raw score which earns Points points, partial amounts are rounded down
+	Per    uint64 `json:"per"`
+	Points uint64 `json:"points,omitempty"`
+	// Maximum points earned from the raw score, 0 for no cap
+	Cap uint64 `json:"cap,omitempty"`
+	// Points added on top of the cap for completing the mission
+	CompletionBonus uint64 `json:"completion_bonus,omitempty"`
+}
+
+// Scoring policies by mission, read from the file passed with the --scoring-policies flag.
+// Missions without a policy are scored by their raw metric.
+var SCORING_POLICIES = map[string]ScoringPolicy{}
+
+// SetScoringPolicies reads scoring policies by mission name from a JSON file.
+func SetScoringPolicies(policiesPath string) error {
+	SCORING_POLICIES = map[string]ScoringPolicy{}
+	if policiesPath == "" {
+		return nil
+	}
+
+	data, readErr := os.ReadFile(policiesPath)
+	if readErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", policiesPath, readErr)
+	}
+	if unmErr := json.Unmarshal(data, &SCORING_POLICIES); unmErr != nil {
+		return fmt.Errorf("Unable to parse scoring policies %s, err: %v", policiesPath, unmErr)
+	}
+
+	missions := make(map[string]bool, len(LEADERBOARD_MISSIONS))
+	for _, lm := range LEADERBOARD_MISSIONS {
+		missions[lm.Name] = true
+	}
+	for mission, policy := range SCORING_POLICIES {
+		if !missions[mission] {
+			return fmt.Errorf("scoring policy for unknown mission %s in %s", mission, policiesPath)
+		}
+		if policy.Per == 0 {
+			return fmt.Errorf("scoring policy of %s should set per, the raw score which earns points", mission)
+		}
+	}
+	return nil
+}
+
+// MissionScoringPolicy returns the scoring policy of the mission, or nil if it is scored by its
+// raw metric.
+func MissionScoringPolicy(lm LeaderboardCommandFunc) *ScoringPolicy {
+	policy, ok := SCORING_POLICIES[lm.Name]
+	if !ok {
+		return nil
+	}
+	return &policy
+}
+
+// Score returns the points earned with a raw score.
+func (p ScoringPolicy) Score(raw uint64, complete bool) uint64 {
+	points := p.Points
+	if points == 0 {
+		points = 1
+	}
+	earned := new(big.Int).Mul(new(big.Int).SetUint64(raw), new(big.Int).SetUint64(points))
+	earned.Quo(earned, new(big.Int).SetUint64(p.Per))
+	if p.Cap > 0 && (!earned.IsUint64() || earned.Uint64() > p.Cap) {
+		earned.SetUint64(p.Cap)
+	}
+	if complete {
+		earned.Add(earned, new(big.Int).SetUint64(p.CompletionBonus))
+	}
+	if !earned.IsUint64() {
+		return ^uint64(0)
+	}
+	return earned.Uint64()
+}
+
+// Apply replaces raw scores with the points they earn, keeping the raw score as raw_score in
+// points_data.
+func (p ScoringPolicy) Apply(scores []LeaderboardScore) {
+	for i, score := range scores {
+		if scores[i].PointsData.Extra == nil {
+			scores[i].PointsData.Extra = make(map[string]any)
+		}
+		scores[i].PointsData.Extra["raw_score"] = score.Score
+		complete := score.PointsData.Complete != nil && *score.PointsData.Complete
+		scores[i].Score = p.Score(score.Score, complete)
+	}
+}
@@ -0,0 +1,474 @@
+package leaderboards
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+
+	starlarkjson "go.starlark.net/lib/json"
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// Name of the function a leaderboard script defines to compute scores.
+var SCRIPT_ENTRYPOINT = "scores"
+
+// ScriptEvents is the events file as seen by leaderboard scripts. Iterating over it streams the
+// events from file on each pass, calling it with event names returns the events with those names:
+//
+//	for e in events("TransitFinished"):
+//	    crew = e.event["CallerCrew"]["Id"]
+//
+// Errors reading the file are collected and reported by Err once the script returns.
+type ScriptEvents struct {
+	FilePath string
+	Names    map[string]bool
+	Options  EventOptions
+
+	err *error
+}
+
+func NewScriptEvents(filePath string, opts EventOptions) *ScriptEvents {
+	var err error
+	return &ScriptEvents{FilePath: filePath, Options: opts, err: &err}
+}
+
+func (e *ScriptEvents) Err() error {
+	return *e.err
+}
+
+func (e *ScriptEvents) String() string        { return "events" }
+func (e *ScriptEvents) Type() string          { return "events" }
+func (e *ScriptEvents) Freeze()               {}
+func (e *ScriptEvents) Truth() starlark.Bool  { return starlark.True }
+func (e *ScriptEvents) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: events") }
+func (e *ScriptEvents) Name() string          { return "events" }
+
+func (e *ScriptEvents) CallInternal(thread *starlark.Thread, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) > 0 {
+		return nil, fmt.Errorf("events: unexpected keyword arguments")
+	}
+	names := make(map[string]bool)
+	for _, arg := range args {
+		name, ok := starlark.AsString(arg)
+		if !ok {
+			return nil, fmt.Errorf("events: event names should be strings, got %s", arg.Type())
+		}
+		names[name] = true
+	}
+	return &ScriptEvents{FilePath: e.FilePath, Names: names, Options: e.Options, err: e.err}, nil
+}
+
+func (e *ScriptEvents) Iterate() starlark.Iterator {
+	iterator := &scriptEventsIterator{events: e}
+	iterator.filePaths, iterator.err = influence.EventFilePaths(e.FilePath)
+	if iterator.err == nil {
+		iterator.confirmedBlock, iterator.checkConfirmations, iterator.err = e.Options.ConfirmedBlock(e.FilePath)
+	}
+	return iterator
+}
+
+type scriptEventsIterator struct {
+	events             *ScriptEvents
+	filePaths          []string
+	confirmedBlock     uint64
+	checkConfirmations bool
+
+	inputFile  *os.File
+	scanner    *bufio.Scanner
+	lineNumber int
+	// Whether lines and events of the current file are counted in PARSE_STATS, see
+	// StreamEventsFromFile
+	countLines  bool
+	countEvents bool
+	err         error
+}
+
+func (it *scriptEventsIterator) nextLine() bool {
+	for {
+		if it.scanner != nil && it.scanner.Scan() {
+			it.lineNumber++
+			return true
+		}
+		if it.scanner != nil {
+			if scanErr := it.scanner.Err(); scanErr != nil {
+				it.err = fmt.Errorf("Error reading file: %v", scanErr)
+				return false
+			}
+			it.inputFile.Close()
+			it.inputFile, it.scanner = nil, nil
+		}
+		if len(it.filePaths) == 0 {
+			return false
+		}
+
+		filePath := it.filePaths[0]
+		it.filePaths = it.filePaths[1:]
+		inputFile, openErr := os.Open(filePath)
+		if openErr != nil {
+			it.err = fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+			return false
+		}
+		it.inputFile, it.scanner, it.lineNumber = inputFile, bufio.NewScanner(inputFile), 0
+		it.countLines = PARSE_STATS.firstRead(filePath)
+		it.countEvents = PARSE_STATS.firstRead(filePath + "#script")
+	}
+}
+
+func (it *scriptEventsIterator) Next(p *starlark.Value) bool {
+	for it.err == nil && it.nextLine() {
+		if it.countLines {
+			PARSE_STATS.Lines++
+		}
+
+		var line PartialEventLine
+		if unmErr := json.Unmarshal(it.scanner.Bytes(), &line); unmErr != nil {
+			if it.countLines {
+				log.Printf("Error parsing JSON line: %v", unmErr)
+				PARSE_STATS.Skipped++
+			}
+			continue
+		}
+		if it.countLines && !influence.REGISTERED_EVENT_NAMES[line.Name] {
+			PARSE_STATS.Unmatched++
+		}
+		if len(it.events.Names) > 0 && !it.events.Names[line.Name] {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line.Event))
+		decoder.UseNumber()
+		var event any
+		if decodeErr := decoder.Decode(&event); decodeErr != nil {
+			if it.countEvents {
+				log.Printf("Error parsing Event: %v", decodeErr)
+				PARSE_STATS.DecodeFailures++
+			}
+			continue
+		}
+
+		var blockNumber uint64
+		if fields, ok := event.(map[string]any); ok {
+			if number, ok := fields["BlockNumber"].(json.Number); ok {
+				fmt.Sscan(number.String(), &blockNumber)
+			}
+		}
+		if it.checkConfirmations && blockNumber > it.confirmedBlock {
+			if it.countEvents {
+				PARSE_STATS.Unconfirmed++
+			}
+			continue
+		}
+
+		eventValue, convErr := ToStarlark(event)
+		if convErr != nil {
+			it.err = convErr
+			return false
+		}
+		*p = starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"name":             starlark.String(line.Name),
+			"transaction_hash": starlark.String(line.TransactionHash),
+			"block_number":     starlark.MakeUint64(blockNumber),
+			"line":             starlark.MakeInt(it.lineNumber),
+			"event":            eventValue,
+		})
+		return true
+	}
+	return false
+}
+
+func (it *scriptEventsIterator) Done() {
+	if it.inputFile != nil {
+		it.inputFile.Close()
+	}
+	if it.err != nil && *it.events.err == nil {
+		*it.events.err = it.err
+	}
+}
+
+// ToStarlark converts values decoded from JSON with json.Number numbers into Starlark values.
+// Felts do not fit into int64, so integers are converted exactly into big Starlark integers.
+func ToStarlark(value any) (starlark.Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case json.Number:
+		if integer, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return starlark.MakeBigInt(integer), nil
+		}
+		float, floatErr := v.Float64()
+		if floatErr != nil {
+			return nil, fmt.Errorf("invalid number %s", v)
+		}
+		return starlark.Float(float), nil
+	case []any:
+		items := make([]starlark.Value, len(v))
+		for i, item := range v {
+			converted, convErr := ToStarlark(item)
+			if convErr != nil {
+				return nil, convErr
+			}
+			items[i] = converted
+		}
+		return starlark.NewList(items), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(v))
+		for _, key := range keys {
+			converted, convErr := ToStarlark(v[key])
+			if convErr != nil {
+				return nil, convErr
+			}
+			dict.SetKey(starlark.String(key), converted)
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %T", value)
+}
+
+// FromStarlark converts values returned by scripts into values which marshal to JSON. Integers
+// which do not fit into int64 are returned as *big.Int.
+func FromStarlark(value starlark.Value) (any, error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Int:
+		if integer, ok := v.Int64(); ok {
+			return integer, nil
+		}
+		return v.BigInt(), nil
+	case starlark.Float:
+		return float64(v), nil
+	case *starlark.List, starlark.Tuple:
+		items := []any{}
+		iterator := starlark.Iterate(v)
+		defer iterator.Done()
+		var item starlark.Value
+		for iterator.Next(&item) {
+			converted, convErr := FromStarlark(item)
+			if convErr != nil {
+				return nil, convErr
+			}
+			items = append(items, converted)
+		}
+		return items, nil
+	case *starlark.Dict:
+		result := make(map[string]any, v.Len())
+		for _, entry := range v.Items() {
+			key, ok := starlark.AsString(entry[0])
+			if !ok {
+				return nil, fmt.Errorf("dict keys should be strings, got %s", entry[0].Type())
+			}
+			converted, convErr := FromStarlark(entry[1])
+			if convErr != nil {
+				return nil, convErr
+			}
+			result[key] = converted
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %s", value.Type())
+}
+
+func scriptUint64(address, key string, value any) (uint64, error) {
+	integer, ok := value.(int64)
+	if !ok || integer < 0 {
+		return 0, fmt.Errorf("%s of address %s should be a non-negative integer which fits into uint64, got %v", key, address, value)
+	}
+	return uint64(integer), nil
+}
+
+// ScriptScore converts a score returned by a script, a dict with an address, a score and optional
+// points_data fields, into a leaderboard score. Scores are scaled like other big scores, see
+// BigScoreToUint64.
+func ScriptScore(value map[string]any, scale *big.Rat) (LeaderboardScore, error) {
+	address, ok := value["address"].(string)
+	if !ok || address == "" {
+		return LeaderboardScore{}, fmt.Errorf("scores should have a string address, got %v", value["address"])
+	}
+	score := LeaderboardScore{Address: address}
+
+	for key, field := range value {
+		var convErr error
+		switch key {
+		case "address":
+		case "score":
+			// Scores may be big integers, e.g. SWAY volumes, which are scaled like other big scores
+			if integer, ok := field.(*big.Int); ok {
+				score.Score, convErr = BigScoreToUint64(new(big.Rat).SetInt(integer), scale)
+			} else if integer, ok := field.(int64); ok && integer >= 0 {
+				score.Score, convErr = BigScoreToUint64(new(big.Rat).SetInt64(integer), scale)
+			} else {
+				convErr = fmt.Errorf("score of address %s should be a non-negative integer, got %v", address, field)
+			}
+		case "complete":
+			complete, ok := field.(bool)
+			if !ok {
+				convErr = fmt.Errorf("complete of address %s should be a bool, got %v", address, field)
+			}
+			score.PointsData.Complete = Completed(complete)
+		case "must_reach":
+			score.PointsData.MustReach, convErr = scriptUint64(address, key, field)
+		case "must_reach_counter":
+			score.PointsData.MustReachCounter, convErr = scriptUint64(address, key, field)
+		case "cap":
+			score.PointsData.Cap, convErr = scriptUint64(address, key, field)
+		case "event_count":
+			score.EventCount, convErr = scriptUint64(address, key, field)
+		case "transaction_hash":
+			transactionHash, ok := field.(string)
+			if !ok {
+				convErr = fmt.Errorf("transaction_hash of address %s should be a string, got %v", address, field)
+			}
+			score.TransactionHash = transactionHash
+		case "data":
+			score.PointsData.Data = field
+		case "extra":
+			extra, ok := field.(map[string]any)
+			if !ok {
+				convErr = fmt.Errorf("extra of address %s should be a dict, got %v", address, field)
+			}
+			score.PointsData.Extra = extra
+		default:
+			convErr = fmt.Errorf("unknown key %s in score of address %s", key, address)
+		}
+		if convErr != nil {
+			return LeaderboardScore{}, convErr
+		}
+	}
+
+	if _, ok := value["score"]; !ok {
+		return LeaderboardScore{}, fmt.Errorf("score of address %s is missing", address)
+	}
+	return score, nil
+}
+
+// ScriptScores converts the result of a script into leaderboard scores. Scripts return either a
+// list of score dicts (see ScriptScore) or a dict of scores by address.
+func ScriptScores(result starlark.Value, scale *big.Rat) ([]LeaderboardScore, error) {
+	value, convErr := FromStarlark(result)
+	if convErr != nil {
+		return nil, convErr
+	}
+
+	scores := []LeaderboardScore{}
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			fields, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("scores should be dicts, got %v", item)
+			}
+			score, scoreErr := ScriptScore(fields, scale)
+			if scoreErr != nil {
+				return nil, scoreErr
+			}
+			scores = append(scores, score)
+		}
+	case map[string]any:
+		for address, field := range v {
+			score, scoreErr := ScriptScore(map[string]any{"address": address, "score": field}, scale)
+			if scoreErr != nil {
+				return nil, scoreErr
+			}
+			scores = append(scores, score)
+		}
+		sort.Slice(scores, func(i, j int) bool {
+			if scores[i].Score != scores[j].Score {
+				return scores[i].Score > scores[j].Score
+			}
+			return scores[i].Address < scores[j].Address
+		})
+	default:
+		return nil, fmt.Errorf("%s should return a list of scores or a dict of scores by address, got %s", SCRIPT_ENTRYPOINT, result.Type())
+	}
+	return scores, nil
+}
+
+// RunLeaderboardScript executes the Starlark script and calls its scores function with the events
+// of the file, read and scaled with the options of the mission. The json and math modules are
+// available to scripts, print writes to the log.
+func RunLeaderboardScript(opts MissionOptions, scriptFile, infile string) ([]LeaderboardScore, error) {
+	if infile == "" {
+		return nil, fmt.Errorf("Please specify file with events with --input flag")
+	}
+	if _, statErr := os.Stat(infile); statErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", infile, statErr)
+	}
+	source, readErr := os.ReadFile(scriptFile)
+	if readErr != nil {
+		return nil, fmt.Errorf("Unable to read file %s, err: %v", scriptFile, readErr)
+	}
+
+	thread := &starlark.Thread{
+		Name: scriptFile,
+		Print: func(thread *starlark.Thread, msg string) {
+			log.Printf("%s: %s", scriptFile, msg)
+		},
+	}
+	predeclared := starlark.StringDict{
+		"json":   starlarkjson.Module,
+		"math":   starlarkmath.Module,
+		"struct": starlark.NewBuiltin("struct", starlarkstruct.Make),
+	}
+	globals, execErr := starlark.ExecFile(thread, scriptFile, source, predeclared)
+	if execErr != nil {
+		return nil, scriptError(execErr)
+	}
+
+	entrypoint, ok := globals[SCRIPT_ENTRYPOINT].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("script %s should define a %s(events) function", scriptFile, SCRIPT_ENTRYPOINT)
+	}
+
+	events := NewScriptEvents(infile, opts.Events)
+	result, callErr := starlark.Call(thread, entrypoint, starlark.Tuple{events}, nil)
+	if callErr != nil {
+		return nil, scriptError(callErr)
+	}
+	if eventsErr := events.Err(); eventsErr != nil {
+		return nil, eventsErr
+	}
+
+	return ScriptScores(result, opts.ScoreScale)
+}
+
+// scriptError includes the Starlark backtrace in errors raised by scripts.
+func scriptError(err error) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%s", evalErr.Backtrace())
+	}
+	return err
+}
+
+// LScript is the leaderboard script mission, the script file is set with the --file flag.
+func LScript(scriptFile string) LeaderboardCommandCreator {
+	return func(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+		scores, scriptErr := RunLeaderboardScript(opts, scriptFile, *infile)
+		if scriptErr != nil {
+			return scriptErr
+		}
+		return PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	}
+}
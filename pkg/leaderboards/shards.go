@@ -0,0 +1,99 @@
+package leaderboards
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Number of goroutines pair-matching generators shard crews across, set with the --jobs flag.
+var MATCH_JOBS = runtime.NumCPU()
+
+// GroupByCrew indexes events by the ID of their caller crew, keeping their order.
+func GroupByCrew[T any](events []EventWrapper[T], crewOf func(event T) uint64) map[uint64][]EventWrapper[T] {
+	byCrew := make(map[uint64][]EventWrapper[T])
+	for _, event := range events {
+		crew := crewOf(event.Event)
+		byCrew[crew] = append(byCrew[crew], event)
+	}
+	return byCrew
+}
+
+// CrewMatches holds the results of a pair-matching generator, by crew. Counts is a second counter of
+// the generator per crew, e.g. of events behind the scores or of buildings torn down, and Total a
+// counter over all crews.
+type CrewMatches[V any] struct {
+	ByCrews      map[uint64]V
+	Counts       map[uint64]uint64
+	Transactions LatestTransactions[uint64]
+	Total        uint64
+}
+
+func NewCrewMatches[V any]() *CrewMatches[V] {
+	return &CrewMatches[V]{
+		ByCrews:      make(map[uint64]V),
+		Counts:       make(map[uint64]uint64),
+		Transactions: make(LatestTransactions[uint64]),
+	}
+}
+
+func (m *CrewMatches[V]) merge(shard *CrewMatches[V]) {
+	for crew, value := range shard.ByCrews {
+		m.ByCrews[crew] = value
+	}
+	for crew, count := range shard.Counts {
+		m.Counts[crew] += count
+	}
+	for crew, transactionHash := range shard.Transactions {
+		m.Transactions[crew] = transactionHash
+	}
+	m.Total += shard.Total
+}
+
+// MatchByCrew runs match for every crew, sharded by crew ID across MATCH_JOBS goroutines, and merges
+// the results of the shards. match must only read events of the crew it is called with, which
+// generators index with GroupByCrew first, so every crew is matched by a single goroutine with the
+// events in their original order. Crews are no longer matched once ctx is done, e.g. after the
+// mission timed out.
+func MatchByCrew[V any](ctx context.Context, crews []uint64, match func(matches *CrewMatches[V], crew uint64)) *CrewMatches[V] {
+	sort.Slice(crews, func(i, j int) bool { return crews[i] < crews[j] })
+
+	jobs := MATCH_JOBS
+	if jobs < 1 {
+		jobs = 1
+	}
+	shards := make([]*CrewMatches[V], jobs)
+	var wg sync.WaitGroup
+	for i := range shards {
+		shards[i] = NewCrewMatches[V]()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, crew := range crews {
+				if ctx.Err() != nil {
+					return
+				}
+				if crew%uint64(jobs) == uint64(i) {
+					match(shards[i], crew)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	matches := NewCrewMatches[V]()
+	for _, shard := range shards {
+		matches.merge(shard)
+	}
+	return matches
+}
+
+// CrewIds returns the crews of events indexed with GroupByCrew.
+func CrewIds[T any](byCrew map[uint64][]EventWrapper[T]) []uint64 {
+	crews := make([]uint64, 0, len(byCrew))
+	for crew := range byCrew {
+		crews = append(crews, crew)
+	}
+	return crews
+}
@@ -0,0 +1,19 @@
+package leaderboards
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMatchByCrewStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	matched := 0
+	MatchByCrew(ctx, []uint64{1, 2, 3}, func(m *CrewMatches[uint64], crew uint64) {
+		matched++
+	})
+	if matched != 0 {
+		t.Errorf("expected no crew to be matched once the context is done, got %d", matched)
+	}
+}
@@ -0,0 +1,392 @@
+package leaderboards
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	_ "github.com/lib/pq"
+)
+
+// ScoreBatch is the output of a mission, handed to each of its sinks.
+type ScoreBatch struct {
+	Mission       string
+	LeaderboardId string
+	AccessToken   string
+	// Moonstream.to API of the leaderboard, see PortalAPIURL
+	APIURL string
+	Scores []LeaderboardScore
+	// Scores serialized as pushed to the Moonstream.to portal
+	Payload []byte
+	// Settings of the mission the scores are written and pushed with
+	Output OutputOptions
+	Push   PushOptions
+}
+
+// ScoreSink receives the scores of missions, e.g. to write them to a file or push them to a
+// leaderboard. Writes stop with the error of ctx once it is done.
+type ScoreSink interface {
+	Write(ctx context.Context, batch ScoreBatch) error
+}
+
+// NewScoreSink creates a sink from its specification:
+//   - "moonstream" pushes scores to the leaderboard at the Moonstream.to portal
+//   - "stdout" writes scores to standard output as a JSON array, one line per mission
+//   - "file://<path>" writes a score file, {mission} in the path is replaced by the mission name
+//   - "http://..." or "https://..." POSTs scores as a JSON array to the URL
+//   - "s3://<bucket>/<prefix>" uploads a score file to <prefix>/<mission>.json in the bucket
+//   - "postgres://...?table=<table>" replaces the rows of the leaderboard in the table
+func NewScoreSink(spec string) (ScoreSink, error) {
+	switch spec {
+	case "moonstream":
+		return MoonstreamScoreSink{}, nil
+	case "stdout":
+		return WriterScoreSink{Writer: os.Stdout}, nil
+	}
+
+	sinkURL, parseErr := url.Parse(spec)
+	if parseErr != nil {
+		return nil, fmt.Errorf("invalid sink %s: %v", spec, parseErr)
+	}
+	switch sinkURL.Scheme {
+	case "file":
+		path := strings.TrimPrefix(spec, "file://")
+		if path == "" {
+			return nil, fmt.Errorf("file sink should be specified as file://<path>, got %s", spec)
+		}
+		return FileScoreSink{Path: path}, nil
+	case "http", "https":
+		return HTTPScoreSink{URL: spec, Client: &http.Client{Timeout: 30 * time.Second}}, nil
+	case "s3":
+		if sinkURL.Host == "" {
+			return nil, fmt.Errorf("s3 sink should be specified as s3://<bucket>/<prefix>, got %s", spec)
+		}
+		return S3ScoreSink{Bucket: sinkURL.Host, Prefix: strings.Trim(sinkURL.Path, "/")}, nil
+	case "postgres", "postgresql":
+		query := sinkURL.Query()
+		table := query.Get("table")
+		if table == "" {
+			table = POSTGRES_SINK_TABLE
+		}
+		if !sqlIdentifier.MatchString(table) {
+			return nil, fmt.Errorf("invalid table %s of postgres sink", table)
+		}
+		query.Del("table")
+		sinkURL.RawQuery = query.Encode()
+		return PostgresScoreSink{DSN: sinkURL.String(), Table: table}, nil
+	}
+	return nil, fmt.Errorf("unsupported sink %s, use moonstream, stdout, file://, http(s)://, s3:// or postgres://", spec)
+}
+
+// NewScoreSinks creates the sinks of a list of specifications, or returns nil for an empty list.
+func NewScoreSinks(specs []string) ([]ScoreSink, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	sinks := make([]ScoreSink, 0, len(specs))
+	for _, spec := range specs {
+		sink, sinkErr := NewScoreSink(spec)
+		if sinkErr != nil {
+			return nil, sinkErr
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// WriteScoreSinks writes the batch to every sink. All sinks are written even if some fail, the
+// first error is returned.
+func WriteScoreSinks(ctx context.Context, sinks []ScoreSink, batch ScoreBatch) error {
+	var firstErr error
+	for _, sink := range sinks {
+		if writeErr := sink.Write(ctx, batch); writeErr != nil {
+			log.Printf("Unable to write scores of %s to sink %T, err: %v", batch.Mission, sink, writeErr)
+			if firstErr == nil {
+				firstErr = writeErr
+			}
+		}
+	}
+	return firstErr
+}
+
+// FileScoreSink writes scores in the score file version and format of the batch, with their
+// provenance.
+type FileScoreSink struct {
+	Path string
+}
+
+func (s FileScoreSink) Write(ctx context.Context, batch ScoreBatch) error {
+	outfile := strings.ReplaceAll(s.Path, "{mission}", batch.Mission)
+	// Scores read from a score file keep the provenance they were generated with
+	var provenance Provenance
+	if batch.Output.Provenance != nil {
+		provenance = *batch.Output.Provenance
+	} else {
+		var provenanceErr error
+		provenance, provenanceErr = NewProvenance(batch.LeaderboardId)
+		if provenanceErr != nil {
+			return provenanceErr
+		}
+	}
+
+	var fileData []byte
+	var fileMarshErr error
+	sidecar := true
+	if batch.Output.FileFormat == SCORES_FILE_FORMAT_MOONSTREAM_CSV {
+		fileData, fileMarshErr = MarshalMoonstreamCSV(batch.Scores, batch.Output.Schema())
+	} else {
+		version := batch.Output.FileVersion
+		if version == 0 {
+			version = LATEST_SCORES_FILE_VERSION
+		}
+		fileData, fileMarshErr = MarshalScoresFile(batch.Scores, version, batch.Output.Schema(), &provenance)
+		sidecar = version < 3
+	}
+	if fileMarshErr != nil {
+		return fmt.Errorf("Error marshaling scores: %v", fileMarshErr)
+	}
+	if writeErr := os.WriteFile(outfile, fileData, 0644); writeErr != nil {
+		return fmt.Errorf("Error writing to file: %v", writeErr)
+	}
+	if sidecar {
+		if writeErr := WriteProvenance(outfile, provenance); writeErr != nil {
+			return fmt.Errorf("Error writing provenance of %s: %v", outfile, writeErr)
+		}
+	}
+	return nil
+}
+
+// MoonstreamScoreSink pushes scores to the leaderboard of the batch, with the push options of the
+// batch. Batches without leaderboard or access token are skipped.
+type MoonstreamScoreSink struct{}
+
+func (s MoonstreamScoreSink) Write(ctx context.Context, batch ScoreBatch) error {
+	accessToken := batch.AccessToken
+	if accessToken == "" {
+		accessToken = MOONSTREAM_ACCESS_TOKEN
+	}
+	leaderboardId := batch.LeaderboardId
+	if leaderboardId == "" || accessToken == "" {
+		return nil
+	}
+
+	// An empty push in overwrite mode wipes the leaderboard, which is mostly the result of a
+	// truncated or wrong events file
+	if len(batch.Scores) == 0 && !batch.Push.AllowEmpty {
+		log.Printf("Warning: no scores were generated, skipping push to leaderboard %s (use --allow-empty to push anyway)", leaderboardId)
+		return nil
+	}
+
+	pushData := batch.Payload
+	entries := PushedEntries(batch.Scores)
+	if !batch.Push.Overwrite() {
+		current, fetchErr := FetchLeaderboardScores(batch.APIURL, accessToken, leaderboardId)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		var pushed []PortalScore
+		if unmErr := json.Unmarshal(batch.Payload, &pushed); unmErr != nil {
+			return fmt.Errorf("Error unmarshalling scores: %v", unmErr)
+		}
+		var changed []PortalScore
+		changed, entries = ChangedScores(current, pushed)
+		if len(changed) == 0 {
+			log.Printf("Scores of leaderboard %s are up to date, nothing to append", leaderboardId)
+			return nil
+		}
+		log.Printf("Appending %d changed of %d scores to leaderboard %s", len(changed), len(pushed), leaderboardId)
+		var marshErr error
+		pushData, marshErr = json.Marshal(changed)
+		if marshErr != nil {
+			return fmt.Errorf("Error marshaling scores: %v", marshErr)
+		}
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	pushedAt := time.Now()
+	if batch.Push.ChunkSize > 0 {
+		if uploadErr := UploadScoresInChunks(batch.APIURL, accessToken, leaderboardId, pushData, batch.Push); uploadErr != nil {
+			return uploadErr
+		}
+	} else {
+		statusCode, reqErr := UpdateLeaderboardScores(batch.APIURL, accessToken, leaderboardId, bytes.NewBuffer(pushData), batch.Push.Overwrite())
+		if reqErr != nil {
+			return reqErr
+		}
+		if statusCode >= 300 {
+			return fmt.Errorf("unable to update leaderboard %s, status code: %d", leaderboardId, statusCode)
+		}
+	}
+
+	if batch.Push.Verify {
+		if verifyErr := VerifyLeaderboardPush(batch.APIURL, accessToken, leaderboardId, entries, pushedAt); verifyErr != nil {
+			return verifyErr
+		}
+	}
+	return nil
+}
+
+// WriterScoreSink writes the scores of each batch as a JSON array on one line.
+type WriterScoreSink struct {
+	Writer io.Writer
+}
+
+func (s WriterScoreSink) Write(ctx context.Context, batch ScoreBatch) error {
+	if _, writeErr := s.Writer.Write(batch.Payload); writeErr != nil {
+		return writeErr
+	}
+	_, writeErr := s.Writer.Write([]byte("\n"))
+	return writeErr
+}
+
+// HTTPScoreSink POSTs the scores of each batch as a JSON array, with the mission and leaderboard
+// in the X-Influence-Mission and X-Leaderboard-Id headers.
+type HTTPScoreSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s HTTPScoreSink) Write(ctx context.Context, batch ScoreBatch) error {
+	request, requestErr := http.NewRequestWithContext(ctx, "POST", s.URL, bytes.NewReader(batch.Payload))
+	if requestErr != nil {
+		return requestErr
+	}
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("X-Influence-Mission", batch.Mission)
+	if batch.LeaderboardId != "" {
+		request.Header.Add("X-Leaderboard-Id", batch.LeaderboardId)
+	}
+
+	response, responseErr := s.Client.Do(request)
+	if responseErr != nil {
+		return responseErr
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status code %d", s.URL, response.StatusCode)
+	}
+	return nil
+}
+
+// S3ScoreSink uploads a score file of each batch to <Prefix>/<mission>.json in the bucket. The
+// credentials and region are read like those of the aws command line tool.
+type S3ScoreSink struct {
+	Bucket string
+	Prefix string
+}
+
+func (s S3ScoreSink) Write(ctx context.Context, batch ScoreBatch) error {
+	tempDir, tempErr := os.MkdirTemp("", "influence-eth-scores-")
+	if tempErr != nil {
+		return tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	name := batch.Mission
+	if name == "" {
+		name = batch.LeaderboardId
+	}
+	localPath := filepath.Join(tempDir, name+".json")
+	if writeErr := (FileScoreSink{Path: localPath}).Write(ctx, batch); writeErr != nil {
+		return writeErr
+	}
+	data, readErr := os.ReadFile(localPath)
+	if readErr != nil {
+		return readErr
+	}
+
+	awsConfig, configErr := config.LoadDefaultConfig(ctx)
+	if configErr != nil {
+		return fmt.Errorf("Unable to load aws configuration, err: %v", configErr)
+	}
+	key := name + ".json"
+	if s.Prefix != "" {
+		key = s.Prefix + "/" + key
+	}
+	_, putErr := s3.NewFromConfig(awsConfig).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if putErr != nil {
+		return fmt.Errorf("Unable to upload scores to s3://%s/%s, err: %v", s.Bucket, key, putErr)
+	}
+	return nil
+}
+
+// Table of postgres sinks without a table parameter. It needs the columns mission text,
+// leaderboard_id text, address text, score numeric, points_data jsonb and generated_at timestamptz.
+var POSTGRES_SINK_TABLE = "leaderboard_scores"
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// PostgresScoreSink replaces the rows of the mission and leaderboard of each batch in a table, in a
+// single transaction. Values are bound as parameters, only the table name, which is checked to be
+// an identifier, is part of the statements.
+type PostgresScoreSink struct {
+	DSN   string
+	Table string
+}
+
+func (s PostgresScoreSink) Write(ctx context.Context, batch ScoreBatch) error {
+	var pushed []PortalScore
+	if unmErr := json.Unmarshal(batch.Payload, &pushed); unmErr != nil {
+		return fmt.Errorf("Error unmarshalling scores: %v", unmErr)
+	}
+
+	db, openErr := sql.Open("postgres", s.DSN)
+	if openErr != nil {
+		return fmt.Errorf("Unable to connect to postgres, err: %v", openErr)
+	}
+	defer db.Close()
+
+	tx, txErr := db.BeginTx(ctx, nil)
+	if txErr != nil {
+		return fmt.Errorf("Unable to start transaction to write scores of %s to %s, err: %v", batch.Mission, s.Table, txErr)
+	}
+	defer tx.Rollback()
+
+	if _, deleteErr := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE mission = $1 AND leaderboard_id = $2", s.Table), batch.Mission, batch.LeaderboardId); deleteErr != nil {
+		return fmt.Errorf("Unable to delete scores of %s from %s, err: %v", batch.Mission, s.Table, deleteErr)
+	}
+	insert, prepareErr := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (mission, leaderboard_id, address, score, points_data, generated_at) VALUES ($1, $2, $3, $4, $5::jsonb, $6)", s.Table))
+	if prepareErr != nil {
+		return fmt.Errorf("Unable to prepare insert of scores into %s, err: %v", s.Table, prepareErr)
+	}
+	defer insert.Close()
+
+	generatedAt := time.Now().UTC()
+	for _, score := range pushed {
+		pointsData := string(score.PointsData)
+		if pointsData == "" {
+			pointsData = "null"
+		}
+		if _, insertErr := insert.ExecContext(ctx, batch.Mission, batch.LeaderboardId, score.Address, score.Score, pointsData, generatedAt); insertErr != nil {
+			return fmt.Errorf("Unable to insert score of %s into %s, err: %v", score.Address, s.Table, insertErr)
+		}
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		return fmt.Errorf("Unable to commit scores of %s to %s, err: %v", batch.Mission, s.Table, commitErr)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package leaderboards
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Directory to spill events read from stdin into, defaults to the system temporary directory.
+var SPILL_DIR = ""
+
+// SpillInput copies events from r into a temporary file, since missions read their input in
+// several passes and stdin can only be read once. The caller removes the file once done.
+func SpillInput(r io.Reader) (string, error) {
+	spillFile, createErr := os.CreateTemp(SPILL_DIR, "influence-eth-events-*.jsonl")
+	if createErr != nil {
+		return "", fmt.Errorf("Unable to create file to spill events into, err: %v", createErr)
+	}
+	defer spillFile.Close()
+
+	if _, copyErr := io.Copy(spillFile, r); copyErr != nil {
+		os.Remove(spillFile.Name())
+		return "", fmt.Errorf("Error reading events from stdin: %v", copyErr)
+	}
+	return spillFile.Name(), nil
+}
@@ -0,0 +1,234 @@
+package leaderboards
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+)
+
+// ID of Adalia Prime, all other asteroids are belt asteroids. Set from constants.json, see
+// Constants.
+var ADALIA_PRIME_ID = uint64(1)
+
+// AsteroidSelector matches the origin or destination asteroid of transits. It is written as "any",
+// "ap" (Adalia Prime), "belt" (any asteroid but Adalia Prime) or a list of asteroid IDs.
+type AsteroidSelector struct {
+	Spec      string
+	Asteroids map[uint64]bool
+}
+
+func ParseAsteroidSelector(spec string) (AsteroidSelector, error) {
+	selector := AsteroidSelector{Spec: strings.ToLower(strings.TrimSpace(spec))}
+	switch selector.Spec {
+	case "", "any":
+		selector.Spec = "any"
+		return selector, nil
+	case "ap", "belt":
+		return selector, nil
+	}
+
+	selector.Asteroids = make(map[uint64]bool)
+	for _, value := range strings.Split(selector.Spec, ",") {
+		asteroid, parseErr := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if parseErr != nil {
+			return selector, fmt.Errorf("invalid asteroid selector %s, use any, ap, belt or comma-separated asteroid IDs", spec)
+		}
+		selector.Asteroids[asteroid] = true
+	}
+	return selector, nil
+}
+
+func (s *AsteroidSelector) UnmarshalJSON(data []byte) error {
+	var spec string
+	if err := json.Unmarshal(data, &spec); err == nil {
+		selector, parseErr := ParseAsteroidSelector(spec)
+		if parseErr != nil {
+			return parseErr
+		}
+		*s = selector
+		return nil
+	}
+
+	var asteroids []uint64
+	if err := json.Unmarshal(data, &asteroids); err != nil {
+		return errors.New("asteroid selector must be any, ap, belt or a list of asteroid IDs")
+	}
+	ids := make([]string, len(asteroids))
+	for i, asteroid := range asteroids {
+		ids[i] = fmt.Sprintf("%d", asteroid)
+	}
+	selector, parseErr := ParseAsteroidSelector(strings.Join(ids, ","))
+	if parseErr != nil {
+		return parseErr
+	}
+	*s = selector
+	return nil
+}
+
+func (s AsteroidSelector) String() string {
+	if s.Spec == "" {
+		return "any"
+	}
+	return s.Spec
+}
+
+func (s AsteroidSelector) Matches(asteroid uint64) bool {
+	switch s.Spec {
+	case "", "any":
+		return true
+	case "ap":
+		return asteroid == ADALIA_PRIME_ID
+	case "belt":
+		return asteroid != ADALIA_PRIME_ID
+	}
+	return s.Asteroids[asteroid]
+}
+
+// TransitRoute selects transits by their origin and destination asteroids. Crews complete the route
+// once they finished MustReach matching transits (at least one).
+type TransitRoute struct {
+	Origin      AsteroidSelector `json:"origin"`
+	Destination AsteroidSelector `json:"destination"`
+	MustReach   uint64           `json:"must_reach"`
+}
+
+// TransitLeg counts the transits of a crew from one asteroid to another.
+type TransitLeg struct {
+	Origin      uint64 `json:"origin"`
+	Destination uint64 `json:"destination"`
+	Transits    uint64 `json:"transits"`
+}
+
+type transitLegKey struct {
+	origin      uint64
+	destination uint64
+}
+
+// GenerateTransitRouteToScores ranks crews by their finished transits along the route, with the
+// origin and destination of each leg they flew in points_data data.
+func GenerateTransitRouteToScores(events EventSource[influence.TransitFinished], route TransitRoute) []LeaderboardScore {
+	mustReach := route.MustReach
+	if mustReach == 0 {
+		mustReach = 1
+	}
+
+	byCrews := make(map[uint64]map[transitLegKey]uint64)
+	transactions := make(LatestTransactions[uint64])
+	events.Each(func(e EventWrapper[influence.TransitFinished]) {
+		if !route.Origin.Matches(e.Event.Origin.Id) || !route.Destination.Matches(e.Event.Destination.Id) {
+			return
+		}
+		crew := e.Event.CallerCrew.Id
+		if _, ok := byCrews[crew]; !ok {
+			byCrews[crew] = make(map[transitLegKey]uint64)
+		}
+		byCrews[crew][transitLegKey{origin: e.Event.Origin.Id, destination: e.Event.Destination.Id}]++
+		transactions.Record(crew, e.TransactionHash)
+	})
+
+	scores := []LeaderboardScore{}
+	for crew, legs := range byCrews {
+		var transits uint64
+		data := make([]TransitLeg, 0, len(legs))
+		for key, count := range legs {
+			transits += count
+			data = append(data, TransitLeg{Origin: key.origin, Destination: key.destination, Transits: count})
+		}
+		sort.Slice(data, func(i, j int) bool {
+			if data[i].Origin != data[j].Origin {
+				return data[i].Origin < data[j].Origin
+			}
+			return data[i].Destination < data[j].Destination
+		})
+
+		requirements := []Requirement{{Name: "transits", Have: transits, Need: mustReach}}
+		scores = append(scores, LeaderboardScore{
+			Address:         fmt.Sprintf("%d", crew),
+			Requirements:    requirements,
+			Score:           transits,
+			EventCount:      transits,
+			TransactionHash: transactions[crew],
+			PointsData: PointsData{
+				Complete:  Completed(RequirementsMet(requirements)),
+				MustReach: mustReach,
+				Data:      data,
+				ScoreDetails: &ScoreDetails{
+					Postfix:     " transit(s)",
+					AddressName: "Crew",
+				},
+			},
+		})
+	}
+	return scores
+}
+
+// LTransitRoute is the mission of a transit route, set with flags of the transit-route command or
+// registered from a transit missions file.
+func LTransitRoute(route TransitRoute) LeaderboardCommandCreator {
+	return func(ctx context.Context, opts MissionOptions, infile, outfile, accessToken, leaderboardId *string) error {
+		events, parseEventsErr := LoadEvents[influence.TransitFinished](ctx, opts.Events, *infile)
+		if parseEventsErr != nil {
+			return parseEventsErr
+		}
+
+		scores := GenerateTransitRouteToScores(events, route)
+		if streamErr := EventSourcesErr(events); streamErr != nil {
+			return streamErr
+		}
+
+		return PrepareLeaderboardOutput(ctx, opts, scores, *outfile, *accessToken, *leaderboardId)
+	}
+}
+
+// TransitMission is a mission defined in a transit missions file, so missions which only differ in
+// their route need no code changes.
+type TransitMission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TransitRoute
+}
+
+// RegisterTransitMissions reads a JSON list of transit missions and adds them to
+// LEADERBOARD_MISSIONS, so they can be listed in the leaderboards map like built-in missions.
+func RegisterTransitMissions(filePath string) error {
+	byteValue, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", filePath, readErr)
+	}
+	var missions []TransitMission
+	if unmErr := json.Unmarshal(byteValue, &missions); unmErr != nil {
+		return fmt.Errorf("Unable to parse transit missions %s, err: %v", filePath, unmErr)
+	}
+
+	names := make(map[string]bool, len(LEADERBOARD_MISSIONS))
+	for _, lm := range LEADERBOARD_MISSIONS {
+		names[lm.Name] = true
+	}
+	for _, mission := range missions {
+		if mission.Name == "" {
+			return fmt.Errorf("name is required for each transit mission in %s", filePath)
+		}
+		if names[mission.Name] {
+			return fmt.Errorf("transit mission %s in %s is already registered", mission.Name, filePath)
+		}
+		names[mission.Name] = true
+
+		description := mission.Description
+		if description == "" {
+			description = fmt.Sprintf("Prepare leaderboard with crews ranked by transits from %s to %s", mission.Origin, mission.Destination)
+		}
+		LEADERBOARD_MISSIONS = append(LEADERBOARD_MISSIONS, LeaderboardCommandFunc{
+			Name:        mission.Name,
+			Description: description,
+			Func:        LTransitRoute(mission.TransitRoute),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package leaderboards
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Directory holding the state of chunked uploads which have not finished yet, unless another one
+// is set with the --upload-state-dir flag.
+const DEFAULT_UPLOAD_STATE_DIR = ".influence-eth-uploads"
+
+// Chunks which fail are retried with the same idempotency key before the upload is given up.
+var (
+	UPLOAD_CHUNK_ATTEMPTS = 3
+	UPLOAD_CHUNK_DELAY    = 2 * time.Second
+)
+
+// UploadState is the progress of a chunked upload to a leaderboard. It is only resumed by a push
+// of the same scores with the same chunk size and mode, other pushes start over.
+type UploadState struct {
+	LeaderboardId string `json:"leaderboard_id"`
+	PayloadHash   string `json:"payload_hash"`
+	Overwrite     bool   `json:"overwrite"`
+	ChunkSize     int    `json:"chunk_size"`
+	Chunks        int    `json:"chunks"`
+	Completed     int    `json:"completed"`
+	StartedAt     string `json:"started_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+func uploadStatePath(stateDir, leaderboardId string) string {
+	if stateDir == "" {
+		stateDir = DEFAULT_UPLOAD_STATE_DIR
+	}
+	return filepath.Join(stateDir, fmt.Sprintf("%s.json", leaderboardId))
+}
+
+// ReadUploadState returns the state of the unfinished upload to the leaderboard, or nil if there
+// is none.
+func ReadUploadState(stateDir, leaderboardId string) (*UploadState, error) {
+	data, readErr := os.ReadFile(uploadStatePath(stateDir, leaderboardId))
+	if os.IsNotExist(readErr) {
+		return nil, nil
+	} else if readErr != nil {
+		return nil, fmt.Errorf("Unable to read upload state of leaderboard %s, err: %v", leaderboardId, readErr)
+	}
+	var state UploadState
+	if unmErr := json.Unmarshal(data, &state); unmErr != nil {
+		return nil, fmt.Errorf("Unable to parse upload state of leaderboard %s, err: %v", leaderboardId, unmErr)
+	}
+	return &state, nil
+}
+
+// Save writes the state to the state directory through a temporary file, so a crash while saving
+// keeps the previous state intact.
+func (s *UploadState) Save(stateDir string) error {
+	s.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, marshErr := json.MarshalIndent(s, "", "  ")
+	if marshErr != nil {
+		return marshErr
+	}
+	statePath := uploadStatePath(stateDir, s.LeaderboardId)
+	if mkdirErr := os.MkdirAll(filepath.Dir(statePath), 0755); mkdirErr != nil {
+		return mkdirErr
+	}
+	if writeErr := os.WriteFile(statePath+".tmp", data, 0644); writeErr != nil {
+		return writeErr
+	}
+	return os.Rename(statePath+".tmp", statePath)
+}
+
+// ChunkKey is the idempotency key of a chunk. It is derived from the scores, so retries of the
+// chunk in this and later runs send the same key, while pushes of other scores never reuse it.
+func (s *UploadState) ChunkKey(chunk int) string {
+	return fmt.Sprintf("%s-%s-%d-%d", s.LeaderboardId, s.PayloadHash[:16], s.ChunkSize, chunk)
+}
+
+// sortedScoreItems splits a JSON list of scores into its items, ordered by address so the same
+// scores make up the same chunks in every run. Scores of the same address keep their order.
+func sortedScoreItems(payload []byte) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if unmErr := json.Unmarshal(payload, &items); unmErr != nil {
+		return nil, fmt.Errorf("Error unmarshalling scores: %v", unmErr)
+	}
+	addresses := make([]string, len(items))
+	for i, item := range items {
+		var score struct {
+			Address string `json:"address"`
+		}
+		json.Unmarshal(item, &score)
+		addresses[i] = score.Address
+	}
+	indices := make([]int, len(items))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return addresses[indices[i]] < addresses[indices[j]]
+	})
+	sorted := make([]json.RawMessage, len(items))
+	for i, index := range indices {
+		sorted[i] = items[index]
+	}
+	return sorted, nil
+}
+
+// pushWithRetries pushes body to the leaderboard of the upload with the idempotency key, retrying
+// failures which may pass on a retry. step names the push in logs and errors.
+func pushWithRetries(apiURL, accessToken string, state *UploadState, step, key string, body []byte, overwrite bool) error {
+	var pushErr error
+	for attempt := 1; attempt <= UPLOAD_CHUNK_ATTEMPTS; attempt++ {
+		statusCode, reqErr := UpdateLeaderboardScoresIdempotent(apiURL, accessToken, state.LeaderboardId, bytes.NewReader(body), overwrite, key)
+		if reqErr == nil && statusCode < 300 {
+			return nil
+		}
+		pushErr = reqErr
+		if reqErr == nil {
+			pushErr = fmt.Errorf("status code: %d", statusCode)
+			// Client errors other than rate limits fail the same way on retries
+			if statusCode < 500 && statusCode != 429 {
+				break
+			}
+		}
+		if attempt < UPLOAD_CHUNK_ATTEMPTS {
+			log.Printf("Unable to push %s to leaderboard %s, retrying: %v", step, state.LeaderboardId, pushErr)
+			time.Sleep(UPLOAD_CHUNK_DELAY)
+		}
+	}
+	return fmt.Errorf("unable to push %s to leaderboard %s, rerun to resume the upload: %v", step, state.LeaderboardId, pushErr)
+}
+
+// pushChunk adds the scores of a chunk to the leaderboard, replacing the scores of their addresses.
+func pushChunk(apiURL, accessToken string, state *UploadState, chunk int, body []byte) error {
+	return pushWithRetries(apiURL, accessToken, state, fmt.Sprintf("chunk %d of %d", chunk+1, state.Chunks), state.ChunkKey(chunk), body, false)
+}
+
+// removeStaleScores ends an overwrite in chunks, once every chunk was added to the leaderboard, by
+// removing the scores of addresses which are not in the upload. The portal only removes scores by
+// overwriting all of them, so if there are any, all scores of the upload are pushed once more in a
+// single overwrite. Until then the leaderboard holds every uploaded score, next to the stale ones.
+func removeStaleScores(apiURL, accessToken string, state *UploadState, items []json.RawMessage, payload []byte) error {
+	current, fetchErr := FetchLeaderboardScores(apiURL, accessToken, state.LeaderboardId)
+	if fetchErr != nil {
+		return fmt.Errorf("unable to check leaderboard %s for stale scores, rerun to resume the upload: %v", state.LeaderboardId, fetchErr)
+	}
+
+	uploaded := make(map[string]bool, len(items))
+	for _, item := range items {
+		var score struct {
+			Address string `json:"address"`
+		}
+		json.Unmarshal(item, &score)
+		uploaded[strings.ToLower(score.Address)] = true
+	}
+	stale := 0
+	for _, score := range current {
+		if !uploaded[strings.ToLower(score.Address)] {
+			stale++
+		}
+	}
+	if stale == 0 {
+		return nil
+	}
+
+	log.Printf("Removing %d scores of addresses which are not in the upload from leaderboard %s", stale, state.LeaderboardId)
+	return pushWithRetries(apiURL, accessToken, state, "the overwrite removing stale scores", state.ChunkKey(state.Chunks), payload, true)
+}
+
+// UploadScoresInChunks pushes the JSON list of scores to the leaderboard in chunks of the chunk
+// size of the options, each added to the scores of the leaderboard. In overwrite mode, the scores
+// of other addresses are removed once every chunk was accepted, see removeStaleScores. Chunked
+// uploads record their progress in the state directory of the options, so an unfinished upload of
+// the same scores, e.g. interrupted by a crash, is resumed after its last accepted chunk.
+//
+// The portal has no transactions or staging leaderboards. Chunks are therefore always added to the
+// scores of the leaderboard, so an overwrite which fails or crashes leaves the previous scores of
+// the addresses it did not reach yet rather than a partly emptied leaderboard.
+func UploadScoresInChunks(apiURL, accessToken, leaderboardId string, payload []byte, push PushOptions) error {
+	items, itemsErr := sortedScoreItems(payload)
+	if itemsErr != nil {
+		return itemsErr
+	}
+	sortedPayload, marshErr := json.Marshal(items)
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling scores: %v", marshErr)
+	}
+	payloadHash := sha256.Sum256(sortedPayload)
+
+	chunks := (len(items) + push.ChunkSize - 1) / push.ChunkSize
+	state := &UploadState{
+		LeaderboardId: leaderboardId,
+		PayloadHash:   hex.EncodeToString(payloadHash[:]),
+		Overwrite:     push.Overwrite(),
+		ChunkSize:     push.ChunkSize,
+		Chunks:        chunks,
+		StartedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+
+	previous, stateErr := ReadUploadState(push.StateDir, leaderboardId)
+	if stateErr != nil {
+		return stateErr
+	}
+	if previous != nil {
+		if previous.PayloadHash == state.PayloadHash && previous.ChunkSize == state.ChunkSize && previous.Overwrite == state.Overwrite && previous.Chunks == state.Chunks {
+			state = previous
+			if state.Completed < state.Chunks {
+				log.Printf("Resuming upload to leaderboard %s at chunk %d of %d", leaderboardId, state.Completed+1, state.Chunks)
+			} else {
+				log.Printf("Resuming upload to leaderboard %s at the removal of stale scores", leaderboardId)
+			}
+		} else {
+			log.Printf("Scores of leaderboard %s changed since the unfinished upload started at %s, starting over", leaderboardId, previous.StartedAt)
+		}
+	}
+
+	for chunk := state.Completed; chunk < state.Chunks; chunk++ {
+		end := (chunk + 1) * state.ChunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+		body := []byte("[]")
+		if start := chunk * state.ChunkSize; start < end {
+			var marshErr error
+			body, marshErr = json.Marshal(items[start:end])
+			if marshErr != nil {
+				return fmt.Errorf("Error marshaling scores: %v", marshErr)
+			}
+		}
+
+		if pushErr := pushChunk(apiURL, accessToken, state, chunk, body); pushErr != nil {
+			return pushErr
+		}
+		state.Completed = chunk + 1
+		// Overwrites still remove stale scores after the last chunk, and resume there
+		if state.Completed < state.Chunks || state.Overwrite {
+			if saveErr := state.Save(push.StateDir); saveErr != nil {
+				return fmt.Errorf("Unable to save upload state of leaderboard %s, err: %v", leaderboardId, saveErr)
+			}
+		}
+	}
+
+	if state.Overwrite {
+		if staleErr := removeStaleScores(apiURL, accessToken, state, items, sortedPayload); staleErr != nil {
+			return staleErr
+		}
+	}
+
+	if removeErr := os.Remove(uploadStatePath(push.StateDir, leaderboardId)); removeErr != nil && !os.IsNotExist(removeErr) {
+		log.Printf("Unable to remove upload state of leaderboard %s, err: %v", leaderboardId, removeErr)
+	}
+	return nil
+}
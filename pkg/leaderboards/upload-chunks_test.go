@@ -0,0 +1,109 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePortal holds the scores of a leaderboard, served like the scores endpoints of the portal.
+type fakePortal struct {
+	mu     sync.Mutex
+	scores map[string]PortalScore
+	// Overwrite flag and idempotency key of every push
+	pushes []string
+	// Push failing with a server error, counting from 1
+	failPush int
+}
+
+func (p *fakePortal) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r.Method == "GET" {
+		page := []PortalScore{}
+		if r.URL.Query().Get("offset") == "0" {
+			for _, score := range p.scores {
+				page = append(page, score)
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+		return
+	}
+
+	overwrite := r.URL.Query().Get("overwrite") == "true"
+	p.pushes = append(p.pushes, fmt.Sprintf("overwrite=%t key=%s", overwrite, r.Header.Get("Idempotency-Key")))
+	if len(p.pushes) == p.failPush {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var pushed []PortalScore
+	json.NewDecoder(r.Body).Decode(&pushed)
+	if overwrite {
+		p.scores = make(map[string]PortalScore)
+	}
+	for _, score := range pushed {
+		p.scores[score.Address] = score
+	}
+}
+
+func (p *fakePortal) addresses() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var addresses []string
+	for address := range p.scores {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return strings.Join(addresses, ",")
+}
+
+func TestUploadScoresInChunksOverwrite(t *testing.T) {
+	defer func(auditLog string) { AUDIT_LOG = auditLog }(AUDIT_LOG)
+	AUDIT_LOG = ""
+	push := PushOptions{Mode: UPLOAD_MODE_OVERWRITE, ChunkSize: 2, StateDir: t.TempDir()}
+
+	portal := &fakePortal{scores: map[string]PortalScore{
+		"1": {Address: "1", Score: 1},
+		"9": {Address: "9", Score: 9},
+	}}
+	server := httptest.NewServer(portal)
+	defer server.Close()
+
+	payload := []byte(`[{"address":"3","score":3},{"address":"1","score":10},{"address":"2","score":2}]`)
+
+	// The second chunk is rejected: the leaderboard keeps every previous score next to the first chunk
+	portal.failPush = 2
+	if uploadErr := UploadScoresInChunks(server.URL, "token", "lb", payload, push); uploadErr == nil {
+		t.Fatal("expected the upload to fail")
+	}
+	if addresses := portal.addresses(); addresses != "1,2,9" {
+		t.Errorf("expected the first chunk next to the previous scores, got addresses %s", addresses)
+	}
+
+	portal.failPush = 0
+	if uploadErr := UploadScoresInChunks(server.URL, "token", "lb", payload, push); uploadErr != nil {
+		t.Fatal(uploadErr)
+	}
+	if addresses := portal.addresses(); addresses != "1,2,3" {
+		t.Errorf("expected the stale score of address 9 to be removed, got addresses %s", addresses)
+	}
+
+	expected := []string{"overwrite=false key=lb-", "overwrite=false key=lb-", "overwrite=false key=lb-", "overwrite=true key=lb-"}
+	if len(portal.pushes) != len(expected) {
+		t.Fatalf("expected %d pushes, got %v", len(expected), portal.pushes)
+	}
+	for i, push := range portal.pushes {
+		if !strings.HasPrefix(push, expected[i]) {
+			t.Errorf("push %d: expected %s..., got %s", i+1, expected[i], push)
+		}
+	}
+	if portal.pushes[1] != portal.pushes[2] {
+		t.Errorf("expected the resumed chunk to be pushed with the same idempotency key, got %s and %s", portal.pushes[1], portal.pushes[2])
+	}
+}
@@ -0,0 +1,60 @@
+package leaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	UPLOAD_MODE_OVERWRITE = "overwrite"
+	UPLOAD_MODE_APPEND    = "append"
+)
+
+// CheckUploadMode returns an error if the mode, set with the --mode flag, is not supported.
+// Overwrite replaces all scores of the leaderboard, append only uploads scores which differ from
+// those at the portal and keeps the others.
+func CheckUploadMode(mode string) error {
+	if mode != UPLOAD_MODE_OVERWRITE && mode != UPLOAD_MODE_APPEND {
+		return fmt.Errorf("unknown upload mode %s, supported modes: %s, %s", mode, UPLOAD_MODE_OVERWRITE, UPLOAD_MODE_APPEND)
+	}
+	return nil
+}
+
+// canonicalJSON re-encodes the value with sorted object keys, so points_data generated locally
+// compares equal to points_data returned by the portal.
+func canonicalJSON(raw json.RawMessage) string {
+	var value any
+	if unmErr := json.Unmarshal(raw, &value); unmErr != nil {
+		return string(raw)
+	}
+	canonical, marshErr := json.Marshal(value)
+	if marshErr != nil {
+		return string(raw)
+	}
+	return string(canonical)
+}
+
+// ChangedScores returns the scores which are missing from the current scores of the leaderboard
+// or differ in score or points_data, and the number of entries the leaderboard holds once they
+// are appended.
+func ChangedScores(current, scores []PortalScore) ([]PortalScore, uint64) {
+	byAddress := make(map[string]PortalScore, len(current))
+	for _, score := range current {
+		byAddress[score.Address] = score
+	}
+
+	changed := []PortalScore{}
+	entries := uint64(len(byAddress))
+	for _, score := range scores {
+		existing, ok := byAddress[score.Address]
+		if !ok {
+			entries++
+		} else if existing.Score == score.Score && canonicalJSON(existing.PointsData) == canonicalJSON(score.PointsData) {
+			continue
+		}
+		// Later scores of the same address replace earlier ones, as at the portal
+		byAddress[score.Address] = score
+		changed = append(changed, score)
+	}
+	return changed, entries
+}
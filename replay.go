@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ReplaySink receives lines of an events dump during a replay.
+type ReplaySink interface {
+	Send(ctx context.Context, line []byte) error
+	Close() error
+}
+
+// NewReplaySink creates a sink from its specification:
+//   - "stdout" writes events to the given writer, one per line
+//   - "http://..." or "https://..." POSTs each event as a JSON body to the webhook URL
+//   - "kafka://broker1:9092,broker2:9092/topic" produces each event as a message to the Kafka topic
+func NewReplaySink(spec string, stdout io.Writer) (ReplaySink, error) {
+	if spec == "" || spec == "stdout" {
+		return &StdoutReplaySink{Writer: stdout}, nil
+	}
+
+	sinkURL, parseErr := url.Parse(spec)
+	if parseErr != nil {
+		return nil, fmt.Errorf("invalid sink %s: %v", spec, parseErr)
+	}
+
+	switch sinkURL.Scheme {
+	case "http", "https":
+		return &WebhookReplaySink{URL: spec, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case "kafka":
+		topic := strings.Trim(sinkURL.Path, "/")
+		if sinkURL.Host == "" || topic == "" {
+			return nil, fmt.Errorf("kafka sink should be specified as kafka://<brokers>/<topic>, got %s", spec)
+		}
+		sink := &KafkaReplaySink{}
+		sink.Writer = &kafka.Writer{
+			Addr:         kafka.TCP(strings.Split(sinkURL.Host, ",")...),
+			Topic:        topic,
+			Balancer:     &kafka.LeastBytes{},
+			Async:        true,
+			BatchTimeout: KAFKA_REPLAY_BATCH_TIMEOUT,
+			Completion:   sink.complete,
+		}
+		return sink, nil
+	}
+
+	return nil, fmt.Errorf("unsupported sink %s, use stdout, an http(s):// webhook URL or kafka://<brokers>/<topic>", spec)
+}
+
+type StdoutReplaySink struct {
+	Writer io.Writer
+}
+
+func (s *StdoutReplaySink) Send(ctx context.Context, line []byte) error {
+	if _, writeErr := s.Writer.Write(line); writeErr != nil {
+		return writeErr
+	}
+	_, writeErr := s.Writer.Write([]byte("\n"))
+	return writeErr
+}
+
+func (s *StdoutReplaySink) Close() error {
+	return nil
+}
+
+type WebhookReplaySink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *WebhookReplaySink) Send(ctx context.Context, line []byte) error {
+	request, requestErr := http.NewRequestWithContext(ctx, "POST", s.URL, bytes.NewReader(line))
+	if requestErr != nil {
+		return requestErr
+	}
+	request.Header.Add("Content-Type", "application/json")
+
+	response, responseErr := s.Client.Do(request)
+	if responseErr != nil {
+		return responseErr
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookReplaySink) Close() error {
+	return nil
+}
+
+// Longest time messages of the Kafka sink wait for a batch to fill up before they are produced.
+// The writer is asynchronous, so Send does not wait for it, but it bounds the latency of events.
+var KAFKA_REPLAY_BATCH_TIMEOUT = 10 * time.Millisecond
+
+// KafkaReplaySink produces events asynchronously, so replays are not held back by the round trips
+// to the brokers. Errors of produced batches are reported by the next Send and by Close.
+type KafkaReplaySink struct {
+	Writer *kafka.Writer
+
+	mu  sync.Mutex
+	err error
+}
+
+func (s *KafkaReplaySink) complete(messages []kafka.Message, err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = fmt.Errorf("unable to produce %d events to kafka: %v", len(messages), err)
+	}
+}
+
+func (s *KafkaReplaySink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *KafkaReplaySink) Send(ctx context.Context, line []byte) error {
+	if produceErr := s.Err(); produceErr != nil {
+		return produceErr
+	}
+	// Writer retains the message until its batch is produced, so it gets its own copy of the line
+	value := make([]byte, len(line))
+	copy(value, line)
+	return s.Writer.WriteMessages(ctx, kafka.Message{Value: value})
+}
+
+// Close produces the pending batches and returns the first error of the produced batches.
+func (s *KafkaReplaySink) Close() error {
+	if closeErr := s.Writer.Close(); closeErr != nil {
+		return closeErr
+	}
+	return s.Err()
+}
+
+// ParseReplayRate parses rates like "100/s", "600/m" or "5000/h" into the interval between two
+// events. A rate of "0" (or an empty rate) means that events are replayed as fast as possible.
+func ParseReplayRate(rate string) (time.Duration, error) {
+	if rate == "" || rate == "0" {
+		return 0, nil
+	}
+
+	countRaw, unit, found := strings.Cut(rate, "/")
+	if !found {
+		unit = "s"
+	}
+
+	count, parseErr := strconv.ParseFloat(countRaw, 64)
+	if parseErr != nil || math.IsNaN(count) || math.IsInf(count, 0) || count < 0 {
+		return 0, fmt.Errorf("invalid rate %s, expected format like 100/s", rate)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return 0, fmt.Errorf("invalid rate unit %s, supported units: s, m, h", unit)
+	}
+
+	interval := float64(period) / count
+	if interval > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid rate %s, it is too slow", rate)
+	}
+	return time.Duration(interval), nil
+}
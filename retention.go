@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/influence"
+	"github.com/moonstream-to/influence-eth/pkg/leaderboards"
+)
+
+// RetentionWeek summarizes the crews active in a week. Crews are new in the first week they are
+// active and returning in every later one. Retained crews were active in the previous week as
+// well, churned crews were active in the previous week but not in this one.
+type RetentionWeek struct {
+	WeekStart      string  `json:"week_start"`
+	ActiveCrews    int     `json:"active_crews"`
+	NewCrews       int     `json:"new_crews"`
+	ReturningCrews int     `json:"returning_crews"`
+	RetainedCrews  int     `json:"retained_crews"`
+	ChurnedCrews   int     `json:"churned_crews"`
+	RetentionRate  float64 `json:"retention_rate"`
+}
+
+// RetentionReport holds the weekly activity of crews over an events dump. Events without a block
+// timestamp (crawled without --block-timestamps) can not be placed in a week and are only counted.
+type RetentionReport struct {
+	Crews         int             `json:"crews"`
+	Events        uint64          `json:"events"`
+	UntimedEvents uint64          `json:"untimed_events"`
+	Weeks         []RetentionWeek `json:"weeks"`
+}
+
+// WeekStart returns the start of the week (Monday 00:00 UTC) of the timestamp.
+func WeekStart(timestamp uint64) time.Time {
+	day := leaderboards.DayStart(timestamp)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// EventCallerCrew returns the crew which emitted the event, the crew entity in its CallerCrew field.
+func EventCallerCrew(event json.RawMessage) (uint64, bool) {
+	var fields struct {
+		CallerCrew *influence.Influence_Common_Types_Entity_Entity
+	}
+	if unmErr := json.Unmarshal(event, &fields); unmErr != nil || fields.CallerCrew == nil {
+		return 0, false
+	}
+	if fields.CallerCrew.Label != CREW_LABEL || fields.CallerCrew.Id == 0 {
+		return 0, false
+	}
+	return fields.CallerCrew.Id, true
+}
+
+// ComputeCrewRetention reads the events dump (or directory partitioned by event type) and counts
+// active, new, returning and churned crews per week. Weeks without activity between the first and
+// last active week are kept, so the series has no gaps.
+func ComputeCrewRetention(filePath string) (RetentionReport, error) {
+	report := RetentionReport{Weeks: []RetentionWeek{}}
+
+	filePaths, pathsErr := EventFilePaths(filePath, nil)
+	if pathsErr != nil {
+		return report, pathsErr
+	}
+
+	activity := make(map[time.Time]map[uint64]bool)
+	for _, path := range filePaths {
+		inputFile, openErr := os.Open(path)
+		if openErr != nil {
+			return report, fmt.Errorf("Unable to read file %s, err: %v", path, openErr)
+		}
+
+		scanner := bufio.NewScanner(inputFile)
+		for scanner.Scan() {
+			var line leaderboards.PartialEventLine
+			if unmErr := json.Unmarshal(scanner.Bytes(), &line); unmErr != nil {
+				continue
+			}
+			crew, ok := EventCallerCrew(line.Event)
+			if !ok {
+				continue
+			}
+			report.Events++
+			if line.Timestamp == 0 {
+				report.UntimedEvents++
+				continue
+			}
+
+			week := WeekStart(line.Timestamp)
+			if _, ok := activity[week]; !ok {
+				activity[week] = make(map[uint64]bool)
+			}
+			activity[week][crew] = true
+		}
+		scanErr := scanner.Err()
+		inputFile.Close()
+		if scanErr != nil {
+			return report, fmt.Errorf("Error reading file: %v", scanErr)
+		}
+	}
+
+	if len(activity) == 0 {
+		return report, nil
+	}
+
+	weeks := make([]time.Time, 0, len(activity))
+	for week := range activity {
+		weeks = append(weeks, week)
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].Before(weeks[j]) })
+
+	seen := make(map[uint64]bool)
+	var previous map[uint64]bool
+	for week := weeks[0]; !week.After(weeks[len(weeks)-1]); week = week.AddDate(0, 0, 7) {
+		active := activity[week]
+		summary := RetentionWeek{WeekStart: week.Format("2006-01-02"), ActiveCrews: len(active)}
+		for crew := range active {
+			if seen[crew] {
+				summary.ReturningCrews++
+			} else {
+				summary.NewCrews++
+				seen[crew] = true
+			}
+			if previous[crew] {
+				summary.RetainedCrews++
+			}
+		}
+		summary.ChurnedCrews = len(previous) - summary.RetainedCrews
+		if len(previous) > 0 {
+			summary.RetentionRate = float64(summary.RetainedCrews) / float64(len(previous))
+		}
+
+		report.Weeks = append(report.Weeks, summary)
+		previous = active
+	}
+	report.Crews = len(seen)
+
+	return report, nil
+}
+
+func WriteRetentionCSV(w io.Writer, report RetentionReport) error {
+	writer := csv.NewWriter(w)
+	header := []string{"week_start", "active_crews", "new_crews", "returning_crews", "retained_crews", "churned_crews", "retention_rate"}
+	if writeErr := writer.Write(header); writeErr != nil {
+		return writeErr
+	}
+	for _, week := range report.Weeks {
+		record := []string{
+			week.WeekStart,
+			strconv.Itoa(week.ActiveCrews),
+			strconv.Itoa(week.NewCrews),
+			strconv.Itoa(week.ReturningCrews),
+			strconv.Itoa(week.RetainedCrews),
+			strconv.Itoa(week.ChurnedCrews),
+			strconv.FormatFloat(week.RetentionRate, 'f', 4, 64),
+		}
+		if writeErr := writer.Write(record); writeErr != nil {
+			return writeErr
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
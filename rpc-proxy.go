@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// How long the chain head reported by the upstream provider is reused before it is requested again.
+var RPC_PROXY_HEAD_TTL = 10 * time.Second
+
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// RPCCachingProxy forwards Starknet JSON-RPC requests to an upstream provider. Responses of
+// starknet_getEvents for block ranges which have at least Confirmations blocks on top of them are
+// stored in CacheDir, addressed by the hash of the filter and page, and served from there when the
+// same range is crawled again. All other requests are passed through.
+type RPCCachingProxy struct {
+	Upstream      string
+	CacheDir      string
+	Confirmations uint64
+
+	client *http.Client
+
+	mu     sync.Mutex
+	head   uint64
+	headAt time.Time
+}
+
+func NewRPCCachingProxy(upstream, cacheDir string, confirmations uint64) (*RPCCachingProxy, error) {
+	if mkdirErr := os.MkdirAll(cacheDir, 0755); mkdirErr != nil {
+		return nil, mkdirErr
+	}
+	return &RPCCachingProxy{
+		Upstream:      upstream,
+		CacheDir:      cacheDir,
+		Confirmations: confirmations,
+		client:        &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (p *RPCCachingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, readErr := io.ReadAll(r.Body)
+	if readErr != nil {
+		http.Error(w, readErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var request jsonRPCRequest
+	if unmErr := json.Unmarshal(body, &request); unmErr != nil || request.Method != "starknet_getEvents" {
+		// Batches and other methods are not cached
+		p.forward(w, body)
+		return
+	}
+
+	cachePath, cacheable := p.cachePath(request)
+	if cacheable {
+		if result, cacheErr := os.ReadFile(cachePath); cacheErr == nil {
+			p.writeJSON(w, jsonRPCResponse{JSONRPC: "2.0", Id: request.Id, Result: result})
+			return
+		}
+	}
+
+	responseBody, statusCode, upstreamErr := p.call(body)
+	if upstreamErr != nil {
+		http.Error(w, upstreamErr.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if cacheable && statusCode == http.StatusOK {
+		var response jsonRPCResponse
+		if unmErr := json.Unmarshal(responseBody, &response); unmErr == nil && len(response.Error) == 0 && len(response.Result) > 0 {
+			if writeErr := p.store(cachePath, response.Result); writeErr != nil {
+				log.Printf("Unable to cache getEvents response, err: %v", writeErr)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(responseBody)
+}
+
+// cachePath returns the path of the cached response for the request, and whether the request
+// covers a range deep enough below the chain head to be cached.
+func (p *RPCCachingProxy) cachePath(request jsonRPCRequest) (string, bool) {
+	// Parameters may be passed as [filter] or as {"filter": filter}
+	var filter map[string]any
+	var positional []map[string]any
+	if unmErr := json.Unmarshal(request.Params, &positional); unmErr == nil && len(positional) == 1 {
+		filter = positional[0]
+	} else {
+		var named struct {
+			Filter map[string]any `json:"filter"`
+		}
+		if unmErr := json.Unmarshal(request.Params, &named); unmErr != nil || named.Filter == nil {
+			return "", false
+		}
+		filter = named.Filter
+	}
+
+	toBlock, ok := filter["to_block"].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	toBlockNumber, ok := toBlock["block_number"].(float64)
+	if !ok {
+		return "", false
+	}
+
+	head, headErr := p.chainHead()
+	if headErr != nil {
+		log.Printf("Unable to get chain head from upstream, err: %v", headErr)
+		return "", false
+	}
+	if uint64(toBlockNumber)+p.Confirmations > head {
+		return "", false
+	}
+
+	// Maps are marshaled with sorted keys, so equal filters and pages share the same key
+	canonical, marshErr := json.Marshal(filter)
+	if marshErr != nil {
+		return "", false
+	}
+	hash := sha256.Sum256(append([]byte(p.Upstream+"\n"), canonical...))
+	key := hex.EncodeToString(hash[:])
+
+	return filepath.Join(p.CacheDir, key[:2], key+".json"), true
+}
+
+func (p *RPCCachingProxy) store(cachePath string, result []byte) error {
+	if mkdirErr := os.MkdirAll(filepath.Dir(cachePath), 0755); mkdirErr != nil {
+		return mkdirErr
+	}
+	// Written through a temporary file, so concurrent readers never see a partial response
+	tmpPath := fmt.Sprintf("%s.%d.tmp", cachePath, time.Now().UnixNano())
+	if writeErr := os.WriteFile(tmpPath, result, 0644); writeErr != nil {
+		return writeErr
+	}
+	return os.Rename(tmpPath, cachePath)
+}
+
+func (p *RPCCachingProxy) chainHead() (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.headAt) < RPC_PROXY_HEAD_TTL {
+		return p.head, nil
+	}
+
+	body, _ := json.Marshal(jsonRPCRequest{JSONRPC: "2.0", Id: json.RawMessage("1"), Method: "starknet_blockNumber", Params: json.RawMessage("[]")})
+	responseBody, statusCode, callErr := p.call(body)
+	if callErr != nil {
+		return 0, callErr
+	}
+	if statusCode != http.StatusOK {
+		return 0, fmt.Errorf("upstream responded with status code %d", statusCode)
+	}
+
+	var response jsonRPCResponse
+	if unmErr := json.Unmarshal(responseBody, &response); unmErr != nil {
+		return 0, unmErr
+	}
+	if len(response.Error) > 0 {
+		return 0, fmt.Errorf("upstream error: %s", string(response.Error))
+	}
+	var head uint64
+	if unmErr := json.Unmarshal(response.Result, &head); unmErr != nil {
+		return 0, unmErr
+	}
+
+	p.head = head
+	p.headAt = time.Now()
+	return head, nil
+}
+
+func (p *RPCCachingProxy) call(body []byte) ([]byte, int, error) {
+	response, responseErr := p.client.Post(p.Upstream, "application/json", bytes.NewReader(body))
+	if responseErr != nil {
+		return nil, 0, responseErr
+	}
+	defer response.Body.Close()
+
+	responseBody, readErr := io.ReadAll(response.Body)
+	if readErr != nil {
+		return nil, 0, readErr
+	}
+	return responseBody, response.StatusCode, nil
+}
+
+func (p *RPCCachingProxy) forward(w http.ResponseWriter, body []byte) {
+	responseBody, statusCode, upstreamErr := p.call(body)
+	if upstreamErr != nil {
+		http.Error(w, upstreamErr.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(responseBody)
+}
+
+func (p *RPCCachingProxy) writeJSON(w http.ResponseWriter, response jsonRPCResponse) {
+	responseBody, marshErr := json.Marshal(response)
+	if marshErr != nil {
+		http.Error(w, marshErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseBody)
+}
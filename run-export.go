@@ -0,0 +1,139 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/moonstream-to/influence-eth/pkg/leaderboards"
+)
+
+var RUN_EXPORT_MANIFEST_FILE = "manifest.json"
+
+// ExportedMission is the run report of a mission in an export.
+type ExportedMission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     int    `json:"version"`
+	File        string `json:"file,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	Entries     int    `json:"entries"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// RunExportManifest describes a scoring run exported with "leaderboards export": the events it
+// read, the program which scored them and the outcome of every mission.
+type RunExportManifest struct {
+	Created          string                   `json:"created"`
+	Version          string                   `json:"version"`
+	PointsDataSchema string                   `json:"points_data_schema"`
+	Input            *leaderboards.Provenance `json:"input,omitempty"`
+	Missions         []ExportedMission        `json:"missions"`
+	Failed           int                      `json:"failed"`
+}
+
+// Statuses of exported missions.
+const (
+	EXPORT_STATUS_OK     = "ok"
+	EXPORT_STATUS_EMPTY  = "empty"
+	EXPORT_STATUS_FAILED = "failed"
+)
+
+// ExportRunDir returns the timestamped directory of an export started at the given time.
+func ExportRunDir(dir string, startedAt time.Time) string {
+	return filepath.Join(dir, startedAt.UTC().Format("20060102T150405Z"))
+}
+
+// ExportMission runs a mission with its score file written into the run directory and reports
+// the outcome. Scores are not pushed anywhere else.
+func ExportMission(ctx context.Context, lm leaderboards.LeaderboardCommandFunc, opts leaderboards.MissionOptions, infile, runDir string) ExportedMission {
+	exported := ExportedMission{Name: lm.Name, Description: lm.Description, Version: lm.Version}
+
+	extension := "json"
+	if opts.Output.FileFormat == leaderboards.SCORES_FILE_FORMAT_MOONSTREAM_CSV {
+		extension = "csv"
+	}
+	exported.File = fmt.Sprintf("%s.%s", lm.Name, extension)
+	outfile := filepath.Join(runDir, exported.File)
+
+	noToken, noLeaderboard := "", ""
+	startedAt := time.Now()
+	runErr := leaderboards.RunMission(ctx, lm, opts, 0, &infile, &outfile, &noToken, &noLeaderboard)
+	exported.DurationMs = time.Since(startedAt).Milliseconds()
+	if runErr != nil {
+		exported.Status = EXPORT_STATUS_FAILED
+		exported.Error = runErr.Error()
+		if _, statErr := os.Stat(outfile); statErr != nil {
+			exported.File = ""
+		}
+		return exported
+	}
+
+	exported.Status = EXPORT_STATUS_OK
+	if extension == "json" {
+		if scoresFile, readErr := leaderboards.ReadScoresFile(outfile, opts.Output.Schema()); readErr == nil {
+			exported.Entries = len(scoresFile.Scores)
+		}
+		if exported.Entries == 0 {
+			exported.Status = EXPORT_STATUS_EMPTY
+		}
+	}
+	return exported
+}
+
+// WriteRunExportManifest writes the manifest into the run directory.
+func WriteRunExportManifest(runDir string, manifest RunExportManifest) error {
+	manifestData, marshErr := json.MarshalIndent(manifest, "", "  ")
+	if marshErr != nil {
+		return fmt.Errorf("Error marshaling manifest: %v", marshErr)
+	}
+	return os.WriteFile(filepath.Join(runDir, RUN_EXPORT_MANIFEST_FILE), manifestData, 0644)
+}
+
+// TarRunExport bundles the run directory into a gzipped tar archive next to it, with the files
+// under the name of the directory, and removes the directory.
+func TarRunExport(runDir string) (string, error) {
+	archivePath := runDir + ".tar.gz"
+	outputFile, createErr := os.Create(archivePath)
+	if createErr != nil {
+		return archivePath, createErr
+	}
+	defer outputFile.Close()
+	gzipWriter := gzip.NewWriter(outputFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	entries, readErr := os.ReadDir(runDir)
+	if readErr != nil {
+		return archivePath, readErr
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, addErr := addTarFile(tarWriter, filepath.Join(runDir, entry.Name()), path.Join(filepath.Base(runDir), entry.Name())); addErr != nil {
+			return archivePath, addErr
+		}
+	}
+
+	if closeErr := tarWriter.Close(); closeErr != nil {
+		return archivePath, closeErr
+	}
+	if closeErr := gzipWriter.Close(); closeErr != nil {
+		return archivePath, closeErr
+	}
+	if closeErr := outputFile.Close(); closeErr != nil {
+		return archivePath, closeErr
+	}
+	if removeErr := os.RemoveAll(runDir); removeErr != nil {
+		log.Printf("Unable to remove %s after archiving it, err: %v", runDir, removeErr)
+	}
+	return archivePath, nil
+}
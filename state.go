@@ -0,0 +1,239 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var STATE_MANIFEST_FILE = "state-manifest.json"
+
+// StateComponent is a file or directory of crawl state bundled into a state archive, stored under
+// its name in the archive and restored to Path unless another path is given on import.
+type StateComponent struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Dir   bool   `json:"dir"`
+	Files int    `json:"files"`
+	Size  int64  `json:"size"`
+}
+
+// StateManifest lists the components of a state archive.
+type StateManifest struct {
+	Created    string           `json:"created"`
+	Version    string           `json:"version"`
+	Components []StateComponent `json:"components"`
+}
+
+func addTarFile(writer *tar.Writer, filePath, name string) (int64, error) {
+	inputFile, openErr := os.Open(filePath)
+	if openErr != nil {
+		return 0, fmt.Errorf("Unable to read file %s, err: %v", filePath, openErr)
+	}
+	defer inputFile.Close()
+
+	info, statErr := inputFile.Stat()
+	if statErr != nil {
+		return 0, statErr
+	}
+	header := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if headerErr := writer.WriteHeader(header); headerErr != nil {
+		return 0, headerErr
+	}
+	return io.Copy(writer, inputFile)
+}
+
+// ExportState bundles the components into a gzipped tar archive. Components whose path does not
+// exist are skipped, so the same command works for crawls which do not keep every kind of state.
+func ExportState(outfile string, components []StateComponent) (StateManifest, error) {
+	manifest := StateManifest{Created: time.Now().UTC().Format(time.RFC3339), Version: Version}
+
+	outputFile, createErr := os.Create(outfile)
+	if createErr != nil {
+		return manifest, createErr
+	}
+	defer outputFile.Close()
+	gzipWriter := gzip.NewWriter(outputFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, component := range components {
+		if component.Path == "" {
+			continue
+		}
+		info, statErr := os.Stat(component.Path)
+		if os.IsNotExist(statErr) {
+			continue
+		} else if statErr != nil {
+			return manifest, statErr
+		}
+
+		component.Dir = info.IsDir()
+		if !component.Dir {
+			size, addErr := addTarFile(tarWriter, component.Path, component.Name)
+			if addErr != nil {
+				return manifest, addErr
+			}
+			component.Files, component.Size = 1, size
+		} else {
+			walkErr := filepath.Walk(component.Path, func(filePath string, fileInfo os.FileInfo, err error) error {
+				if err != nil || fileInfo.IsDir() {
+					return err
+				}
+				relativePath, relErr := filepath.Rel(component.Path, filePath)
+				if relErr != nil {
+					return relErr
+				}
+				size, addErr := addTarFile(tarWriter, filePath, path.Join(component.Name, filepath.ToSlash(relativePath)))
+				if addErr != nil {
+					return addErr
+				}
+				component.Files++
+				component.Size += size
+				return nil
+			})
+			if walkErr != nil {
+				return manifest, walkErr
+			}
+		}
+		manifest.Components = append(manifest.Components, component)
+	}
+
+	manifestData, marshErr := json.MarshalIndent(manifest, "", "  ")
+	if marshErr != nil {
+		return manifest, marshErr
+	}
+	header := &tar.Header{Name: STATE_MANIFEST_FILE, Mode: 0644, Size: int64(len(manifestData)), ModTime: time.Now()}
+	if headerErr := tarWriter.WriteHeader(header); headerErr != nil {
+		return manifest, headerErr
+	}
+	if _, writeErr := tarWriter.Write(manifestData); writeErr != nil {
+		return manifest, writeErr
+	}
+
+	if closeErr := tarWriter.Close(); closeErr != nil {
+		return manifest, closeErr
+	}
+	if closeErr := gzipWriter.Close(); closeErr != nil {
+		return manifest, closeErr
+	}
+	return manifest, outputFile.Close()
+}
+
+func extractTar(infile, dir string) error {
+	inputFile, openErr := os.Open(infile)
+	if openErr != nil {
+		return fmt.Errorf("Unable to read file %s, err: %v", infile, openErr)
+	}
+	defer inputFile.Close()
+
+	gzipReader, readerErr := gzip.NewReader(inputFile)
+	if readerErr != nil {
+		return fmt.Errorf("Unable to decompress %s, err: %v", infile, readerErr)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, nextErr := tarReader.Next()
+		if nextErr == io.EOF {
+			return nil
+		} else if nextErr != nil {
+			return fmt.Errorf("Unable to read state archive %s, err: %v", infile, nextErr)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("state archive %s contains file %s outside of the archive", infile, header.Name)
+		}
+		extractedPath := filepath.Join(dir, filepath.FromSlash(name))
+		if mkdirErr := os.MkdirAll(filepath.Dir(extractedPath), 0755); mkdirErr != nil {
+			return mkdirErr
+		}
+		outputFile, createErr := os.Create(extractedPath)
+		if createErr != nil {
+			return createErr
+		}
+		if _, copyErr := io.Copy(outputFile, tarReader); copyErr != nil {
+			outputFile.Close()
+			return copyErr
+		}
+		if closeErr := outputFile.Close(); closeErr != nil {
+			return closeErr
+		}
+	}
+}
+
+// ImportState restores the components of a state archive to their recorded paths, or to the paths
+// given in destinations by component name. Existing files are only replaced if force is set, so a
+// crawl's state is not overwritten by accident.
+func ImportState(infile string, destinations map[string]string, force bool) (StateManifest, error) {
+	var manifest StateManifest
+
+	tempDir, tempErr := os.MkdirTemp("", "influence-eth-state-")
+	if tempErr != nil {
+		return manifest, tempErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	if extractErr := extractTar(infile, tempDir); extractErr != nil {
+		return manifest, extractErr
+	}
+	manifestData, readErr := os.ReadFile(filepath.Join(tempDir, STATE_MANIFEST_FILE))
+	if readErr != nil {
+		return manifest, fmt.Errorf("state archive %s has no manifest, err: %v", infile, readErr)
+	}
+	if unmErr := json.Unmarshal(manifestData, &manifest); unmErr != nil {
+		return manifest, fmt.Errorf("Unable to parse state manifest, err: %v", unmErr)
+	}
+
+	// Destinations are checked before anything is restored, so a refused import changes nothing
+	for i, component := range manifest.Components {
+		if destination := destinations[component.Name]; destination != "" {
+			manifest.Components[i].Path = destination
+		}
+		if !force {
+			if _, statErr := os.Stat(manifest.Components[i].Path); statErr == nil {
+				return manifest, fmt.Errorf("%s already exists, pass --force to replace it with the %s of the state archive", manifest.Components[i].Path, component.Name)
+			}
+		}
+	}
+
+	for _, component := range manifest.Components {
+		extractedPath := filepath.Join(tempDir, component.Name)
+		if !component.Dir {
+			if copyErr := copyFile(extractedPath, component.Path); copyErr != nil {
+				return manifest, copyErr
+			}
+		} else if component.Files == 0 {
+			if mkdirErr := os.MkdirAll(component.Path, 0755); mkdirErr != nil {
+				return manifest, mkdirErr
+			}
+		} else {
+			walkErr := filepath.Walk(extractedPath, func(filePath string, fileInfo os.FileInfo, err error) error {
+				if err != nil || fileInfo.IsDir() {
+					return err
+				}
+				relativePath, relErr := filepath.Rel(extractedPath, filePath)
+				if relErr != nil {
+					return relErr
+				}
+				return copyFile(filePath, filepath.Join(component.Path, relativePath))
+			})
+			if walkErr != nil {
+				return manifest, walkErr
+			}
+		}
+	}
+
+	return manifest, nil
+}
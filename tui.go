@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var MONITOR_REFRESH_INTERVAL = 500 * time.Millisecond
+
+const MONITOR_LOG_LINES = 5
+
+// Monitor collects crawl and push statistics and renders them live in a terminal UI. It is safe
+// to update a Monitor from multiple goroutines. Monitor also implements io.Writer so that it can
+// be used as the output of the standard logger while the terminal UI is running.
+type Monitor struct {
+	mu sync.Mutex
+
+	title        string
+	startedAt    time.Time
+	chainHead    uint64
+	currentBlock uint64
+	eventCounts  map[string]uint64
+	missions     []string
+	pushStatus   map[string]string
+	logLines     []string
+
+	program *tea.Program
+	done    chan struct{}
+	cancel  context.CancelFunc
+}
+
+type monitorTickMsg time.Time
+
+type monitorEventRate struct {
+	name  string
+	total uint64
+	rate  float64
+}
+
+type monitorModel struct {
+	monitor    *Monitor
+	lastTick   time.Time
+	lastCounts map[string]uint64
+	rates      []monitorEventRate
+}
+
+// NewMonitor creates a Monitor. If the operator quits the terminal UI, cancel is called so that
+// the running command can stop gracefully.
+func NewMonitor(title string, cancel context.CancelFunc) *Monitor {
+	return &Monitor{
+		title:       title,
+		startedAt:   time.Now(),
+		eventCounts: make(map[string]uint64),
+		pushStatus:  make(map[string]string),
+		done:        make(chan struct{}),
+		cancel:      cancel,
+	}
+}
+
+// Start renders the terminal UI in the background until Stop is called or the operator quits.
+func (m *Monitor) Start() {
+	m.program = tea.NewProgram(monitorModel{monitor: m, lastTick: time.Now(), lastCounts: make(map[string]uint64)})
+	go func() {
+		defer close(m.done)
+		m.program.Run()
+		if m.cancel != nil {
+			m.cancel()
+		}
+	}()
+}
+
+// Stop renders the final state and waits for the terminal UI to exit.
+func (m *Monitor) Stop() {
+	if m.program == nil {
+		return
+	}
+	m.program.Quit()
+	<-m.done
+}
+
+func (m *Monitor) SetChainHead(blockNumber uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.chainHead = blockNumber
+}
+
+func (m *Monitor) RecordEvent(name string, blockNumber uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eventCounts[name]++
+	if blockNumber > m.currentBlock {
+		m.currentBlock = blockNumber
+	}
+}
+
+func (m *Monitor) SetPushStatus(mission, status string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pushStatus[mission]; !ok {
+		m.missions = append(m.missions, mission)
+	}
+	m.pushStatus[mission] = status
+}
+
+func (m *Monitor) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		m.logLines = append(m.logLines, line)
+	}
+	if len(m.logLines) > MONITOR_LOG_LINES {
+		m.logLines = m.logLines[len(m.logLines)-MONITOR_LOG_LINES:]
+	}
+	return len(p), nil
+}
+
+func monitorTick() tea.Cmd {
+	return tea.Tick(MONITOR_REFRESH_INTERVAL, func(t time.Time) tea.Msg {
+		return monitorTickMsg(t)
+	})
+}
+
+func (model monitorModel) Init() tea.Cmd {
+	return monitorTick()
+}
+
+func (model monitorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return model, tea.Quit
+		}
+	case monitorTickMsg:
+		now := time.Time(msg)
+		elapsed := now.Sub(model.lastTick).Seconds()
+
+		model.monitor.mu.Lock()
+		rates := make([]monitorEventRate, 0, len(model.monitor.eventCounts))
+		counts := make(map[string]uint64, len(model.monitor.eventCounts))
+		for name, total := range model.monitor.eventCounts {
+			rate := 0.0
+			if elapsed > 0 {
+				rate = float64(total-model.lastCounts[name]) / elapsed
+			}
+			rates = append(rates, monitorEventRate{name: name, total: total, rate: rate})
+			counts[name] = total
+		}
+		model.monitor.mu.Unlock()
+
+		sort.Slice(rates, func(i, j int) bool {
+			if rates[i].total != rates[j].total {
+				return rates[i].total > rates[j].total
+			}
+			return rates[i].name < rates[j].name
+		})
+
+		model.rates = rates
+		model.lastCounts = counts
+		model.lastTick = now
+		return model, monitorTick()
+	}
+	return model, nil
+}
+
+func (model monitorModel) View() string {
+	m := model.monitor
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (running for %s, press q to quit)\n\n", m.title, time.Since(m.startedAt).Round(time.Second))
+
+	if m.chainHead > 0 || m.currentBlock > 0 {
+		var lag uint64
+		if m.chainHead > m.currentBlock {
+			lag = m.chainHead - m.currentBlock
+		}
+		fmt.Fprintf(&b, "Chain head: %d  Crawled block: %d  Lag: %d block(s)\n\n", m.chainHead, m.currentBlock, lag)
+	}
+
+	if len(model.rates) > 0 {
+		var total uint64
+		var totalRate float64
+		fmt.Fprintf(&b, "%-50s %12s %12s\n", "Event", "Total", "Events/s")
+		for _, r := range model.rates {
+			fmt.Fprintf(&b, "%-50s %12d %12.1f\n", r.name, r.total, r.rate)
+			total += r.total
+			totalRate += r.rate
+		}
+		fmt.Fprintf(&b, "%-50s %12d %12.1f\n\n", "All", total, totalRate)
+	}
+
+	if len(m.missions) > 0 {
+		fmt.Fprintf(&b, "%-40s %s\n", "Leaderboard", "Status")
+		for _, mission := range m.missions {
+			fmt.Fprintf(&b, "%-40s %s\n", mission, m.pushStatus[mission])
+		}
+		b.WriteString("\n")
+	}
+
+	for _, line := range m.logLines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/redis/go-redis/v9"
+)
+
+// Kinds of worker jobs.
+const (
+	// Crawl the events of a contract over a block range into a file
+	JOB_KIND_CRAWL = "crawl"
+	// Generate the scores of a set of missions from an events file
+	JOB_KIND_GENERATE = "generate"
+)
+
+// Statuses of job results.
+const (
+	JOB_STATUS_SUCCEEDED = "succeeded"
+	JOB_STATUS_FAILED    = "failed"
+)
+
+// How long a worker waits for a job before polling the queue again.
+var WORKER_POLL_TIMEOUT = 20 * time.Second
+
+// WorkerJob is a crawl or generate job read from a worker queue as JSON, e.g.
+//
+//	{"id": "backfill-12", "kind": "crawl", "contract_name": "dispatcher", "from_block": 600000, "to_block": 610000, "parse": true, "output": "/data/events-600000.jsonl"}
+//	{"id": "scores-12", "kind": "generate", "input": "/data/events-600000.jsonl", "missions": ["3-market-maker-r1"], "output": "/data/scores"}
+type WorkerJob struct {
+	Id   string `json:"id"`
+	Kind string `json:"kind"`
+	// Crawl jobs: contract address or name, with the network of named contracts
+	Contract     string `json:"contract,omitempty"`
+	ContractName string `json:"contract_name,omitempty"`
+	Network      string `json:"network,omitempty"`
+	FromBlock    uint64 `json:"from_block,omitempty"`
+	ToBlock      uint64 `json:"to_block,omitempty"`
+	// Crawl jobs: also parse the crawled events, so generate jobs can read them
+	Parse bool `json:"parse,omitempty"`
+	// Generate jobs: parsed events file and the missions to score
+	Input    string   `json:"input,omitempty"`
+	Missions []string `json:"missions,omitempty"`
+	// File crawl jobs write events to, or directory generate jobs write <mission>.json scores to
+	Output string `json:"output"`
+	// Extra flags passed to the events or leaderboard command, e.g. ["--min-confirmations", "10"]
+	Args []string `json:"args,omitempty"`
+}
+
+func (j WorkerJob) Validate() error {
+	if j.Output == "" {
+		return errors.New("job has no output")
+	}
+	switch j.Kind {
+	case JOB_KIND_CRAWL:
+		if j.Contract == "" && j.ContractName == "" {
+			return errors.New("crawl job has neither contract nor contract_name")
+		}
+		if j.ToBlock == 0 || j.ToBlock < j.FromBlock {
+			return fmt.Errorf("crawl job needs a block range with to_block at or after from_block, got %d to %d", j.FromBlock, j.ToBlock)
+		}
+	case JOB_KIND_GENERATE:
+		if j.Input == "" {
+			return errors.New("generate job has no input")
+		}
+		if len(j.Missions) == 0 {
+			return errors.New("generate job has no missions")
+		}
+	default:
+		return fmt.Errorf("unknown job kind %s, supported kinds: %s, %s", j.Kind, JOB_KIND_CRAWL, JOB_KIND_GENERATE)
+	}
+	return nil
+}
+
+// WorkerJobResult reports the outcome of a job.
+type WorkerJobResult struct {
+	Id         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Outputs    []string  `json:"outputs,omitempty"`
+	Worker     string    `json:"worker"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// WorkerMessage is a job received from a queue, acknowledged once it has been processed.
+type WorkerMessage struct {
+	Body   []byte
+	handle string
+}
+
+// WorkerQueue delivers jobs to workers and collects their results.
+type WorkerQueue interface {
+	// Receive waits for the next message, it returns nil if none arrived before the poll timeout
+	Receive(ctx context.Context) (*WorkerMessage, error)
+	// Ack removes a processed message from the queue
+	Ack(ctx context.Context, message *WorkerMessage) error
+	Send(ctx context.Context, body []byte) error
+	Close() error
+}
+
+// NewWorkerQueue creates a queue from its specification:
+//   - "redis://[:password@]host:port/<db>/<list>" pops jobs from a Redis list, keeping jobs in
+//     progress on <list>:processing until they are acknowledged
+//   - "sqs://sqs.<region>.amazonaws.com/<account>/<queue>" receives jobs from an SQS queue, jobs
+//     which are not acknowledged within the visibility timeout are delivered again
+func NewWorkerQueue(ctx context.Context, spec string, visibilityTimeout time.Duration) (WorkerQueue, error) {
+	queueURL, parseErr := url.Parse(spec)
+	if parseErr != nil {
+		return nil, fmt.Errorf("invalid queue %s: %v", spec, parseErr)
+	}
+
+	switch queueURL.Scheme {
+	case "redis", "rediss":
+		db, list, found := strings.Cut(strings.Trim(queueURL.Path, "/"), "/")
+		if queueURL.Host == "" || !found || list == "" {
+			return nil, fmt.Errorf("redis queue should be specified as redis://<host>:<port>/<db>/<list>, got %s", spec)
+		}
+		// The list is not an option of the client
+		queueURL.Path = "/" + db
+		options, optionsErr := redis.ParseURL(queueURL.String())
+		if optionsErr != nil {
+			return nil, fmt.Errorf("invalid redis queue %s: %v", spec, optionsErr)
+		}
+		return &RedisWorkerQueue{Client: redis.NewClient(options), List: list}, nil
+	case "sqs":
+		if queueURL.Host == "" || strings.Trim(queueURL.Path, "/") == "" {
+			return nil, fmt.Errorf("sqs queue should be specified as sqs://sqs.<region>.amazonaws.com/<account>/<queue>, got %s", spec)
+		}
+		awsConfig, configErr := config.LoadDefaultConfig(ctx)
+		if configErr != nil {
+			return nil, fmt.Errorf("unable to load AWS configuration, err: %v", configErr)
+		}
+		queueURL.Scheme = "https"
+		return &SQSWorkerQueue{Client: sqs.NewFromConfig(awsConfig), QueueURL: queueURL.String(), VisibilityTimeout: visibilityTimeout}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported queue %s, use redis://<host>:<port>/<db>/<list> or sqs://<queue_url_host_and_path>", spec)
+}
+
+type RedisWorkerQueue struct {
+	Client *redis.Client
+	List   string
+}
+
+func (q *RedisWorkerQueue) processingList() string {
+	return q.List + ":processing"
+}
+
+func (q *RedisWorkerQueue) Receive(ctx context.Context) (*WorkerMessage, error) {
+	body, popErr := q.Client.BLMove(ctx, q.List, q.processingList(), "RIGHT", "LEFT", WORKER_POLL_TIMEOUT).Result()
+	if errors.Is(popErr, redis.Nil) {
+		return nil, nil
+	}
+	if popErr != nil {
+		return nil, popErr
+	}
+	return &WorkerMessage{Body: []byte(body), handle: body}, nil
+}
+
+func (q *RedisWorkerQueue) Ack(ctx context.Context, message *WorkerMessage) error {
+	return q.Client.LRem(ctx, q.processingList(), 1, message.handle).Err()
+}
+
+func (q *RedisWorkerQueue) Send(ctx context.Context, body []byte) error {
+	return q.Client.LPush(ctx, q.List, body).Err()
+}
+
+func (q *RedisWorkerQueue) Close() error {
+	return q.Client.Close()
+}
+
+type SQSWorkerQueue struct {
+	Client            *sqs.Client
+	QueueURL          string
+	VisibilityTimeout time.Duration
+}
+
+func (q *SQSWorkerQueue) Receive(ctx context.Context) (*WorkerMessage, error) {
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.QueueURL),
+		MaxNumberOfMessages: 1,
+		WaitTimeSeconds:     int32(WORKER_POLL_TIMEOUT / time.Second),
+	}
+	if q.VisibilityTimeout > 0 {
+		input.VisibilityTimeout = int32(q.VisibilityTimeout / time.Second)
+	}
+	output, receiveErr := q.Client.ReceiveMessage(ctx, input)
+	if receiveErr != nil {
+		return nil, receiveErr
+	}
+	if len(output.Messages) == 0 {
+		return nil, nil
+	}
+	message := output.Messages[0]
+	return &WorkerMessage{Body: []byte(aws.ToString(message.Body)), handle: aws.ToString(message.ReceiptHandle)}, nil
+}
+
+func (q *SQSWorkerQueue) Ack(ctx context.Context, message *WorkerMessage) error {
+	_, deleteErr := q.Client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: aws.String(q.QueueURL), ReceiptHandle: aws.String(message.handle)})
+	return deleteErr
+}
+
+func (q *SQSWorkerQueue) Send(ctx context.Context, body []byte) error {
+	_, sendErr := q.Client.SendMessage(ctx, &sqs.SendMessageInput{QueueUrl: aws.String(q.QueueURL), MessageBody: aws.String(string(body))})
+	return sendErr
+}
+
+func (q *SQSWorkerQueue) Close() error {
+	return nil
+}
+
+// Worker runs jobs from a queue. Each step of a job runs as a separate process of this binary, so
+// jobs do not share the package state of the commands they run and a failing job does not take
+// the worker down.
+type Worker struct {
+	Jobs    WorkerQueue
+	Results WorkerQueue
+	// Path of the influence-eth binary to run jobs with
+	Executable string
+	Name       string
+}
+
+// Run processes jobs until the context is done. Jobs are acknowledged after their result was
+// reported, failed jobs included, so they are not retried in a loop: resubmit them after fixing
+// the cause. Jobs of a worker which dies are delivered again (SQS) or stay on the processing list
+// (Redis), their outputs are only renamed into place once complete.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		message, receiveErr := w.Jobs.Receive(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if receiveErr != nil {
+			return fmt.Errorf("unable to receive job, err: %v", receiveErr)
+		}
+		if message == nil {
+			continue
+		}
+
+		result := w.Process(ctx, message.Body)
+		if ctx.Err() != nil {
+			// Interrupted jobs are left on the queue to be delivered again
+			log.Printf("Worker stopped during job %s", result.Id)
+			return nil
+		}
+		if reportErr := w.Report(ctx, result); reportErr != nil {
+			return reportErr
+		}
+		if ackErr := w.Jobs.Ack(ctx, message); ackErr != nil {
+			return fmt.Errorf("unable to acknowledge job %s, err: %v", result.Id, ackErr)
+		}
+	}
+}
+
+// Process runs the job and returns its result.
+func (w *Worker) Process(ctx context.Context, body []byte) WorkerJobResult {
+	result := WorkerJobResult{Worker: w.Name, StartedAt: time.Now().UTC()}
+	outputs, jobErr := w.process(ctx, body, &result)
+	result.FinishedAt = time.Now().UTC()
+	result.Outputs = outputs
+	result.Status = JOB_STATUS_SUCCEEDED
+	if jobErr != nil {
+		result.Status = JOB_STATUS_FAILED
+		result.Error = jobErr.Error()
+		log.Printf("Job %s failed, err: %v", result.Id, jobErr)
+	} else {
+		log.Printf("Job %s succeeded in %s", result.Id, result.FinishedAt.Sub(result.StartedAt).Round(time.Millisecond))
+	}
+	return result
+}
+
+func (w *Worker) process(ctx context.Context, body []byte, result *WorkerJobResult) ([]string, error) {
+	var job WorkerJob
+	if unmErr := json.Unmarshal(body, &job); unmErr != nil {
+		return nil, fmt.Errorf("invalid job, err: %v", unmErr)
+	}
+	result.Id = job.Id
+	result.Kind = job.Kind
+	if validateErr := job.Validate(); validateErr != nil {
+		return nil, validateErr
+	}
+
+	log.Printf("Running %s job %s", job.Kind, job.Id)
+	if job.Kind == JOB_KIND_CRAWL {
+		return w.crawl(ctx, job)
+	}
+	return w.generate(ctx, job)
+}
+
+func (w *Worker) crawl(ctx context.Context, job WorkerJob) ([]string, error) {
+	// The events command appends to its output, a partial crawl must not be appended to again
+	crawled := job.Output + ".crawl"
+	os.Remove(crawled)
+	defer os.Remove(crawled)
+
+	args := []string{"events", "--from", fmt.Sprint(job.FromBlock), "--to", fmt.Sprint(job.ToBlock), "-o", crawled}
+	if job.Contract != "" {
+		args = append(args, "--contract", job.Contract)
+	} else {
+		args = append(args, "--contract-name", job.ContractName)
+	}
+	if job.Network != "" {
+		args = append(args, "--network", job.Network)
+	}
+	if runErr := w.run(ctx, append(args, job.Args...)...); runErr != nil {
+		return nil, runErr
+	}
+
+	if job.Parse {
+		parsed := job.Output + ".parse"
+		defer os.Remove(parsed)
+		if runErr := w.run(ctx, "parse", "-i", crawled, "-o", parsed); runErr != nil {
+			return nil, runErr
+		}
+		crawled = parsed
+	}
+
+	if renameErr := os.Rename(crawled, job.Output); renameErr != nil {
+		return nil, fmt.Errorf("unable to move events to %s, err: %v", job.Output, renameErr)
+	}
+	return []string{job.Output}, nil
+}
+
+func (w *Worker) generate(ctx context.Context, job WorkerJob) ([]string, error) {
+	if mkdirErr := os.MkdirAll(job.Output, 0755); mkdirErr != nil {
+		return nil, fmt.Errorf("unable to create directory %s, err: %v", job.Output, mkdirErr)
+	}
+
+	var outputs []string
+	for _, mission := range job.Missions {
+		outfile := filepath.Join(job.Output, mission+".json")
+		args := append([]string{"leaderboard", mission, "-i", job.Input, "-o", outfile}, job.Args...)
+		if runErr := w.run(ctx, args...); runErr != nil {
+			return outputs, fmt.Errorf("mission %s failed, err: %v", mission, runErr)
+		}
+		outputs = append(outputs, outfile)
+	}
+	return outputs, nil
+}
+
+func (w *Worker) run(ctx context.Context, args ...string) error {
+	command := exec.CommandContext(ctx, w.Executable, args...)
+	command.Stdout = os.Stderr
+	command.Stderr = os.Stderr
+	if runErr := command.Run(); runErr != nil {
+		return fmt.Errorf("influence-eth %s failed, err: %v", args[0], runErr)
+	}
+	return nil
+}
+
+// Report sends the result to the results queue, or logs it if there is none.
+func (w *Worker) Report(ctx context.Context, result WorkerJobResult) error {
+	body, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	if w.Results == nil {
+		log.Printf("Result: %s", body)
+		return nil
+	}
+	if sendErr := w.Results.Send(ctx, body); sendErr != nil {
+		return fmt.Errorf("unable to report result of job %s, err: %v", result.Id, sendErr)
+	}
+	return nil
+}